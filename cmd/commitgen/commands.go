@@ -0,0 +1,873 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/app"
+	"github.com/hoanghonghuy/commitgen/internal/config"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/logx"
+)
+
+type command struct {
+	name    string
+	usage   string // argument synopsis shown in help
+	summary string
+	hidden  bool // kept for backward compatibility, not listed in usage
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "suggest", usage: "[flags]", summary: "Generate a commit message for staged changes (default)", run: runSuggest},
+		{name: "dump-prompt", usage: "[flags]", summary: "Print the prompt that would be sent to the AI as JSON", run: runDumpPrompt},
+		{name: "bench", usage: "--providers openai,ollama:llama3.1,anthropic [flags]", summary: "Compare providers and models on the staged changes", run: runBench},
+		{name: "watch", usage: "[--interval 2s] [flags]", summary: "Pre-generate a message in the background whenever staged changes change", run: runWatch},
+		{name: "serve", usage: "[--addr host:port] [flags]", summary: "Run an HTTP API for editors and tools (POST /suggest)", run: runServe},
+		{name: "rpc", usage: "[flags]", summary: "Speak JSON-RPC on stdin/stdout for editor plugins", run: runRPC},
+		{name: "action", usage: "[--mode description | squash] [flags]", summary: "Describe a pull request from inside a GitHub Actions job", run: runAction},
+		{name: "config", usage: "[get KEY | set KEY VALUE | show [--redact-keys] | explain [--all]] [flags]", summary: "Edit settings interactively, read and write them from scripts, or explain where each comes from", run: runConfig},
+		{name: "describe", usage: "[flags] <commit | patch-file | ->", summary: "Explain in prose what a commit or patch does and why", run: runDescribe},
+		{name: "review", usage: "[flags]", summary: "Review staged changes for likely bugs, missing tests and risky spots", run: runReview},
+		{name: "pr", usage: "[--base BRANCH] [--open [--draft]] [flags]", summary: "Write a pull request title and description for the current branch, and open it", run: runPR},
+		{name: "mr", usage: "[--base BRANCH] [--open [--draft]] [flags]", summary: "Write a GitLab merge request title and description for the current branch, and open it", run: runMR},
+		{name: "lint", usage: "[--range a..b | --file msg.txt] [flags]", summary: "Check commit messages against the configured rules", run: runLint},
+		{name: "next-version", usage: "[--from TAG] [flags]", summary: "Print the next semantic version for the commits since the last release tag", run: runNextVersion},
+		{name: "changelog", usage: "[--from TAG] [flags]", summary: "Print the Markdown changelog of the commits since the last release tag", run: runChangelog},
+		{name: "history", usage: "[flags]", summary: "List previously generated messages", run: runHistory},
+		{name: "stats", usage: "[--since 720h]", summary: "Show acceptance rate, latency and token spend per model", run: runStats},
+		{name: "resume", usage: "[--print] [--repo PATH]", summary: "Commit the message saved when git commit failed, or print it", run: runResume},
+		{name: "init", usage: "[--hook] [--force] [--repo PATH]", summary: "Set up a repository with a shared .commitgen/ config, prompt template and ignore file", run: runInit},
+		{name: "hook", usage: "install | uninstall | status [--type prepare-commit-msg | commit-msg | post-commit]", summary: "Manage commitgen's git hooks", run: runHook},
+		{name: "version", usage: "", summary: "Print the commitgen version", run: runVersion},
+
+		{name: "feedback", hidden: true, run: runFeedback}, // run by the post-commit hook
+		{name: "install-hook", hidden: true, run: func(ctx context.Context, args []string) error {
+			return runHook(ctx, append([]string{"install"}, args...))
+		}},
+		{name: "uninstall-hook", hidden: true, run: func(ctx context.Context, args []string) error {
+			return runHook(ctx, append([]string{"uninstall"}, args...))
+		}},
+	}
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Output flags shared by every command, applied by parseFlags.
+var (
+	verboseFlag bool
+	quietFlag   bool
+)
+
+// newFlagSet creates a flag set whose -h output describes the command.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("commitgen "+name, flag.ContinueOnError)
+	fs.BoolVar(&verboseFlag, "verbose", false, "Log git commands, included files, prompt size and provider latency to stderr")
+	fs.BoolVar(&quietFlag, "quiet", false, "Print only the result (no notices or logs)")
+	fs.Usage = func() {
+		c := findCommand(name)
+		fmt.Fprintf(fs.Output(), "%s commitgen %s %s\n\n%s\n", i18n.T("Usage:"), c.name, c.usage, i18n.T(c.summary))
+		hasFlags := false
+		fs.VisitAll(func(f *flag.Flag) {
+			hasFlags = true
+			f.Usage = i18n.T(f.Usage)
+		})
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\n%s\n", i18n.T("Flags:"))
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseFlags parses args into fs and applies the logging flags.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	logx.Setup(verboseFlag, quietFlag)
+	return nil
+}
+
+// commonFlags are the settings shared by every command that talks to the repo or the AI.
+type commonFlags struct {
+	configPath string
+
+	repo         string
+	baseURL      string
+	apiKey       string
+	model        string
+	provider     string
+	anthropicKey string
+	geminiKey    string
+
+	recentN      int
+	maxFiles     int
+	summarize    bool
+	temp         float64
+	conventional bool
+	instructions []string
+
+	only    []string
+	exclude []string
+
+	allowSensitive bool
+}
+
+func addConfigFlag(fs *flag.FlagSet, f *commonFlags) {
+	fs.StringVar(&f.configPath, "config", "", "Path to config file")
+}
+
+func addRepoFlag(fs *flag.FlagSet, f *commonFlags) {
+	fs.StringVar(&f.repo, "repo", "", "Path to git repository (default: current directory)")
+}
+
+func addConventionalFlag(fs *flag.FlagSet, f *commonFlags) {
+	fs.BoolVar(&f.conventional, "conventional", false, "Enforce conventional commits")
+}
+
+// addProviderFlags registers the flags that select and configure the AI backend.
+func addProviderFlags(fs *flag.FlagSet, f *commonFlags) {
+	fs.StringVar(&f.baseURL, "base-url", "", "AI provider base URL")
+	fs.StringVar(&f.apiKey, "api-key", "", "AI provider API key")
+	fs.StringVar(&f.model, "model", "", "AI model name")
+	fs.StringVar(&f.provider, "provider", "", "AI provider (openai | ollama | anthropic | gemini)")
+
+	fs.StringVar(&f.anthropicKey, "anthropic-key", "", "Anthropic API key")
+	fs.StringVar(&f.geminiKey, "gemini-key", "", "Gemini API key")
+	fs.Float64Var(&f.temp, "temp", 0, "LLM temperature")
+}
+
+func addCommonFlags(fs *flag.FlagSet, f *commonFlags) {
+	addConfigFlag(fs, f)
+	addRepoFlag(fs, f)
+	addProviderFlags(fs, f)
+	addConventionalFlag(fs, f)
+
+	fs.IntVar(&f.recentN, "recent-n", 0, "Number of recent commits to include")
+	fs.IntVar(&f.maxFiles, "max-files", 0, "Max staged files to analyze")
+	fs.BoolVar(&f.summarize, "summarize", false, "Summarize file content")
+	fs.Func("instructions", "Custom instructions file, path in the repository, or URL, after those in the config (repeatable)", func(s string) error {
+		f.instructions = append(f.instructions, s)
+		return nil
+	})
+	fs.Func("only", "Only describe staged files matching this git pathspec (repeatable)", func(s string) error {
+		f.only = append(f.only, s)
+		return nil
+	})
+	fs.Func("exclude", "Leave out staged files matching this git pathspec (repeatable)", func(s string) error {
+		f.exclude = append(f.exclude, s)
+		return nil
+	})
+	fs.BoolVar(&f.allowSensitive, "allow-sensitive", false, "Include staged files that look like secrets (.env, id_rsa, *.pem, ...) in the prompt; they are left out by default")
+}
+
+// resolveConfig loads the config files and merges them with flags and env
+// (Flag > Env > personal file > team file > Default).
+// Env comes from COMMITGEN_* variables, including those in .commitgen.env/.env files,
+// and beneath them the standardEnv ones.
+func resolveConfig(fs *flag.FlagSet, f *commonFlags) app.Config {
+	fileCfg, err := config.Load(f.configPath)
+	if err != nil {
+		slog.Warn("could not load config file", "err", err)
+	}
+	// The repository's team config sits beneath the personal one.
+	if teamCfg, path, err := config.LoadTeam(f.repo); err != nil {
+		slog.Warn("could not load team config", "path", path, "err", err)
+	} else if path != "" {
+		slog.Debug("using team config", "path", path)
+		fileCfg = config.Merge(teamCfg, fileCfg)
+	}
+	getenv := config.LoadDotEnv(f.repo)
+	applyStandardEnv(&fileCfg)
+	if err := config.ApplyEnv(&fileCfg, getenv); err != nil {
+		slog.Warn("ignoring invalid settings", "err", err)
+	}
+	if fileCfg.Language != "" {
+		i18n.SetLanguage(fileCfg.Language)
+	}
+
+	isSet := func(name string) bool {
+		found := false
+		fs.Visit(func(fl *flag.Flag) {
+			if fl.Name == name {
+				found = true
+			}
+		})
+		return found
+	}
+
+	if fileCfg.HookSkipSources == nil {
+		fileCfg.HookSkipSources = app.DefaultHookSkipSources
+	}
+
+	// fileCfg already holds the env values, so the env arguments are left empty.
+	return app.Config{
+		RepoArg:  f.repo,
+		BaseURL:  config.ResolveString(f.baseURL, "", fileCfg.BaseURL, ""),
+		APIKey:   config.ResolveString(f.apiKey, "", fileCfg.APIKey, ""),
+		Model:    config.ResolveString(f.model, "", fileCfg.Model, "gpt-4o"),
+		Provider: config.ResolveString(f.provider, "", fileCfg.Provider, "openai"),
+
+		AnthropicKey:  config.ResolveString(f.anthropicKey, "", fileCfg.AnthropicKey, ""),
+		GeminiKey:     config.ResolveString(f.geminiKey, "", fileCfg.GeminiKey, ""),
+		OpenAIOrg:     fileCfg.OpenAIOrg,
+		OpenAIProject: fileCfg.OpenAIProject,
+		OpenAIAPI:     strings.ToLower(fileCfg.OpenAIAPI),
+
+		AzureAuth:         strings.ToLower(fileCfg.AzureAuth),
+		AzureTenantID:     fileCfg.AzureTenantID,
+		AzureClientID:     fileCfg.AzureClientID,
+		AzureClientSecret: fileCfg.AzureClientSecret,
+
+		AWSRegion:  fileCfg.AWSRegion,
+		AWSProfile: fileCfg.AWSProfile,
+
+		VaultAddr:      fileCfg.VaultAddr,
+		VaultNamespace: fileCfg.VaultNamespace,
+		VaultToken:     fileCfg.VaultToken,
+		VaultRoleID:    fileCfg.VaultRoleID,
+		VaultSecretID:  fileCfg.VaultSecretID,
+		GitLabURL:      fileCfg.GitLabURL,
+		GitLabToken:    fileCfg.GitLabToken,
+
+		RecentN:      config.ResolveInt(f.recentN, isSet("recent-n"), fileCfg.RecentN, 5),
+		MaxFiles:     config.ResolveInt(f.maxFiles, isSet("max-files"), fileCfg.MaxFiles, 10),
+		Summarize:    config.ResolveBool(f.summarize, isSet("summarize"), fileCfg.Summarize, true),
+		Temperature:  config.ResolveFloat(f.temp, isSet("temp"), fileCfg.Temperature, 0.7),
+		Conventional: config.ResolveBool(f.conventional, isSet("conventional"), fileCfg.Conventional, true),
+
+		ContextBudget:   config.ResolveInt(0, false, fileCfg.ContextBudget, 32000),
+		OllamaNumCtx:    config.ResolveInt(0, false, fileCfg.OllamaNumCtx, 0),
+		MaxTokensPerRun: config.ResolveInt(0, false, fileCfg.MaxTokensPerRun, 0),
+		MaxTokensPerDay: config.ResolveInt(0, false, fileCfg.MaxTokensPerDay, 0),
+		BudgetFallback:  fileCfg.BudgetFallback,
+		MaxCostPerRun:   config.ResolveFloat(0, false, fileCfg.MaxCostPerRun, 0),
+		MaxCostPerDay:   config.ResolveFloat(0, false, fileCfg.MaxCostPerDay, 0),
+		ModelPrices:     fileCfg.ModelPrices,
+		SelectFiles:     config.ResolveBool(false, false, fileCfg.SelectFiles, false),
+		Keybindings:     fileCfg.Keybindings,
+		OtherModels:     fileCfg.OtherModels,
+		Quick:           config.ResolveBool(false, false, fileCfg.Quick, false),
+		Accessible:      config.ResolveBool(false, false, fileCfg.Accessible, false),
+		StyleExamples:   config.ResolveInt(0, false, fileCfg.StyleExamples, 3),
+		Refine:          config.ResolveBool(false, false, fileCfg.Refine, false),
+		BestOf:          config.ResolveInt(0, false, fileCfg.BestOf, 1),
+		RiskCheck:       config.ResolveBool(false, false, fileCfg.RiskCheck, false),
+		Imperative:      config.ResolveBool(false, false, fileCfg.Imperative, true),
+		BodyWrap:        config.ResolveInt(0, false, fileCfg.BodyWrap, 72),
+		Spellcheck:      config.ResolveBool(false, false, fileCfg.Spellcheck, true),
+		Dictionary:      fileCfg.Dictionary,
+		ASCII:           config.ResolveBool(false, false, fileCfg.ASCII, false),
+
+		SummarizerPlugins: fileCfg.SummarizerPlugins,
+		AuditLog:          fileCfg.AuditLog,
+		AuditRedact:       fileCfg.AuditRedact,
+
+		PrePromptCommand:   fileCfg.PrePromptCommand,
+		PostMessageCommand: fileCfg.PostMessageCommand,
+
+		JiraSmartCommit: config.ResolveBool(false, false, fileCfg.JiraSmartCommit, false),
+		JiraProjects:    fileCfg.JiraProjects,
+		JiraTransition:  fileCfg.JiraTransition,
+
+		Linear:          config.ResolveBool(false, false, fileCfg.Linear, false),
+		LinearTeams:     fileCfg.LinearTeams,
+		LinearMagicWord: config.ResolveString("", "", fileCfg.LinearMagicWord, "Fixes"),
+		LinearAPIKey:    fileCfg.LinearAPIKey,
+
+		InstructionsPaths: append(slices.Clip(fileCfg.Instructions), f.instructions...),
+		Only:              f.only,
+		Exclude:           f.exclude,
+		AllowSensitive:    f.allowSensitive,
+		ConfigPath:        f.configPath,
+		Timeout:           60 * time.Second,
+		PromptTemplate:    fileCfg.PromptTemplate,
+		IgnoredFiles:      fileCfg.IgnoredFiles,
+		GeneratedFiles:    fileCfg.GeneratedFiles,
+		HookSkipSources:   fileCfg.HookSkipSources,
+		HookTimeout:       time.Duration(config.ResolveInt(0, false, fileCfg.HookTimeout, 45)) * time.Second,
+
+		MaxSubjectLength:  config.ResolveInt(0, false, fileCfg.MaxSubjectLength, 72),
+		MaxBodyLineLength: config.ResolveInt(0, false, fileCfg.MaxBodyLineLength, 0),
+		AllowedTypes:      fileCfg.AllowedTypes,
+
+		RepairAttempts:   config.ResolveInt(0, false, fileCfg.RepairAttempts, 2),
+		BannedWords:      fileCfg.BannedWords,
+		DenyPatterns:     fileCfg.DenyPatterns,
+		RequiredPrefixes: fileCfg.RequiredPrefixes,
+
+		Signoff:     config.ResolveBool(false, false, fileCfg.Signoff, false),
+		CoAuthors:   fileCfg.CoAuthors,
+		Trailers:    fileCfg.Trailers,
+		GeneratedBy: config.ResolveBool(false, false, fileCfg.GeneratedBy, false),
+
+		NoAI:            config.ResolveBool(false, false, fileCfg.NoAI, false),
+		MessageTemplate: fileCfg.MessageTemplate,
+	}
+}
+
+// settingFlags are the flags of addCommonFlags that set a setting, by setting name.
+var settingFlags = map[string]string{
+	"base_url":      "base-url",
+	"api_key":       "api-key",
+	"model":         "model",
+	"provider":      "provider",
+	"anthropic_key": "anthropic-key",
+	"gemini_key":    "gemini-key",
+	"temperature":   "temp",
+	"conventional":  "conventional",
+	"recent_n":      "recent-n",
+	"max_files":     "max-files",
+	"summarize":     "summarize",
+}
+
+// standardEnv are the variables of other tools and platforms that resolveConfig
+// takes over the config files, as those tools do, though COMMITGEN_ ones still win.
+// A CI job that points VAULT_ADDR or AZURE_TENANT_ID at its own must not have a
+// config file send the login elsewhere.
+var standardEnv = map[string]string{
+	"openai_org":          "OPENAI_ORG_ID",
+	"openai_project":      "OPENAI_PROJECT_ID",
+	"azure_tenant_id":     "AZURE_TENANT_ID",
+	"azure_client_id":     "AZURE_CLIENT_ID",
+	"azure_client_secret": "AZURE_CLIENT_SECRET",
+	"aws_region":          "AWS_REGION",
+	"vault_addr":          "VAULT_ADDR",
+	"vault_namespace":     "VAULT_NAMESPACE",
+	"vault_token":         "VAULT_TOKEN",
+	"gitlab_url":          "CI_SERVER_URL",
+	"gitlab_token":        "GITLAB_TOKEN",
+	"linear_api_key":      "LINEAR_API_KEY",
+}
+
+// applyStandardEnv sets in cfg the settings given by standardEnv variables.
+func applyStandardEnv(cfg *config.FileConfig) {
+	for key, name := range standardEnv {
+		if v := os.Getenv(name); v != "" {
+			_ = cfg.Set(key, v)
+		}
+	}
+}
+
+// explainConfig returns where each setting resolveConfig resolves comes from,
+// telling variables from the .env files from those set in the environment.
+func explainConfig(fs *flag.FlagSet, f *commonFlags) []config.Origin {
+	var flagCfg config.FileConfig
+	fs.Visit(func(fl *flag.Flag) {
+		for key, name := range settingFlags {
+			if fl.Name == name {
+				_ = flagCfg.Set(key, fl.Value.String())
+			}
+		}
+	})
+
+	getenv := config.LoadDotEnv(f.repo)
+	var envCfg, standardCfg config.FileConfig
+	_ = config.ApplyEnv(&envCfg, getenv) // resolveConfig reports invalid values
+	applyStandardEnv(&standardCfg)
+
+	path := f.configPath
+	if path == "" {
+		path = config.DefaultPath()
+	}
+	fileCfg, _ := config.Load(f.configPath)
+	teamCfg, teamPath, _ := config.LoadTeam(f.repo)
+
+	origins := config.Explain([]config.Layer{
+		{Cfg: flagCfg, Source: func(key string) string { return i18n.Tf("flag --%s", settingFlags[key]) }},
+		{Cfg: envCfg, Source: func(key string) string {
+			name := config.EnvSource(key, getenv)
+			if _, set := os.LookupEnv(name); !set {
+				return i18n.Tf("env %s (from a .env file)", name)
+			}
+			return i18n.Tf("env %s", name)
+		}},
+		{Cfg: standardCfg, Source: func(key string) string { return i18n.Tf("env %s", standardEnv[key]) }},
+		{Cfg: fileCfg, Source: func(string) string { return i18n.Tf("global config %s", path) }},
+		{Cfg: teamCfg, Source: func(string) string { return i18n.Tf("repo config %s", teamPath) }},
+	})
+	return origins
+}
+
+func runSuggest(ctx context.Context, args []string) error {
+	fs := newFlagSet("suggest")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	hook := fs.String("hook", "", "Path to commit message file (used by git hook)")
+	hookSource := fs.String("hook-source", "", "Commit message source passed to prepare-commit-msg (used by git hook)")
+	hookFast := fs.Bool("hook-fast", false, "With --hook: skip the interface, reuse a cached message or wait briefly for one, and add it as comment lines (used by git hook without a terminal)")
+	stdinDiff := fs.Bool("stdin-diff", false, "Read a unified diff from stdin instead of staged changes and print the message")
+	noAI := fs.Bool("no-ai", false, "Don't call any AI; fill the message template with facts about the diff")
+	compare := fs.String("compare", "", "Generate with two or more comma-separated models side by side and pick one (model, or provider[:model])")
+	refine := fs.Bool("refine", false, "Send the message back with the diff for a critique-and-improve pass (two requests)")
+	bestOf := fs.Int("best-of", 0, "Generate N candidate messages and show the one ranked best by token log probability and local checks")
+	riskCheck := fs.Bool("risk-check", false, "Also ask the model to flag risky changes before I commit (one more request)")
+	selectFiles := fs.Bool("select-files", false, "List the staged files first and let me leave some out of the message")
+	quick := fs.Bool("quick", false, "Skip the action list: Enter commits the message, shortcut keys do the rest")
+	dryRun := fs.Bool("dry-run", false, "Print the git commit command for the accepted message instead of running it")
+	accessible := fs.Bool("accessible", false, "Use plain prompts instead of the full-screen interface (for screen readers)")
+	typ := fs.String("type", "", "Conventional type for the message, e.g. fix (default: guessed from the changed files when it is clear)")
+	tmpl := fs.String("template", "", "Go template file for --no-ai (default: message_template setting, else built-in)")
+	ascii := fs.Bool("ascii", false, "Strip emoji and non-ASCII punctuation from the message")
+	signoff := fs.Bool("signoff", false, "Add a Signed-off-by trailer for the committer")
+	repos := fs.String("repos", "", "Go through these comma-separated repositories in turn, each with staged changes")
+	workspace := fs.String("workspace", "", "Like --repos, with the repositories listed in this file, one path per line")
+	jiraTime := fs.String("jira-time", "", "Log this much work on the branch's Jira issue with a smart commit, e.g. 2h (implies jira_smart_commit)")
+	var coAuthors, trailers []string
+	fs.Func("co-author", "Add a Co-authored-by trailer for \"Name <email>\" (repeatable)", func(s string) error {
+		coAuthors = append(coAuthors, s)
+		return nil
+	})
+	fs.Func("trailer", "Add a trailer such as \"Refs: PROJ-123\" (repeatable)", func(s string) error {
+		trailers = append(trailers, s)
+		return nil
+	})
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// Each repository gets its own config, so that its team config applies.
+	suggestConfig := func(repo string) app.Config {
+		cf.repo = repo
+		cfg := resolveConfig(fs, &cf)
+		if *ascii {
+			cfg.ASCII = true
+		}
+		if *signoff {
+			cfg.Signoff = true
+		}
+		if *jiraTime != "" {
+			cfg.JiraSmartCommit = true
+			cfg.JiraTime = *jiraTime
+		}
+		cfg.CoAuthors = append(cfg.CoAuthors, coAuthors...)
+		cfg.Trailers = append(cfg.Trailers, trailers...)
+		if *noAI {
+			cfg.NoAI = true
+		}
+		if *selectFiles {
+			cfg.SelectFiles = true
+		}
+		if *quick {
+			cfg.Quick = true
+		}
+		if *accessible {
+			cfg.Accessible = true
+		}
+		if *dryRun {
+			cfg.DryRun = true
+		}
+		if *refine {
+			cfg.Refine = true
+		}
+		if *bestOf > 0 {
+			cfg.BestOf = *bestOf
+		}
+		if *riskCheck {
+			cfg.RiskCheck = true
+		}
+		for _, m := range strings.Split(*compare, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				cfg.Compare = append(cfg.Compare, m)
+			}
+		}
+		cfg.Type = *typ
+		cfg.MessageTemplatePath = *tmpl
+		cfg.HookFile = *hook
+		cfg.HookSource = *hookSource
+		cfg.HookFast = *hookFast
+		cfg.StdinDiff = *stdinDiff
+		return cfg
+	}
+
+	var repoList []string
+	if *workspace != "" {
+		var err error
+		if repoList, err = app.ReadWorkspace(*workspace); err != nil {
+			return err
+		}
+	}
+	for _, r := range strings.Split(*repos, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			repoList = append(repoList, r)
+		}
+	}
+	if len(repoList) == 0 {
+		return app.Suggest(ctx, suggestConfig(cf.repo))
+	}
+	cfgs := make([]app.Config, 0, len(repoList))
+	for _, r := range repoList {
+		cfgs = append(cfgs, suggestConfig(r))
+	}
+	return app.SuggestRepos(ctx, cfgs)
+}
+
+func runDumpPrompt(ctx context.Context, args []string) error {
+	fs := newFlagSet("dump-prompt")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	out := fs.String("out", "", "Output path (default: stdout)")
+	fs.StringVar(out, "dump-out", "", "Alias for -out")
+	stdinDiff := fs.Bool("stdin-diff", false, "Read a unified diff from stdin instead of staged changes")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.StdinDiff = *stdinDiff
+	cfg.DumpOutPath = *out
+	return app.DumpPrompt(ctx, cfg)
+}
+
+func runBench(ctx context.Context, args []string) error {
+	fs := newFlagSet("bench")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	providers := fs.String("providers", "", "Comma-separated providers to compare, each optionally with :model (e.g. openai:gpt-4o-mini)")
+	stdinDiff := fs.Bool("stdin-diff", false, "Read a unified diff from stdin instead of staged changes")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.StdinDiff = *stdinDiff
+	for _, p := range strings.Split(*providers, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			cfg.BenchProviders = append(cfg.BenchProviders, p)
+		}
+	}
+	return app.Bench(ctx, cfg)
+}
+
+func runDescribe(ctx context.Context, args []string) error {
+	fs := newFlagSet("describe")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return i18n.Errorf("describe takes one commit or patch file, got %d arguments", fs.NArg())
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.DescribeTarget = fs.Arg(0)
+	return app.Describe(ctx, cfg)
+}
+
+func runReview(ctx context.Context, args []string) error {
+	fs := newFlagSet("review")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	stdinDiff := fs.Bool("stdin-diff", false, "Review a unified diff from stdin instead of staged changes")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.StdinDiff = *stdinDiff
+	return app.Review(ctx, cfg)
+}
+
+func runPR(ctx context.Context, args []string) error {
+	fs := newFlagSet("pr")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	base := fs.String("base", "", "Branch to merge into (default: origin's default branch, else main)")
+	open := fs.Bool("open", false, "Push the branch and open the pull request (with gh if installed, else GITHUB_TOKEN)")
+	draft := fs.Bool("draft", false, "With --open, open the pull request as a draft")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.PRBase = *base
+	cfg.PROpen = *open
+	cfg.PRDraft = *draft
+	cfg.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	return app.PR(ctx, cfg)
+}
+
+func runMR(ctx context.Context, args []string) error {
+	fs := newFlagSet("mr")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	base := fs.String("base", "", "Branch to merge into (default: origin's default branch, else main)")
+	open := fs.Bool("open", false, "Push the branch and open the merge request with the GitLab API")
+	draft := fs.Bool("draft", false, "With --open, open the merge request as a draft")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.PRBase = *base
+	cfg.PROpen = *open
+	cfg.PRDraft = *draft
+	return app.MR(ctx, cfg)
+}
+
+func runWatch(ctx context.Context, args []string) error {
+	fs := newFlagSet("watch")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	interval := fs.Duration("interval", 2*time.Second, "How often to check the index for changes")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.WatchInterval = *interval
+	return app.Watch(ctx, cfg)
+}
+
+func runServe(ctx context.Context, args []string) error {
+	fs := newFlagSet("serve")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	addr := fs.String("addr", "127.0.0.1:7878", "Address to listen on")
+	token := fs.String("token", "", "Require this bearer token on every request (default: env COMMITGEN_SERVE_TOKEN)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.ServeAddr = *addr
+	cfg.ServeToken = config.ResolveString(*token, os.Getenv("COMMITGEN_SERVE_TOKEN"), "", "")
+	return app.Serve(ctx, cfg)
+}
+
+func runRPC(ctx context.Context, args []string) error {
+	fs := newFlagSet("rpc")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return app.RPC(ctx, resolveConfig(fs, &cf), os.Stdin, os.Stdout)
+}
+
+func runAction(ctx context.Context, args []string) error {
+	fs := newFlagSet("action")
+	var cf commonFlags
+	addCommonFlags(fs, &cf)
+	mode := fs.String("mode", "", "What to generate: description (PR body) or squash (squash-merge message comment) (default: env COMMITGEN_ACTION_MODE, else description)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.ActionMode = config.ResolveString(*mode, os.Getenv("COMMITGEN_ACTION_MODE"), "", app.ActionDescription)
+	cfg.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	return app.Action(ctx, cfg)
+}
+
+func runConfig(ctx context.Context, args []string) error {
+	fs := newFlagSet("config")
+	var cf commonFlags
+	addCommonFlags(fs, &cf) // so explain can show what the flags change
+	redact := fs.Bool("redact-keys", false, "show: replace API keys with a placeholder")
+	all := fs.Bool("all", false, "explain: also list settings that are set nowhere and empty")
+
+	// Flags may come before, between or after the words: config set --config x.json model y
+	var words []string
+	for {
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	action, rest := "", []string(nil)
+	if len(words) > 0 {
+		action, rest = words[0], words[1:]
+	}
+	var origins []config.Origin
+	if action == "explain" {
+		origins = explainConfig(fs, &cf) // before resolveConfig loads the .env files
+	}
+	cfg := resolveConfig(fs, &cf)
+
+	switch {
+	case action == "":
+		return app.Configure(cfg)
+	case action == "get" && len(rest) == 1:
+		return app.ConfigGet(cfg, rest[0])
+	case action == "set" && len(rest) == 2:
+		return app.ConfigSet(cfg, rest[0], rest[1])
+	case action == "show" && len(rest) == 0:
+		return app.ConfigShow(cfg, *redact)
+	case action == "explain" && len(rest) == 0:
+		return app.ConfigExplain(cfg, origins, *all)
+	default:
+		fs.Usage()
+		return i18n.Errorf("usage: commitgen config [get KEY | set KEY VALUE | show [--redact-keys] | explain [--all]] (settings: %s)", strings.Join(config.Keys(), ", "))
+	}
+}
+
+func runLint(ctx context.Context, args []string) error {
+	fs := newFlagSet("lint")
+	var cf commonFlags
+	addConfigFlag(fs, &cf)
+	addRepoFlag(fs, &cf)
+	addConventionalFlag(fs, &cf)
+	addProviderFlags(fs, &cf)
+	rangeFlag := fs.String("range", "", "Revision range to check, e.g. main..HEAD (default: last commit)")
+	fileFlag := fs.String("file", "", "Check the message in this file ('-' for stdin)")
+	formatFlag := fs.String("format", "", "Output format: text | json | github | junit (default: github in GitHub Actions, else text)")
+	fixFlag := fs.Bool("fix", false, "If the --file message fails, offer an AI-corrected version and save it on accept")
+	suggestFlag := fs.Bool("suggest", false, "Generate a replacement message for each failing commit (non-interactive)")
+	junitFlag := fs.String("junit", "", "Also write a JUnit XML report to this file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.LintRange = *rangeFlag
+	cfg.LintFile = *fileFlag
+	cfg.LintFormat = *formatFlag
+	cfg.LintFix = *fixFlag
+	cfg.LintSuggest = *suggestFlag
+	cfg.LintJUnitPath = *junitFlag
+	return app.Lint(ctx, cfg)
+}
+
+func runNextVersion(ctx context.Context, args []string) error {
+	fs := newFlagSet("next-version")
+	var cf commonFlags
+	addConfigFlag(fs, &cf)
+	addRepoFlag(fs, &cf)
+	from := fs.String("from", "", "Release tag to count from (default: the highest semantic-version tag reachable from HEAD)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.ReleaseFrom = *from
+	return app.NextVersion(ctx, cfg)
+}
+
+func runChangelog(ctx context.Context, args []string) error {
+	fs := newFlagSet("changelog")
+	var cf commonFlags
+	addConfigFlag(fs, &cf)
+	addRepoFlag(fs, &cf)
+	from := fs.String("from", "", "Release tag to list changes since (default: the highest semantic-version tag reachable from HEAD)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.ReleaseFrom = *from
+	return app.Changelog(ctx, cfg)
+}
+
+func runHistory(ctx context.Context, args []string) error {
+	fs := newFlagSet("history")
+	var cf commonFlags
+	addConfigFlag(fs, &cf)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return app.History(resolveConfig(fs, &cf))
+}
+
+func runStats(ctx context.Context, args []string) error {
+	fs := newFlagSet("stats")
+	var cf commonFlags
+	addConfigFlag(fs, &cf)
+	since := fs.Duration("since", 0, "Only count usage from this long ago, e.g. 168h (default: all)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(fs, &cf)
+	cfg.StatsSince = *since
+	return app.Stats(cfg)
+}
+
+func runFeedback(ctx context.Context, args []string) error {
+	fs := newFlagSet("feedback")
+	var cf commonFlags
+	addConfigFlag(fs, &cf)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return app.Feedback(ctx, resolveConfig(fs, &cf))
+}
+
+func runInit(ctx context.Context, args []string) error {
+	fs := newFlagSet("init")
+	var cf commonFlags
+	addRepoFlag(fs, &cf)
+	hook := fs.Bool("hook", false, "Also install the prepare-commit-msg hook")
+	force := fs.Bool("force", false, "Overwrite files that already exist")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return app.Init(ctx, cf.repo, *hook, *force)
+}
+
+func runResume(ctx context.Context, args []string) error {
+	fs := newFlagSet("resume")
+	var cf commonFlags
+	addRepoFlag(fs, &cf)
+	print := fs.Bool("print", false, "Print the saved message instead of committing it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return app.Resume(ctx, cf.repo, *print)
+}
+
+func runHook(ctx context.Context, args []string) error {
+	fs := newFlagSet("hook")
+	kind := fs.String("type", app.HookPrepareCommitMsg, "Hook to manage: prepare-commit-msg (generate), commit-msg (validate and fix) or post-commit (record how suggestions were committed)")
+
+	action := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "install":
+		return app.InstallHook(ctx, *kind)
+	case "uninstall":
+		return app.UninstallHook(ctx, *kind)
+	case "status":
+		return app.HookStatus(ctx)
+	default:
+		fs.Usage()
+		return i18n.Errorf("unknown hook action %q (use: install | uninstall | status)", action)
+	}
+}
+
+func runVersion(ctx context.Context, args []string) error {
+	fs := newFlagSet("version")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	fmt.Println("commitgen", version)
+	return nil
+}
@@ -2,112 +2,160 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/hoanghonghuy/commitgen/internal/app"
 	"github.com/hoanghonghuy/commitgen/internal/config"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/tracex"
 )
 
+// version is set at build time by goreleaser (-X main.version=...).
+var version = "dev"
+
 func main() {
-	// 1. Define flags
-	cmdFlag := flag.String("cmd", "suggest", "Command to run (suggest | dump-prompt | config | install-hook | uninstall-hook)")
-	repoFlag := flag.String("repo", "", "Path to git repository (default: current directory)")
-	baseURLFlag := flag.String("base-url", "", "AI provider base URL")
-	apiKeyFlag := flag.String("api-key", "", "AI provider API key")
-	modelFlag := flag.String("model", "", "AI model name")
-	providerFlag := flag.String("provider", "", "AI provider (openai | ollama | anthropic | gemini)")
-	
-	anthropicKeyFlag := flag.String("anthropic-key", "", "Anthropic API key")
-	geminiKeyFlag := flag.String("gemini-key", "", "Gemini API key")
-
-	recentNFlag := flag.Int("recent-n", 0, "Number of recent commits to include")
-	maxFilesFlag := flag.Int("max-files", 0, "Max staged files to analyze")
-	summarizeFlag := flag.Bool("summarize", false, "Summarize file content")
-	tempFlag := flag.Float64("temp", 0, "LLM temperature")
-	conventionalFlag := flag.Bool("conventional", false, "Enforce conventional commits")
-	
-	hookFlag := flag.String("hook", "", "Path to commit message file (used by git hook)")
-	dumpOutFlag := flag.String("dump-out", "", "Output path for dump-prompt")
-	instructionsFlag := flag.String("instructions", "", "Path to custom instructions file")
-	configPathFlag := flag.String("config", "", "Path to config file")
-
-	flag.Parse()
-
-	// Support positional commands (e.g., 'commitgen config' instead of 'commitgen -cmd=config')
-	cmd := *cmdFlag
-	if flag.NArg() > 0 {
-		posCmd := flag.Arg(0)
-		switch posCmd {
-		case "suggest", "dump-prompt", "config", "install-hook", "uninstall-hook":
-			cmd = posCmd
+	app.Version = version
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go handleInterrupts(sigChan, cancel, os.Exit)
+
+	i18n.SetLanguage(i18n.Detect(startupLanguage(), os.Getenv))
+	shutdownTracing := tracex.Setup(version)
+	err := run(ctx, os.Args[1:])
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 2*time.Second)
+	shutdownTracing(flushCtx)
+	cancelFlush()
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(app.ExitOK)
+	}
+	if ctx.Err() == context.Canceled && err != nil {
+		err = context.Canceled // interrupted; whatever failed did so because of it
+	}
+	code := app.ExitCode(err)
+	if hookRun(os.Args[1:]) {
+		code = app.HookExitCode(code)
+	}
+	// Lint findings were already reported, and a cancel needs no message.
+	if err != nil && code != app.ExitLintFailed && code != app.ExitCanceled {
+		fmt.Fprintln(os.Stderr, i18n.Tf("Error: %v", err))
+	}
+	os.Exit(code)
+}
+
+// handleInterrupts cancels the run on the first signal from sigs, and exits with
+// ExitCanceled on the second, e.g. when git is stuck on a dead mount. The exit is
+// explicit: the TUI listens for signals too, so they never reach the default
+// handler that would kill the process.
+func handleInterrupts(sigs <-chan os.Signal, cancel context.CancelFunc, exit func(int)) {
+	<-sigs
+	cancel()
+	<-sigs
+	exit(app.ExitCanceled)
+}
+
+// hookRun reports whether args run commitgen from the prepare-commit-msg hook.
+func hookRun(args []string) bool {
+	for _, a := range args {
+		if a == "--" {
+			break
+		}
+		if a == "--hook" || a == "-hook" || strings.HasPrefix(a, "--hook=") || strings.HasPrefix(a, "-hook=") {
+			return true
 		}
 	}
+	return false
+}
 
-	// 2. Load config from file
-	fileCfg, err := config.Load(*configPathFlag)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+// startupLanguage returns the language set in the environment or the default config
+// file. It is needed before flags are parsed, for -h; resolveConfig sets it again
+// from the config file given with --config.
+func startupLanguage() string {
+	if v := os.Getenv(config.EnvName("language")); v != "" {
+		return v
 	}
+	fileCfg, _ := config.Load("")
+	return fileCfg.Language
+}
+
+// run dispatches args to a subcommand. Without a subcommand name, "suggest" is
+// assumed so that `commitgen` and `commitgen --hook FILE` keep working.
+func run(ctx context.Context, args []string) error {
+	args = translateLegacyCmd(args)
 
-	// 3. Resolve final config (Flag > Env > File > Default)
-	cfg := app.Config{
-		Command:      cmd,
-		RepoArg:      *repoFlag,
-		BaseURL:      config.ResolveString(*baseURLFlag, os.Getenv("COMMITAI_BASE_URL"), fileCfg.BaseURL, ""),
-		APIKey:       config.ResolveString(*apiKeyFlag, os.Getenv("COMMITAI_API_KEY"), fileCfg.APIKey, ""),
-		Model:        config.ResolveString(*modelFlag, os.Getenv("COMMITAI_MODEL"), fileCfg.Model, "gpt-4o"),
-		Provider:     config.ResolveString(*providerFlag, os.Getenv("COMMITAI_PROVIDER"), fileCfg.Provider, "openai"),
-		
-		AnthropicKey: config.ResolveString(*anthropicKeyFlag, os.Getenv("COMMITAI_ANTHROPIC_KEY"), fileCfg.AnthropicKey, ""),
-		GeminiKey:    config.ResolveString(*geminiKeyFlag, os.Getenv("COMMITAI_GEMINI_KEY"), fileCfg.GeminiKey, ""),
-
-		RecentN:      config.ResolveInt(*recentNFlag, isFlagSet("recent-n"), fileCfg.RecentN, 5),
-		MaxFiles:     config.ResolveInt(*maxFilesFlag, isFlagSet("max-files"), fileCfg.MaxFiles, 10),
-		Summarize:    config.ResolveBool(*summarizeFlag, isFlagSet("summarize"), fileCfg.Summarize, true),
-		Temperature:  config.ResolveFloat(*tempFlag, isFlagSet("temp"), fileCfg.Temperature, 0.7),
-		Conventional: config.ResolveBool(*conventionalFlag, isFlagSet("conventional"), fileCfg.Conventional, true),
-		
-		HookFile:         *hookFlag,
-		DumpOutPath:      *dumpOutFlag,
-		InstructionsPath: *instructionsFlag,
-		ConfigPath:       *configPathFlag,
-		Timeout:          60 * time.Second,
-		PromptTemplate:   fileCfg.PromptTemplate,
+	name := "suggest"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// 4. Setup context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if name == "help" {
+		if len(args) > 0 {
+			if c := findCommand(args[0]); c != nil {
+				return c.run(ctx, []string{"-h"})
+			}
+		}
+		printUsage()
+		return nil
+	}
 
-	// Handle signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		cancel()
-	}()
-
-	// 5. Run application
-	if err := app.Run(ctx, cfg); err != nil {
-		if ctx.Err() == context.Canceled {
-			os.Exit(0)
+	c := findCommand(name)
+	if c == nil {
+		printUsage()
+		return i18n.Errorf("unknown command %q", name)
+	}
+	ctx, span := tracex.Start(ctx, "commitgen "+c.name, tracex.KindInternal)
+	err := c.run(ctx, args)
+	span.End(err)
+	return err
+}
+
+// translateLegacyCmd rewrites the old `-cmd=NAME` / `-cmd NAME` form into a subcommand.
+func translateLegacyCmd(args []string) []string {
+	for i, a := range args {
+		trimmed := strings.TrimLeft(a, "-")
+		if !strings.HasPrefix(a, "-") {
+			continue
 		}
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		var name string
+		var n int
+		switch {
+		case strings.HasPrefix(trimmed, "cmd="):
+			name, n = strings.TrimPrefix(trimmed, "cmd="), 1
+		case trimmed == "cmd" && i+1 < len(args):
+			name, n = args[i+1], 2
+		default:
+			continue
+		}
+		rest := append([]string{}, args[:i]...)
+		rest = append(rest, args[i+n:]...)
+		return append([]string{name}, rest...)
 	}
+	return args
 }
 
-func isFlagSet(name string) bool {
-	found := false
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == name {
-			found = true
+func printUsage() {
+	out := os.Stderr
+	fmt.Fprintln(out, i18n.T("Usage: commitgen <command> [flags]"))
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, i18n.T("Commands:"))
+	for _, c := range commands {
+		if c.hidden {
+			continue
 		}
-	})
-	return found
+		fmt.Fprintf(out, "  %-16s %s\n", c.name, i18n.T(c.summary))
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, i18n.T("Run 'commitgen help <command>' or 'commitgen <command> -h' for command flags."))
 }
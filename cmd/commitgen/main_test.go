@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/app"
+)
+
+func TestHandleInterrupts(t *testing.T) {
+	sigs := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exited := make(chan int, 1)
+	go handleInterrupts(sigs, cancel, func(code int) { exited <- code })
+
+	sigs <- os.Interrupt
+	<-ctx.Done()
+	select {
+	case code := <-exited:
+		t.Fatalf("exited with %d after one interrupt", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sigs <- os.Interrupt
+	select {
+	case code := <-exited:
+		if code != app.ExitCanceled {
+			t.Errorf("exit code %d; want %d", code, app.ExitCanceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a second interrupt did not exit")
+	}
+}
@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+)
+
+var (
+	// ErrRateLimited matches provider errors for too many requests or tokens in a period.
+	ErrRateLimited error = i18n.NewError("rate limited")
+	// ErrContextTooLarge matches provider errors for a prompt over the model's context window.
+	ErrContextTooLarge error = i18n.NewError("prompt too large for the model")
+	// ErrEmptyResponse is returned when a provider answers without any message text.
+	ErrEmptyResponse error = i18n.NewError("empty response")
+)
+
+// APIError is an error answer from a provider's API. It matches ErrRateLimited or
+// ErrContextTooLarge with errors.Is when its status or code says so.
+type APIError struct {
+	Provider   string // e.g. "openai"
+	StatusCode int    // 0 for errors inside a stream
+	Code       string // the provider's error type or code, if it gives one
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, msg)
+	}
+	return fmt.Sprintf("%s API error: %s", e.Provider, msg)
+}
+
+func (e *APIError) Is(target error) bool {
+	code, msg := strings.ToLower(e.Code), strings.ToLower(e.Message)
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			strings.Contains(code, "rate_limit") || code == "resource_exhausted"
+	case ErrContextTooLarge:
+		if e.StatusCode == http.StatusRequestEntityTooLarge || code == "context_length_exceeded" {
+			return true
+		}
+		for _, s := range tooLargeMessages {
+			if strings.Contains(msg, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tooLargeMessages are how providers word a prompt over the context window, where
+// they have no error code for it.
+var tooLargeMessages = []string{
+	"context length", // OpenAI and compatible servers
+	"context window",
+	"prompt is too long",                   // Anthropic
+	"exceeds the maximum number of tokens", // Gemini
+	"input is too long",
+}
@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"context"
+	"sync"
+)
+
+// Logprobs collects the token log probabilities a provider reported for a response.
+type Logprobs struct {
+	mu     sync.Mutex
+	sum    float64
+	tokens int
+}
+
+type logprobsKey struct{}
+
+// WithLogprobs returns a context in which providers that can report token log
+// probabilities ask for them and report them into the returned Logprobs. When a
+// generation makes several requests, the last response counts.
+func WithLogprobs(ctx context.Context) (context.Context, *Logprobs) {
+	lp := &Logprobs{}
+	return context.WithValue(ctx, logprobsKey{}, lp), lp
+}
+
+// LogprobsWanted reports whether the caller asked for log probabilities with WithLogprobs.
+func LogprobsWanted(ctx context.Context) bool {
+	_, ok := ctx.Value(logprobsKey{}).(*Logprobs)
+	return ok
+}
+
+// ReportLogprobs records the sum of the token log probabilities of a response, and
+// how many tokens it had, in the Logprobs in ctx, if any.
+func ReportLogprobs(ctx context.Context, sum float64, tokens int) {
+	lp, _ := ctx.Value(logprobsKey{}).(*Logprobs)
+	if lp == nil || tokens == 0 {
+		return
+	}
+	lp.mu.Lock()
+	lp.sum, lp.tokens = sum, tokens
+	lp.mu.Unlock()
+}
+
+// Mean returns the average token log probability of the response, and false if the
+// provider reported none.
+func (lp *Logprobs) Mean() (float64, bool) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	if lp.tokens == 0 {
+		return 0, false
+	}
+	return lp.sum / float64(lp.tokens), true
+}
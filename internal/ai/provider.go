@@ -11,3 +11,10 @@ type Provider interface {
 	// GenerateCommitMessage sends the prompt to the AI and returns the generated commit message text.
 	GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error)
 }
+
+// ContextWindower is implemented by providers whose model has a context window small
+// enough to matter, such as a local model. Prompts past it are cut, not rejected.
+type ContextWindower interface {
+	// ContextWindow returns the model's context window in tokens, or 0 if unknown.
+	ContextWindow(ctx context.Context) int
+}
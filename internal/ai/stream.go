@@ -0,0 +1,18 @@
+package ai
+
+import "context"
+
+type streamKey struct{}
+
+// WithStream returns a context in which providers that support streaming send the
+// response text to fn as it arrives, piece by piece. They still return the whole text.
+func WithStream(ctx context.Context, fn func(delta string)) context.Context {
+	return context.WithValue(ctx, streamKey{}, fn)
+}
+
+// StreamFunc returns the function set by WithStream, or nil if the caller doesn't
+// want the response streamed.
+func StreamFunc(ctx context.Context) func(delta string) {
+	fn, _ := ctx.Value(streamKey{}).(func(string))
+	return fn
+}
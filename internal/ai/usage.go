@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage counts the tokens a provider reported for one or more requests.
+type Usage struct {
+	mu         sync.Mutex
+	prompt     int
+	completion int
+	parent     *Usage // an enclosing WithUsage, which counts the same tokens
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context in which providers report token usage into the returned Usage.
+// Usage reported there also counts toward any Usage from an enclosing WithUsage.
+func WithUsage(ctx context.Context) (context.Context, *Usage) {
+	parent, _ := ctx.Value(usageKey{}).(*Usage)
+	u := &Usage{parent: parent}
+	return context.WithValue(ctx, usageKey{}, u), u
+}
+
+// ReportUsage adds the token counts of a response to the Usage in ctx, if any.
+func ReportUsage(ctx context.Context, prompt, completion int) {
+	u, _ := ctx.Value(usageKey{}).(*Usage)
+	for ; u != nil; u = u.parent {
+		u.mu.Lock()
+		u.prompt += prompt
+		u.completion += completion
+		u.mu.Unlock()
+	}
+}
+
+// Totals returns the counts reported so far.
+func (u *Usage) Totals() (prompt, completion int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.prompt, u.completion
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/hoanghonghuy/commitgen/internal/ai"
 	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
 )
 
@@ -47,6 +48,10 @@ type messageResponse struct {
 	Content []struct {
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
 }
 
 func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temperature float64) (string, error) {
@@ -108,7 +113,7 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("anthropic API error (status %d): %s", resp.StatusCode, string(body))
+		return "", apiError(resp.StatusCode, body)
 	}
 
 	var msgResp messageResponse
@@ -117,8 +122,24 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 	}
 
 	if len(msgResp.Content) == 0 {
-		return "", fmt.Errorf("empty response content")
+		return "", fmt.Errorf("anthropic: %w", ai.ErrEmptyResponse)
 	}
+	ai.ReportUsage(ctx, msgResp.Usage.InputTokens, msgResp.Usage.OutputTokens)
 
 	return msgResp.Content[0].Text, nil
 }
+
+// apiError returns the error in an error response, whose body is
+// {"type": "error", "error": {"type": ..., "message": ...}}.
+func apiError(status int, body []byte) error {
+	var out struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &out) != nil || out.Error.Message == "" {
+		return &ai.APIError{Provider: "anthropic", StatusCode: status, Message: strings.TrimSpace(string(body))}
+	}
+	return &ai.APIError{Provider: "anthropic", StatusCode: status, Code: out.Error.Type, Message: out.Error.Message}
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/github"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// Action modes: what the GitHub Action generates for a pull request.
+const (
+	ActionDescription = "description" // rewrite the generated part of the PR description
+	ActionSquash      = "squash"      // post the squash-merge commit message as a PR comment
+)
+
+// Markers delimiting commitgen's output, so reruns update it instead of appending.
+const (
+	descriptionStart = "<!-- commitgen:description -->"
+	descriptionEnd   = "<!-- /commitgen:description -->"
+	squashMarker     = "<!-- commitgen:squash -->"
+)
+
+// maxPRDiffSize caps how much of the pull request diff goes into a description prompt.
+const maxPRDiffSize = 32 * 1024
+
+// actionEnv is what the Actions runner tells us about the run.
+type actionEnv struct {
+	eventName string
+	eventPath string
+	repo      string // owner/name
+	apiURL    string
+	token     string
+	output    string // GITHUB_OUTPUT file
+}
+
+func actionEnvFromOS(cfg Config) actionEnv {
+	return actionEnv{
+		eventName: os.Getenv("GITHUB_EVENT_NAME"),
+		eventPath: os.Getenv("GITHUB_EVENT_PATH"),
+		repo:      os.Getenv("GITHUB_REPOSITORY"),
+		apiURL:    os.Getenv("GITHUB_API_URL"),
+		token:     cfg.GitHubToken,
+		output:    os.Getenv("GITHUB_OUTPUT"),
+	}
+}
+
+type pullRequestEvent struct {
+	PullRequest *github.PullRequest `json:"pull_request"`
+}
+
+// Action runs inside a GitHub Actions job without a terminal. For the pull request in
+// GITHUB_EVENT_PATH it generates a description or a squash-merge commit message
+// (cfg.ActionMode) and posts it with the GitHub API.
+func Action(ctx context.Context, cfg Config) error {
+	env := actionEnvFromOS(cfg)
+	if env.eventPath == "" || env.repo == "" {
+		return errors.New("not running in GitHub Actions (GITHUB_EVENT_PATH and GITHUB_REPOSITORY are unset)")
+	}
+	if env.token == "" {
+		return errors.New("missing GitHub token. Set env GITHUB_TOKEN (the action passes its github-token input)")
+	}
+	mode := cfg.ActionMode
+	if mode == "" {
+		mode = ActionDescription
+	}
+	if mode != ActionDescription && mode != ActionSquash {
+		return fmt.Errorf("unknown action mode %q (use: %s | %s)", mode, ActionDescription, ActionSquash)
+	}
+
+	b, err := os.ReadFile(env.eventPath)
+	if err != nil {
+		return fmt.Errorf("read event: %w", err)
+	}
+	var ev pullRequestEvent
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return fmt.Errorf("decode event: %w", err)
+	}
+	if ev.PullRequest == nil {
+		return fmt.Errorf("event %q has no pull request; run the action on pull_request or pull_request_target", env.eventName)
+	}
+	pr := *ev.PullRequest
+
+	gh := github.New(github.Config{BaseURL: env.apiURL, Token: env.token})
+	diff, err := gh.PullRequestDiff(ctx, env.repo, pr.Number)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(diff) == "" {
+		infof("Pull request #%d has no changes; nothing to do.\n", pr.Number)
+		return nil
+	}
+
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var msg string
+	switch mode {
+	case ActionDescription:
+		commits, err := gh.PullRequestCommits(ctx, env.repo, pr.Number)
+		if err != nil {
+			return err
+		}
+		msgs := make([]string, 0, len(commits))
+		for _, c := range commits {
+			msgs = append(msgs, c.Commit.Message)
+		}
+		if msg, err = describePullRequest(genCtx, cfg, provider, "", pr.Title, msgs, diff); err != nil {
+			return err
+		}
+		if err := gh.UpdatePullRequestBody(ctx, env.repo, pr.Number, mergeDescription(pr.Body, msg)); err != nil {
+			return err
+		}
+		infof("Updated the description of pull request #%d.\n", pr.Number)
+
+	case ActionSquash:
+		// The checkout, if the workflow made one, adds recent history as context.
+		repoRoot, _ := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+		p, err := preparePromptFromDiff(ctx, cfg, repoRoot, diff)
+		if err != nil {
+			return err
+		}
+		msg, err = generate.Message(genCtx, forPrompt(genCtx, provider, p, cfg), p.msgs, cfg.Temperature, cfg.Conventional)
+		if err != nil {
+			return err
+		}
+		msg = strings.TrimSpace(msg)
+		if err := upsertSquashComment(ctx, gh, env.repo, pr.Number, msg); err != nil {
+			return err
+		}
+		infof("Posted the suggested squash message on pull request #%d.\n", pr.Number)
+	}
+
+	if env.output != "" {
+		if err := writeActionOutput(env.output, "message", msg); err != nil {
+			slog.Warn("could not write step output", "err", err)
+		}
+	}
+	return nil
+}
+
+// describePullRequest asks provider for a markdown description of a pull request
+// with title, commits (oldest first) and diff, less the files filterDiff leaves
+// out. repoRoot, if known, is where instructions paths are looked up.
+func describePullRequest(ctx context.Context, cfg Config, provider ai.Provider, repoRoot, title string, commits []string, diff string) (string, error) {
+	diff = filterDiff(cfg, diff)
+	if len(diff) > maxPRDiffSize {
+		diff = diff[:maxPRDiffSize] + "\n...[Diff truncated due to size]..."
+	}
+	customInstructions, err := loadInstructions(ctx, repoRoot, cfg.InstructionsPaths)
+	if err != nil {
+		return "", err
+	}
+	raw, err := provider.GenerateCommitMessage(ctx, vscodeprompt.BuildPRMessages(vscodeprompt.PRData{
+		Title:              title,
+		Commits:            commits,
+		Diff:               diff,
+		CustomInstructions: customInstructions,
+	}), cfg.Temperature)
+	if err != nil {
+		return "", err
+	}
+	return unfence(raw), nil
+}
+
+// unfence strips a code fence the model may have wrapped the whole answer in.
+func unfence(s string) string {
+	s = strings.TrimSpace(s)
+	first, rest, ok := strings.Cut(s, "\n")
+	if ok && strings.HasPrefix(first, "```") && strings.HasSuffix(rest, "```") {
+		return strings.TrimSpace(strings.TrimSuffix(rest, "```"))
+	}
+	return s
+}
+
+// mergeDescription puts desc into body between the commitgen markers, replacing
+// an earlier run's output and keeping whatever the author wrote around it.
+func mergeDescription(body, desc string) string {
+	block := descriptionStart + "\n" + desc + "\n" + descriptionEnd
+	if i := strings.Index(body, descriptionStart); i >= 0 {
+		if j := strings.Index(body[i:], descriptionEnd); j >= 0 {
+			return body[:i] + block + body[i+j+len(descriptionEnd):]
+		}
+	}
+	if strings.TrimSpace(body) == "" {
+		return block
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + block
+}
+
+// upsertSquashComment posts msg as a comment on pull request n, or edits the one from an earlier run.
+func upsertSquashComment(ctx context.Context, gh *github.Client, repo string, n int, msg string) error {
+	body := squashMarker + "\n**Suggested squash-merge commit message**\n\n````text\n" + msg + "\n````\n"
+	comments, err := gh.IssueComments(ctx, repo, n)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if strings.HasPrefix(c.Body, squashMarker) {
+			return gh.UpdateComment(ctx, repo, c.ID, body)
+		}
+	}
+	return gh.CreateComment(ctx, repo, n, body)
+}
+
+// writeActionOutput appends a (possibly multi-line) step output to the GITHUB_OUTPUT file.
+func writeActionOutput(path, name, value string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	delim := "COMMITGEN_EOF"
+	for strings.Contains(value, delim) {
+		delim += "_"
+	}
+	_, err = fmt.Fprintf(f, "%s<<%s\n%s\n%s\n", name, delim, value, delim)
+	return err
+}
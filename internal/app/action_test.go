@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMergeDescription(t *testing.T) {
+	block := descriptionStart + "\nnew\n" + descriptionEnd
+	tests := []struct {
+		name, body, want string
+	}{
+		{"empty", "", block},
+		{"author text", "Fixes #1\n", "Fixes #1\n\n" + block},
+		{"rerun", "Intro\n\n" + descriptionStart + "\nold\n" + descriptionEnd + "\n\nOutro", "Intro\n\n" + block + "\n\nOutro"},
+	}
+	for _, tt := range tests {
+		if got := mergeDescription(tt.body, "new"); got != tt.want {
+			t.Errorf("%s: mergeDescription() = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnfence(t *testing.T) {
+	if got := unfence("```markdown\nAdds X.\n\n- one\n```"); got != "Adds X.\n\n- one" {
+		t.Errorf("unfence() = %q", got)
+	}
+	if got := unfence("Adds X.\n```go\nx()\n```"); got != "Adds X.\n```go\nx()\n```" {
+		t.Errorf("unfence() changed unwrapped text: %q", got)
+	}
+}
+
+func TestDescribePullRequestFilters(t *testing.T) {
+	diff := "diff --git a/.env b/.env\n--- a/.env\n+++ b/.env\n@@ -0,0 +1 @@\n+API_KEY=sk-live-1\n" +
+		"diff --git a/app.go b/app.go\n--- a/app.go\n+++ b/app.go\n@@ -1 +1 @@\n-a\n+b\n"
+	rp := &scriptedProvider{script: summarize}
+	if _, err := describePullRequest(context.Background(), Config{}, rp, "", "Title", nil, diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(rp.users) != 1 || strings.Contains(rp.users[0], "sk-live") || !strings.Contains(rp.users[0], "app.go") {
+		t.Errorf("prompt = %q; want app.go only", rp.users)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/audit"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// auditedProvider records each request in the audit log before sending it, and
+// its outcome afterwards. A request that cannot be logged is not sent.
+type auditedProvider struct {
+	ai.Provider
+	path     string
+	redactor *audit.Redactor
+	provider string
+	model    string
+	endpoint string
+	user     string
+}
+
+func newAuditedProvider(p ai.Provider, cfg Config, provider string) (*auditedProvider, error) {
+	r, err := audit.NewRedactor(cfg.AuditRedact)
+	if err != nil {
+		return nil, err
+	}
+	ap := &auditedProvider{Provider: p, path: cfg.AuditLog, redactor: r, provider: provider, model: cfg.Model, endpoint: providerEndpoint(cfg, provider)}
+	if u, err := user.Current(); err == nil {
+		ap.user = u.Username
+	}
+	return ap, nil
+}
+
+func (p *auditedProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	e := audit.Entry{ID: hex.EncodeToString(id[:]), Event: audit.EventRequest, Provider: p.provider, Model: p.model, Endpoint: p.endpoint, User: p.user}
+	for _, m := range vscodeprompt.ToOpenAIMessages(msgs) {
+		content, n := p.redactor.Redact(m.Content)
+		e.Messages = append(e.Messages, audit.Message{Role: m.Role, Content: content})
+		e.Redactions += n
+	}
+	if err := audit.Append(p.path, e); err != nil {
+		return "", fmt.Errorf("audit log: %w", err)
+	}
+
+	start := time.Now()
+	out, err := p.Provider.GenerateCommitMessage(ctx, msgs, temp)
+	e.Time, e.Event, e.Messages, e.LatencyMs = time.Time{}, audit.EventResponse, nil, time.Since(start).Milliseconds()
+	e.Response, e.Redactions = p.redactor.Redact(out)
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if aerr := audit.Append(p.path, e); aerr != nil && err == nil {
+		return "", fmt.Errorf("audit log: %w", aerr)
+	}
+	return out, err
+}
+
+// ContextWindow passes the provider's context window through, if it has one.
+func (p *auditedProvider) ContextWindow(ctx context.Context) int {
+	if cw, ok := p.Provider.(ai.ContextWindower); ok {
+		return cw.ContextWindow(ctx)
+	}
+	return 0
+}
+
+// providerEndpoint returns where cfg's requests to provider go.
+func providerEndpoint(cfg Config, provider string) string {
+	switch provider {
+	case "anthropic":
+		return "https://api.anthropic.com"
+	case "gemini":
+		return "https://generativelanguage.googleapis.com"
+	}
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	if provider == "ollama" {
+		return "http://localhost:11434"
+	}
+	return "https://api.openai.com/v1"
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestAuditedProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	cfg := Config{Model: "gpt-4o", BaseURL: "https://llm.internal/v1", AuditLog: path, AuditRedact: []string{`ACME-\d+`}}
+	msgs := vscodeprompt.BuildVSCodeMessages(vscodeprompt.Data{Changes: []vscodeprompt.Change{
+		{Path: ".env", Diff: "+API_KEY=abcdef123456\n+ticket ACME-42"},
+	}})
+
+	p, err := newAuditedProvider(&scriptedProvider{reply: "feat: add ACME-42 config"}, cfg, "openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GenerateCommitMessage(context.Background(), msgs, 0.7); err != nil {
+		t.Fatal(err)
+	}
+	p.Provider = &scriptedProvider{err: errors.New("boom")}
+	if _, err := p.GenerateCommitMessage(context.Background(), msgs, 0.7); err == nil {
+		t.Fatal("expected the provider's error")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("%d lines, want 4:\n%s", len(lines), b)
+	}
+	for _, want := range []string{`"event":"request"`, `"endpoint":"https://llm.internal/v1"`, `API_KEY=[REDACTED]`, `ticket [REDACTED]`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("request line lacks %s:\n%s", want, lines[0])
+		}
+	}
+	if !strings.Contains(lines[1], `"response":"feat: add [REDACTED] config"`) || !strings.Contains(lines[3], `"error":"boom"`) {
+		t.Errorf("response lines:\n%s\n%s", lines[1], lines[3])
+	}
+	if strings.Contains(string(b), "abcdef123456") || strings.Contains(string(b), "ACME-42") {
+		t.Error("secret written to the audit log")
+	}
+
+	// A request that cannot be logged is not sent.
+	cfg.AuditLog = filepath.Join(t.TempDir(), "missing", "audit.jsonl")
+	p, _ = newAuditedProvider(&scriptedProvider{reply: "feat: x"}, cfg, "openai")
+	if _, err := p.GenerateCommitMessage(context.Background(), msgs, 0.7); err == nil {
+		t.Error("unwritable audit log: expected an error")
+	}
+}
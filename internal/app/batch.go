@@ -0,0 +1,125 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+// ReadWorkspace reads a workspace file: one repository path per line, relative to
+// the file's directory unless absolute. Blank lines and lines starting with '#'
+// are skipped.
+func ReadWorkspace(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read workspace: %w", err)
+	}
+	defer f.Close()
+
+	var repos []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		ln := strings.TrimSpace(sc.Text())
+		if ln == "" || strings.HasPrefix(ln, "#") {
+			continue
+		}
+		if !filepath.IsAbs(ln) {
+			ln = filepath.Join(filepath.Dir(path), ln)
+		}
+		repos = append(repos, ln)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read workspace: %w", err)
+	}
+	return repos, nil
+}
+
+// SuggestRepos runs Suggest in turn for each repository in cfgs (by RepoArg) that
+// has staged changes, then prints what happened in each. Quitting one repository's
+// TUI moves on to the next. It fails if Suggest failed in any repository.
+func SuggestRepos(ctx context.Context, cfgs []Config) error {
+	if len(cfgs) > 0 && (cfgs[0].HookFile != "" || cfgs[0].StdinDiff) {
+		return errors.New("--repos and --workspace can't be combined with --hook or --stdin-diff")
+	}
+
+	type outcome struct{ repo, result string }
+	var outcomes []outcome
+	failed := 0
+	for i, cfg := range cfgs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := cfg.RepoArg
+		root, err := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+		if err == nil {
+			name = gitx.RepoNameFromRoot(root)
+		}
+		var staged, dirty bool
+		if err == nil {
+			staged, dirty, err = gitx.ChangeState(ctx, root)
+		}
+		switch {
+		case err != nil:
+			outcomes = append(outcomes, outcome{name, fmt.Sprintf("failed: %v", err)})
+			failed++
+			continue
+		case !staged && dirty:
+			outcomes = append(outcomes, outcome{name, "nothing staged (has unstaged changes; run git add)"})
+			continue
+		case !staged:
+			outcomes = append(outcomes, outcome{name, "no changes"})
+			continue
+		}
+
+		cfg.RepoArg = root
+		infof("\n── %s (%d/%d) ──\n", name, i+1, len(cfgs))
+		before := headCommit(ctx, root)
+		err = Suggest(ctx, cfg)
+		switch {
+		case err == nil:
+			result := "nothing committed"
+			if after := headCommit(ctx, root); after != before && after != "" {
+				result = "committed " + after
+			} else if cfg.DryRun {
+				result = "dry run: nothing committed"
+			}
+			outcomes = append(outcomes, outcome{name, result})
+		case errors.Is(err, ErrCanceled) && ctx.Err() == nil:
+			outcomes = append(outcomes, outcome{name, "skipped"})
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			outcomes = append(outcomes, outcome{name, fmt.Sprintf("failed: %v", err)})
+			failed++
+		}
+	}
+
+	width := 0
+	for _, o := range outcomes {
+		width = max(width, len(o.repo))
+	}
+	infof("\n")
+	for _, o := range outcomes {
+		infof("  %-*s  %s\n", width, o.repo, o.result)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed", failed, len(cfgs))
+	}
+	return nil
+}
+
+// headCommit returns the abbreviated hash and subject of HEAD in repoRoot, or ""
+// before the first commit.
+func headCommit(ctx context.Context, repoRoot string) string {
+	out, err := gitx.Git(ctx, repoRoot, "log", "-1", "--format=%h %s")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
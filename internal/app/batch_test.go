@@ -0,0 +1,21 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "feature.repos")
+	if err := os.WriteFile(path, []byte("# the checkout service\napi\n\n../web\n/srv/shared\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadWorkspace(path)
+	want := []string{filepath.Join(dir, "api"), filepath.Join(filepath.Dir(dir), "web"), "/srv/shared"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, %v; want %q", got, err, want)
+	}
+}
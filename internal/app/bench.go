@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+)
+
+// defaultModels are used by bench for providers other than the configured one
+// when no model is given ("ollama" rather than "ollama:qwen2.5-coder").
+var defaultModels = map[string]string{
+	"openai":    "gpt-4o",
+	"anthropic": "claude-3-5-sonnet-latest",
+	"gemini":    "gemini-1.5-pro",
+	"ollama":    "llama3.1",
+}
+
+type benchResult struct {
+	provider         string
+	model            string
+	message          string
+	latency          time.Duration
+	promptTokens     int
+	completionTokens int
+	err              error
+}
+
+// benchTarget returns the config for one --providers entry, "provider" or "provider:model".
+func benchTarget(cfg Config, entry string) (Config, error) {
+	name, model, _ := strings.Cut(strings.TrimSpace(entry), ":")
+	name = strings.ToLower(name)
+	if _, ok := defaultModels[name]; !ok {
+		return cfg, fmt.Errorf("unknown provider %q in --providers (supported: openai, ollama, anthropic, gemini)", name)
+	}
+	if name != strings.ToLower(cfg.Provider) {
+		// The base URL belongs to the configured provider.
+		cfg.BaseURL = ""
+		cfg.Model = defaultModels[name]
+	}
+	if model != "" {
+		cfg.Model = model
+	}
+	cfg.Provider = name
+	return cfg, nil
+}
+
+// Bench sends the prompt for the staged changes to every provider in cfg.BenchProviders
+// at once and prints the messages with their latency and token counts.
+func Bench(ctx context.Context, cfg Config) error {
+	if len(cfg.BenchProviders) == 0 {
+		return errors.New("no providers to compare. Use --providers openai,ollama:llama3.1,anthropic")
+	}
+	targets := make([]Config, 0, len(cfg.BenchProviders))
+	for _, entry := range cfg.BenchProviders {
+		t, err := benchTarget(cfg, entry)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, t)
+	}
+
+	pr, err := preparePrompt(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	infof("Sending the same prompt (%d files) to %d providers...\n\n", len(pr.data.Changes), len(targets))
+
+	results := make([]benchResult, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		results[i] = benchResult{provider: t.Provider, model: t.Model}
+		provider, err := newProvider(ctx, t)
+		if err != nil {
+			results[i].err = err
+			continue
+		}
+		wg.Add(1)
+		go func(r *benchResult, provider ai.Provider) {
+			defer wg.Done()
+			genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+			genCtx, usage := ai.WithUsage(genCtx)
+			start := time.Now()
+			msg, err := generate.Message(genCtx, forPrompt(genCtx, provider, pr, t), pr.msgs, t.Temperature, t.Conventional)
+			r.latency = time.Since(start)
+			r.message, r.err = strings.TrimSpace(msg), err
+			r.promptTokens, r.completionTokens = usage.Totals()
+		}(&results[i], provider)
+	}
+	wg.Wait()
+
+	printBench(os.Stdout, results)
+	for _, r := range results {
+		if r.err == nil {
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("%w: every provider failed", ErrProvider)
+}
+
+func printBench(w io.Writer, results []benchResult) {
+	for _, r := range results {
+		fmt.Fprintf(w, "── %s / %s ", r.provider, r.model)
+		if r.err != nil {
+			fmt.Fprintf(w, "(%s)\n%v\n\n", i18n.T("failed"), r.err)
+			continue
+		}
+		fmt.Fprintf(w, "(%s, %d → %d tokens)\n%s\n\n", r.latency.Round(time.Millisecond), r.promptTokens, r.completionTokens, r.message)
+	}
+
+	// Fastest first; failures last.
+	sorted := append([]benchResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].err == nil) != (sorted[j].err == nil) {
+			return sorted[i].err == nil
+		}
+		return sorted[i].latency < sorted[j].latency
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tMODEL\tLATENCY\tTOKENS IN\tTOKENS OUT\tSUBJECT")
+	for _, r := range sorted {
+		if r.err != nil {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t(error)\n", r.provider, r.model)
+			continue
+		}
+		subject, _, _ := strings.Cut(r.message, "\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", r.provider, r.model, r.latency.Round(time.Millisecond), r.promptTokens, r.completionTokens, subject)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// bestOfTemperature is the lowest temperature candidates are generated at, so that
+// they differ.
+const bestOfTemperature = 0.7
+
+// maxBestOf caps --best-of: each candidate is a full request, and more than a few
+// rarely find a better message.
+const maxBestOf = 5
+
+// issuePenalty is what each issue found in a candidate costs it, against its average
+// token log probability (typically between -1 and 0), so that a valid message wins.
+const issuePenalty = 1.0
+
+// bestOfProvider generates n candidate messages at once and returns the one the model
+// was most sure of, by average token log probability, less a penalty for each issue
+// found locally (--best-of). Providers that report no log probabilities are ranked
+// on the issues alone, the first candidate winning a tie.
+type bestOfProvider struct {
+	ai.Provider
+	n       int
+	checker generate.Checker
+}
+
+type candidate struct {
+	raw     string
+	err     error
+	score   float64
+	logprob float64
+	issues  []string
+}
+
+func (p *bestOfProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	// Only the chosen message would be worth streaming, and it is known only at the end.
+	ctx = ai.WithStream(ctx, nil)
+	temp = max(temp, bestOfTemperature)
+
+	cands := make([]candidate, p.n)
+	var wg sync.WaitGroup
+	for i := range cands {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cctx, lp := ai.WithLogprobs(ctx)
+			c := &cands[i]
+			if c.raw, c.err = p.Provider.GenerateCommitMessage(cctx, msgs, temp); c.err != nil {
+				return
+			}
+			msg, ok := vscodeprompt.ExtractOneTextCodeBlock(c.raw)
+			if !ok {
+				msg = c.raw
+			}
+			c.issues = p.checker.Issues(msg)
+			c.logprob, _ = lp.Mean()
+			c.score = c.logprob - issuePenalty*float64(len(c.issues))
+		}()
+	}
+	wg.Wait()
+
+	best := -1
+	for i, c := range cands {
+		if c.err != nil {
+			slog.Debug("candidate failed", "candidate", i+1, "err", c.err)
+			continue
+		}
+		slog.Debug("candidate", "candidate", i+1, "score", c.score, "logprob", c.logprob, "issues", c.issues)
+		if best < 0 || c.score > cands[best].score {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", cands[0].err
+	}
+	return cands[best].raw, nil
+}
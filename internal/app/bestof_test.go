@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// ranked answers with each of answers in turn, reporting its log probability
+// unless logprob is nil.
+func ranked(answers []string, logprob []float64) *scriptedProvider {
+	return &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		if logprob != nil {
+			ai.ReportLogprobs(ctx, logprob[n]*10, 10)
+		}
+		return "```text\n" + answers[n] + "\n```", nil
+	}}
+}
+
+func TestBestOf(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "p.go", Diff: "+if s == \"\" {\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	cfg := Config{BestOf: 3, MaxSubjectLength: 50}
+	answers := []string{
+		"fix(parser): handle empty input",
+		"fix(parser): return early on empty input",
+		"fix(parser): handle empty input by returning an empty AST instead of panicking",
+	}
+
+	// The likeliest message wins unless it has issues.
+	rp := ranked(answers, []float64{-0.5, -0.2, -0.1})
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), rp, pr, cfg), pr.msgs, 0, false)
+	if err != nil || msg != answers[1] {
+		t.Fatalf("got %q, %v; want %q", msg, err, answers[1])
+	}
+	if len(rp.temps) != 3 || rp.temps[0] != bestOfTemperature {
+		t.Errorf("temperatures = %v; want 3 at %v", rp.temps, bestOfTemperature)
+	}
+
+	// Without log probabilities, a message without issues wins.
+	rp = ranked([]string{answers[2], answers[0], answers[2]}, nil)
+	msg, err = generate.Message(context.Background(), forPrompt(context.Background(), rp, pr, cfg), pr.msgs, 0, false)
+	if err != nil || msg != answers[0] {
+		t.Fatalf("no logprobs: got %q, %v; want %q", msg, err, answers[0])
+	}
+
+	// At most maxBestOf candidates are generated.
+	rp = ranked([]string{answers[0], answers[0], answers[0], answers[0], answers[0], answers[0]}, nil)
+	cfg.BestOf = 50
+	if _, err := generate.Message(context.Background(), forPrompt(context.Background(), rp, pr, cfg), pr.msgs, 0, false); err != nil || len(rp.temps) != maxBestOf {
+		t.Errorf("best of 50: %d requests, %v; want %d", len(rp.temps), err, maxBestOf)
+	}
+}
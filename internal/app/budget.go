@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+)
+
+// localProviders run on the user's machine, so tokens cost nothing and spend limits
+// don't apply to them.
+var localProviders = []string{"ollama"}
+
+// modelPrice is what a model costs, in US dollars per million tokens.
+type modelPrice struct{ input, output float64 }
+
+// parseModelPrices reads model_prices entries, "model=input/output" with each the
+// price per million tokens, e.g. "gpt-4o=2.5/10".
+func parseModelPrices(entries []string) (map[string]modelPrice, error) {
+	prices := make(map[string]modelPrice, len(entries))
+	for _, entry := range entries {
+		model, price, _ := strings.Cut(entry, "=")
+		in, out, ok := strings.Cut(price, "/")
+		input, errIn := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		output, errOut := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		model = strings.TrimSpace(model)
+		if model == "" || !ok || errIn != nil || errOut != nil || input < 0 || output < 0 {
+			return nil, fmt.Errorf("model_prices: %q is not model=input/output, e.g. gpt-4o=2.5/10", entry)
+		}
+		prices[model] = modelPrice{input, output}
+	}
+	return prices, nil
+}
+
+// overBudget returns why a run whose prompt is estimated at tokens breaks cfg's spend
+// limits, or "" if it doesn't. Today's usage, from midnight local time, is read from
+// the stats file; local providers' usage doesn't count. Costs are estimated from
+// cfg.ModelPrices, and the usage of models without a price costs nothing.
+func overBudget(cfg Config, tokens int, now time.Time) (string, error) {
+	if cfg.NoAI || slices.Contains(localProviders, strings.ToLower(cfg.Provider)) {
+		return "", nil
+	}
+	prices, err := parseModelPrices(cfg.ModelPrices)
+	if err != nil {
+		return "", err
+	}
+	price, priced := prices[cfg.Model]
+	cost := float64(tokens) * price.input / 1e6
+	if cfg.MaxTokensPerRun > 0 && tokens > cfg.MaxTokensPerRun {
+		return fmt.Sprintf("the prompt is about %d tokens, over max_tokens_per_run (%d)", tokens, cfg.MaxTokensPerRun), nil
+	}
+	if cfg.MaxCostPerRun > 0 && priced && cost > cfg.MaxCostPerRun {
+		return fmt.Sprintf("the prompt costs about $%.4f, over max_cost_per_run ($%g)", cost, cfg.MaxCostPerRun), nil
+	}
+	if cfg.MaxTokensPerDay <= 0 && cfg.MaxCostPerDay <= 0 {
+		return "", nil
+	}
+
+	events, _ := stats.Load(cfg.StatsPath)
+	events = slices.DeleteFunc(events, func(e stats.Event) bool { return slices.Contains(localProviders, e.Provider) })
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if used := stats.TokensSince(events, midnight); cfg.MaxTokensPerDay > 0 && used+tokens > cfg.MaxTokensPerDay {
+		return fmt.Sprintf("%d tokens used today and about %d more for this prompt, over max_tokens_per_day (%d)", used, tokens, cfg.MaxTokensPerDay), nil
+	}
+	if cfg.MaxCostPerDay > 0 {
+		spent := 0.0
+		for _, e := range events {
+			if e.Time.Before(midnight) {
+				continue
+			}
+			p := prices[e.Model]
+			spent += (float64(e.PromptTokens)*p.input + float64(e.CompletionTokens)*p.output) / 1e6
+		}
+		if spent+cost > cfg.MaxCostPerDay {
+			return fmt.Sprintf("about $%.4f spent today and $%.4f more for this prompt, over max_cost_per_day ($%g)", spent, cost, cfg.MaxCostPerDay), nil
+		}
+	}
+	return "", nil
+}
+
+// applyBudget checks a run whose prompt is estimated at tokens against cfg's spend
+// limits. Over them it warns, and returns cfg switched to cfg.BudgetFallback: the
+// message template for "no-ai", else that model, written as for --compare. Without
+// a fallback the run goes on as configured. Commands whose output the template
+// can't write, such as pull request descriptions, pass template false: for them
+// the "no-ai" fallback is an error, and nothing is sent.
+func applyBudget(cfg Config, tokens int, template bool) (Config, error) {
+	reason, err := overBudget(cfg, tokens, time.Now())
+	if err != nil || reason == "" {
+		return cfg, err
+	}
+	switch fallback := strings.TrimSpace(cfg.BudgetFallback); fallback {
+	case "":
+		slog.Warn("over the spend limits; set budget_fallback to use something cheaper", "reason", reason)
+		return cfg, nil
+	case "no-ai":
+		if !template {
+			return cfg, fmt.Errorf("over the spend limits: %s (budget_fallback is no-ai, which can't write this)", reason)
+		}
+		slog.Warn("over the spend limits; filling the message template instead", "reason", reason)
+		cfg.NoAI = true
+		return cfg, nil
+	default:
+		t, err := compareTarget(cfg, fallback)
+		if err != nil {
+			return cfg, fmt.Errorf("budget_fallback: %w", err)
+		}
+		slog.Warn("over the spend limits; using budget_fallback", "reason", reason, "provider", t.Provider, "model", t.Model)
+		return t, nil
+	}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	now := time.Date(2025, 3, 4, 15, 0, 0, 0, time.Local)
+	for _, e := range []stats.Event{
+		{Time: now.Add(-24 * time.Hour), Provider: "openai", Model: "gpt-4o", PromptTokens: 50000},
+		{Time: now.Add(-time.Hour), Provider: "openai", Model: "gpt-4o", PromptTokens: 8000, CompletionTokens: 200},
+		{Time: now.Add(-time.Hour), Provider: "ollama", PromptTokens: 90000},
+	} {
+		if err := stats.Append(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := Config{Provider: "openai", Model: "gpt-4o", StatsPath: path, MaxTokensPerRun: 5000, MaxTokensPerDay: 10000}
+
+	if reason, _ := overBudget(cfg, 1000, now); reason != "" {
+		t.Errorf("1000 tokens: over budget: %s", reason)
+	}
+	if reason, _ := overBudget(cfg, 6000, now); !strings.Contains(reason, "max_tokens_per_run") {
+		t.Errorf("6000 tokens: reason = %q, want the run limit", reason)
+	}
+	if reason, _ := overBudget(cfg, 2000, now); !strings.Contains(reason, "8200 tokens used today") {
+		t.Errorf("2000 tokens: reason = %q, want the daily limit", reason)
+	}
+	local := cfg
+	local.Provider = "ollama"
+	if reason, _ := overBudget(local, 60000, now); reason != "" {
+		t.Errorf("ollama: over budget: %s", reason)
+	}
+
+	// gpt-4o at $2.50 per million prompt tokens: 8000 today cost $0.02, and the
+	// completion $0.002.
+	costly := Config{Provider: "openai", Model: "gpt-4o", StatsPath: path, ModelPrices: []string{"gpt-4o=2.5/10"}, MaxCostPerRun: 0.01, MaxCostPerDay: 0.025}
+	if reason, err := overBudget(costly, 1000, now); err != nil || reason != "" {
+		t.Errorf("$0.0025 prompt: over budget: %q, %v", reason, err)
+	}
+	if reason, _ := overBudget(costly, 5000, now); !strings.Contains(reason, "max_cost_per_run") {
+		t.Errorf("$0.0125 prompt: reason = %q, want the run cost limit", reason)
+	}
+	if reason, _ := overBudget(costly, 2000, now); !strings.Contains(reason, "about $0.0220 spent today") {
+		t.Errorf("$0.005 prompt: reason = %q, want the daily cost limit", reason)
+	}
+	costly.Model = "unpriced"
+	if reason, _ := overBudget(costly, 100000, now); reason != "" {
+		t.Errorf("unpriced model: over budget: %s", reason)
+	}
+	costly.ModelPrices = []string{"gpt-4o=2.5"}
+	if _, err := overBudget(costly, 1000, now); err == nil {
+		t.Error("malformed model_prices accepted")
+	}
+
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "big.json", Diff: strings.Repeat("+x\n", 2000*generate.CharsPerToken)}}}
+	big := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	cfg.BudgetFallback = "no-ai"
+	if got, err := applyBudget(cfg, big.tokens(), true); err != nil || !got.NoAI {
+		t.Errorf("no-ai fallback: NoAI = %v, %v", got.NoAI, err)
+	}
+	if _, err := applyBudget(cfg, big.tokens(), false); err == nil || !strings.Contains(err.Error(), "over the spend limits") {
+		t.Errorf("no-ai fallback without a template: err = %v", err)
+	}
+	cfg.BudgetFallback = "gpt-4o-mini"
+	if got, err := applyBudget(cfg, big.tokens(), false); err != nil || got.Model != "gpt-4o-mini" || got.NoAI {
+		t.Errorf("model fallback: model = %q, NoAI = %v, %v", got.Model, got.NoAI, err)
+	}
+	if got, _ := applyBudget(cfg, 0, true); got.Model != "gpt-4o" {
+		t.Errorf("within budget: model = %q, want gpt-4o", got.Model)
+	}
+}
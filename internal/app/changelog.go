@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/logx"
+)
+
+// changelogSections are the sections of a changelog, in order, and the commit type
+// each lists. Breaking changes get their own section whatever their type.
+var changelogSections = []struct{ title, typ string }{
+	{"Features", "feat"},
+	{"Bug Fixes", "fix"},
+	{"Performance Improvements", "perf"},
+	{"Reverts", "revert"},
+}
+
+// Changelog prints the Markdown changelog of the next release to stdout: the
+// commits since the last release tag that call for one, grouped as
+// conventional-changelog groups them, under the version next-version prints. When
+// no commit calls for a release it prints nothing, and says why on stderr.
+func Changelog(ctx context.Context, cfg Config) error {
+	r, err := releaseSince(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if r.bump == commitmsg.BumpNone {
+		if !logx.Quiet() {
+			writeReleaseReasoning(os.Stderr, r)
+		}
+		return nil
+	}
+	writeChangelog(os.Stdout, r)
+	return nil
+}
+
+// writeChangelog writes the changelog of r to w.
+func writeChangelog(w io.Writer, r release) {
+	fmt.Fprintf(w, "## %s\n", r.next)
+	section := func(title string, keep func(releaseCommit, commitmsg.Commit) bool) {
+		first := true
+		for _, rc := range r.bumps {
+			c, _ := commitmsg.ParseLenient(rc.subject)
+			if !keep(rc, c) {
+				continue
+			}
+			if first {
+				fmt.Fprintf(w, "\n### %s\n\n", title)
+				first = false
+			}
+			entry := c.Description
+			if c.Scope != "" {
+				entry = "**" + c.Scope + ":** " + entry
+			}
+			fmt.Fprintf(w, "- %s (%s)\n", entry, shortSource(rc.hash))
+		}
+	}
+	section("BREAKING CHANGES", func(rc releaseCommit, _ commitmsg.Commit) bool {
+		return rc.bump == commitmsg.BumpMajor
+	})
+	for _, s := range changelogSections {
+		section(s.title, func(rc releaseCommit, c commitmsg.Commit) bool {
+			return rc.bump != commitmsg.BumpMajor && c.Type == s.typ
+		})
+	}
+}
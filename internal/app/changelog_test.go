@@ -0,0 +1,33 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+func TestWriteChangelog(t *testing.T) {
+	commits := []gitx.CommitMessage{
+		{Hash: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Message: "fix(parser): handle empty input"},
+		{Hash: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Message: "docs: explain changelog"},
+		{Hash: "cccccccccccccccccccccccccccccccccccccccc", Message: "feat(api)!: drop the v1 endpoints"},
+		{Hash: "dddddddddddddddddddddddddddddddddddddddd", Message: "feat:add changelog"},
+		{Hash: "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", Message: "perf: cache attachments\n\nBREAKING CHANGE: the cache needs a writable home"},
+	}
+	last, _ := commitmsg.ParseVersion("v1.4.2")
+	var b strings.Builder
+	writeChangelog(&b, planRelease(last, true, commits))
+	want := "## v2.0.0\n" +
+		"\n### BREAKING CHANGES\n\n" +
+		"- **api:** drop the v1 endpoints (ccccccc)\n" +
+		"- cache attachments (eeeeeee)\n" +
+		"\n### Features\n\n" +
+		"- add changelog (ddddddd)\n" +
+		"\n### Bug Fixes\n\n" +
+		"- **parser:** handle empty input (aaaaaaa)\n"
+	if b.String() != want {
+		t.Errorf("changelog:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
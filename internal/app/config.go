@@ -0,0 +1,119 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hoanghonghuy/commitgen/internal/config"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+)
+
+// configPath is the file the config subcommands read and write.
+func configPath(cfg Config) string {
+	if cfg.ConfigPath != "" {
+		return cfg.ConfigPath
+	}
+	return config.DefaultPath()
+}
+
+// ConfigGet prints the value of one setting from the config file.
+// Settings not in the file print nothing, so scripts can test for an empty result.
+func ConfigGet(cfg Config, key string) error {
+	fileCfg, err := config.Load(cfg.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	v, _, err := fileCfg.Get(key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(v)
+	return nil
+}
+
+// ConfigSet stores one setting in the config file; an empty value removes it.
+func ConfigSet(cfg Config, key, value string) error {
+	fileCfg, err := config.Load(cfg.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := fileCfg.Set(key, value); err != nil {
+		return err
+	}
+	path := configPath(cfg)
+	if err := config.Save(fileCfg, path); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if value == "" {
+		infof("Removed %s from %s\n", key, path)
+	} else if config.IsSecret(key) {
+		infof("Set %s in %s\n", key, path)
+	} else {
+		infof("Set %s = %s in %s\n", key, value, path)
+	}
+	return nil
+}
+
+// ConfigShow prints the config file as JSON, optionally with credentials hidden.
+func ConfigShow(cfg Config, redact bool) error {
+	fileCfg, err := config.Load(cfg.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if redact {
+		fileCfg = fileCfg.Redacted()
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(fileCfg)
+}
+
+// ConfigExplain prints the resolved value of each setting in cfg, and where it
+// comes from, for origins as returned by config.Explain. Settings that are set
+// nowhere and left at their zero value are skipped unless all is set.
+func ConfigExplain(cfg Config, origins []config.Origin, all bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, o := range origins {
+		value, nonZero, ok := settingValue(cfg, o.Key)
+		if !ok {
+			value, nonZero = o.Value, o.Value != ""
+		}
+		if o.Source == "" && !nonZero && !all {
+			continue
+		}
+		if config.IsSecret(o.Key) && value != "" && !config.IsSecretReference(value) {
+			value = "<redacted>"
+		}
+		source := o.Source
+		if source == "" {
+			source = i18n.T("default")
+		}
+		if len(o.Shadowed) > 0 {
+			source += " " + i18n.Tf("(overrides %s)", strings.Join(o.Shadowed, ", "))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", o.Key, value, source)
+	}
+	return w.Flush()
+}
+
+// settingValue returns the value of setting key in cfg as text, formatted like
+// config.FileConfig.Get, and whether it differs from the zero value. The field is
+// the one named like key without underscores (recent_n is RecentN); ok is false
+// for settings Config has no field for.
+func settingValue(cfg Config, key string) (value string, nonZero, ok bool) {
+	name := strings.ReplaceAll(key, "_", "")
+	f := reflect.ValueOf(cfg).FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, name) })
+	if !f.IsValid() {
+		return "", false, false
+	}
+	if list, isList := f.Interface().([]string); isList {
+		return strings.Join(list, ","), len(list) > 0, true
+	}
+	return fmt.Sprint(f.Interface()), !f.IsZero(), true
+}
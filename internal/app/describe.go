@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// Describe explains a commit or patch in prose and prints the explanation to stdout.
+// cfg.DescribeTarget is a revision in the
+// repository, a patch file, or "-" for a patch on stdin.
+func Describe(ctx context.Context, cfg Config) error {
+	if cfg.DescribeTarget == "" {
+		return errors.New("describe needs a commit or a patch file, e.g. commitgen describe HEAD~2")
+	}
+	message, diff, repoRoot, err := describeInput(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	changes := gitx.ParseUnifiedDiff(diff)
+	if len(changes) == 0 {
+		return fmt.Errorf("%w: %s has no diff", ErrNoChanges, cfg.DescribeTarget)
+	}
+	// No repository root: the files in HEAD are not what the patch was made against.
+	data, err := generate.BuildData(ctx, "", changes, generate.Options{
+		MaxFiles:       cfg.MaxFiles,
+		IgnoredFiles:   cfg.IgnoredFiles,
+		GeneratedFiles: cfg.GeneratedFiles,
+		AllowSensitive: cfg.AllowSensitive,
+	})
+	if err != nil {
+		return err
+	}
+	if repoRoot != "" {
+		data.RepositoryName = gitx.RepoNameFromRoot(repoRoot)
+	}
+
+	return printProse(ctx, cfg, vscodeprompt.BuildDescribeMessages(data, message))
+}
+
+// printProse sends msgs to the configured provider and prints the answer to stdout,
+// as it streams if the provider supports it.
+func printProse(ctx context.Context, cfg Config, msgs []vscodeprompt.VSCodeMessage) error {
+	cfg, err := applyBudget(cfg, prompt{msgs: msgs}.tokens(), false)
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	streamed := false
+	genCtx = ai.WithStream(genCtx, func(delta string) {
+		streamed = true
+		fmt.Print(delta)
+	})
+	out, err := provider.GenerateCommitMessage(genCtx, msgs, cfg.Temperature)
+	if err != nil {
+		return err
+	}
+	if streamed {
+		fmt.Println()
+		return nil
+	}
+	fmt.Println(strings.TrimSpace(out))
+	return nil
+}
+
+// describeInput returns the commit message (if known) and patch that cfg.DescribeTarget
+// names, and the repository it came from ("" for patch files).
+func describeInput(ctx context.Context, cfg Config) (message, diff, repoRoot string, err error) {
+	target := cfg.DescribeTarget
+	if target == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", "", "", fmt.Errorf("read patch from stdin: %w", err)
+		}
+		return patchMessage(string(b)), string(b), "", nil
+	}
+	if st, err := os.Stat(target); err == nil && st.Mode().IsRegular() {
+		b, err := os.ReadFile(target)
+		if err != nil {
+			return "", "", "", fmt.Errorf("read patch: %w", err)
+		}
+		return patchMessage(string(b)), string(b), "", nil
+	}
+
+	repoRoot, err = gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+	if err != nil {
+		return "", "", "", fmt.Errorf("%q is not a patch file, and there is no repository to look it up in: %w", target, err)
+	}
+	commits, err := gitx.CommitMessages(ctx, repoRoot, target+"^!")
+	if err != nil || len(commits) == 0 {
+		return "", "", "", fmt.Errorf("%q is neither a patch file nor a commit", target)
+	}
+	diff, err = gitx.CommitDiff(ctx, repoRoot, commits[0].Hash)
+	if err != nil {
+		return "", "", "", err
+	}
+	return commits[0].Message, diff, repoRoot, nil
+}
+
+// patchMessage returns the commit message of a patch in git format-patch (mbox) form:
+// the Subject header without its [PATCH] tag, and the body up to the "---" line.
+// Plain diffs have no message.
+func patchMessage(patch string) string {
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	headers, rest, ok := strings.Cut(patch, "\n\n")
+	if !ok || !strings.HasPrefix(headers, "From ") {
+		return ""
+	}
+	var subject string
+	lines := strings.Split(headers, "\n")
+	for i, ln := range lines {
+		if s, ok := strings.CutPrefix(ln, "Subject: "); ok {
+			subject = s
+			// Folded header lines continue the subject.
+			for _, cont := range lines[i+1:] {
+				if !strings.HasPrefix(cont, " ") && !strings.HasPrefix(cont, "\t") {
+					break
+				}
+				subject += " " + strings.TrimSpace(cont)
+			}
+		}
+	}
+	if strings.HasPrefix(subject, "[") {
+		if _, after, ok := strings.Cut(subject, "] "); ok {
+			subject = after
+		}
+	}
+	body, _, _ := strings.Cut(rest, "\n---\n")
+	return strings.TrimSpace(subject + "\n\n" + strings.TrimSpace(body))
+}
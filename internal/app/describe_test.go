@@ -0,0 +1,53 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+const formatPatch = `From 1a2b3c Mon Sep 17 00:00:00 2001
+From: Ann Author <ann@example.com>
+Date: Tue, 1 Oct 2024 10:00:00 +0200
+Subject: [PATCH 2/3] parser: return an empty AST for empty
+ input
+
+Parse used to index the first token without checking.
+---
+ parser.go | 3 +++
+ 1 file changed, 3 insertions(+)
+
+diff --git a/parser.go b/parser.go
+--- a/parser.go
++++ b/parser.go
+@@ -1,3 +1,6 @@ func Parse(s string) *AST {
++	if s == "" {
++		return &AST{}
++	}
+`
+
+func TestPatchMessage(t *testing.T) {
+	want := "parser: return an empty AST for empty input\n\nParse used to index the first token without checking."
+	if got := patchMessage(formatPatch); got != want {
+		t.Errorf("patchMessage = %q; want %q", got, want)
+	}
+	if got := patchMessage("diff --git a/x b/x\n--- a/x\n+++ b/x\n"); got != "" {
+		t.Errorf("plain diff has message %q", got)
+	}
+}
+
+func TestDescribePrompt(t *testing.T) {
+	changes := gitx.ParseUnifiedDiff(formatPatch)
+	if len(changes) != 1 || changes[0].Path != "parser.go" {
+		t.Fatalf("changes = %+v", changes)
+	}
+	msgs := vscodeprompt.BuildDescribeMessages(vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "parser.go", Diff: changes[0].Diff}}}, patchMessage(formatPatch))
+	user := msgs[1].Content[0].Text
+	for _, want := range []string{"# COMMIT MESSAGE:\nparser: return an empty AST", "+\tif s == \"\" {", "explain the CODE CHANGES"} {
+		if !strings.Contains(user, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, user)
+		}
+	}
+}
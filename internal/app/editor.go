@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+const editorHelp = `
+# Edit the commit message above. Lines starting with '#' are ignored.
+# Save and close the editor to return to commitgen.
+`
+
+type editorDoneMsg struct {
+	content string
+	err     error
+}
+
+// editorCommand builds the command for editor, which may include arguments
+// (e.g. "code --wait"). Like git, it is run through the shell on Unix.
+func editorCommand(editor, path string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(editor)
+		return exec.Command(fields[0], append(fields[1:], path)...)
+	}
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+}
+
+// openInEditor writes msg to a temp file, suspends the TUI while the user's editor
+// runs, and reports the edited text back as an editorDoneMsg.
+func openInEditor(repoRoot, msg string) tea.Cmd {
+	path, err := writeEditFile(msg)
+	if err != nil {
+		return func() tea.Msg { return editorDoneMsg{err: err} }
+	}
+
+	editor := gitx.Editor(context.Background(), repoRoot)
+	return tea.ExecProcess(editorCommand(editor, path), func(err error) tea.Msg {
+		if err != nil {
+			os.Remove(path)
+			return editorDoneMsg{err: err}
+		}
+		return readEditFile(path)
+	})
+}
+
+// writeEditFile writes msg and the editing help to a new temp file and returns its path.
+func writeEditFile(msg string) (string, error) {
+	f, err := os.CreateTemp("", "COMMITGEN_EDITMSG-*.txt")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	_, err = f.WriteString(strings.TrimRight(msg, "\n") + "\n" + editorHelp)
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// readEditFile reads back the message edited in path, and removes the file.
+func readEditFile(path string) editorDoneMsg {
+	defer os.Remove(path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return editorDoneMsg{err: err}
+	}
+	return editorDoneMsg{content: commitmsg.Clean(string(b))}
+}
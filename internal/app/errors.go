@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+)
+
+// Process exit codes, so that hooks and CI wrappers can tell failures apart.
+// They are documented in README.md; keep the two in sync.
+const (
+	ExitOK          = 0
+	ExitError       = 1   // anything not listed below (bad flags, git failures, ...)
+	ExitLintFailed  = 2   // a commit message failed validation
+	ExitNoChanges   = 3   // nothing staged, or no diff on stdin
+	ExitProvider    = 4   // the AI provider returned an error
+	ExitTimeout     = 5   // the AI provider did not answer in time
+	ExitRateLimited = 6   // the AI provider refused the request for too many requests or tokens
+	ExitTooLarge    = 7   // the prompt is over the model's context window
+	ExitCanceled    = 130 // the user canceled (Cancel, Ctrl-C, SIGINT)
+)
+
+var (
+	// ErrNoChanges is returned when there is nothing to generate a message for.
+	ErrNoChanges error = i18n.NewError("no changes")
+	// ErrProvider wraps errors returned by the AI provider.
+	ErrProvider error = i18n.NewError("AI provider error")
+	// ErrCanceled is returned when the user quits without using a message.
+	ErrCanceled error = i18n.NewError("canceled")
+
+	// Kinds of ErrProvider errors, matched with errors.Is. An *ai.APIError in the
+	// chain has the provider's status and message.
+	ErrRateLimited     = ai.ErrRateLimited
+	ErrContextTooLarge = ai.ErrContextTooLarge
+	ErrEmptyResponse   = ai.ErrEmptyResponse
+)
+
+// The same errors under the names they were first specified with: each is the
+// value above it maps to, so errors.Is matches either name.
+var (
+	ErrNoStagedChanges     = ErrNoChanges   // nothing staged, or no diff on stdin
+	ErrProviderRateLimited = ErrRateLimited // the provider refused for too many requests or tokens
+	ErrUserCancelled       = ErrCanceled    // the user quit without using a message
+)
+
+// HookExitCode adjusts code for a run from the prepare-commit-msg hook. Hooks
+// installed before ExitRateLimited and ExitTooLarge existed let the commit go on
+// only for ExitNoChanges to ExitTimeout, so those two are reported as ExitProvider.
+func HookExitCode(code int) int {
+	if code == ExitRateLimited || code == ExitTooLarge {
+		return ExitProvider
+	}
+	return code
+}
+
+// ExitCode maps an error returned by a command to the process exit code.
+func ExitCode(err error) int {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrLintFailed):
+		return ExitLintFailed
+	case errors.Is(err, ErrCanceled), errors.Is(err, context.Canceled):
+		return ExitCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return ExitTimeout
+	case errors.Is(err, ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, ErrContextTooLarge):
+		return ExitTooLarge
+	case errors.Is(err, ErrProvider):
+		return ExitProvider
+	case errors.Is(err, ErrNoChanges):
+		return ExitNoChanges
+	default:
+		return ExitError
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string { return "i/o timeout" }
+func (timeoutErr) Timeout() bool { return true }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitError},
+		{fmt.Errorf("lint: %w", ErrLintFailed), ExitLintFailed},
+		{fmt.Errorf("%w: nothing is staged", ErrNoChanges), ExitNoChanges},
+		{fmt.Errorf("%w: 401 unauthorized", ErrProvider), ExitProvider},
+		{fmt.Errorf("%w: %w", ErrProvider, context.DeadlineExceeded), ExitTimeout},
+		{fmt.Errorf("%w: %w", ErrProvider, &url.Error{Op: "Post", URL: "x", Err: timeoutErr{}}), ExitTimeout},
+		{fmt.Errorf("%w: %w", ErrProvider, &ai.APIError{Provider: "openai", StatusCode: 429, Message: "slow down"}), ExitRateLimited},
+		{fmt.Errorf("%w: %w", ErrProvider, &ai.APIError{Provider: "gemini", StatusCode: 400, Code: "RESOURCE_EXHAUSTED"}), ExitRateLimited},
+		{fmt.Errorf("%w: %w", ErrProvider, &ai.APIError{Provider: "openai", StatusCode: 400, Code: "context_length_exceeded"}), ExitTooLarge},
+		{fmt.Errorf("%w: %w", ErrProvider, &ai.APIError{Provider: "anthropic", StatusCode: 400, Message: "prompt is too long: 210000 tokens > 200000 maximum"}), ExitTooLarge},
+		{fmt.Errorf("%w: %w", ErrProvider, &ai.APIError{Provider: "anthropic", StatusCode: 401, Code: "authentication_error"}), ExitProvider},
+		{fmt.Errorf("%w: openai: %w", ErrProvider, ErrEmptyResponse), ExitProvider},
+		{ErrCanceled, ExitCanceled},
+		{fmt.Errorf("%w: nothing is staged", ErrNoStagedChanges), ExitNoChanges},
+		{fmt.Errorf("%w: %w", ErrProvider, ErrProviderRateLimited), ExitRateLimited},
+		{ErrUserCancelled, ExitCanceled},
+		{context.Canceled, ExitCanceled},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d; want %d", tt.err, got, tt.want)
+		}
+	}
+	for code, want := range map[int]int{ExitRateLimited: ExitProvider, ExitTooLarge: ExitProvider, ExitTimeout: ExitTimeout, ExitCanceled: ExitCanceled} {
+		if got := HookExitCode(code); got != want {
+			t.Errorf("HookExitCode(%d) = %d; want %d", code, got, want)
+		}
+	}
+}
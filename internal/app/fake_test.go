@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// scriptedProvider is the provider of these tests. It keeps what it was asked and
+// answers with script, or with reply and err when there is no script.
+type scriptedProvider struct {
+	reply  string
+	err    error
+	script func(ctx context.Context, user string, n int) (string, error) // n counts requests from 0
+
+	mu       sync.Mutex
+	users    []string // the user prompt of each request
+	temps    []float64
+	streamed []bool
+	last     []vscodeprompt.VSCodeMessage
+}
+
+func (p *scriptedProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	user := ""
+	for _, m := range msgs {
+		if m.Role == vscodeprompt.RoleUser && len(m.Content) > 0 {
+			user = m.Content[0].Text
+			break
+		}
+	}
+
+	p.mu.Lock()
+	n := len(p.users)
+	p.users = append(p.users, user)
+	p.temps = append(p.temps, temp)
+	p.streamed = append(p.streamed, ai.StreamFunc(ctx) != nil)
+	p.last = msgs
+	p.mu.Unlock()
+
+	if p.script != nil {
+		return p.script(ctx, user, n)
+	}
+	return p.reply, p.err
+}
+
+// calls returns the number of requests p got.
+func (p *scriptedProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.users)
+}
+
+// blockingProvider returns a provider that waits until its request is canceled.
+func blockingProvider() *scriptedProvider {
+	return &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+}
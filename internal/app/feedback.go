@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+)
+
+// pendingFile, in the git directory, holds the last accepted suggestion until the
+// post-commit hook compares it with what was committed.
+const pendingFile = "commitgen-suggestion.json"
+
+type pendingSuggestion struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Suggested string `json:"suggested"` // as generated
+	Accepted  string `json:"accepted"`  // as accepted in commitgen, before trailers
+	Outcome   string `json:"outcome"`   // acceptOutcome(Suggested, Accepted)
+	Tree      string `json:"tree"`      // the staged tree it was accepted for
+}
+
+// pendAccepted is called before committing accepted, which started from the
+// suggestion provider made. With the post-commit hook installed, it leaves the
+// outcome for the hook, so edits made afterwards in git's editor count too, and
+// returns true. Otherwise the caller records the outcome once the commit is made.
+func pendAccepted(ctx context.Context, repoRoot string, provider ai.Provider, suggested, accepted string) bool {
+	mp, ok := provider.(*meteredProvider)
+	if !ok || repoRoot == "" || !postCommitInstalled(ctx, repoRoot) {
+		return false
+	}
+	p := pendingSuggestion{Provider: mp.provider, Model: mp.model, Suggested: suggested, Accepted: accepted, Outcome: acceptOutcome(suggested, accepted)}
+	if err := savePending(ctx, repoRoot, p); err != nil {
+		slog.Debug("could not leave the suggestion for the post-commit hook", "err", err)
+		return false
+	}
+	return true
+}
+
+// postCommitInstalled reports whether commitgen's post-commit hook is installed.
+func postCommitInstalled(ctx context.Context, repoRoot string) bool {
+	dir, err := repoHooksDir(ctx, repoRoot)
+	if err != nil {
+		return false
+	}
+	_, ours, _, _ := inspectHook(filepath.Join(dir, HookPostCommit))
+	return ours
+}
+
+func savePending(ctx context.Context, repoRoot string, p pendingSuggestion) error {
+	tree, err := gitx.Git(ctx, repoRoot, "write-tree")
+	if err != nil {
+		return err
+	}
+	p.Tree = strings.TrimSpace(tree)
+	path, err := gitx.GitPath(ctx, repoRoot, pendingFile)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// Feedback is run by the post-commit hook. It compares the message just committed
+// with the suggestion commitgen left for it and records the outcome in the stats
+// file: accepted verbatim, edited, or replaced. Commits made without a suggestion,
+// or of other changes than it was made for, are not counted.
+func Feedback(ctx context.Context, cfg Config) error {
+	root, err := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+	if err != nil {
+		return err
+	}
+	path, err := gitx.GitPath(ctx, root, pendingFile)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_ = os.Remove(path) // one commit per suggestion
+
+	var p pendingSuggestion
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	tree, err := gitx.Git(ctx, root, "rev-parse", "HEAD^{tree}")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(tree) != p.Tree {
+		slog.Debug("commit is not the one the suggestion was for", "tree", strings.TrimSpace(tree), "want", p.Tree)
+		return nil
+	}
+	committed, err := gitx.Git(ctx, root, "log", "-1", "--format=%B")
+	if err != nil {
+		return err
+	}
+
+	// Trailers and issue references are appended after acceptance; anything else
+	// means the message changed again, e.g. in git's editor.
+	outcome := p.Outcome
+	if !strings.HasPrefix(strings.TrimSpace(committed), strings.TrimSpace(p.Accepted)) {
+		outcome = acceptOutcome(p.Suggested, committed)
+	}
+	slog.Debug("commit feedback", "outcome", outcome)
+	return stats.Append(cfg.StatsPath, stats.Event{Provider: p.Provider, Model: p.Model, Outcome: outcome})
+}
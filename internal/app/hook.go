@@ -1,41 +1,223 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
 )
 
-// InstallHook installs the prepare-commit-msg hook
-func InstallHook() error {
-	if runtime.GOOS == "windows" {
-		fmt.Println("Warning: The git hook uses /dev/tty and #!/bin/sh which may not work correctly on Windows.")
-		fmt.Println("Consider running commitgen manually instead of using the hook on Windows.")
+// Version is the commitgen version, set by main. It is stamped into installed hooks.
+var Version = "dev"
+
+// hookMarker starts the line that identifies hooks written by commitgen.
+// Hooks from older releases only carry the "# commitgen hook" line.
+const hookMarker = "# commitgen-hook-version: "
+
+// Hook names accepted by InstallHook and UninstallHook.
+const (
+	HookPrepareCommitMsg = "prepare-commit-msg"
+	HookCommitMsg        = "commit-msg"
+	HookPostCommit       = "post-commit"
+)
+
+// hookKinds lists the hooks commitgen can install.
+var hookKinds = []string{HookPrepareCommitMsg, HookCommitMsg, HookPostCommit}
+
+// DefaultHookSkipSources are the prepare-commit-msg sources for which the hook does
+// nothing: the message already exists (-m, merges, squashes, amend/cherry-pick/rebase).
+var DefaultHookSkipSources = []string{"message", "merge", "squash", "commit"}
+
+// skipHook reports whether the prepare-commit-msg hook should leave the message alone.
+func skipHook(cfg Config) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("COMMITGEN_SKIP"))) {
+	case "1", "true", "yes":
+		slog.Debug("hook skipped", "reason", "COMMITGEN_SKIP")
+		return true
+	}
+	if cfg.HookSource != "" && slices.Contains(cfg.HookSkipSources, cfg.HookSource) {
+		slog.Debug("hook skipped", "source", cfg.HookSource)
+		return true
+	}
+	return false
+}
+
+// hookTimeoutComment marks a message the hook guessed because generation ran out of
+// time. git strips it, with its other comment lines, from the final message.
+const hookTimeoutComment = "# commitgen: generation timed out"
+
+// writeHookFallback writes msg, a message guessed from the diff without the model, to
+// the hook's message file, for the user to edit in place of the one that timed out.
+func writeHookFallback(path, msg string) error {
+	slog.Warn("generation timed out; wrote a message guessed from the diff to edit instead")
+	content := hookTimeoutComment + "; this message was guessed from the diff.\n"
+	if msg = strings.TrimSpace(msg); msg != "" {
+		content = msg + "\n\n" + content
 	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
 
-	// 1. Detect .git directory
-	gitDir := ".git"
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		return fmt.Errorf("current directory is not a git repository root (no .git found)")
+// hasTerminal reports whether the user can be asked anything: stdin is a terminal, or
+// the process has a controlling one to open. Hooks run by IDEs and GUI clients have
+// neither.
+func hasTerminal() bool {
+	if isTerminal(os.Stdin) {
+		return true
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
 	}
+	tty.Close()
+	return true
+}
+
+// hookFastTimeout caps how long --hook-fast waits for the model.
+const hookFastTimeout = 10 * time.Second
+
+// hookSuggestionHeader starts the suggestion --hook-fast adds to the message file.
+const hookSuggestionHeader = "# commitgen suggestion (uncomment to use):"
+
+// hookFast is the prepare-commit-msg fast path for IDE and GUI commits: no TUI, a
+// message from history when the same diff was seen before, and otherwise one request
+// of at most hookFastTimeout. The message is added as comment lines under what the
+// file already holds, where commit dialogs show it without taking over the message.
+// Failing to get one leaves the file as it was.
+func hookFast(ctx context.Context, cfg Config, provider ai.Provider, pr prompt, trailers trailerSet) error {
+	hash := pr.diffHash()
+	entries, _ := history.Load(cfg.HistoryPath)
+	msg, cached := history.Prefetched(entries, hash)
+	if !cached {
+		msg, cached = history.Cached(entries, hash)
+	}
+	if cached {
+		slog.Debug("hook uses cached message", "diff_hash", hash)
+	} else {
+		genCtx, cancel := context.WithTimeout(ctx, min(cfg.Timeout, hookFastTimeout))
+		defer cancel()
+		var err error
+		if msg, err = generate.Message(genCtx, provider, pr.msgs, cfg.Temperature, cfg.Conventional); err != nil {
+			slog.Warn("no suggestion for the commit message", "err", err)
+			return nil
+		}
+		_ = history.Append(cfg.HistoryPath, history.Entry{
+			Repo:     gitx.RepoNameFromRoot(pr.repoRoot),
+			Root:     pr.repoRoot,
+			DiffHash: hash,
+			Status:   history.StatusGenerated,
+			Message:  msg,
+		})
+	}
+	msg, err := trailers.apply(ctx, pr.repoRoot, msg, provider)
+	if err != nil {
+		return err
+	}
+	return appendSuggestion(cfg.HookFile, msg)
+}
 
-	hooksDir := filepath.Join(gitDir, "hooks")
-	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+// appendSuggestion adds msg to the message file at path as comment lines, below what
+// the file holds.
+func appendSuggestion(path, msg string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var b strings.Builder
+	b.Write(existing)
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(hookSuggestionHeader + "\n")
+	for _, ln := range strings.Split(strings.TrimSpace(msg), "\n") {
+		b.WriteString(strings.TrimRight("# "+ln, " ") + "\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// localSuffix is appended to a pre-existing hook when commitgen takes its place.
+// The commitgen hook runs the backed-up hook first, so both keep working.
+const localSuffix = ".local"
+
+// hooksDir returns the directory git runs hooks from, honoring core.hooksPath and
+// worktrees. With husky (core.hooksPath=.husky/_), hooks belong in .husky itself.
+func hooksDir(ctx context.Context) (string, error) {
+	root, err := gitx.ResolveRepoRoot(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return repoHooksDir(ctx, root)
+}
+
+// repoHooksDir is hooksDir for the repository at root.
+func repoHooksDir(ctx context.Context, root string) (string, error) {
+	dir, err := gitx.GitPath(ctx, root, "hooks")
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(dir) == "_" && filepath.Base(filepath.Dir(dir)) == ".husky" {
+		dir = filepath.Dir(dir)
+	}
+	return dir, nil
+}
+
+// InstallHook installs the given git hook (HookPrepareCommitMsg, HookCommitMsg or
+// HookPostCommit). An existing hook not written by commitgen is kept as <hook>.local
+// and chained.
+func InstallHook(ctx context.Context, kind string) error {
+	return installHook(ctx, "", kind)
+}
+
+// installHook is InstallHook for the repository at repoArg, or the current one.
+func installHook(ctx context.Context, repoArg, kind string) error {
+	if !slices.Contains(hookKinds, kind) {
+		return fmt.Errorf("unknown hook type %q (use: %s)", kind, strings.Join(hookKinds, " | "))
+	}
+
+	if runtime.GOOS == "windows" {
+		slog.Warn("the git hook uses /dev/tty and #!/bin/sh which may not work correctly on Windows; consider running commitgen manually instead")
+	}
+
+	root, err := gitx.ResolveRepoRoot(ctx, repoArg)
+	if err != nil {
+		return err
+	}
+	dir, err := repoHooksDir(ctx, root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("create hooks dir: %w", err)
 	}
 
-	hookPath := filepath.Join(hooksDir, "prepare-commit-msg")
+	hookPath := filepath.Join(dir, kind)
 
-	// 2. Check if hook exists
-	if _, err := os.Stat(hookPath); err == nil {
-		// Hook exists. We should not overwrite blindly.
-		// For now, let's error out or ask user (but this is a command).
-		// Let's notify user.
-		return fmt.Errorf("hook %s already exists. Please remove it first", hookPath)
+	// Keep a foreign hook by moving it aside; our script calls it first.
+	exists, ours, _, err := inspectHook(hookPath)
+	if err != nil {
+		return fmt.Errorf("read hook: %w", err)
+	}
+	if exists && !ours {
+		localPath := hookPath + localSuffix
+		if _, err := os.Stat(localPath); err == nil {
+			return fmt.Errorf("hook %s exists and %s is already taken. Please merge them first", hookPath, localPath)
+		}
+		if err := os.Rename(hookPath, localPath); err != nil {
+			return fmt.Errorf("back up existing hook: %w", err)
+		}
+		infof("Existing hook moved to %s; it will run before commitgen.\n", localPath)
 	}
 
-	// 3. Create hook script
 	// We need the absolute path to commitgen binary?
 	// Or assume it's in PATH.
 	// Since we are running the binary, we can try `os.Executable()`.
@@ -47,8 +229,29 @@ func InstallHook() error {
 		exe, _ = filepath.Abs(exe)
 	}
 
-	script := fmt.Sprintf(`#!/bin/sh
+	script := fmt.Sprintf(prepareCommitMsgScript, exe, Version)
+	switch kind {
+	case HookCommitMsg:
+		script = fmt.Sprintf(commitMsgScript, exe, Version)
+	case HookPostCommit:
+		script = fmt.Sprintf(postCommitScript, exe, Version)
+	}
+
+	if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+		return fmt.Errorf("write hook file: %w", err)
+	}
+
+	if ours {
+		infof("Hook updated at %s\n", hookPath)
+	} else {
+		infof("Hook installed to %s\n", hookPath)
+	}
+	return nil
+}
+
+const prepareCommitMsgScript = `#!/bin/sh
 # commitgen hook
+# commitgen-hook-version: %[2]s
 # This hook runs commitgen to generate a commit message.
 # It uses /dev/tty to allow interaction even inside a hook.
 
@@ -59,51 +262,189 @@ COMMIT_MSG_FILE=$1
 COMMIT_SOURCE=$2
 SHA1=$3
 
-# Skip if amending or if message source is arguably "template" or "message" provided?
-# Usually we want it for empty "git commit".
-# If source is "message" (-m), skip.
-if [ "$COMMIT_SOURCE" = "message" ]; then
-  exit 0
+# Run the hook that was here before commitgen was installed. Husky's hooks are
+# not executable: husky runs them with sh -e.
+if [ -x "$0.local" ]; then
+  "$0.local" "$@" || exit $?
+elif [ -f "$0.local" ]; then
+  sh -e "$0.local" "$@" || exit $?
 fi
 
+# COMMITGEN_SKIP=1 git commit ... bypasses commitgen entirely.
+case "$COMMITGEN_SKIP" in
+  1|true|yes) exit 0 ;;
+esac
+
+# commitgen decides from the commit source (message, template, merge, squash,
+# commit) whether to run; see hook_skip_sources in the config.
 # Run commitgen in hook mode
 # We redirect stdin/stdout to tty to allow interactive UI
 if [ -t 0 ]; then
     exec < /dev/tty
 fi
 
-echo "commitgen is analyzing changes..."
-"%s" --hook "$COMMIT_MSG_FILE" < /dev/tty > /dev/tty
+if { true < /dev/tty; } 2>/dev/null; then
+  "%[1]s" --hook "$COMMIT_MSG_FILE" --hook-source "$COMMIT_SOURCE" < /dev/tty > /dev/tty
+elif grep -v '^#' "$COMMIT_MSG_FILE" 2>/dev/null | grep -q '[^[:space:]]'; then
+  # No terminal (a commit from an IDE or GUI) and a message already written:
+  # commitgen adds a suggestion as comment lines, quickly and without asking.
+  "%[1]s" --hook "$COMMIT_MSG_FILE" --hook-source "$COMMIT_SOURCE" --hook-fast < /dev/null
+else
+  # No terminal and no message yet: commitgen writes the message it would have
+  # suggested, for the commit dialog to show.
+  "%[1]s" --hook "$COMMIT_MSG_FILE" --hook-source "$COMMIT_SOURCE" < /dev/null
+fi
+status=$?
 
-# If commitgen succeeds, it writes to the file.
-`, exe)
+# If commitgen succeeds, it writes to the file. When no message could be generated
+# (3: no changes, 4: provider error, 5: timeout, 6: rate limited, 7: prompt too
+# large), let the commit go on so the message can be written by hand; anything
+# else (e.g. 130: canceled) aborts it.
+case $status in
+  3|4|5|6|7)
+    echo "commitgen: no message generated (exit $status); write one yourself." >&2
+    exit 0 ;;
+esac
+exit $status
+`
 
-	if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
-		return fmt.Errorf("write hook file: %w", err)
-	}
+const commitMsgScript = `#!/bin/sh
+# commitgen commit-msg hook
+# commitgen-hook-version: %[2]s
+# Validates the final commit message. If it breaks the configured rules,
+# commitgen offers an AI-corrected version; rejecting it aborts the commit.
 
-	fmt.Printf("Hook installed to %s\n", hookPath)
-	return nil
-}
+COMMIT_MSG_FILE=$1
+
+# Run the hook that was here before commitgen was installed. Husky's hooks are
+# not executable: husky runs them with sh -e.
+if [ -x "$0.local" ]; then
+  "$0.local" "$@" || exit $?
+elif [ -f "$0.local" ]; then
+  sh -e "$0.local" "$@" || exit $?
+fi
+
+case "$COMMITGEN_SKIP" in
+  1|true|yes) exit 0 ;;
+esac
 
-// UninstallHook removes the prepare-commit-msg hook
-func UninstallHook() error {
-	gitDir := ".git"
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		return fmt.Errorf("current directory is not a git repository root (no .git found)")
+# Without a terminal we can only validate.
+if ! ( : > /dev/tty ) 2>/dev/null; then
+  exec "%[1]s" lint --file "$COMMIT_MSG_FILE"
+fi
+
+"%[1]s" lint --file "$COMMIT_MSG_FILE" --fix < /dev/tty > /dev/tty
+`
+
+const postCommitScript = `#!/bin/sh
+# commitgen post-commit hook
+# commitgen-hook-version: %[2]s
+# Records whether the committed message is commitgen's suggestion as is, edited,
+# or replaced, for commitgen stats. It never affects the commit.
+
+# Run the hook that was here before commitgen was installed.
+if [ -x "$0.local" ]; then
+  "$0.local" "$@"
+elif [ -f "$0.local" ]; then
+  sh -e "$0.local" "$@"
+fi
+
+"%[1]s" feedback > /dev/null 2>&1
+exit 0
+`
+
+// inspectHook reads the hook at path. It reports whether the file exists, whether
+// commitgen wrote it, and the version recorded in it ("" for older hooks).
+func inspectHook(path string) (exists, ours bool, version string, err error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, false, "", nil
 	}
+	if err != nil {
+		return false, false, "", err
+	}
+	for _, ln := range strings.Split(string(b), "\n") {
+		ln = strings.TrimSpace(ln)
+		if v, ok := strings.CutPrefix(ln, hookMarker); ok {
+			return true, true, strings.TrimSpace(v), nil
+		}
+		if ln == "# commitgen hook" || ln == "# commitgen commit-msg hook" {
+			ours = true
+		}
+	}
+	return true, ours, "", nil
+}
 
-	hookPath := filepath.Join(gitDir, "hooks", "prepare-commit-msg")
+// UninstallHook removes the given git hook, but only if commitgen installed it.
+// A hook backed up by InstallHook is put back.
+func UninstallHook(ctx context.Context, kind string) error {
+	dir, err := hooksDir(ctx)
+	if err != nil {
+		return err
+	}
+	hookPath := filepath.Join(dir, kind)
 
-	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
-		fmt.Println("Hook is not installed.")
+	exists, ours, _, err := inspectHook(hookPath)
+	if err != nil {
+		return fmt.Errorf("read hook: %w", err)
+	}
+	if !exists {
+		infof("Hook is not installed.\n")
 		return nil
 	}
+	if !ours {
+		return fmt.Errorf("hook %s was not installed by commitgen; leaving it in place", hookPath)
+	}
 
 	if err := os.Remove(hookPath); err != nil {
 		return fmt.Errorf("failed to remove hook: %w", err)
 	}
 
-	fmt.Println("Hook uninstalled successfully.")
+	localPath := hookPath + localSuffix
+	if _, err := os.Stat(localPath); err == nil {
+		if err := os.Rename(localPath, hookPath); err != nil {
+			return fmt.Errorf("restore previous hook: %w", err)
+		}
+		infof("Restored previous hook from %s\n", localPath)
+	}
+
+	infof("Hook uninstalled successfully.\n")
+	return nil
+}
+
+// HookStatus prints whether each commitgen hook is installed in the current repository.
+func HookStatus(ctx context.Context) error {
+	dir, err := hooksDir(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Print(i18n.Tf("Hooks directory: %s\n", dir))
+
+	for _, kind := range hookKinds {
+		hookPath := filepath.Join(dir, kind)
+		exists, ours, version, err := inspectHook(hookPath)
+		if err != nil {
+			return fmt.Errorf("read hook: %w", err)
+		}
+
+		status := i18n.T("not installed")
+		switch {
+		case exists && !ours:
+			status = i18n.T("present, not managed by commitgen")
+		case ours && version == "":
+			status = i18n.T("installed (commitgen, unknown version)")
+		case ours:
+			status = i18n.Tf("installed (commitgen %s)", version)
+			if version != Version {
+				status += i18n.Tf(", current is %s; reinstall to update", Version)
+			}
+		}
+		if ours {
+			if _, err := os.Stat(hookPath + localSuffix); err == nil {
+				status += i18n.Tf(", chains to %s%s", kind, localSuffix)
+			}
+		}
+		fmt.Printf("%-20s %s\n", kind, status)
+	}
 	return nil
 }
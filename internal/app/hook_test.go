@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestSkipHook(t *testing.T) {
+	tests := []struct {
+		env    string
+		source string
+		want   bool
+	}{
+		{"", "", false},
+		{"", "template", false},
+		{"", "message", true},
+		{"", "commit", true},
+		{"1", "", true},
+		{"yes", "template", true},
+		{"0", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("COMMITGEN_SKIP", tt.env)
+		cfg := Config{HookSource: tt.source, HookSkipSources: DefaultHookSkipSources}
+		if got := skipHook(cfg); got != tt.want {
+			t.Errorf("skipHook(env=%q, source=%q) = %v; want %v", tt.env, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestHookRunsLocalHook(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	hook := filepath.Join(dir, "commit-msg")
+	if err := os.WriteFile(hook, []byte(fmt.Sprintf(commitMsgScript, "true", "test")), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A husky hook: not executable, and no shebang.
+	local := "echo ran > \"$0.out\"\nexit 3\n"
+	if err := os.WriteFile(hook+localSuffix, []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := exec.Command(hook, filepath.Join(dir, "MSG")).Run()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("hook exited with %v; want the local hook's status 3", err)
+	}
+	if _, err := os.Stat(hook + localSuffix + ".out"); err != nil {
+		t.Errorf("local hook did not run: %v", err)
+	}
+}
+
+func TestHookDeadlineWritesFallback(t *testing.T) {
+	dir := t.TempDir()
+	msgFile := filepath.Join(dir, "COMMIT_EDITMSG")
+	m := newTuiModel("", blockingProvider(), nil, 0, time.Minute, false, msgFile, "h", filepath.Join(dir, "h.jsonl")).
+		withDeadline(time.Now().Add(20*time.Millisecond), "chore: update 2 files")
+
+	m = update(m, m.generateCommitCmd()())
+	if m.err != nil || m.state != stateDone {
+		t.Fatalf("after the deadline: state %v, err %v", m.state, m.err)
+	}
+	b, err := os.ReadFile(msgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "chore: update 2 files\n\n" + hookTimeoutComment; !strings.HasPrefix(string(b), want) {
+		t.Errorf("message file = %q; want it to start with %q", b, want)
+	}
+	if !m.deadline.IsZero() {
+		t.Error("deadline still set after the first result")
+	}
+}
+
+func TestHookFastUsesCache(t *testing.T) {
+	dir := t.TempDir()
+	msgFile := filepath.Join(dir, "COMMIT_EDITMSG")
+	os.WriteFile(msgFile, []byte("WIP"), 0o644)
+	pr := prompt{data: vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "a.go", Diff: "+a\n"}}}}
+	cfg := Config{HookFile: msgFile, HistoryPath: filepath.Join(dir, "h.jsonl"), Timeout: time.Minute}
+	history.Append(cfg.HistoryPath, history.Entry{DiffHash: pr.diffHash(), Status: history.StatusGenerated, Message: "feat: add a\n\nWith a body."})
+
+	// blockingProvider would time out: the message must come from history.
+	if err := hookFast(context.Background(), cfg, blockingProvider(), pr, trailerSet{}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(msgFile)
+	want := "WIP\n" + hookSuggestionHeader + "\n# feat: add a\n#\n# With a body.\n"
+	if string(b) != want {
+		t.Errorf("message file = %q; want %q", b, want)
+	}
+}
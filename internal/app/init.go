@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/config"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+// initConfig is the starter team config commitgen init writes.
+var initConfig = `# commitgen settings shared by everyone working in this repository.
+# Your own config and flags override them; credentials, commands and endpoints
+# (provider, base_url, ...) are ignored here.
+# See "commitgen config explain --all" for every setting.
+
+conventional: true
+allowed_types: [` + strings.Join(commitmsg.DefaultTypes, ", ") + `]
+max_subject_length: 72
+
+# Files, paths in the repository, or URLs with guidelines for the model:
+# instructions:
+#   - CONTRIBUTING.md
+`
+
+// initPrompt is the starter prompt template. As written it changes nothing: it
+// redefines the reminder as its default, to be extended.
+const initPrompt = `{{- /*
+  The prompt commitgen sends for this repository. Redefine any part of it and keep
+  the rest: system, diffstat, context, changes, reminder or instructions, each with
+  its default as default_<name>. A prompt_template in the config takes precedence.
+*/ -}}
+{{define "reminder"}}{{template "default_reminder" .}}{{end}}
+`
+
+// initIgnore is the starter ignore file.
+const initIgnore = `# Files left out of the prompt, one pattern per line, matched against each file's
+# name or path like ignored_files in the config. For example:
+# *.snap
+# package-lock.json
+`
+
+// Init creates TeamDir in the repository at repoArg, or the current one, with a
+// starter team config, prompt template and ignore file, for the team to edit and
+// commit. Files that exist are kept unless force is set. With hook, it also
+// installs the prepare-commit-msg hook.
+func Init(ctx context.Context, repoArg string, hook, force bool) error {
+	root, err := gitx.ResolveRepoRoot(ctx, repoArg)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(root, config.TeamDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", config.TeamDir, err)
+	}
+
+	files := []struct{ name, content string }{
+		{"config.yaml", initConfig},
+		{config.TeamPromptFile, initPrompt},
+		{config.TeamIgnoreFile, initIgnore},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		rel := filepath.ToSlash(filepath.Join(config.TeamDir, f.name))
+		if _, err := os.Stat(path); err == nil && !force {
+			infof("%s already exists; kept it (use --force to overwrite)\n", rel)
+			continue
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", rel, err)
+		}
+		infof("Created %s\n", rel)
+	}
+
+	// A team config at the root is read before the one in TeamDir.
+	for _, ext := range []string{"yaml", "yml", "toml", "json"} {
+		if _, err := os.Stat(filepath.Join(root, ".commitgen."+ext)); err == nil {
+			infof("Note: .commitgen.%s takes precedence over %s/config.yaml; merge them or remove one.\n", ext, config.TeamDir)
+		}
+	}
+
+	if hook {
+		if err := installHook(ctx, repoArg, HookPrepareCommitMsg); err != nil {
+			return err
+		}
+	}
+	infof("Commit %s/ to share these settings with everyone working in the repository.\n", config.TeamDir)
+	return nil
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/config"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestInit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	if _, err := gitx.Git(ctx, repo, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(ctx, repo, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, path, err := config.LoadTeam(repo)
+	if err != nil || path != filepath.Join(repo, config.TeamDir, "config.yaml") {
+		t.Fatalf("LoadTeam() = %s, %v", path, err)
+	}
+	if cfg.Conventional == nil || !*cfg.Conventional || len(cfg.AllowedTypes) == 0 || len(cfg.IgnoredFiles) != 0 {
+		t.Errorf("starter config = %+v", cfg)
+	}
+	// The starter template leaves the prompt as it was.
+	data := vscodeprompt.Data{RepositoryName: "r", Changes: []vscodeprompt.Change{{Path: "a.go", Diff: "+x\n"}}}
+	want := vscodeprompt.ToOpenAIMessages(vscodeprompt.BuildVSCodeMessages(data))
+	data.SystemPromptTemplate = cfg.PromptTemplate
+	if got := vscodeprompt.ToOpenAIMessages(vscodeprompt.BuildVSCodeMessages(data)); !slices.Equal(got, want) {
+		t.Errorf("starter template changed the prompt:\n%v\nwant:\n%v", got, want)
+	}
+
+	ignore := filepath.Join(repo, config.TeamDir, config.TeamIgnoreFile)
+	if err := os.WriteFile(ignore, []byte("*.snap\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(ctx, repo, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(ignore); string(b) != "*.snap\n" {
+		t.Errorf("init without --force overwrote the ignore file: %q", b)
+	}
+	if err := Init(ctx, repo, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(ignore); string(b) != initIgnore {
+		t.Errorf("init --force kept the ignore file: %q", b)
+	}
+}
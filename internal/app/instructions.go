@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// instructionsTimeout bounds fetching one instructions URL.
+const instructionsTimeout = 10 * time.Second
+
+// maxInstructionsSize caps what is read from one instructions URL.
+const maxInstructionsSize = 256 << 10
+
+// loadInstructions reads the custom instructions from sources, in order: files,
+// paths in repoRoot, and http(s) URLs, such as an organization's guide followed by
+// the project's own. A relative path is read from the current directory if it is
+// there, else from repoRoot. With more than one source, each text is preceded by a
+// line naming its source, so that the model can tell the general from the specific.
+func loadInstructions(ctx context.Context, repoRoot string, sources []string) (string, error) {
+	var b strings.Builder
+	for _, src := range sources {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		text, err := readInstructions(ctx, repoRoot, src)
+		if err != nil {
+			return "", err
+		}
+		if len(sources) > 1 {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "# Instructions from %s:\n", src)
+		}
+		b.WriteString(strings.TrimRight(text, "\n") + "\n")
+	}
+	return b.String(), nil
+}
+
+func readInstructions(ctx context.Context, repoRoot, src string) (string, error) {
+	if strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://") {
+		return fetchInstructions(ctx, src)
+	}
+	path := src
+	if _, err := os.Stat(path); err != nil && !filepath.IsAbs(path) && repoRoot != "" {
+		path = filepath.Join(repoRoot, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read instructions file: %w", err)
+	}
+	return string(b), nil
+}
+
+func fetchInstructions(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, instructionsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("fetch instructions: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch instructions: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch instructions %s: %s", url, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxInstructionsSize))
+	if err != nil {
+		return "", fmt.Errorf("fetch instructions %s: %w", url, err)
+	}
+	return string(b), nil
+}
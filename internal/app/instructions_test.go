@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadInstructions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org.md" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("Write in English.\n"))
+	}))
+	defer ts.Close()
+
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".github", "commits.md"), []byte("Use the ticket as scope.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+
+	got, err := loadInstructions(ctx, repo, []string{".github/commits.md"})
+	if err != nil || got != "Use the ticket as scope.\n" {
+		t.Errorf("one file: %q, %v", got, err)
+	}
+
+	got, err = loadInstructions(ctx, repo, []string{ts.URL + "/org.md", ".github/commits.md"})
+	want := "# Instructions from " + ts.URL + "/org.md:\nWrite in English.\n\n# Instructions from .github/commits.md:\nUse the ticket as scope.\n"
+	if err != nil || got != want {
+		t.Errorf("layered:\n%s\nwant:\n%s (err %v)", got, want, err)
+	}
+
+	if _, err := loadInstructions(ctx, repo, []string{ts.URL + "/missing.md"}); err == nil {
+		t.Error("missing URL: expected an error")
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// actionNames name the confirm screen's actions in keybindings, in action order.
+var actionNames = []string{"commit", "regenerate", "edit", "editor", "previous", "type", "model", "cancel"}
+
+// defaultKeybindings are the shortcuts of the confirm screen's actions.
+var defaultKeybindings = []string{"commit=y", "regenerate=r", "edit=e", "editor=E", "previous=p", "type=t", "model=m", "cancel=q"}
+
+// reservedKeys move through and pick from the action list, or quit.
+var reservedKeys = []string{"up", "down", "k", "j", "enter", "pgup", "pgdown", "ctrl+c"}
+
+// keyMap binds keys (as tea.KeyMsg.String() spells them) to actions.
+type keyMap map[string]int
+
+// parseKeybindings applies "action=key[,key...]" overrides to the default
+// bindings. An action bound to "none" has no shortcut.
+func parseKeybindings(specs []string) (keyMap, error) {
+	keys := make([][]string, len(actionNames))
+	for _, spec := range append(slices.Clone(defaultKeybindings), specs...) {
+		name, list, ok := strings.Cut(spec, "=")
+		action := slices.Index(actionNames, strings.ToLower(strings.TrimSpace(name)))
+		if !ok || action < 0 {
+			return nil, fmt.Errorf("keybindings: %q is not action=key (actions: %s)", spec, strings.Join(actionNames, ", "))
+		}
+		keys[action] = nil
+		for _, k := range strings.Split(list, ",") {
+			if k = strings.TrimSpace(k); k != "" && k != "none" {
+				keys[action] = append(keys[action], k)
+			}
+		}
+	}
+
+	km := keyMap{}
+	for action, ks := range keys {
+		for _, k := range ks {
+			if slices.Contains(reservedKeys, k) {
+				return nil, fmt.Errorf("keybindings: %q is reserved for moving through the actions", k)
+			}
+			if other, dup := km[k]; dup && other != action {
+				return nil, fmt.Errorf("keybindings: %q is bound to both %s and %s", k, actionNames[other], actionNames[action])
+			}
+			km[k] = action
+		}
+	}
+	return km, nil
+}
+
+// keysFor returns the keys bound to action, sorted.
+func (km keyMap) keysFor(action int) []string {
+	var out []string
+	for k, a := range km {
+		if a == action {
+			out = append(out, k)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseKeybindings(t *testing.T) {
+	km, err := parseKeybindings([]string{"commit=c,ctrl+s", "Previous=none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(km.keysFor(actionCommit), []string{"c", "ctrl+s"}) {
+		t.Errorf("commit keys = %v", km.keysFor(actionCommit))
+	}
+	if _, ok := km["y"]; ok {
+		t.Error("the default commit key was kept")
+	}
+	if km["r"] != actionRegenerate || len(km.keysFor(actionPrevious)) != 0 {
+		t.Errorf("keys = %v", km)
+	}
+
+	for _, bad := range [][]string{{"commit"}, {"push=p"}, {"commit=enter"}, {"commit=r"}} {
+		if _, err := parseKeybindings(bad); err == nil {
+			t.Errorf("parseKeybindings(%q): expected an error", bad)
+		}
+	}
+}
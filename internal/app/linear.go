@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/linear"
+)
+
+// maxIssueDescription caps how much of a Linear issue's description goes into the prompt.
+const maxIssueDescription = 2000
+
+type linearLookup struct {
+	issue *linear.Issue // nil when not fetched
+	found bool
+}
+
+var (
+	linearMu    sync.Mutex
+	linearCache = map[string]linearLookup{} // by identifier, so watch and serve ask once
+)
+
+// linearIssue returns the Linear issue key in branch when cfg.Linear is on, or "".
+// With cfg.LinearAPIKey the issue is fetched too: a key Linear doesn't know is
+// dropped, and a failed request only leaves issue nil.
+func linearIssue(ctx context.Context, cfg Config, branch string) (key string, issue *linear.Issue) {
+	if !cfg.Linear {
+		return "", nil
+	}
+	key = commitmsg.LinearKey(branch, cfg.LinearTeams)
+	if key == "" || cfg.LinearAPIKey == "" {
+		return key, nil
+	}
+
+	linearMu.Lock()
+	defer linearMu.Unlock()
+	if l, ok := linearCache[key]; ok {
+		if !l.found {
+			return "", nil
+		}
+		return key, l.issue
+	}
+	apiKey := cfg.LinearAPIKey
+	if err := resolveSecret(ctx, cfg, &apiKey, "linear_api_key"); err != nil {
+		slog.Warn("could not read the Linear API key", "err", err)
+		return key, nil
+	}
+	got, err := linear.New(linear.Config{APIKey: apiKey}).Issue(ctx, key)
+	switch {
+	case errors.Is(err, linear.ErrNotFound):
+		slog.Debug("no such Linear issue", "key", key)
+		linearCache[key] = linearLookup{}
+		return "", nil
+	case err != nil:
+		slog.Warn("could not fetch the Linear issue", "key", key, "err", err)
+		return key, nil
+	}
+	linearCache[key] = linearLookup{issue: &got, found: true}
+	return key, &got
+}
+
+// issueContext formats issue for the prompt.
+func issueContext(issue linear.Issue) string {
+	desc := strings.TrimSpace(issue.Description)
+	if len(desc) > maxIssueDescription {
+		desc = desc[:maxIssueDescription] + "\n...[Description truncated]..."
+	}
+	s := issue.Identifier + ": " + issue.Title
+	if desc != "" {
+		s += "\n\n" + desc
+	}
+	return s
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// ErrLintFailed is returned by Lint when at least one message violates the rules.
+var ErrLintFailed error = i18n.NewError("commit message lint failed")
+
+type lintResult struct {
+	Source     string            `json:"source"` // commit hash or file path
+	Subject    string            `json:"subject"`
+	Valid      bool              `json:"valid"`
+	Issues     []commitmsg.Issue `json:"issues"`
+	Suggestion string            `json:"suggestion,omitempty"` // AI-generated replacement (--suggest)
+}
+
+// lintRules builds the message rules from cfg.
+func lintRules(cfg Config) commitmsg.Rules {
+	return commitmsg.Rules{
+		Conventional:      cfg.Conventional,
+		Types:             cfg.AllowedTypes,
+		MaxSubjectLength:  cfg.MaxSubjectLength,
+		MaxBodyLineLength: cfg.MaxBodyLineLength,
+	}
+}
+
+// Lint validates the messages in cfg.LintRange, or the one in cfg.LintFile ("-" for stdin),
+// and reports the results as text or JSON. It returns ErrLintFailed if any message is invalid.
+func Lint(ctx context.Context, cfg Config) error {
+	if cfg.LintRange != "" && cfg.LintFile != "" {
+		return errors.New("use either --range or --file, not both")
+	}
+	if cfg.LintFix && (cfg.LintFile == "" || cfg.LintFile == "-") {
+		return errors.New("--fix requires --file with a message file path")
+	}
+
+	type input struct{ source, msg string }
+	var inputs []input
+	repoRoot := ""
+
+	switch {
+	case cfg.LintFile != "":
+		var b []byte
+		var err error
+		if cfg.LintFile == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(cfg.LintFile)
+		}
+		if err != nil {
+			return fmt.Errorf("read message file: %w", err)
+		}
+		inputs = append(inputs, input{cfg.LintFile, string(b)})
+
+	default:
+		var err error
+		repoRoot, err = gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+		if err != nil {
+			return err
+		}
+		rev := cfg.LintRange
+		if rev == "" {
+			rev = "HEAD^!" // just the last commit
+		}
+		commits, err := gitx.CommitMessages(ctx, repoRoot, rev)
+		if err != nil {
+			return err
+		}
+		for _, c := range commits {
+			inputs = append(inputs, input{c.Hash, c.Message})
+		}
+	}
+
+	rules := lintRules(cfg)
+	policy, err := cfg.policy()
+	if err != nil {
+		return err
+	}
+	results := make([]lintResult, 0, len(inputs))
+	failed := false
+	for _, in := range inputs {
+		issues := append(commitmsg.Lint(in.msg, rules), policy.Check(in.msg)...)
+		if issues == nil {
+			issues = []commitmsg.Issue{}
+		}
+		results = append(results, lintResult{
+			Source:  in.source,
+			Subject: commitmsg.Subject(commitmsg.Clean(in.msg)),
+			Valid:   len(issues) == 0,
+			Issues:  issues,
+		})
+		failed = failed || len(issues) > 0
+	}
+
+	if failed && cfg.LintSuggest && repoRoot != "" {
+		if err := suggestReplacements(ctx, cfg, repoRoot, results); err != nil {
+			return err
+		}
+	}
+
+	if err := writeLintReport(os.Stdout, cfg.LintFormat, results); err != nil {
+		return err
+	}
+	if cfg.LintJUnitPath != "" {
+		f, err := os.Create(cfg.LintJUnitPath)
+		if err != nil {
+			return fmt.Errorf("create junit report: %w", err)
+		}
+		err = writeLintJUnit(f, results)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("write junit report: %w", err)
+		}
+	}
+
+	if failed && cfg.LintFix {
+		return fixMessage(ctx, cfg, inputs[0].msg, results[0].Issues)
+	}
+	if failed {
+		return ErrLintFailed
+	}
+	return nil
+}
+
+// maxFixDiffSize caps how much of the staged diff is sent along with a fix request.
+const maxFixDiffSize = 8 * 1024
+
+// fixMessage asks the AI for a corrected version of msg and lets the user accept it in the TUI.
+// Accepting overwrites cfg.LintFile; anything else returns ErrLintFailed so a commit-msg hook aborts.
+func fixMessage(ctx context.Context, cfg Config, msg string, issues []commitmsg.Issue) error {
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// The staged diff is optional context; lint may run outside a repository.
+	repoRoot, _ := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+	diff := ""
+	if repoRoot != "" {
+		diff, _ = gitx.StagedDiff(ctx, repoRoot)
+		diff = filterDiff(cfg, diff)
+		if len(diff) > maxFixDiffSize {
+			diff = diff[:maxFixDiffSize] + "\n...[Diff truncated due to size]..."
+		}
+	}
+
+	issueText := make([]string, 0, len(issues))
+	for _, is := range issues {
+		issueText = append(issueText, is.String())
+	}
+	rules := lintRules(cfg)
+	types := rules.Types
+	if rules.Conventional && len(types) == 0 {
+		types = commitmsg.DefaultTypes
+	}
+	msgs := vscodeprompt.BuildFixMessages(vscodeprompt.FixData{
+		Message:          commitmsg.Clean(msg),
+		Issues:           issueText,
+		Conventional:     rules.Conventional,
+		Types:            types,
+		MaxSubjectLength: rules.MaxSubjectLength,
+		Diff:             diff,
+	})
+
+	m, err := runProgram(newTuiModel(repoRoot, provider, msgs, cfg.Temperature, cfg.Timeout, false, cfg.LintFile, history.HashDiffs([]string{diff}), cfg.HistoryPath).withTrailers(trailerSet{changeID: commitmsg.ChangeID(msg)}).withAccessible(accessibleMode(cfg)))
+	if err != nil {
+		return err
+	}
+	if m.applied() {
+		return nil
+	}
+	return ErrLintFailed
+}
+
+// suggestReplacements fills in Suggestion for every invalid commit, generated from the commit's own diff.
+func suggestReplacements(ctx context.Context, cfg Config, repoRoot string, results []lintResult) error {
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		r := &results[i]
+		if r.Valid {
+			continue
+		}
+		diff, err := gitx.CommitDiff(ctx, repoRoot, r.Source)
+		if err != nil {
+			slog.Warn("could not read commit diff", "commit", r.Source, "err", err)
+			continue
+		}
+		pr, err := preparePromptFromDiff(ctx, cfg, repoRoot, diff)
+		if err != nil {
+			slog.Warn("could not build prompt", "commit", r.Source, "err", err)
+			continue
+		}
+		genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		msg, err := generate.Message(genCtx, forPrompt(genCtx, provider, pr, cfg), pr.msgs, cfg.Temperature, cfg.Conventional)
+		cancel()
+		if err != nil {
+			slog.Warn("could not generate suggestion", "commit", r.Source, "err", err)
+			continue
+		}
+		r.Suggestion = strings.TrimSpace(msg)
+	}
+	return nil
+}
+
+func printLintText(w io.Writer, results []lintResult) {
+	bad := 0
+	for _, r := range results {
+		if r.Valid {
+			continue
+		}
+		bad++
+		fmt.Fprintf(w, "✗ %s %s\n", shortSource(r.Source), r.Subject)
+		for _, is := range r.Issues {
+			fmt.Fprintf(w, "    %s\n", is)
+		}
+		if r.Suggestion != "" {
+			fmt.Fprint(w, i18n.Tf("    suggestion: %s\n", commitmsg.Subject(r.Suggestion)))
+		}
+	}
+	fmt.Fprint(w, i18n.Tf("%d of %d message(s) passed\n", len(results)-bad, len(results)))
+}
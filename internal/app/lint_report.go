@@ -0,0 +1,148 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// defaultLintFormat picks "github" inside GitHub Actions so problems show up as annotations.
+func defaultLintFormat() string {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return "github"
+	}
+	return "text"
+}
+
+func writeLintReport(w io.Writer, format string, results []lintResult) error {
+	if format == "" {
+		format = defaultLintFormat()
+	}
+	switch strings.ToLower(format) {
+	case "text":
+		printLintText(w, results)
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "github":
+		writeLintGitHub(w, results)
+		return nil
+	case "junit":
+		return writeLintJUnit(w, results)
+	default:
+		return fmt.Errorf("unknown format %q (use: text | json | github | junit)", format)
+	}
+}
+
+// writeLintGitHub prints GitHub Actions workflow commands, which the runner turns into annotations.
+func writeLintGitHub(w io.Writer, results []lintResult) {
+	for _, r := range results {
+		for _, is := range r.Issues {
+			title := fmt.Sprintf("%s (%s)", is.Rule, shortSource(r.Source))
+			body := fmt.Sprintf("%s: %s", r.Subject, is.Message)
+			if r.Suggestion != "" {
+				body += "\nSuggested message:\n" + r.Suggestion
+			}
+			props := "title=" + escapeGitHubProperty(title)
+			if !looksLikeHash(r.Source) && r.Source != "-" {
+				props = fmt.Sprintf("file=%s,line=%d,%s", escapeGitHubProperty(r.Source), is.Line, props)
+			}
+			fmt.Fprintf(w, "::error %s::%s\n", props, escapeGitHubData(body))
+		}
+	}
+	printLintText(w, results)
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeLintJUnit writes one test case per message, failing when the message has issues.
+func writeLintJUnit(w io.Writer, results []lintResult) error {
+	suite := junitSuite{Name: "commitgen lint", Tests: len(results)}
+	for _, r := range results {
+		tc := junitCase{Classname: "commit-messages", Name: fmt.Sprintf("%s %s", shortSource(r.Source), r.Subject)}
+		if !r.Valid {
+			suite.Failures++
+			var body strings.Builder
+			rules := make([]string, 0, len(r.Issues))
+			for _, is := range r.Issues {
+				rules = append(rules, is.Rule)
+				body.WriteString(is.String() + "\n")
+			}
+			if r.Suggestion != "" {
+				body.WriteString("\nSuggested message:\n" + r.Suggestion + "\n")
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d issue(s)", len(r.Issues)),
+				Type:    strings.Join(rules, ","),
+				Body:    body.String(),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func looksLikeHash(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// shortSource abbreviates commit hashes; file paths are returned unchanged.
+func shortSource(s string) string {
+	if looksLikeHash(s) {
+		return s[:7]
+	}
+	return s
+}
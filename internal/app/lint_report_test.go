@@ -0,0 +1,40 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+)
+
+func TestWriteLintReport(t *testing.T) {
+	hash := strings.Repeat("a", 40)
+	results := []lintResult{
+		{Source: hash, Subject: "fix: ok", Valid: true, Issues: []commitmsg.Issue{}},
+		{Source: "msg.txt", Subject: "bad.", Issues: []commitmsg.Issue{{Rule: "subject-full-stop", Line: 1, Message: "100% wrong"}}},
+	}
+
+	var gh bytes.Buffer
+	if err := writeLintReport(&gh, "github", results); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=msg.txt,line=1,title=subject-full-stop (msg.txt)::bad.: 100%25 wrong\n"
+	if !strings.HasPrefix(gh.String(), want) {
+		t.Errorf("github output = %q; want prefix %q", gh.String(), want)
+	}
+
+	var junit bytes.Buffer
+	if err := writeLintReport(&junit, "junit", results); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`tests="2"`, `failures="1"`, `name="aaaaaaa fix: ok"`, `type="subject-full-stop"`} {
+		if !strings.Contains(junit.String(), s) {
+			t.Errorf("junit output missing %s:\n%s", s, junit.String())
+		}
+	}
+
+	if err := writeLintReport(&bytes.Buffer{}, "xml", results); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
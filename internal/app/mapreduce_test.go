@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// summarize answers summary prompts with a summary and anything else with a message.
+func summarize(ctx context.Context, user string, n int) (string, error) {
+	if strings.HasPrefix(user, "<file>\n") {
+		path, _, _ := strings.Cut(strings.TrimPrefix(user, "<file>\n"), "\n")
+		return "update " + path + "\nignored second line", nil
+	}
+	return "```text\nfeat: big change\n```", nil
+}
+
+func TestMapReduce(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{
+		{Path: "a.go", Diff: strings.Repeat("+a\n", 200)},
+		{Path: "b.go", Diff: strings.Repeat("+b\n", 200)},
+	}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+
+	rp := &scriptedProvider{script: summarize}
+	if p := forPrompt(context.Background(), rp, pr, Config{ContextBudget: 1 << 20}); p != rp {
+		t.Fatal("prompt under the budget should use the provider as is")
+	}
+	p := forPrompt(context.Background(), rp, pr, Config{ContextBudget: 100})
+
+	msg, err := generate.Message(context.Background(), p, pr.msgs, 0, true)
+	if err != nil || msg != "feat: big change" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(rp.users) != 3 {
+		t.Fatalf("got %d requests; want 2 summaries and the message", len(rp.users))
+	}
+	final := rp.users[2]
+	for _, want := range []string{"- a.go: update a.go\n", "- b.go: update b.go\n"} {
+		if !strings.Contains(final, want) {
+			t.Errorf("final prompt lacks %q:\n%s", want, final)
+		}
+	}
+	if strings.Contains(final, "+a") {
+		t.Error("final prompt should not contain the diffs")
+	}
+
+	// Regenerating reuses the summaries.
+	if _, err := generate.Message(context.Background(), p, pr.msgs, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(rp.users) != 4 {
+		t.Errorf("regenerating made %d requests; want 1", len(rp.users)-3)
+	}
+}
+
+// windowedProvider summarizes for a model with a small context window.
+type windowedProvider struct {
+	scriptedProvider
+	window int
+}
+
+func (p *windowedProvider) ContextWindow(context.Context) int { return p.window }
+
+func TestMapReduceContextWindow(t *testing.T) {
+	var diff strings.Builder
+	for i := range 6 {
+		fmt.Fprintf(&diff, "@@ -%d,1 +%d,1 @@\n%s", i*100, i*100, strings.Repeat("+line of code\n", 60))
+	}
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "big.go", Diff: diff.String()}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+
+	rp := &scriptedProvider{script: summarize}
+	if p := forPrompt(context.Background(), rp, pr, Config{ContextBudget: 1000}); p != rp {
+		t.Fatal("a single file without a context window should be sent as is")
+	}
+
+	wp := &windowedProvider{scriptedProvider: scriptedProvider{script: summarize}, window: 2048}
+	p := forPrompt(context.Background(), wp, pr, Config{ContextBudget: 32000})
+	if _, err := generate.Message(context.Background(), p, pr.msgs, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	// (2048-512)*3/4 = 1152 tokens, so chunks of 2304 bytes: each holds two hunks.
+	if len(wp.users) != 4 {
+		t.Fatalf("got %d requests; want 3 chunk summaries and the message", len(wp.users))
+	}
+	for _, u := range wp.users {
+		if len(u)/generate.CharsPerToken > 1152+200 {
+			t.Errorf("request of %d bytes does not fit the window", len(u))
+		}
+	}
+	want := "- big.go: update big.go (part 1 of 3); update big.go (part 2 of 3); update big.go (part 3 of 3)\n"
+	if final := wp.users[3]; !strings.Contains(final, want) {
+		t.Errorf("final prompt lacks %q:\n%s", want, final)
+	}
+	if strings.Contains(wp.users[3], "<truncation-report>") {
+		t.Error("truncation report for a diff summarized in full")
+	}
+}
+
+// hungWindowProvider never answers how large its context window is.
+type hungWindowProvider struct{ scriptedProvider }
+
+func (p *hungWindowProvider) ContextWindow(ctx context.Context) int {
+	<-ctx.Done()
+	return 0
+}
+
+func TestContextWindowCanceled(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "a.go", Diff: "+a\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	hp := &hungWindowProvider{}
+	if p := forPrompt(ctx, hp, pr, Config{ContextBudget: 32000}); p != hp {
+		t.Error("a provider without a known window should be used as is")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("forPrompt waited %v for the window after ctx was canceled", d)
+	}
+}
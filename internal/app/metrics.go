@@ -0,0 +1,178 @@
+package app
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the provider latency histogram.
+var latencyBuckets = []float64{0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// otherModel is the model label of generations with a model the server was not
+// configured with. Requests name their model, and a label per name would let
+// clients grow the metrics without bound.
+const otherModel = "other"
+
+// serveMetrics counts what the server did, for /metrics in the Prometheus text format.
+type serveMetrics struct {
+	mu        sync.Mutex
+	requests  map[int]int // /suggest responses by status code
+	cacheHits int
+	gens      map[genLabels]*genStats
+	models    map[string]bool // the models labeled by name
+}
+
+type genLabels struct{ provider, model string }
+
+// genStats describes the generations with one provider and model.
+type genStats struct {
+	ok, failed         int
+	buckets            []int // generations that took at most latencyBuckets[i]
+	seconds            float64
+	prompt, completion int // tokens
+}
+
+// newServeMetrics returns metrics that label generations with cfg's model, or
+// one of cfg.OtherModels, by name, and with any other model as otherModel.
+func newServeMetrics(cfg Config) *serveMetrics {
+	models := map[string]bool{cfg.Model: true}
+	for _, entry := range cfg.OtherModels {
+		if t, err := compareTarget(cfg, entry); err == nil {
+			models[t.Model] = true
+		}
+	}
+	return &serveMetrics{requests: map[int]int{}, gens: map[genLabels]*genStats{}, models: models}
+}
+
+// instrument counts the responses of next by status code.
+func (m *serveMetrics) instrument(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next(sw, r)
+		m.mu.Lock()
+		m.requests[sw.status]++
+		m.mu.Unlock()
+	}
+}
+
+func (m *serveMetrics) cacheHit() {
+	m.mu.Lock()
+	m.cacheHits++
+	m.mu.Unlock()
+}
+
+// generated records a generation with provider and model that took d.
+func (m *serveMetrics) generated(provider, model string, d time.Duration, err error, prompt, completion int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.models[model] {
+		model = otherModel
+	}
+	l := genLabels{provider, model}
+	g := m.gens[l]
+	if g == nil {
+		g = &genStats{buckets: make([]int, len(latencyBuckets))}
+		m.gens[l] = g
+	}
+	if err != nil {
+		g.failed++
+	} else {
+		g.ok++
+	}
+	for i, le := range latencyBuckets {
+		if d.Seconds() <= le {
+			g.buckets[i]++
+		}
+	}
+	g.seconds += d.Seconds()
+	g.prompt += prompt
+	g.completion += completion
+}
+
+func (m *serveMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+// write renders the metrics in the Prometheus text exposition format.
+func (m *serveMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP commitgen_requests_total Suggest requests answered, by HTTP status code.")
+	fmt.Fprintln(w, "# TYPE commitgen_requests_total counter")
+	codes := make([]int, 0, len(m.requests))
+	for c := range m.requests {
+		codes = append(codes, c)
+	}
+	slices.Sort(codes)
+	for _, c := range codes {
+		fmt.Fprintf(w, "commitgen_requests_total{code=\"%d\"} %d\n", c, m.requests[c])
+	}
+
+	fmt.Fprintln(w, "# HELP commitgen_cache_hits_total Suggest requests answered from the cache.")
+	fmt.Fprintln(w, "# TYPE commitgen_cache_hits_total counter")
+	fmt.Fprintf(w, "commitgen_cache_hits_total %d\n", m.cacheHits)
+
+	labels := make([]genLabels, 0, len(m.gens))
+	for l := range m.gens {
+		labels = append(labels, l)
+	}
+	slices.SortFunc(labels, func(a, b genLabels) int {
+		return cmp.Or(cmp.Compare(a.provider, b.provider), cmp.Compare(a.model, b.model))
+	})
+	ls := func(l genLabels) string {
+		return fmt.Sprintf("provider=%s,model=%s", labelValue(l.provider), labelValue(l.model))
+	}
+
+	fmt.Fprintln(w, "# HELP commitgen_generations_total Messages generated, by provider, model, and result.")
+	fmt.Fprintln(w, "# TYPE commitgen_generations_total counter")
+	for _, l := range labels {
+		g := m.gens[l]
+		fmt.Fprintf(w, "commitgen_generations_total{%s,result=\"ok\"} %d\n", ls(l), g.ok)
+		fmt.Fprintf(w, "commitgen_generations_total{%s,result=\"error\"} %d\n", ls(l), g.failed)
+	}
+
+	fmt.Fprintln(w, "# HELP commitgen_generation_duration_seconds Time to generate a message, including retries and repairs.")
+	fmt.Fprintln(w, "# TYPE commitgen_generation_duration_seconds histogram")
+	for _, l := range labels {
+		g := m.gens[l]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "commitgen_generation_duration_seconds_bucket{%s,le=\"%s\"} %d\n", ls(l), strconv.FormatFloat(le, 'g', -1, 64), g.buckets[i])
+		}
+		fmt.Fprintf(w, "commitgen_generation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", ls(l), g.ok+g.failed)
+		fmt.Fprintf(w, "commitgen_generation_duration_seconds_sum{%s} %g\n", ls(l), g.seconds)
+		fmt.Fprintf(w, "commitgen_generation_duration_seconds_count{%s} %d\n", ls(l), g.ok+g.failed)
+	}
+
+	fmt.Fprintln(w, "# HELP commitgen_tokens_total Tokens the provider reported, by kind.")
+	fmt.Fprintln(w, "# TYPE commitgen_tokens_total counter")
+	for _, l := range labels {
+		g := m.gens[l]
+		fmt.Fprintf(w, "commitgen_tokens_total{%s,kind=\"prompt\"} %d\n", ls(l), g.prompt)
+		fmt.Fprintf(w, "commitgen_tokens_total{%s,kind=\"completion\"} %d\n", ls(l), g.completion)
+	}
+}
+
+// labelValue quotes s as a Prometheus label value.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitlab"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+// MR is PR for GitLab: it writes a merge request title and description for the
+// current branch and prints them, and with cfg.PROpen pushes the branch and opens
+// the merge request with the GitLab API. The instance is cfg.GitLabURL, else the
+// host of the origin remote, so self-hosted GitLab works without setup.
+func MR(ctx context.Context, cfg Config) error {
+	if cfg.PROpen {
+		// Fail before spending a request on the description.
+		if err := resolveSecret(ctx, cfg, &cfg.GitLabToken, "gitlab_token"); err != nil {
+			return err
+		}
+		if cfg.GitLabToken == "" {
+			return errors.New("opening a merge request needs a GitLab token with the api scope. Set gitlab_token, or env GITLAB_TOKEN")
+		}
+	}
+	req, err := writeBranchRequest(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n\n%s\n", req.title, req.body)
+	if !cfg.PROpen {
+		return nil
+	}
+
+	remote, err := gitx.RemoteURL(ctx, req.repoRoot, prRemote)
+	if err != nil {
+		return err
+	}
+	web, project, ok := gitx.SplitRemote(remote)
+	if !ok {
+		return fmt.Errorf("can't tell the GitLab project from %s's URL %q", prRemote, remote)
+	}
+	if cfg.GitLabURL != "" {
+		web = cfg.GitLabURL
+	}
+
+	if err := pushBranch(ctx, req); err != nil {
+		return err
+	}
+	title := req.title
+	if cfg.PRDraft {
+		title = "Draft: " + title
+	}
+	client := gitlab.New(gitlab.Config{BaseURL: web, Token: cfg.GitLabToken})
+	created, err := client.CreateMergeRequest(ctx, project, gitlab.NewMergeRequest{
+		Title:        title,
+		Description:  req.body,
+		SourceBranch: req.branch,
+		TargetBranch: req.base,
+	})
+	if err != nil {
+		return err
+	}
+	infof("Opened %s\n", created.WebURL)
+	return nil
+}
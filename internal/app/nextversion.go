@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/logx"
+)
+
+// maxListedBumps caps how many release-worthy commits the reasoning lists.
+const maxListedBumps = 20
+
+// release is the next version and the commits that decided it.
+type release struct {
+	last    commitmsg.Version
+	tagged  bool // last is an existing tag; otherwise this is the first release
+	next    commitmsg.Version
+	bump    commitmsg.Bump
+	bumps   []releaseCommit // commits that call for a release, newest first
+	skipped map[string]int  // commits that don't, by type or reason
+}
+
+type releaseCommit struct {
+	hash    string
+	subject string
+	bump    commitmsg.Bump
+}
+
+// NextVersion prints the version the commits since the last release tag call for,
+// by the semantic-release rules, to stdout, and the reasoning to stderr. When no
+// commit calls for a release it prints the last version, or nothing without one.
+func NextVersion(ctx context.Context, cfg Config) error {
+	r, err := releaseSince(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if r.tagged || r.bump != commitmsg.BumpNone {
+		fmt.Println(r.next)
+	}
+	if !logx.Quiet() {
+		writeReleaseReasoning(os.Stderr, r)
+	}
+	return nil
+}
+
+// releaseSince plans the release for the commits since cfg.ReleaseFrom, or since
+// the latest release tag.
+func releaseSince(ctx context.Context, cfg Config) (release, error) {
+	repoRoot, err := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+	if err != nil {
+		return release{}, err
+	}
+
+	var last commitmsg.Version
+	tag := cfg.ReleaseFrom
+	if tag == "" {
+		tag, err = latestVersionTag(ctx, repoRoot)
+		if err != nil {
+			return release{}, err
+		}
+	}
+	revRange := "HEAD"
+	if tag != "" {
+		if last, err = commitmsg.ParseVersion(tag); err != nil {
+			return release{}, fmt.Errorf("--from: %w", err)
+		}
+		revRange = tag + "..HEAD"
+	}
+	commits, err := gitx.CommitMessages(ctx, repoRoot, revRange)
+	if err != nil {
+		return release{}, err
+	}
+	return planRelease(last, tag != "", commits), nil
+}
+
+// latestVersionTag returns the highest semantic-version tag reachable from HEAD, or "".
+func latestVersionTag(ctx context.Context, repoRoot string) (string, error) {
+	tags, err := gitx.MergedTags(ctx, repoRoot)
+	if err != nil {
+		return "", err
+	}
+	best, bestTag := commitmsg.Version{}, ""
+	for _, t := range tags {
+		v, err := commitmsg.ParseVersion(t)
+		if err != nil {
+			continue
+		}
+		if bestTag == "" || best.Less(v) {
+			best, bestTag = v, t
+		}
+	}
+	return bestTag, nil
+}
+
+// planRelease works out the release for commits made since last. Without a
+// previous tag, any release-worthy commit makes the first release 1.0.0, as
+// semantic-release does.
+func planRelease(last commitmsg.Version, tagged bool, commits []gitx.CommitMessage) release {
+	r := release{last: last, tagged: tagged, skipped: map[string]int{}}
+	for _, c := range commits {
+		b, why := commitmsg.CommitBump(c.Message)
+		if b == commitmsg.BumpNone {
+			r.skipped[why]++
+			continue
+		}
+		r.bumps = append(r.bumps, releaseCommit{hash: c.Hash, subject: commitmsg.Subject(commitmsg.Clean(c.Message)), bump: b})
+		r.bump = max(r.bump, b)
+	}
+	switch {
+	case r.bump == commitmsg.BumpNone:
+		r.next = last
+	case !tagged:
+		r.next = commitmsg.Version{Major: 1}
+	default:
+		r.next = last.Bump(r.bump)
+	}
+	return r
+}
+
+// writeReleaseReasoning explains r: the bump, the commits that called for a
+// release, and how many did not.
+func writeReleaseReasoning(w io.Writer, r release) {
+	switch {
+	case r.bump == commitmsg.BumpNone && r.tagged:
+		fmt.Fprint(w, i18n.Tf("No release: nothing since %s calls for one (feat, fix, perf, revert or a breaking change)\n", r.last))
+	case r.bump == commitmsg.BumpNone:
+		fmt.Fprintln(w, i18n.T("No release: no release tag yet, and no commit calls for one (feat, fix, perf, revert or a breaking change)"))
+	case r.tagged:
+		fmt.Fprint(w, i18n.Tf("%s release: %s -> %s\n", r.bump, r.last, r.next))
+	default:
+		fmt.Fprintln(w, i18n.T("First release: no semantic-version tag yet"))
+	}
+
+	for i, c := range r.bumps {
+		if i == maxListedBumps {
+			fmt.Fprint(w, i18n.Tf("  ... and %d more\n", len(r.bumps)-i))
+			break
+		}
+		fmt.Fprintf(w, "  %-5s  %s %s\n", c.bump, shortSource(c.hash), c.subject)
+	}
+
+	if n := sumCounts(r.skipped); n > 0 {
+		reasons := make([]string, 0, len(r.skipped))
+		for why := range r.skipped {
+			reasons = append(reasons, why)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if r.skipped[reasons[i]] != r.skipped[reasons[j]] {
+				return r.skipped[reasons[i]] > r.skipped[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		parts := make([]string, len(reasons))
+		for i, why := range reasons {
+			parts[i] = fmt.Sprintf("%s: %d", why, r.skipped[why])
+		}
+		format := "  %d commits need no release (%s)\n"
+		if n == 1 {
+			format = "  %d commit needs no release (%s)\n"
+		}
+		fmt.Fprint(w, i18n.Tf(format, n, strings.Join(parts, ", ")))
+	}
+}
+
+func sumCounts(m map[string]int) int {
+	n := 0
+	for _, v := range m {
+		n += v
+	}
+	return n
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+func TestPlanRelease(t *testing.T) {
+	commits := []gitx.CommitMessage{
+		{Hash: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Message: "docs: explain next-version"},
+		{Hash: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Message: "feat(cli): add next-version"},
+		{Hash: "cccccccccccccccccccccccccccccccccccccccc", Message: "fix: handle empty tags"},
+		{Hash: "dddddddddddddddddddddddddddddddddddddddd", Message: "Merge branch 'main'"},
+	}
+	last, _ := commitmsg.ParseVersion("v1.4.2")
+	r := planRelease(last, true, commits)
+	if r.next.String() != "v1.5.0" || r.bump != commitmsg.BumpMinor {
+		t.Fatalf("got %s (%v); want v1.5.0 (minor)", r.next, r.bump)
+	}
+
+	var b strings.Builder
+	writeReleaseReasoning(&b, r)
+	want := "minor release: v1.4.2 -> v1.5.0\n" +
+		"  minor  bbbbbbb feat(cli): add next-version\n" +
+		"  patch  ccccccc fix: handle empty tags\n" +
+		"  2 commits need no release (docs: 1, not a conventional commit: 1)\n"
+	if b.String() != want {
+		t.Errorf("reasoning:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	if r := planRelease(commitmsg.Version{}, false, commits); r.next.String() != "1.0.0" {
+		t.Errorf("first release: got %s", r.next)
+	}
+	if r := planRelease(last, true, commits[:1]); r.next != last || r.bump != commitmsg.BumpNone {
+		t.Errorf("docs only: got %s (%v)", r.next, r.bump)
+	}
+}
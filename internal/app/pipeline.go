@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+)
+
+// contextBudget returns cfg.ContextBudget, lowered to fit the model's context window
+// when provider reports one; see generate.ContextBudget.
+func contextBudget(ctx context.Context, provider ai.Provider, cfg Config) (budget int, windowed bool) {
+	if mp, ok := provider.(*meteredProvider); ok {
+		provider = mp.Provider
+	}
+	return generate.ContextBudget(ctx, provider, cfg.ContextBudget)
+}
+
+// tokens estimates the size of the prompt in tokens.
+func (p prompt) tokens() int {
+	return generate.Tokens(p.msgs)
+}
+
+// diff returns the prompt's diffs as one, cut to half of budget (in tokens) if set.
+func (p prompt) diff(budget int) string {
+	return generate.Diff(p.data, budget)
+}
+
+// forPrompt returns the provider to generate pr's message with: provider itself, wrapped
+// as cfg asks. When pr is over cfg.ContextBudget the message is written from one-line
+// summaries of each file; cfg.BestOf generates that many candidates and keeps the
+// likeliest valid one; cfg.Refine adds a critique-and-improve pass; a subject over
+// cfg.MaxSubjectLength, a Conventional Commits syntax error, or a policy violation
+// sends the message back to be repaired (up to cfg.RepairAttempts times);
+// and the resulting message gets the local fixes (e.g. imperative mood).
+// cfg.PrePromptCommand sees every request first, and cfg.PostMessageCommand
+// the final message. Asking the model for its context window stops with ctx.
+func forPrompt(ctx context.Context, provider ai.Provider, pr prompt, cfg Config) ai.Provider {
+	if _, ok := provider.(templateProvider); ok {
+		return provider
+	}
+	// A prompt over a context window must be split even for a single file.
+	budget, windowed := contextBudget(ctx, provider, cfg)
+	cfg.ContextBudget = budget
+	mapReduce := budget > 0 && (len(pr.data.Changes) >= 2 || windowed) && pr.tokens() > budget
+	fixes := messageFixes(cfg)
+	if fixes.Spelling {
+		// Misspelled identifiers in the code keep their spelling in the message.
+		fixes.Dictionary = append(slices.Clip(fixes.Dictionary), commitmsg.KnownMisspellings(pr.diff(0))...)
+	}
+	// newProvider has rejected invalid policies already.
+	policy, _ := cfg.policy()
+	repair := cfg.RepairAttempts > 0 && (cfg.MaxSubjectLength > 0 || cfg.Conventional || !policy.Empty())
+	bestOf := cfg.BestOf > 1
+	if cfg.BestOf > maxBestOf {
+		slog.Warn("too many candidates; generating the most allowed", "best_of", cfg.BestOf, "max", maxBestOf)
+		cfg.BestOf = maxBestOf
+	}
+	commands := cfg.PrePromptCommand != "" || cfg.PostMessageCommand != ""
+	if !mapReduce && !bestOf && !cfg.Refine && !repair && !fixes.Enabled() && !commands {
+		return provider
+	}
+	if mapReduce {
+		slog.Debug("prompt over context budget, summarizing files first", "tokens", pr.tokens(), "budget", cfg.ContextBudget, "files", len(pr.data.Changes))
+	}
+
+	wrap := func(base ai.Provider) ai.Provider {
+		if cfg.PrePromptCommand != "" {
+			base = promptCommandProvider{Provider: base, command: cfg.PrePromptCommand, dir: pr.repoRoot, provider: cfg.Provider, model: cfg.Model}
+		}
+		p := base
+		if mapReduce {
+			mr := &generate.MapReduce{Provider: base, Data: pr.data, Base: len(pr.msgs)}
+			// Only a known context window is a hard limit; a budget alone leaves
+			// each file's diff whole.
+			if windowed {
+				mr.MaxDiffChars = cfg.ContextBudget * generate.CharsPerToken / 2
+			}
+			p = mr
+		}
+		if bestOf {
+			p = &bestOfProvider{Provider: p, n: cfg.BestOf, checker: generate.NewChecker(checkRules(cfg), policy)}
+		}
+		if cfg.Refine {
+			p = newRefineProvider(base, p, pr, cfg)
+		}
+		if repair {
+			p = generate.NewRepair(base, p, cfg.RepairAttempts, checkRules(cfg), policy, pr.diff(cfg.ContextBudget))
+		}
+		if fixes.Enabled() {
+			p = generate.Fixing{Provider: p, Fixes: fixes}
+		}
+		if cfg.PostMessageCommand != "" {
+			p = messageCommandProvider{Provider: p, command: cfg.PostMessageCommand, dir: pr.repoRoot}
+		}
+		return p
+	}
+	// Wrap inside the metering so the whole pipeline counts as one generation.
+	if mp, ok := provider.(*meteredProvider); ok {
+		c := *mp
+		c.Provider = wrap(mp.Provider)
+		return &c
+	}
+	return wrap(provider)
+}
+
+// policy compiles the message policy in cfg.
+func (c Config) policy() (commitmsg.Policy, error) {
+	return commitmsg.NewPolicy(c.BannedWords, c.DenyPatterns, c.RequiredPrefixes)
+}
+
+// checkRules returns the lint rules generated messages are checked against, and
+// repaired for.
+func checkRules(cfg Config) commitmsg.Rules {
+	return commitmsg.Rules{
+		Conventional:     cfg.Conventional,
+		Types:            cfg.AllowedTypes,
+		MaxSubjectLength: cfg.MaxSubjectLength,
+	}
+}
+
+// messageFixes returns the local fixes cfg enables.
+func messageFixes(cfg Config) commitmsg.Fixes {
+	wrap := cfg.BodyWrap
+	if cfg.MaxBodyLineLength > 0 && wrap > cfg.MaxBodyLineLength {
+		wrap = cfg.MaxBodyLineLength
+	}
+	return commitmsg.Fixes{Imperative: cfg.Imperative, Wrap: wrap, Spelling: cfg.Spellcheck, Dictionary: cfg.Dictionary, ASCII: cfg.ASCII, Type: cfg.Type}
+}
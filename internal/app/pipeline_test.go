@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestForPromptFixes(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "a.go", Diff: "+a\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	raw := &scriptedProvider{reply: "```text\nfeat: added a cache.\n\nKeeps results.\n```"}
+
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), raw, pr, Config{Imperative: true}), pr.msgs, 0, false)
+	if want := "feat: add a cache\n\nKeeps results."; err != nil || msg != want {
+		t.Errorf("got %q, %v; want %q", msg, err, want)
+	}
+	if p := forPrompt(context.Background(), raw, pr, Config{}); p != raw {
+		t.Errorf("no fixes configured, got %T", p)
+	}
+}
+
+func TestMessageFixesWrap(t *testing.T) {
+	tests := []struct {
+		wrap, maxLine, want int
+	}{
+		{72, 0, 72},
+		{72, 50, 50},
+		{40, 50, 40},
+		{0, 50, 0},
+	}
+	for _, tt := range tests {
+		if got := messageFixes(Config{BodyWrap: tt.wrap, MaxBodyLineLength: tt.maxLine}).Wrap; got != tt.want {
+			t.Errorf("wrap %d, max line %d: Wrap = %d; want %d", tt.wrap, tt.maxLine, got, tt.want)
+		}
+	}
+}
+
+func TestForPromptSpelling(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "q.go", Diff: "+func (q *Queue) Recieve() {}\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	raw := &scriptedProvider{reply: "```text\nfeat: add Queue.Recieve\n\nIt returns teh next recieved item.\n```"}
+
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), raw, pr, Config{Spellcheck: true}), pr.msgs, 0, false)
+	if want := "feat: add Queue.Recieve\n\nIt returns the next received item."; err != nil || msg != want {
+		t.Errorf("got %q, %v; want %q", msg, err, want)
+	}
+}
@@ -0,0 +1,379 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+)
+
+// accessibleMode reports whether to use plain prompts instead of the full-screen
+// interface: when configured, when HUH_ACCESSIBLE is set, or when the terminal
+// can't show it (TERM=dumb, or stdin or stdout is not a terminal).
+func accessibleMode(cfg Config) bool {
+	return cfg.Accessible || os.Getenv("HUH_ACCESSIBLE") != "" || os.Getenv("TERM") == "dumb" ||
+		!isTerminal(os.Stdin) || !isTerminal(os.Stdout)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// withAccessible makes runProgram ask with plain lines and numbered choices instead
+// of running the full-screen TUI.
+func (m tuiModel) withAccessible(on bool) tuiModel {
+	m.accessible = on
+	return m
+}
+
+// plainUI runs the model's screens as plain text, one question at a time, for
+// screen readers and terminals without cursor movement. It has no spinner, colors,
+// or redraws; everything it prints stays readable in the scrollback.
+type plainUI struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// runPlain runs m until it commits or is canceled, like runProgram does with the TUI.
+// End of input cancels.
+func runPlain(m tuiModel, in io.Reader, out io.Writer) tuiModel {
+	ui := plainUI{in: bufio.NewReader(in), out: out}
+	for {
+		switch m.state {
+		case stateFiles:
+			m = ui.files(m)
+		case stateCompare:
+			m = ui.compare(m)
+		case stateGenerating:
+			m = ui.generate(m)
+		case stateConfirm:
+			m = ui.confirm(m)
+		default:
+			return m
+		}
+		if m.quitting {
+			return m
+		}
+	}
+}
+
+// update passes msg to the model as the TUI would, so both share its handling.
+func update(m tuiModel, msg tea.Msg) tuiModel {
+	next, _ := m.Update(msg)
+	return next.(tuiModel)
+}
+
+// ask prints question and returns the answer, trimmed. ok is false at end of input.
+func (ui plainUI) ask(question string) (answer string, ok bool) {
+	fmt.Fprint(ui.out, question)
+	line, err := ui.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(ui.out)
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// choose asks for a number from 1 to n until it gets one, and returns it 0-based.
+// Other answers are returned in other, for choices with extra commands.
+func (ui plainUI) choose(question string, n int) (i int, other string, ok bool) {
+	for {
+		answer, ok := ui.ask(question)
+		if !ok {
+			return 0, "", false
+		}
+		if v, err := strconv.Atoi(answer); err == nil {
+			if v >= 1 && v <= n {
+				return v - 1, "", true
+			}
+			fmt.Fprintln(ui.out, i18n.Tf("Enter a number from 1 to %d.", n))
+			continue
+		}
+		return -1, answer, true
+	}
+}
+
+// files asks which staged files to leave out of the message.
+func (ui plainUI) files(m tuiModel) tuiModel {
+	fmt.Fprintln(ui.out, i18n.Tf("Staged files (%d):", len(m.files)))
+	for i, f := range m.files {
+		fmt.Fprint(ui.out, i18n.Tf("%d. %s, %d added, %d deleted lines", i+1, f.change.Path, f.fact.Insertions, f.fact.Deletions))
+		if f.summary != "" {
+			fmt.Fprintf(ui.out, ", %s", f.summary)
+		}
+		fmt.Fprintln(ui.out)
+	}
+	for {
+		answer, ok := ui.ask(i18n.T("Numbers of files to leave out, separated by spaces (Enter keeps all, q cancels): "))
+		if !ok || answer == "q" {
+			m.quitting = true
+			return m
+		}
+		for i := range m.files {
+			m.files[i].selected = true
+		}
+		valid := true
+		for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 || n > len(m.files) {
+				fmt.Fprintln(ui.out, i18n.Tf("%q is not a file number.", field))
+				valid = false
+				break
+			}
+			m.files[n-1].selected = false
+		}
+		if !valid {
+			continue
+		}
+		var selected bool
+		if m, selected = m.useSelected(); selected {
+			return m
+		}
+		fmt.Fprintln(ui.out, m.notice)
+	}
+}
+
+// generate asks for a message and waits for it, without a spinner.
+func (ui plainUI) generate(m tuiModel) tuiModel {
+	fmt.Fprintln(ui.out, i18n.T("Generating commit message..."))
+	if len(m.models) > 1 {
+		fmt.Fprintln(ui.out, i18n.Tf("Model: %s", m.models[m.modelIndex].name))
+	}
+	if m.riskCheck != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		risks, err := m.riskCheck(ctx)
+		cancel()
+		m = update(m, riskResultMsg{risks: risks, err: err})
+		m.riskCheck = nil // once per run, as in the TUI
+	}
+	gen := m.generateCommitCmd()
+	go func(stream <-chan string) {
+		for range stream {
+		}
+	}(m.inflight.stream)
+	return update(m, gen())
+}
+
+// compare generates with each side in turn and asks which message to continue with.
+func (ui plainUI) compare(m tuiModel) tuiModel {
+	m.inflight.seq++
+	for i, side := range m.compare {
+		fmt.Fprintln(ui.out, i18n.Tf("Generating with %s...", side.name))
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		msg, err := generate.Message(ctx, side.provider, m.initialMsgs, m.temp, m.conventional)
+		cancel()
+		m = update(m, compareResultMsg{seq: m.inflight.seq, side: i, content: msg, err: err})
+	}
+	for i, side := range m.compare {
+		fmt.Fprintln(ui.out, "\n"+i18n.Tf("Message %d, from %s:", i+1, side.name))
+		if side.err != nil {
+			fmt.Fprintln(ui.out, i18n.Tf("Failed: %v", side.err))
+		} else {
+			fmt.Fprintln(ui.out, side.message)
+		}
+	}
+	fmt.Fprintln(ui.out)
+	for {
+		i, other, ok := ui.choose(i18n.Tf("Use which message? Enter 1 to %d, or q to cancel: ", len(m.compare)), len(m.compare))
+		if !ok || other == "q" {
+			next, _ := m.updateCompare(tea.KeyMsg{Type: tea.KeyEsc})
+			return next
+		}
+		if i < 0 {
+			continue
+		}
+		m, _ = m.pickCompared(i)
+		if m.state == stateConfirm {
+			return m
+		}
+		fmt.Fprintln(ui.out, m.notice)
+	}
+}
+
+// confirm shows the message and asks what to do with it.
+func (ui plainUI) confirm(m tuiModel) tuiModel {
+	if len(m.risks) > 0 {
+		fmt.Fprintln(ui.out, "\n"+i18n.T("Check before committing")+":")
+		for _, r := range m.risks {
+			fmt.Fprintln(ui.out, "- "+r)
+		}
+	}
+	fmt.Fprintln(ui.out, "\n"+i18n.T("Commit message:"))
+	if m.ctype != "" && !m.typeChosen {
+		fmt.Fprintln(ui.out, i18n.Tf("Type %s, guessed from the changed files", m.ctype))
+	}
+	if m.commitMsg == "" {
+		fmt.Fprintln(ui.out, i18n.T("(no message yet)"))
+	} else {
+		fmt.Fprintln(ui.out, m.commitMsg)
+	}
+	fmt.Fprintln(ui.out)
+	if m.notice != "" {
+		fmt.Fprintln(ui.out, m.notice)
+		m.notice = ""
+	}
+
+	question := i18n.T("Action: Enter to commit, or a shortcut: ")
+	if !m.quick {
+		for i, opt := range confirmOptions {
+			opt = i18n.T(opt)
+			if keys := m.keys.keysFor(i); len(keys) > 0 {
+				opt += i18n.Tf(", shortcut %s", strings.Join(keys, i18n.T(" or ")))
+			}
+			fmt.Fprintf(ui.out, "%d. %s\n", i+1, opt)
+		}
+		question = i18n.Tf("Choose an action, 1 to %d: ", len(confirmOptions))
+	}
+	action, other, ok := ui.choose(question, len(confirmOptions))
+	switch {
+	case !ok:
+		action = actionCancel
+	case action >= 0:
+	case other == "" && m.quick:
+		action = actionCommit
+	case other == "":
+		m.notice = i18n.T("Choose an action by its number.")
+		return m
+	default:
+		a, found := m.keys[other]
+		if !found {
+			m.notice = i18n.Tf("%q is not an action.", other)
+			return m
+		}
+		action = a
+	}
+	return ui.run(m, action)
+}
+
+// run does one of the confirm screen's actions, reusing the TUI's where they don't
+// need the screen.
+func (ui plainUI) run(m tuiModel, action int) tuiModel {
+	switch action {
+	case actionRegenerate:
+		m.record(history.StatusRejected, m.commitMsg)
+		recordOutcome(m.provider, stats.OutcomeRegenerated)
+		m = m.reject()
+		m.state = stateGenerating
+		return m
+	case actionEdit:
+		return ui.edit(m)
+	case actionEditor:
+		return update(m, runEditor(m.repoRoot, m.commitMsg))
+	case actionPrevious:
+		m, _ = m.runAction(action)
+		if m.state == statePicking {
+			return ui.pick(m)
+		}
+		return m
+	case actionModel:
+		m, _ = m.runAction(action)
+		if m.state == stateModels {
+			return ui.model(m)
+		}
+		return m
+	}
+
+	m, cmd := m.runAction(action)
+	if m.state == stateCommitting && m.dryRun {
+		m = update(m, cmd())
+	} else if m.state == stateCommitting {
+		fmt.Fprintln(ui.out, i18n.T("Committing..."))
+		m = update(m, cmd())
+		if m.err == nil {
+			fmt.Fprintln(ui.out, i18n.T("Done."))
+		}
+	}
+	return m
+}
+
+// edit reads a new message, line by line, up to a line with a single period.
+func (ui plainUI) edit(m tuiModel) tuiModel {
+	fmt.Fprintln(ui.out, i18n.T("Type the new message. End it with a line holding only a period; a period alone keeps the current message."))
+	var lines []string
+	for {
+		line, err := ui.in.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "." || (err != nil && line == "") {
+			break
+		}
+		lines = append(lines, line)
+		if err != nil {
+			break
+		}
+	}
+	if msg := commitmsg.Clean(strings.Join(lines, "\n")); msg != "" {
+		m.commitMsg = msg
+	}
+	return m
+}
+
+// pick lists the previous suggestions and asks which one to use.
+func (ui plainUI) pick(m tuiModel) tuiModel {
+	fmt.Fprintln(ui.out, "\n"+i18n.T("Previous suggestions:"))
+	for i, s := range m.previous {
+		subject, _, _ := strings.Cut(s, "\n")
+		fmt.Fprintf(ui.out, "%d. %s\n", i+1, subject)
+	}
+	m.state = stateConfirm
+	for {
+		i, other, ok := ui.choose(i18n.Tf("Use which one? Enter 1 to %d, or Enter to go back: ", len(m.previous)), len(m.previous))
+		if ok && i >= 0 {
+			m.commitMsg = m.previous[i]
+			m.suggested = m.commitMsg
+			return m
+		}
+		if !ok || other == "" {
+			return m
+		}
+	}
+}
+
+// model lists the models to try and asks which one to generate with.
+func (ui plainUI) model(m tuiModel) tuiModel {
+	fmt.Fprintln(ui.out, "\n"+i18n.T("Models:"))
+	for i, side := range m.models {
+		fmt.Fprintf(ui.out, "%d. %s", i+1, side.name)
+		if i == m.modelIndex {
+			fmt.Fprint(ui.out, " "+i18n.T("(in use)"))
+		}
+		fmt.Fprintln(ui.out)
+	}
+	m.state = stateConfirm
+	for {
+		i, other, ok := ui.choose(i18n.Tf("Generate with which one? Enter 1 to %d, or Enter to go back: ", len(m.models)), len(m.models))
+		if ok && i >= 0 {
+			return m.useModel(i)
+		}
+		if !ok || other == "" {
+			return m
+		}
+	}
+}
+
+// runEditor opens msg in the user's editor on the terminal and returns the result
+// as openInEditor would.
+func runEditor(repoRoot, msg string) editorDoneMsg {
+	path, err := writeEditFile(msg)
+	if err != nil {
+		return editorDoneMsg{err: err}
+	}
+	cmd := editorCommand(gitx.Editor(context.Background(), repoRoot), path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		return editorDoneMsg{err: err}
+	}
+	return readEditFile(path)
+}
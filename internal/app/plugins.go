@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+	"github.com/hoanghonghuy/commitgen/internal/wasmplugin"
+)
+
+var (
+	pluginsMu sync.Mutex
+	// loadedPlugins holds the resolved specs already registered, so each plugin is
+	// compiled once per process.
+	loadedPlugins = map[string]bool{}
+)
+
+// registerSummarizerPlugins loads the WASM summarizers in specs ("ext[,ext...]=path",
+// paths relative to repoRoot) and registers them for their extensions.
+func registerSummarizerPlugins(ctx context.Context, repoRoot string, specs []string) error {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	for _, spec := range specs {
+		exts, path, ok := strings.Cut(spec, "=")
+		exts, path = strings.TrimSpace(exts), strings.TrimSpace(path)
+		if !ok || exts == "" || path == "" {
+			return fmt.Errorf("summarizer_plugins: %q is not ext=path", spec)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoRoot, path)
+		}
+		key := exts + "=" + path
+		if loadedPlugins[key] {
+			continue
+		}
+		s, err := wasmplugin.Load(ctx, path)
+		if err != nil {
+			return fmt.Errorf("summarizer_plugins: %w", err)
+		}
+		for _, ext := range strings.Split(exts, ",") {
+			ext = strings.TrimSpace(ext)
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			vscodeprompt.RegisterSummarizer(ext, s)
+		}
+		loadedPlugins[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/github"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+// prRemote is the remote that pr and mr push to and open requests on, and whose
+// project and default branch prompts name.
+const prRemote = "origin"
+
+// branchRequest is a generated pull or merge request for the current branch.
+type branchRequest struct {
+	repoRoot string
+	branch   string
+	base     string // branch to merge into, without the remote
+	title    string
+	body     string
+}
+
+// PR writes a title and description for a pull request of the current branch into
+// cfg.PRBase and prints them. With cfg.PROpen it then pushes the branch and opens
+// the pull request, with the gh CLI if it is installed, else with the GitHub API.
+func PR(ctx context.Context, cfg Config) error {
+	req, err := writeBranchRequest(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n\n%s\n", req.title, req.body)
+	if !cfg.PROpen {
+		return nil
+	}
+
+	if err := pushBranch(ctx, req); err != nil {
+		return err
+	}
+	pr := github.NewPullRequest{Title: req.title, Body: req.body, Head: req.branch, Base: req.base, Draft: cfg.PRDraft}
+	link, err := openPullRequest(ctx, cfg, req.repoRoot, pr)
+	if err != nil {
+		return err
+	}
+	infof("Opened %s\n", link)
+	return nil
+}
+
+// writeBranchRequest generates a title and description for the commits on the
+// current branch that are not on cfg.PRBase (default: the remote's default branch).
+func writeBranchRequest(ctx context.Context, cfg Config) (branchRequest, error) {
+	repoRoot, err := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+	if err != nil {
+		return branchRequest{}, err
+	}
+	branch, err := gitx.CurrentBranch(ctx, repoRoot)
+	if err != nil {
+		return branchRequest{}, err
+	}
+	if branch == "HEAD" {
+		return branchRequest{}, errors.New("HEAD is detached; check out the branch to open a request for")
+	}
+	base := cfg.PRBase
+	if base == "" {
+		if base = gitx.DefaultBranch(ctx, repoRoot, prRemote); base == "" {
+			base = "main"
+		}
+	}
+	req := branchRequest{repoRoot: repoRoot, branch: branch, base: strings.TrimPrefix(base, prRemote+"/")}
+	if req.base == branch {
+		return branchRequest{}, fmt.Errorf("%s is the base branch; create a branch for the changes first", branch)
+	}
+
+	commits, err := gitx.CommitMessages(ctx, repoRoot, base+"..HEAD")
+	if err != nil {
+		return branchRequest{}, err
+	}
+	if len(commits) == 0 {
+		return branchRequest{}, fmt.Errorf("%w: %s has no commits that are not on %s", ErrNoChanges, branch, base)
+	}
+	slices.Reverse(commits) // oldest first
+	diff, err := gitx.RangeDiff(ctx, repoRoot, base)
+	if err != nil {
+		return branchRequest{}, err
+	}
+	diff = filterDiff(cfg, diff)
+
+	// The description, and the title of several commits, each send the diff.
+	requests := 1
+	if len(commits) > 1 {
+		requests = 2
+	}
+	if cfg, err = applyBudget(cfg, requests*len(diff)/generate.CharsPerToken, false); err != nil {
+		return branchRequest{}, err
+	}
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return branchRequest{}, err
+	}
+	genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	if req.title, err = pullRequestTitle(genCtx, cfg, provider, repoRoot, commits, diff); err != nil {
+		return branchRequest{}, err
+	}
+	msgs := make([]string, 0, len(commits))
+	for _, c := range commits {
+		msgs = append(msgs, c.Message)
+	}
+	if req.body, err = describePullRequest(genCtx, cfg, provider, repoRoot, req.title, msgs, diff); err != nil {
+		return branchRequest{}, err
+	}
+	return req, nil
+}
+
+// pushBranch pushes req's branch to prRemote.
+func pushBranch(ctx context.Context, req branchRequest) error {
+	infof("\nPushing %s to %s...\n", req.branch, prRemote)
+	return gitx.PushBranch(ctx, req.repoRoot, prRemote)
+}
+
+// pullRequestTitle uses the subject of a lone commit, and otherwise asks for a
+// squash-style message for the whole diff and uses its subject.
+func pullRequestTitle(ctx context.Context, cfg Config, provider ai.Provider, repoRoot string, commits []gitx.CommitMessage, diff string) (string, error) {
+	if len(commits) == 1 {
+		return commitmsg.Subject(commitmsg.Clean(commits[0].Message)), nil
+	}
+	p, err := preparePromptFromDiff(ctx, cfg, repoRoot, diff)
+	if err != nil {
+		return "", err
+	}
+	msg, err := generate.Message(ctx, forPrompt(ctx, provider, p, cfg), p.msgs, cfg.Temperature, cfg.Conventional)
+	if err != nil {
+		return "", err
+	}
+	return commitmsg.Subject(msg), nil
+}
+
+// openPullRequest creates pr and returns its URL. gh is preferred because it is
+// already signed in; without it, GITHUB_TOKEN is used with the API.
+func openPullRequest(ctx context.Context, cfg Config, repoRoot string, pr github.NewPullRequest) (string, error) {
+	if gh, err := exec.LookPath("gh"); err == nil {
+		args := []string{"pr", "create", "--title", pr.Title, "--body-file", "-", "--base", pr.Base, "--head", pr.Head}
+		if pr.Draft {
+			args = append(args, "--draft")
+		}
+		cmd := exec.CommandContext(ctx, gh, args...)
+		cmd.Dir = repoRoot
+		cmd.Stdin = strings.NewReader(pr.Body)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("gh pr create: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	if cfg.GitHubToken == "" {
+		return "", errors.New("opening a pull request needs the gh CLI (https://cli.github.com) or a token in GITHUB_TOKEN")
+	}
+	remote, err := gitx.RemoteURL(ctx, repoRoot, prRemote)
+	if err != nil {
+		return "", err
+	}
+	repo, ok := githubRepo(remote)
+	if !ok {
+		return "", fmt.Errorf("can't tell the GitHub repository from %s's URL %q", prRemote, remote)
+	}
+	client := github.New(github.Config{BaseURL: os.Getenv("GITHUB_API_URL"), Token: cfg.GitHubToken})
+	created, err := client.CreatePullRequest(ctx, repo, pr)
+	if err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
+
+// githubRepo returns "owner/name" from a remote URL.
+func githubRepo(remote string) (string, bool) {
+	_, path, ok := gitx.SplitRemote(remote)
+	if !ok || strings.Count(path, "/") != 1 {
+		return "", false
+	}
+	return path, true
+}
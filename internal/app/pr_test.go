@@ -0,0 +1,23 @@
+package app
+
+import "testing"
+
+func TestGitHubRepo(t *testing.T) {
+	for _, remote := range []string{
+		"https://github.com/hoanghonghuy/commitgen.git",
+		"https://github.com/hoanghonghuy/commitgen",
+		"ssh://git@github.com/hoanghonghuy/commitgen.git",
+		"git@github.com:hoanghonghuy/commitgen.git",
+		"git@ghe.example.com:hoanghonghuy/commitgen",
+	} {
+		if got, ok := githubRepo(remote); !ok || got != "hoanghonghuy/commitgen" {
+			t.Errorf("githubRepo(%q) = %q, %v", remote, got, ok)
+		}
+	}
+	if got, ok := githubRepo("/srv/git/commitgen.git"); ok {
+		t.Errorf("local path parsed as %q", got)
+	}
+	if _, ok := githubRepo("git@gitlab.com:group/sub/app.git"); ok {
+		t.Error("githubRepo accepted a nested project path")
+	}
+}
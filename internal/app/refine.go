@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// refineProvider generates a draft message, then sends it back with the diff asking
+// the model to critique and improve it (--refine).
+type refineProvider struct {
+	ai.Provider             // answers the refine prompt
+	draft       ai.Provider // writes the draft; may be a generate.MapReduce around Provider
+	base        int         // messages in the regular prompt; any after them (e.g. the conventional reminder) are kept
+	data        vscodeprompt.RefineData
+}
+
+func newRefineProvider(base, draft ai.Provider, pr prompt, cfg Config) *refineProvider {
+	return &refineProvider{
+		Provider: base,
+		draft:    draft,
+		base:     len(pr.msgs),
+		data: vscodeprompt.RefineData{
+			Diff:               pr.diff(cfg.ContextBudget),
+			Conventional:       cfg.Conventional,
+			Types:              cfg.AllowedTypes,
+			MaxSubjectLength:   cfg.MaxSubjectLength,
+			CustomInstructions: pr.data.CustomInstructions,
+		},
+	}
+}
+
+func (p *refineProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	// Only the improved message is worth streaming.
+	raw, err := p.draft.GenerateCommitMessage(ai.WithStream(ctx, nil), msgs, temp)
+	if err != nil {
+		return "", err
+	}
+	draft, ok := vscodeprompt.ExtractOneTextCodeBlock(raw)
+	if !ok {
+		draft = raw
+	}
+	slog.Debug("draft message", "message", draft)
+
+	d := p.data
+	d.Message = draft
+	refine := vscodeprompt.BuildRefineMessages(d)
+	if len(msgs) > p.base {
+		refine = append(refine, msgs[p.base:]...)
+	}
+	return p.Provider.GenerateCommitMessage(ctx, refine, temp)
+}
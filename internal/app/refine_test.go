@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestRefine(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "p.go", Diff: "+if s == \"\" {\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	// The draft is sloppy; asked to refine it, the model improves it.
+	rp := &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		if strings.Contains(user, "<candidate-message>") {
+			return "```text\nfix(parser): handle empty input\n```", nil
+		}
+		return "```text\nfixed some stuff in the parser.\n```", nil
+	}}
+	p := forPrompt(context.Background(), rp, pr, Config{Refine: true, Conventional: true, MaxSubjectLength: 50})
+
+	ctx := ai.WithStream(context.Background(), func(string) {})
+	msg, err := generate.Message(ctx, p, pr.msgs, 0, true)
+	if err != nil || msg != "fix(parser): handle empty input" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(rp.users) != 2 {
+		t.Fatalf("got %d requests; want the draft and the refinement", len(rp.users))
+	}
+	refine := rp.users[1]
+	for _, want := range []string{"fixed some stuff in the parser.", "+if s == \"\" {", "at most 50 characters", "Conventional Commits"} {
+		if !strings.Contains(refine, want) {
+			t.Errorf("refine prompt lacks %q:\n%s", want, refine)
+		}
+	}
+	if rp.streamed[0] || !rp.streamed[1] {
+		t.Errorf("streamed = %v; want only the refinement streamed", rp.streamed)
+	}
+}
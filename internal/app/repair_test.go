@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// wordy writes an over-long subject and shortens it when asked, after stubborn tries.
+func wordy(stubborn int) *scriptedProvider {
+	return &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		if strings.Contains(user, "<violations>") && n > stubborn {
+			return "```text\nfix(parser): handle empty input\n\nReturn early with an empty AST instead of panicking.\n```", nil
+		}
+		return "```text\nfix(parser): handle empty input by returning an empty AST instead of panicking\n```", nil
+	}}
+}
+
+func TestShortenSubject(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "p.go", Diff: "+if s == \"\" {\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+
+	wp := wordy(0)
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), wp, pr, Config{MaxSubjectLength: 50, RepairAttempts: 2}), pr.msgs, 0, false)
+	if err != nil || msg != "fix(parser): handle empty input\n\nReturn early with an empty AST instead of panicking." {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(wp.users) != 2 {
+		t.Fatalf("got %d requests; want the draft and one shortening", len(wp.users))
+	}
+	for _, want := range []string{"at most 50 characters", "subject is 78 characters", "+if s == \"\" {"} {
+		if !strings.Contains(wp.users[1], want) {
+			t.Errorf("shorten prompt lacks %q:\n%s", want, wp.users[1])
+		}
+	}
+
+	// A model that will not shorten gets RepairAttempts tries, then its answer stands.
+	wp = wordy(10)
+	msg, err = generate.Message(context.Background(), forPrompt(context.Background(), wp, pr, Config{MaxSubjectLength: 50, RepairAttempts: 2}), pr.msgs, 0, false)
+	if err != nil || !strings.HasPrefix(msg, "fix(parser): handle empty input by") {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(wp.users) != 1+2 {
+		t.Errorf("got %d requests; want %d", len(wp.users), 1+2)
+	}
+
+	// Short subjects and a zero limit cost no extra request.
+	wp = wordy(0)
+	if _, err := generate.Message(context.Background(), forPrompt(context.Background(), wp, pr, Config{}), pr.msgs, 0, false); err != nil || len(wp.users) != 1 {
+		t.Errorf("limit 0: %d requests, %v", len(wp.users), err)
+	}
+}
+
+func TestPolicyRepair(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "rank.go", Diff: "+package rank\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	// The model mentions a codename until told not to.
+	pp := &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		if strings.Contains(user, "<violations>") {
+			return "```text\nfeat(search): add ranking service\n```", nil
+		}
+		return "```text\nfeat(search): add Capybara ranking service\n```", nil
+	}}
+	cfg := Config{BannedWords: []string{"capybara"}, RepairAttempts: 2}
+
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), pp, pr, cfg), pr.msgs, 0, false)
+	if err != nil || msg != "feat(search): add ranking service" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(pp.users) != 2 || !strings.Contains(pp.users[1], `"capybara" must not appear in the message (banned-word)`) {
+		t.Errorf("repair prompt does not explain the violation: %q", pp.users)
+	}
+
+	if _, err := newProvider(context.Background(), Config{Model: "m", DenyPatterns: []string{"("}}); err == nil {
+		t.Error("invalid deny pattern accepted")
+	}
+}
+
+// sloppy forgets the space after the colon until shown the syntax error.
+func sloppy(ctx context.Context, user string, n int) (string, error) {
+	if strings.Contains(user, "expected a space after ':'") {
+		return "```text\nfix(parser): handle empty input.\n```", nil
+	}
+	return "```text\nfix(parser):handle empty input\n```", nil
+}
+
+func TestConventionalRepair(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "p.go", Diff: "+if s == \"\" {\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	sp := &scriptedProvider{script: sloppy}
+	cfg := Config{Conventional: true, Imperative: true, RepairAttempts: 3}
+
+	// The trailing period is left to the local fixes rather than another request.
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), sp, pr, cfg), pr.msgs, 0, true)
+	if err != nil || msg != "fix(parser): handle empty input" || len(sp.users) != 2 {
+		t.Fatalf("got %q, %v after %d requests", msg, err, len(sp.users))
+	}
+
+	// Only grammar and length issues are sent back, even without the local fixes.
+	sp = &scriptedProvider{script: sloppy}
+	cfg.Imperative = false
+	if _, err := generate.Message(context.Background(), forPrompt(context.Background(), sp, pr, cfg), pr.msgs, 0, true); err != nil || len(sp.users) != 2 {
+		t.Errorf("%d requests, %v; want the draft and one repair", len(sp.users), err)
+	}
+}
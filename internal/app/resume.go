@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+)
+
+// resumeFile is where, in the git directory, an accepted message is kept when
+// committing it fails, for Resume.
+const resumeFile = "COMMITGEN_MSG"
+
+// commit commits msg in repoRoot. When git commit fails, say in a hook or while
+// signing, the message is saved to resumeFile rather than lost, and the error says
+// how to get it back. A successful commit removes a message saved earlier.
+func commit(ctx context.Context, repoRoot, msg string) error {
+	path, pathErr := gitx.GitPath(ctx, repoRoot, resumeFile)
+	err := gitx.Commit(ctx, repoRoot, msg)
+	if pathErr != nil {
+		slog.Debug("no place to save the message", "err", pathErr)
+		return err
+	}
+	if err == nil {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Debug("could not remove saved message", "file", path, "err", err)
+		}
+		return nil
+	}
+	if werr := os.WriteFile(path, []byte(msg), 0644); werr != nil {
+		slog.Warn("could not save the message", "file", path, "err", werr)
+		return err
+	}
+	return i18n.Errorf("%w\nThe message was saved to %s. Run commitgen resume to commit it again, or commitgen resume --print to see it.", err, path)
+}
+
+// Resume commits the message saved when committing failed in the repository at
+// repoArg, or the current one. With print, it writes the message to stdout instead.
+func Resume(ctx context.Context, repoArg string, print bool) error {
+	root, err := gitx.ResolveRepoRoot(ctx, repoArg)
+	if err != nil {
+		return err
+	}
+	path, err := gitx.GitPath(ctx, root, resumeFile)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return i18n.Errorf("no saved message: %s exists only after a commit fails", resumeFile)
+	}
+	if err != nil {
+		return err
+	}
+	if print {
+		fmt.Println(string(b))
+		return nil
+	}
+	if err := commit(ctx, root, string(b)); err != nil {
+		return err
+	}
+	infof("Committed the saved message.\n")
+	return nil
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+func TestCommitSavesMessageForResume(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, k := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+k+"_NAME", "Ann Author")
+		t.Setenv("GIT_"+k+"_EMAIL", "ann@example.com")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	if _, err := gitx.Git(ctx, repo, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitx.Git(ctx, repo, "add", "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	hook := filepath.Join(repo, ".git", "hooks", "commit-msg")
+	if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	const msg = "feat: add a\n\nFirst file."
+	err := commit(ctx, repo, msg)
+	saved := filepath.Join(repo, ".git", resumeFile)
+	if err == nil || !strings.Contains(err.Error(), "commitgen resume") {
+		t.Fatalf("commit() = %v; want an error pointing to resume", err)
+	}
+	if b, _ := os.ReadFile(saved); string(b) != msg {
+		t.Fatalf("saved message = %q", b)
+	}
+
+	if err := os.Remove(hook); err != nil {
+		t.Fatal(err)
+	}
+	if err := Resume(ctx, repo, false); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := gitx.Git(ctx, repo, "log", "-1", "--format=%B"); strings.TrimSpace(out) != msg {
+		t.Errorf("committed %q", out)
+	}
+	if _, err := os.Stat(saved); !os.IsNotExist(err) {
+		t.Errorf("saved message kept after the commit: %v", err)
+	}
+	if err := Resume(ctx, repo, true); err == nil {
+		t.Error("Resume() with nothing saved succeeded")
+	}
+}
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"context"
+
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// Review asks the model to review the staged changes for likely bugs, missing tests
+// and risky spots, and prints its findings to stdout.
+func Review(ctx context.Context, cfg Config) error {
+	pr, err := preparePrompt(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return printProse(ctx, cfg, vscodeprompt.BuildReviewMessages(pr.data))
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// maxModelRisks caps how many risks from the model pass are shown.
+const maxModelRisks = 3
+
+// riskChecker asks the model for risks the heuristics cannot see; nil when disabled.
+type riskChecker func(ctx context.Context) ([]string, error)
+
+// heuristicRisks returns the risk flags for data's changes.
+func heuristicRisks(data vscodeprompt.Data) []string {
+	var out []string
+	for _, r := range commitmsg.AssessRisks(diffFiles(data)) {
+		out = append(out, r.String())
+	}
+	return out
+}
+
+// changeAreas returns the unrelated areas data's changes span, or nil if they form
+// one. Generated files, whose diffs are left out, go with any area.
+func changeAreas(changes []vscodeprompt.Change) []commitmsg.Area {
+	files := make([]commitmsg.DiffFile, 0, len(changes))
+	for _, ch := range changes {
+		if strings.Contains(ch.Diff, generate.GeneratedNote) {
+			continue
+		}
+		files = append(files, commitmsg.DiffFile{Path: ch.Path, Diff: ch.Diff})
+	}
+	return commitmsg.UnrelatedAreas(files)
+}
+
+// askSplit asks what to do with changes spanning unrelated areas: commit the first
+// area alone (true), or write one message describing each (false).
+func askSplit(cfg Config, areas []commitmsg.Area) (bool, error) {
+	split := false
+	err := huh.NewSelect[bool]().
+		Title(i18n.Tf("The staged changes span unrelated areas: %s", commitmsg.DescribeAreas(areas))).
+		Options(
+			huh.NewOption(i18n.T("Write one message that describes each area"), false),
+			huh.NewOption(i18n.Tf("Commit %s now and keep the rest staged for the next commit", areas[0].Name), true),
+		).
+		Value(&split).
+		WithAccessible(accessibleMode(cfg)).
+		Run()
+	if errors.Is(err, huh.ErrUserAborted) {
+		return false, ErrCanceled
+	}
+	return split, err
+}
+
+// setAsideFile is where, in the git directory, the index is recorded while changes
+// are set aside for a split commit.
+const setAsideFile = "COMMITGEN_SET_ASIDE"
+
+// setAside unstages paths for a split commit, as gitx.SetAside. A commitgen killed
+// before staging them again cannot say how to, so the command that does is
+// printed now, and the index it restores kept in setAsideFile until then.
+func setAside(ctx context.Context, repoRoot string, paths []string) (restore func(context.Context) error, err error) {
+	tree, restoreIndex, err := gitx.SetAside(ctx, repoRoot, paths)
+	if err != nil {
+		return nil, err
+	}
+	path, pathErr := gitx.GitPath(ctx, repoRoot, setAsideFile)
+	if pathErr == nil {
+		if err := os.WriteFile(path, []byte(tree+"\n"), 0644); err != nil {
+			slog.Debug("could not record the set-aside index", "file", path, "err", err)
+		}
+	}
+	infof("Set aside the other changes. Should commitgen stop before staging them again, run git read-tree %s\n", tree)
+	return func(ctx context.Context) error {
+		if err := restoreIndex(ctx); err != nil {
+			return i18n.Errorf("%w\nRun git read-tree %s to stage the set-aside changes again.", err, tree)
+		}
+		if pathErr == nil {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				slog.Debug("could not remove the set-aside record", "file", path, "err", err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// modelRiskChecker returns a riskChecker that sends data's changes to provider.
+func modelRiskChecker(provider ai.Provider, data vscodeprompt.Data, temp float64) riskChecker {
+	return func(ctx context.Context) ([]string, error) {
+		// The answer is not a commit message and must not reach the stream panel.
+		raw, err := provider.GenerateCommitMessage(ai.WithStream(ctx, nil), vscodeprompt.BuildRiskMessages(data), temp)
+		if err != nil {
+			return nil, err
+		}
+		return parseRisks(raw), nil
+	}
+}
+
+// parseRisks reads the model's bullets; "NONE" or anything without bullets is no risk.
+func parseRisks(raw string) []string {
+	var out []string
+	for _, ln := range strings.Split(raw, "\n") {
+		ln = strings.TrimSpace(ln)
+		item, ok := strings.CutPrefix(ln, "- ")
+		if !ok {
+			item, ok = strings.CutPrefix(ln, "* ")
+		}
+		if item = strings.TrimSpace(item); ok && item != "" {
+			out = append(out, item)
+		}
+		if len(out) == maxModelRisks {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRisks(t *testing.T) {
+	raw := "Here is what I found:\n- Drops the users table without a backup\n* `auth.go` no longer checks expiry\n-\n- Renames a public flag\n- One too many\n"
+	want := []string{"Drops the users table without a backup", "`auth.go` no longer checks expiry", "Renames a public flag"}
+	if got := parseRisks(raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if got := parseRisks("NONE"); got != nil {
+		t.Errorf("NONE: got %q", got)
+	}
+}
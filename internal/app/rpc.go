@@ -0,0 +1,323 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcAppError       = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// rpcSession is one suggest → regenerate* → accept conversation.
+type rpcSession struct {
+	cfg      Config
+	provider ai.Provider
+	prompt   prompt
+	message  string
+	rejected []string // earlier messages, sent back on regenerate
+}
+
+type rpcSuggestParams struct {
+	Repo  string `json:"repo,omitempty"`
+	Diff  string `json:"diff,omitempty"`
+	Model string `json:"model,omitempty"`
+}
+
+type rpcSessionParams struct {
+	Session string `json:"session"`
+}
+
+type rpcAcceptParams struct {
+	Session string `json:"session"`
+	Message string `json:"message,omitempty"` // edited message; defaults to the last suggestion
+	Commit  bool   `json:"commit,omitempty"`  // run git commit in the session's repository
+}
+
+type rpcMessageResult struct {
+	Session string `json:"session"`
+	Message string `json:"message"`
+}
+
+type rpcProgress struct {
+	Session string `json:"session"`
+	Stage   string `json:"stage"` // collecting | generating | done
+}
+
+// rpcServer speaks newline-delimited JSON-RPC 2.0: one request or notification per line.
+type rpcServer struct {
+	cfg         Config
+	provider    ai.Provider
+	newProvider func(context.Context, Config) (ai.Provider, error)
+
+	out   *json.Encoder
+	outMu sync.Mutex
+
+	sessions map[string]*rpcSession
+	nextID   int
+}
+
+func newRPCServer(cfg Config, provider ai.Provider, w io.Writer) *rpcServer {
+	return &rpcServer{
+		cfg:         cfg,
+		provider:    provider,
+		newProvider: newProvider,
+		out:         json.NewEncoder(w),
+		sessions:    map[string]*rpcSession{},
+	}
+}
+
+func (s *rpcServer) send(v any) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	_ = s.out.Encode(v)
+}
+
+func (s *rpcServer) notify(method string, params any) {
+	s.send(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// serve handles requests from r until EOF, an "exit" notification, or ctx is done.
+func (s *rpcServer) serve(ctx context.Context, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 32<<20)
+	for sc.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.send(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.dispatch(ctx, req)
+		if req.ID == nil {
+			continue // notification: no response
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			var re *rpcError
+			if !errors.As(err, &re) {
+				re = &rpcError{Code: rpcAppError, Message: err.Error()}
+			}
+			resp.Result = nil
+			resp.Error = re
+		}
+		s.send(resp)
+	}
+	return sc.Err()
+}
+
+func (s *rpcServer) dispatch(ctx context.Context, req rpcRequest) (any, error) {
+	switch req.Method {
+	case "suggest":
+		var p rpcSuggestParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.suggest(ctx, p)
+	case "regenerate":
+		var p rpcSessionParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.regenerate(ctx, p)
+	case "accept":
+		var p rpcAcceptParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.accept(ctx, p)
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method: " + req.Method}
+	}
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *rpcServer) session(id string) (*rpcSession, error) {
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown session %q", id)}
+	}
+	return sess, nil
+}
+
+func (s *rpcServer) suggest(ctx context.Context, p rpcSuggestParams) (any, error) {
+	cfg, provider, err := withModel(ctx, s.cfg, s.provider, p.Model, s.newProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+
+	s.notify("progress", rpcProgress{Session: id, Stage: "collecting"})
+	pr, err := promptFor(ctx, cfg, p.Repo, p.Diff)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &rpcSession{cfg: cfg, provider: forPrompt(ctx, provider, pr, cfg), prompt: pr}
+	s.sessions[id] = sess
+	if err := s.generate(ctx, id, sess); err != nil {
+		return nil, err
+	}
+	return rpcMessageResult{Session: id, Message: sess.message}, nil
+}
+
+func (s *rpcServer) regenerate(ctx context.Context, p rpcSessionParams) (any, error) {
+	sess, err := s.session(p.Session)
+	if err != nil {
+		return nil, err
+	}
+	s.record(sess, history.StatusRejected, sess.message)
+	recordOutcome(sess.provider, stats.OutcomeRegenerated)
+	sess.rejected = append(sess.rejected, sess.message)
+	if err := s.generate(ctx, p.Session, sess); err != nil {
+		return nil, err
+	}
+	return rpcMessageResult{Session: p.Session, Message: sess.message}, nil
+}
+
+func (s *rpcServer) accept(ctx context.Context, p rpcAcceptParams) (any, error) {
+	sess, err := s.session(p.Session)
+	if err != nil {
+		return nil, err
+	}
+	msg := sess.message
+	if strings.TrimSpace(p.Message) != "" {
+		msg = p.Message
+	}
+	ts, err := configTrailers(ctx, sess.cfg, sess.prompt.repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	final, err := ts.apply(ctx, sess.prompt.repoRoot, msg, sess.provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Commit && sess.prompt.repoRoot == "" {
+		return nil, errors.New("session has no repository to commit to")
+	}
+	// Before committing, so the post-commit hook finds the suggestion.
+	pending := pendAccepted(ctx, sess.prompt.repoRoot, sess.provider, sess.message, msg)
+	if p.Commit {
+		if err := commit(ctx, sess.prompt.repoRoot, final); err != nil {
+			s.record(sess, history.StatusFailed, msg)
+			return nil, err
+		}
+	}
+	s.record(sess, history.StatusAccepted, msg)
+	if !pending {
+		recordOutcome(sess.provider, acceptOutcome(sess.message, msg))
+	}
+	delete(s.sessions, p.Session)
+	return map[string]any{"session": p.Session, "committed": p.Commit, "message": final}, nil
+}
+
+func (s *rpcServer) generate(ctx context.Context, id string, sess *rpcSession) error {
+	s.notify("progress", rpcProgress{Session: id, Stage: "generating"})
+
+	genCtx, cancel := context.WithTimeout(ctx, sess.cfg.Timeout)
+	defer cancel()
+	msg, err := generate.Message(genCtx, sess.provider, withRejected(sess.prompt.msgs, sess.rejected), sess.cfg.Temperature, sess.cfg.Conventional)
+	if err != nil {
+		return err
+	}
+	sess.message = strings.TrimSpace(msg)
+	s.record(sess, history.StatusGenerated, sess.message)
+
+	s.notify("progress", rpcProgress{Session: id, Stage: "done"})
+	return nil
+}
+
+func (s *rpcServer) record(sess *rpcSession, status, msg string) {
+	repoName := ""
+	if sess.prompt.repoRoot != "" {
+		repoName = gitx.RepoNameFromRoot(sess.prompt.repoRoot)
+	}
+	e := history.Entry{
+		Repo:     repoName,
+		Root:     sess.prompt.repoRoot,
+		DiffHash: sess.prompt.diffHash(),
+		Status:   status,
+		Message:  msg,
+	}
+	if status == history.StatusAccepted {
+		e.Paths = changePaths(sess.prompt.data.Changes)
+		e.Edited = strings.TrimSpace(msg) != strings.TrimSpace(sess.message)
+	}
+	_ = history.Append(sess.cfg.HistoryPath, e)
+}
+
+// RPC serves JSON-RPC 2.0 on stdin/stdout for editor plugins. See README for the protocol.
+func RPC(ctx context.Context, cfg Config, in io.Reader, out io.Writer) error {
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return newRPCServer(cfg, provider, out).serve(ctx, in)
+}
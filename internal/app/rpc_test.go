@@ -0,0 +1,64 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRPCSuggestRegenerateAccept(t *testing.T) {
+	stub := &scriptedProvider{reply: "fix: bump x"}
+	cfg := Config{MaxFiles: 10, Timeout: time.Second, HistoryPath: filepath.Join(t.TempDir(), "h.jsonl")}
+
+	diff, _ := json.Marshal(testDiff)
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"suggest","params":{"diff":` + string(diff) + `}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"regenerate","params":{"session":"1"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"accept","params":{"session":"1","message":"fix: edited"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"nope"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := newRPCServer(cfg, stub, &out).serve(context.Background(), strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []rpcResponse
+	progress := 0
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("bad output line %q: %v", line, err)
+		}
+		if _, ok := m["id"]; !ok {
+			progress++
+			continue
+		}
+		var r rpcResponse
+		_ = json.Unmarshal([]byte(line), &r)
+		responses = append(responses, r)
+	}
+
+	if len(responses) != 4 {
+		t.Fatalf("got %d responses, want 4:\n%s", len(responses), out.String())
+	}
+	for i, r := range responses[:3] {
+		if r.Error != nil {
+			t.Errorf("response %d: unexpected error %v", i+1, r.Error)
+		}
+	}
+	if responses[3].Error == nil || responses[3].Error.Code != rpcMethodNotFound {
+		t.Errorf("unknown method: got %+v", responses[3])
+	}
+	if stub.calls() != 2 {
+		t.Errorf("provider calls = %d; want 2", stub.calls())
+	}
+	if progress != 5 { // collecting, generating, done, generating, done
+		t.Errorf("progress notifications = %d; want 5", progress)
+	}
+}
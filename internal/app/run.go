@@ -5,26 +5,30 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hoanghonghuy/commitgen/internal/ai"
-	"github.com/hoanghonghuy/commitgen/internal/anthropic"
+	"github.com/hoanghonghuy/commitgen/internal/awssecrets"
+	"github.com/hoanghonghuy/commitgen/internal/azure"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
 	"github.com/hoanghonghuy/commitgen/internal/config"
-	"github.com/hoanghonghuy/commitgen/internal/gemini"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
 	"github.com/hoanghonghuy/commitgen/internal/gitx"
-	"github.com/hoanghonghuy/commitgen/internal/ollama"
-	"github.com/hoanghonghuy/commitgen/internal/openai"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/logx"
+	"github.com/hoanghonghuy/commitgen/internal/tracex"
+	"github.com/hoanghonghuy/commitgen/internal/vault"
 	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
-
-	tea "github.com/charmbracelet/bubbletea"
 )
 
 type Config struct {
-	Command string
-
 	RepoArg string
 
 	BaseURL string
@@ -34,6 +38,31 @@ type Config struct {
 	AnthropicKey string
 	GeminiKey    string
 
+	// OpenAI organization and project headers
+	OpenAIOrg     string
+	OpenAIProject string
+	OpenAIAPI     string // openai.APIChat (default) or openai.APIResponses
+
+	// Microsoft Entra ID auth for Azure OpenAI, instead of APIKey
+	AzureAuth         string // azure.AuthClientCredentials or azure.AuthManagedIdentity
+	AzureTenantID     string
+	AzureClientID     string
+	AzureClientSecret string
+
+	// HashiCorp Vault, for "vault:PATH#FIELD" key references
+	VaultAddr      string
+	VaultNamespace string
+	VaultToken     string
+	VaultRoleID    string
+	VaultSecretID  string
+
+	// AWS, for "aws-secret:" and "aws-ssm:" key references
+	AWSRegion  string
+	AWSProfile string
+
+	// Context window requested from Ollama (num_ctx); 0 keeps the model's default.
+	OllamaNumCtx int
+
 	RecentN   int
 	MaxFiles  int
 	Summarize bool
@@ -41,9 +70,25 @@ type Config struct {
 	Temperature float64
 	Timeout     time.Duration // passed to TUI for AI request timeout
 
+	// Prompts estimated above this many tokens are generated from per-file summaries (0 disables)
+	ContextBudget int
+
+	// Token spend limits per run and per day (0 disables), and what to use instead
+	// when a run would go over them; see applyBudget
+	MaxTokensPerRun int
+	MaxTokensPerDay int
+	BudgetFallback  string
+
+	// The same in US dollars, estimated from ModelPrices ("model=input/output" per
+	// million tokens)
+	MaxCostPerRun float64
+	MaxCostPerDay float64
+	ModelPrices   []string
+
 	DumpOutPath string
 
-	InstructionsPath string
+	InstructionsPaths []string // files, paths in the repository, or URLs; see loadInstructions
+	Instructions      string   // used when InstructionsPaths is empty
 
 	// Config management
 	ConfigPath string
@@ -51,235 +96,795 @@ type Config struct {
 
 	// Enhancements
 	Conventional   bool
+	Type           string // conventional type every message gets (--type); with Conventional, guessed when empty
 	Provider       string
 	IgnoredFiles   []string
+	GeneratedFiles []string // extra patterns for generated files, or "!pattern" to drop one
 	HookFile       string
 	PromptTemplate string
+
+	// prepare-commit-msg source (message, template, merge, squash, commit) and the ones to skip
+	HookSource      string
+	HookSkipSources []string
+	HookFast        bool // no TUI: add a cached or quick suggestion as comments; see hookFast
+	// In hook mode, how long to wait for the first message before writing a heuristic one (0: no limit)
+	HookTimeout time.Duration
+
+	// Limit the staged changes to these git pathspecs (--only), minus these (--exclude)
+	Only    []string
+	Exclude []string
+
+	// Include files that look like they hold secrets; see generate.IsSensitive
+	AllowSensitive bool
+
+	// Generate with each of these models ("model" or "provider[:model]") and pick one side by side
+	Compare []string
+
+	// Local fixes applied to generated messages
+	Imperative bool     // imperative mood and no trailing period in the subject
+	BodyWrap   int      // hard-wrap the body at this column (capped at MaxBodyLineLength if set)
+	Spellcheck bool     // correct common misspellings
+	Dictionary []string // project words the spell check leaves alone
+	ASCII      bool     // strip emoji and non-ASCII punctuation
+
+	// Trailers appended to accepted messages
+	Signoff     bool     // Signed-off-by with the committer identity
+	CoAuthors   []string // "Name <email>", each as Co-authored-by
+	Trailers    []string // "Key: value"
+	GeneratedBy bool     // Generated-by: commitgen/<model> on AI-written messages
+
+	// Jira smart-commit line for the issue key in the branch name
+	JiraSmartCommit bool
+	JiraProjects    []string // only keys of these projects (default: any upper-case key)
+	JiraTransition  string   // e.g. "resolve"
+	JiraTime        string   // work to log, e.g. "2h"
+
+	// Linear magic-word footer ("Fixes ENG-123") for a Linear-style branch name
+	Linear          bool
+	LinearTeams     []string // only keys of these teams
+	LinearMagicWord string   // default "Fixes"
+	LinearAPIKey    string   // optional; fetches the issue for context and skips unknown keys
+
+	// Times a generated message with issues (subject length, conventional syntax,
+	// policy) is sent back to the model to be repaired; 0 disables
+	RepairAttempts int
+
+	// Message policy: generated messages that break it are sent back to be repaired,
+	// and the TUI will not commit them
+	BannedWords      []string // whole words, any case
+	DenyPatterns     []string // regular expressions
+	RequiredPrefixes []string // the subject must start with one of these
+
+	// WASM summarizers for file types without a built-in summary, as
+	// "ext[,ext...]=path" with paths relative to the repository
+	SummarizerPlugins []string
+
+	// Shell commands that rewrite each request's JSON payload before it is sent, and
+	// the generated message afterwards
+	PrePromptCommand   string
+	PostMessageCommand string
+
+	// Generate this many candidate messages and keep the one the model is surest of
+	// (by token log probabilities, where the provider reports them) with the fewest
+	// issues; 0 or 1 generates one
+	BestOf int
+
+	// Send the generated message back for a critique-and-improve pass
+	Refine bool
+
+	// Also ask the model which changes are risky; the local heuristics always run
+	RiskCheck bool
+
+	// Show the staged files and let the user leave some out of the message before generating
+	SelectFiles bool
+
+	// Shortcut overrides ("action=key[,key...]") for the actions after generating, and
+	// quick mode: no action list, Enter commits
+	Keybindings []string
+	Quick       bool
+
+	// Models the "Try another model" action offers, as model or provider[:model]
+	OtherModels []string
+
+	// Ask with plain lines and numbered choices instead of the full-screen TUI and
+	// forms, for screen readers and dumb terminals. Also on when HUH_ACCESSIBLE is
+	// set, TERM is dumb, or stdin or stdout is not a terminal
+	Accessible bool
+
+	// Read the diff from stdin instead of the index, and print the message instead of committing
+	StdinDiff bool
+
+	// Print the git command that would commit the accepted message, with its trailers,
+	// instead of running it
+	DryRun bool
+
+	// Fill a Go template with facts about the diff instead of asking the AI
+	NoAI                bool
+	MessageTemplate     string // template text (config: message_template)
+	MessageTemplatePath string // template file; wins over MessageTemplate
+
+	// watch
+	WatchInterval time.Duration
+
+	// serve
+	ServeAddr  string
+	ServeToken string // if set, requests need "Authorization: Bearer <token>"
+
+	// History of generated messages (default: ~/.commitgen_history.jsonl)
+	HistoryPath string
+	// Accepted messages from history put in prompts as style examples (0 disables)
+	StyleExamples int
+
+	// Append-only log of every request sent to the provider and its answer, with
+	// secrets (and matches of AuditRedact) redacted; empty disables it
+	AuditLog    string
+	AuditRedact []string
+
+	// Usage statistics (default: ~/.commitgen_stats.jsonl)
+	StatsPath  string
+	StatsSince time.Duration // stats: only count the last StatsSince (0 for all)
+
+	// Message rules
+	MaxSubjectLength  int
+	MaxBodyLineLength int
+	AllowedTypes      []string
+
+	// lint
+	LintRange     string
+	LintFile      string
+	LintFormat    string // text | json | github | junit (default: github in GitHub Actions, else text)
+	LintFix       bool   // offer an AI-corrected message when LintFile fails
+	LintSuggest   bool   // generate a replacement for each failing commit in LintRange
+	LintJUnitPath string // also write a JUnit XML report here
+
+	// next-version, changelog: the release tag to count from (default: the latest semver tag)
+	ReleaseFrom string
+
+	// bench: "provider" or "provider:model" entries to compare
+	BenchProviders []string
+
+	// GitHub Action
+	ActionMode  string // description | squash
+	GitHubToken string
+
+	// pr and mr: the branch to merge into (default: origin's default branch), and
+	// whether to push and open the request instead of only printing it
+	PRBase  string
+	PROpen  bool
+	PRDraft bool
+
+	// mr: the GitLab instance (default: the origin remote's host) and access token
+	GitLabURL   string
+	GitLabToken string
+
+	// describe: a revision, a patch file, or "-" for a patch on stdin
+	DescribeTarget string
 }
 
-func Run(ctx context.Context, cfg Config) error {
-	if cfg.Command == "config" {
-		return runConfig(cfg)
+// pathspecs returns the git pathspecs selected by Only and Exclude.
+func (c Config) pathspecs() []string {
+	specs := append([]string(nil), c.Only...)
+	for _, p := range c.Exclude {
+		specs = append(specs, gitx.ExcludePathspec(p))
 	}
-	if cfg.Command == "install-hook" {
-		return InstallHook()
+	return specs
+}
+
+// infof prints an informational notice to stdout unless --quiet was given, with
+// format translated into the selected language.
+func infof(format string, args ...any) {
+	if logx.Quiet() {
+		return
 	}
-	if cfg.Command == "uninstall-hook" {
-		return UninstallHook()
+	fmt.Print(i18n.Tf(format, args...))
+}
+
+// prompt holds everything the generation commands need about the staged changes.
+type prompt struct {
+	repoRoot string
+	data     vscodeprompt.Data
+	msgs     []vscodeprompt.VSCodeMessage
+	areas    []commitmsg.Area // unrelated areas the changes span, if more than one
+}
+
+func preparePrompt(ctx context.Context, cfg Config) (prompt, error) {
+	if cfg.StdinDiff {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return prompt{}, fmt.Errorf("read diff from stdin: %w", err)
+		}
+		// A checkout is optional here; use it for context when there is one.
+		repoRoot, _ := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+		return preparePromptFromDiff(ctx, cfg, repoRoot, string(b))
 	}
 
 	repoRoot, err := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
 	if err != nil {
-		return err
+		return prompt{}, err
 	}
+	return buildPrompt(ctx, cfg, repoRoot, nil)
+}
 
-	customInstructions := ""
-	if strings.TrimSpace(cfg.InstructionsPath) != "" {
-		b, err := os.ReadFile(cfg.InstructionsPath)
-		if err != nil {
-			return fmt.Errorf("read instructions file: %w", err)
+// preparePromptFromDiff builds the prompt for a unified diff instead of the index.
+// repoRoot is optional and only used for repository context.
+func preparePromptFromDiff(ctx context.Context, cfg Config, repoRoot, diff string) (prompt, error) {
+	changes := gitx.ParseUnifiedDiff(diff)
+	if len(changes) == 0 {
+		return prompt{}, i18n.Errorf("%w: no diff found in input", ErrNoChanges)
+	}
+	return buildPrompt(ctx, cfg, repoRoot, changes)
+}
+
+// buildPrompt builds the prompt for changes, or for the staged changes in repoRoot when changes is nil.
+func buildPrompt(ctx context.Context, cfg Config, repoRoot string, changes []gitx.StagedChange) (pr prompt, err error) {
+	ctx, span := tracex.Start(ctx, "build prompt", tracex.KindInternal)
+	defer func() {
+		span.SetAttrs(tracex.Int("commitgen.files", len(pr.data.Changes)), tracex.Int("commitgen.prompt_tokens", pr.tokens()))
+		span.End(err)
+	}()
+
+	customInstructions := cfg.Instructions
+	if len(cfg.InstructionsPaths) > 0 {
+		if customInstructions, err = loadInstructions(ctx, repoRoot, cfg.InstructionsPaths); err != nil {
+			return prompt{}, err
+		}
+	}
+
+	if cfg.Summarize && len(cfg.SummarizerPlugins) > 0 {
+		if err := registerSummarizerPlugins(ctx, repoRoot, cfg.SummarizerPlugins); err != nil {
+			return prompt{}, err
 		}
-		customInstructions = string(b)
 	}
 
-	// 1. Build Data
-	data, err := buildPromptData(ctx, repoRoot, cfg.RecentN, cfg.MaxFiles, cfg.Summarize, customInstructions, cfg.IgnoredFiles)
+	opts := cfg.dataOptions(customInstructions)
+	var data vscodeprompt.Data
+	if changes != nil {
+		data, err = generate.BuildData(ctx, repoRoot, changes, opts)
+	} else {
+		data, err = buildPromptData(ctx, repoRoot, cfg.pathspecs(), opts)
+	}
 	if err != nil {
-		return err
+		return prompt{}, err
 	}
 	data.SystemPromptTemplate = cfg.PromptTemplate
+	if cfg.StyleExamples > 0 {
+		if entries, err := history.Load(cfg.HistoryPath); err == nil {
+			data.StyleExamples = history.Exemplars(entries, repoRoot, data.RepositoryName, changePaths(data.Changes), cfg.StyleExamples)
+		}
+	}
+	if _, issue := linearIssue(ctx, cfg, data.BranchName); issue != nil {
+		data.Issue = issueContext(*issue)
+	}
+
+	data.Type = cfg.Type
+	if data.Type == "" && cfg.Conventional {
+		data.Type = inferredType(cfg, data)
+	}
+
+	areas := changeAreas(data.Changes)
+	for _, a := range areas {
+		data.Areas = append(data.Areas, a.Name)
+	}
+
+	msgs := vscodeprompt.BuildVSCodeMessages(data)
+	size := 0
+	for _, m := range msgs {
+		for _, part := range m.Content {
+			size += len(part.Text)
+		}
+	}
+	slog.Debug("prompt built", "files", len(data.Changes), "messages", len(msgs), "chars", size)
+
+	return prompt{
+		repoRoot: repoRoot,
+		data:     data,
+		msgs:     msgs,
+		areas:    areas,
+	}, nil
+}
+
+// dataOptions returns how cfg builds the prompt's data, with instructions as the
+// custom instructions.
+func (c Config) dataOptions(instructions string) generate.Options {
+	return generate.Options{
+		RecentN:        c.RecentN,
+		MaxFiles:       c.MaxFiles,
+		Summarize:      c.Summarize,
+		Instructions:   instructions,
+		IgnoredFiles:   c.IgnoredFiles,
+		GeneratedFiles: c.GeneratedFiles,
+		AllowSensitive: c.AllowSensitive,
+	}
+}
 
-	vscodeMsgs := vscodeprompt.BuildVSCodeMessages(data)
+// changePaths returns the paths of changes.
+func changePaths(changes []vscodeprompt.Change) []string {
+	paths := make([]string, len(changes))
+	for i, ch := range changes {
+		paths[i] = ch.Path
+	}
+	return paths
+}
 
-	switch cfg.Command {
-	case "dump-prompt":
-		return dumpPrompt(vscodeMsgs, cfg.DumpOutPath)
+// diffHash identifies the prompt's changes in the history file.
+func (p prompt) diffHash() string {
+	diffs := make([]string, 0, len(p.data.Changes))
+	for _, ch := range p.data.Changes {
+		diffs = append(diffs, ch.Diff)
+	}
+	return history.HashDiffs(diffs)
+}
 
-	case "suggest":
-		if strings.TrimSpace(cfg.Model) == "" {
-			return errors.New("missing model. Set flags or env COMMITAI_MODEL")
+// newProvider builds the AI backend selected by cfg.Provider, recording usage in the stats file.
+func newProvider(ctx context.Context, cfg Config) (ai.Provider, error) {
+	if _, err := cfg.policy(); err != nil {
+		return nil, err
+	}
+	p, err := newBaseProvider(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(cfg.Provider)
+	if name == "" {
+		name = "openai"
+	}
+	if cfg.AuditLog != "" {
+		if p, err = newAuditedProvider(p, cfg, name); err != nil {
+			return nil, err
 		}
+	}
+	if tracex.Enabled() {
+		p = tracedProvider{Provider: p, provider: name, model: cfg.Model}
+	}
+	return &meteredProvider{Provider: p, provider: name, model: cfg.Model, path: cfg.StatsPath}, nil
+}
+
+// newBaseProvider resolves the key references of the provider cfg selects, then builds it.
+func newBaseProvider(ctx context.Context, cfg Config) (ai.Provider, error) {
+	if strings.TrimSpace(cfg.Model) == "" {
+		return nil, errors.New("missing model. Set flags or env COMMITGEN_MODEL")
+	}
 
-		var provider ai.Provider
-
-		switch strings.ToLower(cfg.Provider) {
-		case "ollama":
-			provider = ollama.New(ollama.Config{
-				BaseURL: cfg.BaseURL,
-				Model:   cfg.Model,
-			})
-		case "anthropic":
-			if cfg.AnthropicKey == "" {
-				return errors.New("missing anthropic key. Set flags or env COMMITAI_ANTHROPIC_KEY")
+	pc := generate.ProviderConfig{
+		Provider:     cfg.Provider,
+		Model:        cfg.Model,
+		BaseURL:      cfg.BaseURL,
+		NumCtx:       cfg.OllamaNumCtx,
+		OpenAIAPI:    cfg.OpenAIAPI,
+		Organization: cfg.OpenAIOrg,
+		Project:      cfg.OpenAIProject,
+	}
+	var keyEnv string
+	switch strings.ToLower(cfg.Provider) {
+	case "anthropic":
+		if err := resolveSecret(ctx, cfg, &cfg.AnthropicKey, "anthropic_key"); err != nil {
+			return nil, err
+		}
+		pc.APIKey, keyEnv = cfg.AnthropicKey, "COMMITGEN_ANTHROPIC_KEY"
+	case "gemini":
+		if err := resolveSecret(ctx, cfg, &cfg.GeminiKey, "gemini_key"); err != nil {
+			return nil, err
+		}
+		pc.APIKey, keyEnv = cfg.GeminiKey, "COMMITGEN_GEMINI_KEY"
+	case "openai", "":
+		if err := resolveSecret(ctx, cfg, &cfg.APIKey, "api_key"); err != nil {
+			return nil, err
+		}
+		pc.APIKey, keyEnv = cfg.APIKey, "COMMITGEN_API_KEY"
+		if cfg.AzureAuth != "" {
+			if err := resolveSecret(ctx, cfg, &cfg.AzureClientSecret, "azure_client_secret"); err != nil {
+				return nil, err
 			}
-			provider = anthropic.New(anthropic.Config{
-				APIKey: cfg.AnthropicKey,
-				Model:  cfg.Model,
-			})
-		case "gemini":
-			if cfg.GeminiKey == "" {
-				return errors.New("missing gemini key. Set flags or env COMMITAI_GEMINI_KEY")
+			cred, err := azure.New(azure.Config{Auth: cfg.AzureAuth, TenantID: cfg.AzureTenantID, ClientID: cfg.AzureClientID, ClientSecret: cfg.AzureClientSecret})
+			if err != nil {
+				return nil, err
 			}
-			provider = gemini.New(gemini.Config{
-				APIKey: cfg.GeminiKey,
-				Model:  cfg.Model,
-			})
-		case "openai", "":
-			if strings.TrimSpace(cfg.BaseURL) == "" && strings.TrimSpace(cfg.APIKey) == "" {
-				return errors.New("missing api-key. Set --api-key flag or env COMMITAI_API_KEY")
+			if strings.TrimSpace(cfg.BaseURL) == "" {
+				return nil, errors.New("azure_auth needs base_url set to the Azure OpenAI endpoint, e.g. https://NAME.openai.azure.com/openai/v1")
 			}
-			provider = openai.New(openai.Config{
-				BaseURL: cfg.BaseURL,
-				APIKey:  cfg.APIKey,
-				Model:   cfg.Model,
-			})
-		default:
-			return fmt.Errorf("unknown provider: %s (supported: openai, ollama, anthropic, gemini)", cfg.Provider)
+			pc.Token = cred.Token
 		}
+	}
+	p, err := generate.NewProvider(pc)
+	if errors.Is(err, generate.ErrMissingKey) {
+		return nil, fmt.Errorf("%w. Set flags or env %s", err, keyEnv)
+	}
+	return p, err
+}
 
-		p := tea.NewProgram(
-			newTuiModel(repoRoot, provider, vscodeMsgs, cfg.Temperature, cfg.Timeout, cfg.Conventional, cfg.HookFile),
-			tea.WithAltScreen(),
-			tea.WithMouseCellMotion(),
-		)
-		_, err = p.Run()
-		return err
-
-	default:
-		return fmt.Errorf("unknown -cmd=%s (use: suggest | dump-prompt | config | install-hook | uninstall-hook)", cfg.Command)
+// resolveSecret replaces a "cmd:" key reference with the command's output, and a
+// "vault:", "aws-secret:" or "aws-ssm:" one with the secret read from there. Keys
+// are resolved only for the provider in use, so other commands never run.
+func resolveSecret(ctx context.Context, cfg Config, v *string, name string) error {
+	if ref, ok := config.AWSSecret(*v); ok {
+		s, err := awsClient(cfg).Secret(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*v = s
+		return nil
+	}
+	if param, ok := config.SSMParameter(*v); ok {
+		s, err := awsClient(cfg).Parameter(ctx, param)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*v = s
+		return nil
+	}
+	if config.IsVaultSecret(*v) {
+		c, err := vaultClient(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		s, err := c.Read(ctx, config.VaultSecretPath(*v))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*v = s
+		return nil
 	}
+	s, err := config.ResolveSecret(*v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*v = s
+	return nil
 }
 
-func buildPromptData(ctx context.Context, repoRoot string, recentN, maxFiles int, summarize bool, customInstructions string, ignoredFiles []string) (vscodeprompt.Data, error) {
-	repoName := gitx.RepoNameFromRoot(repoRoot)
+var (
+	vaultMu      sync.Mutex
+	vaultClients = map[vault.Config]*vault.Client{} // one login per server and identity
+)
 
-	branch, _ := gitx.CurrentBranch(ctx, repoRoot)
-	userEmail, _ := gitx.GitConfig(ctx, repoRoot, "user.email")
+var (
+	awsMu      sync.Mutex
+	awsClients = map[awssecrets.Config]*awssecrets.Client{}
+)
 
-	userCommits, _ := gitx.RecentCommitsByAuthor(ctx, repoRoot, recentN, userEmail)
-	repoCommits, _ := gitx.RecentCommits(ctx, repoRoot, recentN)
+// awsClient returns the AWS client for cfg's region and profile.
+func awsClient(cfg Config) *awssecrets.Client {
+	ac := awssecrets.Config{Region: cfg.AWSRegion, Profile: cfg.AWSProfile}
+	awsMu.Lock()
+	defer awsMu.Unlock()
+	c, ok := awsClients[ac]
+	if !ok {
+		c = awssecrets.New(ac)
+		awsClients[ac] = c
+	}
+	return c
+}
 
-	// Fetch more changes initially to account for filtering
-	fetchFiles := maxFiles * 2
-	if fetchFiles < 20 {
-		fetchFiles = 20
+// vaultClient returns the Vault client for cfg. The token and secret ID may be
+// "cmd:" references.
+func vaultClient(cfg Config) (*vault.Client, error) {
+	vc := vault.Config{Addr: cfg.VaultAddr, Namespace: cfg.VaultNamespace, Token: cfg.VaultToken, RoleID: cfg.VaultRoleID, SecretID: cfg.VaultSecretID}
+	var err error
+	if vc.Token, err = config.ResolveSecret(vc.Token); err != nil {
+		return nil, fmt.Errorf("vault_token: %w", err)
 	}
-	changes, err := gitx.StagedChanges(ctx, repoRoot, fetchFiles)
-	if err != nil {
-		return vscodeprompt.Data{}, err
+	if vc.SecretID, err = config.ResolveSecret(vc.SecretID); err != nil {
+		return nil, fmt.Errorf("vault_secret_id: %w", err)
 	}
-	if len(changes) == 0 {
-		return vscodeprompt.Data{}, errors.New("no staged changes. Run: git add -A")
+
+	vaultMu.Lock()
+	defer vaultMu.Unlock()
+	if c, ok := vaultClients[vc]; ok {
+		return c, nil
 	}
+	c, err := vault.New(vc)
+	if err != nil {
+		return nil, err
+	}
+	vaultClients[vc] = c
+	return c, nil
+}
 
-	// Filter changes
-	defaultIgnores := []string{
-		"go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
-		"*.map", "*.svg", "*.min.js", "*.min.css",
+// Suggest generates a commit message for the staged changes and lets the user
+// review, edit, and commit it in the TUI.
+func Suggest(ctx context.Context, cfg Config) error {
+	if cfg.HookFile != "" && skipHook(cfg) {
+		return nil
+	}
+	if cfg.HookFast && cfg.HookFile == "" {
+		return errors.New("--hook-fast needs --hook")
+	}
+	if cfg.Type != "" && len(cfg.AllowedTypes) > 0 && !slices.Contains(cfg.AllowedTypes, cfg.Type) {
+		return fmt.Errorf("--type %s is not one of the allowed types (%s)", cfg.Type, strings.Join(cfg.AllowedTypes, ", "))
+	}
+	if len(cfg.Compare) > 0 {
+		switch {
+		case len(cfg.Compare) < 2:
+			return errors.New("--compare needs at least two models, e.g. --compare gpt-4o,gpt-4o-mini")
+		case cfg.NoAI, cfg.StdinDiff, cfg.SelectFiles:
+			return errors.New("--compare can't be combined with --no-ai, --stdin-diff or --select-files; use bench to compare without the TUI")
+		}
 	}
-	// Combine ignores
-	allIgnores := append(defaultIgnores, ignoredFiles...)
 
-	filteredChanges := make([]vscodeprompt.Change, 0, maxFiles)
-	for _, ch := range changes {
-		if len(filteredChanges) >= maxFiles {
-			break
+	// In the hook, git commit waits on us: past the deadline, a guessed message is
+	// better than none.
+	var deadline time.Time
+	prepCtx := ctx
+	if cfg.HookFile != "" && cfg.HookTimeout > 0 {
+		deadline = time.Now().Add(cfg.HookTimeout)
+		var cancel context.CancelFunc
+		prepCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	pr, err := preparePrompt(prepCtx, cfg)
+	if err != nil {
+		if !deadline.IsZero() && errors.Is(err, context.DeadlineExceeded) {
+			return writeHookFallback(cfg.HookFile, "")
 		}
+		return err
+	}
 
-		// Check ignores
-		if shouldIgnore(ch.Path, allIgnores) {
-			// Maybe track skipped?
-			continue
+	// Unrelated changes staged together make for a vague message: offer to commit
+	// the first area alone, keeping the others staged for the next commit.
+	if len(pr.areas) > 1 && cfg.HookFile == "" && !cfg.StdinDiff && len(cfg.Compare) == 0 && isTerminal(os.Stdin) {
+		split, err := askSplit(cfg, pr.areas)
+		if err != nil {
+			return err
+		}
+		if split {
+			var rest []string
+			for _, a := range pr.areas[1:] {
+				rest = append(rest, a.Paths...)
+			}
+			restore, err := setAside(ctx, pr.repoRoot, rest)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := restore(context.WithoutCancel(ctx)); err != nil {
+					slog.Warn("could not stage the set-aside changes again", "err", err)
+					return
+				}
+				infof("The rest of the changes are still staged: run commitgen again to commit them.\n")
+			}()
+			if pr, err = preparePrompt(prepCtx, cfg); err != nil {
+				return err
+			}
 		}
+	}
 
-		// Check size (simple heuristic: diff length)
-		// Better: check file size if new, or diff size.
-		// For simplicity, let's treat huge diffs as truncated.
-		const maxDiffSize = 100 * 1024 // 100KB
-		if len(ch.Diff) > maxDiffSize {
-			ch.Diff = ch.Diff[:2000] + "\n...[Diff truncated due to size]..."
+	if cfg, err = applyBudget(cfg, pr.tokens(), true); err != nil {
+		return err
+	}
+	var provider ai.Provider
+	if cfg.NoAI {
+		msg, err := templateMessage(cfg, pr.data)
+		if err != nil {
+			return err
 		}
+		provider = templateProvider{message: msg}
+	} else if provider, err = newProvider(ctx, cfg); err != nil {
+		return err
+	}
+	base := provider
+	provider = forPrompt(ctx, base, pr, cfg)
+	trailers, err := configTrailers(ctx, cfg, pr.repoRoot)
+	if err != nil {
+		return err
+	}
+	if cfg.HookFile != "" {
+		trailers.changeID = hookChangeID(cfg.HookFile)
+	}
+	policy, err := cfg.policy()
+	if err != nil {
+		return err
+	}
+	keys, err := parseKeybindings(cfg.Keybindings)
+	if err != nil {
+		return err
+	}
 
-		orig, _ := gitx.OriginalFileAtHEAD(ctx, repoRoot, ch.Path)
-		if strings.TrimSpace(orig) == "" {
-			orig, _ = gitx.ReadWorkingTreeFile(repoRoot, ch.Path)
+	if cfg.HookFast {
+		return hookFast(ctx, cfg, provider, pr, trailers)
+	}
+
+	// A piped diff has no index to commit and stdin is not a terminal, so just print the message.
+	if cfg.StdinDiff {
+		msg, err := generateOnce(ctx, cfg, provider, pr, trailers, time.Time{})
+		if err != nil {
+			return err
 		}
+		fmt.Println(strings.TrimSpace(msg))
+		if issues := policy.Check(msg); len(issues) > 0 {
+			return fmt.Errorf("%w: %s", ErrLintFailed, issues[0])
+		}
+		return nil
+	}
 
-		// If original content is massive, truncate it too
-		if len(orig) > maxDiffSize {
-			orig = orig[:2000] + "\n...[Content truncated due to size]..."
+	// A hook without a terminal, in a commit from an IDE or GUI, can't ask anything:
+	// it writes the message it would have suggested, for the commit dialog to show.
+	if cfg.HookFile != "" && !hasTerminal() {
+		slog.Debug("hook has no terminal; writing the message without asking")
+		msg, err := generateOnce(ctx, cfg, provider, pr, trailers, deadline)
+		if !deadline.IsZero() && errors.Is(err, context.DeadlineExceeded) {
+			fallback, _ := templateMessage(cfg, pr.data)
+			return writeHookFallback(cfg.HookFile, fallback)
 		}
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(cfg.HookFile, []byte(msg), 0644)
+	}
 
-		attachment := vscodeprompt.BuildAttachment(repoRoot, ch.Path, orig, summarize)
-		filteredChanges = append(filteredChanges, vscodeprompt.Change{
-			Path:         ch.Path,
-			Diff:         ch.Diff,
-			OriginalCode: attachment,
+	model := newTuiModel(pr.repoRoot, provider, pr.msgs, cfg.Temperature, cfg.Timeout, cfg.Conventional, cfg.HookFile, pr.diffHash(), cfg.HistoryPath).withPaths(changePaths(pr.data.Changes)).withTrailers(trailers).withPolicy(policy).withRules(lintRules(cfg)).withKeys(keys, cfg.Quick).withAccessible(accessibleMode(cfg)).withType(pr.data.Type, cfg.Type != "").withDryRun(cfg.DryRun).withModels(otherModels(ctx, cfg, pr, provider))
+	var riskCheck riskChecker
+	if cfg.RiskCheck && !cfg.NoAI {
+		riskCheck = modelRiskChecker(base, pr.data, cfg.Temperature)
+	}
+	model = model.withRisks(heuristicRisks(pr.data), riskCheck)
+	if !deadline.IsZero() {
+		fallback, err := templateMessage(cfg, pr.data)
+		if err != nil {
+			slog.Debug("no fallback message", "err", err)
+		}
+		model = model.withDeadline(deadline, fallback)
+	}
+	if len(cfg.Compare) > 0 {
+		sides := make([]compareSide, 0, len(cfg.Compare))
+		for _, entry := range cfg.Compare {
+			t, err := compareTarget(cfg, entry)
+			if err != nil {
+				return err
+			}
+			p, err := newProvider(ctx, t)
+			if err != nil {
+				return err
+			}
+			sides = append(sides, compareSide{name: t.Provider + "/" + t.Model, provider: forPrompt(ctx, p, pr, t)})
+		}
+		return runSuggestProgram(model.withCompare(sides))
+	}
+	prefetched := false
+	if entries, err := history.Load(cfg.HistoryPath); err == nil {
+		if msg, ok := history.Prefetched(entries, pr.diffHash()); ok {
+			slog.Debug("using message pre-generated by watch", "diff_hash", pr.diffHash())
+			model = model.withMessage(msg)
+			prefetched = true
+		}
+	}
+	if cfg.SelectFiles && !prefetched {
+		model = model.withFileSelection(pr.data, func(data vscodeprompt.Data) (prompt, ai.Provider, error) {
+			sub := pr
+			sub.data = data
+			sub.msgs = vscodeprompt.BuildVSCodeMessages(data)
+			if cfg.NoAI {
+				// The template message describes all the files; it must be rebuilt.
+				msg, err := templateMessage(cfg, data)
+				if err != nil {
+					return prompt{}, nil, err
+				}
+				return sub, templateProvider{message: msg}, nil
+			}
+			return sub, forPrompt(ctx, base, sub, cfg), nil
 		})
 	}
 
-	if len(filteredChanges) == 0 {
-		return vscodeprompt.Data{}, fmt.Errorf("all staged files were ignored (checked %d files)", len(changes))
+	return runSuggestProgram(model)
+}
+
+// generateOnce generates one message without the TUI and adds the trailers, giving
+// up at cfg.Timeout or deadline, if set, whichever comes first.
+func generateOnce(ctx context.Context, cfg Config, provider ai.Provider, pr prompt, trailers trailerSet, deadline time.Time) (string, error) {
+	timeout := cfg.Timeout
+	if !deadline.IsZero() {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	genCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	msg, err := generate.Message(genCtx, provider, pr.msgs, cfg.Temperature, cfg.Conventional)
+	if err != nil {
+		return "", err
 	}
+	return trailers.apply(ctx, pr.repoRoot, msg, provider)
+}
 
-	return vscodeprompt.Data{
-		RepositoryName:       repoName,
-		BranchName:           branch,
-		RecentUserCommits:    userCommits,
-		RecentRepoCommits:    repoCommits,
-		Changes:              filteredChanges,
-		CustomInstructions:   customInstructions, // inserted into <custom-instructions>
-		SummarizeAttachments: summarize,
-	}, nil
+// runSuggestProgram runs the suggest TUI and returns how it ended.
+func runSuggestProgram(model tuiModel) error {
+	final, err := runProgram(model)
+	if err != nil {
+		return err
+	}
+	if final.quitting {
+		return ErrCanceled
+	}
+	if final.command != "" {
+		fmt.Println(final.command)
+	}
+	return final.err
 }
 
-func shouldIgnore(pattern string, ignores []string) bool {
-	base := filepath.Base(pattern)
-	for _, ign := range ignores {
-		// Simple equality
-		if ign == base || ign == pattern {
-			return true
-		}
-		// Glob match
-		if matched, _ := filepath.Match(ign, base); matched {
-			return true
-		}
+// DumpPrompt writes the prompt that Suggest would send as JSON, to cfg.DumpOutPath or stdout.
+func DumpPrompt(ctx context.Context, cfg Config) error {
+	pr, err := preparePrompt(ctx, cfg)
+	if err != nil {
+		return err
 	}
-	return false
+	return dumpPrompt(pr.msgs, cfg.DumpOutPath)
 }
 
+func buildPromptData(ctx context.Context, repoRoot string, pathspecs []string, opts generate.Options) (vscodeprompt.Data, error) {
+	// Fetch more changes initially to account for filtering
+	fetchFiles := opts.MaxFiles * 2
+	if fetchFiles < 20 {
+		fetchFiles = 20
+	}
+	changes, err := gitx.StagedChanges(ctx, repoRoot, fetchFiles, pathspecs...)
+	if err != nil {
+		return vscodeprompt.Data{}, err
+	}
+	if len(changes) == 0 && len(pathspecs) > 0 {
+		return vscodeprompt.Data{}, i18n.Errorf("%w: no staged files match %s", ErrNoChanges, strings.Join(pathspecs, " "))
+	}
+	if len(changes) == 0 {
+		return vscodeprompt.Data{}, i18n.Errorf("%w: nothing is staged. Run: git add -A", ErrNoChanges)
+	}
+
+	return generate.BuildData(ctx, repoRoot, changes, opts)
+}
 
-func runConfig(cfg Config) error {
+// Configure edits the config file interactively.
+func Configure(cfg Config) error {
 	newCfg, ok, err := runConfigInteractive(cfg)
 	if err != nil {
 		return err
 	}
 	if !ok {
-		fmt.Println("Operation cancelled.")
+		infof("Operation cancelled.\n")
 		return nil
 	}
 
-	fileCfg := config.FileConfig{
-		BaseURL:      newCfg.BaseURL,
-		APIKey:       newCfg.APIKey,
-		Model:        newCfg.Model,
-		IgnoredFiles: newCfg.IgnoredFiles,
+	// Start from the file on disk so settings the form doesn't show are kept.
+	fileCfg, err := config.Load(cfg.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	fileCfg.BaseURL = newCfg.BaseURL
+	fileCfg.APIKey = newCfg.APIKey
+	fileCfg.Model = newCfg.Model
+	fileCfg.IgnoredFiles = newCfg.IgnoredFiles
+
+	fileCfg.RecentN = &newCfg.RecentN
+	fileCfg.MaxFiles = &newCfg.MaxFiles
+	fileCfg.Summarize = &newCfg.Summarize
+	fileCfg.Temperature = &newCfg.Temperature
+	fileCfg.Conventional = &newCfg.Conventional
+	fileCfg.Provider = newCfg.Provider
+	fileCfg.AnthropicKey = newCfg.AnthropicKey
+	fileCfg.GeminiKey = newCfg.GeminiKey
+	fileCfg.PromptTemplate = newCfg.PromptTemplate
+
+	if err := config.Save(fileCfg, configPath(cfg)); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	infof("\nConfiguration saved to %s\n", configPath(cfg))
+	return nil
+}
 
-		RecentN:        &newCfg.RecentN,
-		MaxFiles:       &newCfg.MaxFiles,
-		Summarize:      &newCfg.Summarize,
-		Temperature:    &newCfg.Temperature,
-		Conventional:   &newCfg.Conventional,
-		Provider:       newCfg.Provider,
-		AnthropicKey:   newCfg.AnthropicKey,
-		GeminiKey:      newCfg.GeminiKey,
-		PromptTemplate: newCfg.PromptTemplate,
+// historyLimit caps how many entries runHistory prints.
+const historyLimit = 50
+
+// History prints the most recent entries of the message history.
+func History(cfg Config) error {
+	entries, err := history.Load(cfg.HistoryPath)
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println(i18n.T("No history yet."))
+		return nil
 	}
 
-	if err := config.Save(fileCfg, cfg.ConfigPath); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	start := max(0, len(entries)-historyLimit)
+	for i := len(entries) - 1; i >= start; i-- {
+		e := entries[i]
+		subject, _, _ := strings.Cut(e.Message, "\n")
+		fmt.Printf("%s  %-9s  %-20s  %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Status, e.Repo, subject)
 	}
-	fmt.Printf("\nConfiguration saved to %s\n", cfg.ConfigPath)
 	return nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
 )
 
 // runConfigInteractive launches a TUI form to edit key config fields
@@ -31,59 +32,59 @@ func runConfigInteractive(cfg Config) (Config, bool, error) {
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewNote().
-				Title("CommitGen Configuration").
-				Description("Update your global settings in ~/.commitgen.json"),
+				Title(i18n.T("CommitGen Configuration")).
+				Description(i18n.Tf("Update your settings in %s", configPath(cfg))),
 
 			huh.NewSelect[string]().
-				Title("AI Provider").
+				Title(i18n.T("AI Provider")).
 				Options(
 					huh.NewOption("OpenAI", "openai"),
-					huh.NewOption("Ollama (Local)", "ollama"),
+					huh.NewOption(i18n.T("Ollama (Local)"), "ollama"),
 					huh.NewOption("Anthropic (Claude)", "anthropic"),
 					huh.NewOption("Google Gemini", "gemini"),
 				).
 				Value(&provider),
 
 			huh.NewInput().
-				Title("Base URL").
-				Description("API endpoint (default varies by provider)").
+				Title(i18n.T("Base URL")).
+				Description(i18n.T("API endpoint (default varies by provider)")).
 				Placeholder("https://api.openai.com/v1 or http://localhost:11434").
 				Value(&baseURL),
 
 			huh.NewInput().
-				Title("OpenAI API Key").
-				Description("Key for OpenAI/Compatible providers").
+				Title(i18n.T("OpenAI API Key")).
+				Description(i18n.T("Key for OpenAI/Compatible providers")).
 				Value(&apiKey).
 				EchoMode(huh.EchoModePassword),
 
 			huh.NewInput().
-				Title("Anthropic API Key").
-				Description("Key for Claude models").
+				Title(i18n.T("Anthropic API Key")).
+				Description(i18n.T("Key for Claude models")).
 				Value(&anthropicKey).
 				EchoMode(huh.EchoModePassword),
 
 			huh.NewInput().
-				Title("Gemini API Key").
-				Description("Key for Google Gemini").
+				Title(i18n.T("Gemini API Key")).
+				Description(i18n.T("Key for Google Gemini")).
 				Value(&geminiKey).
 				EchoMode(huh.EchoModePassword),
 
 			huh.NewInput().
-				Title("Model").
-				Description("Model name").
+				Title(i18n.T("Model")).
+				Description(i18n.T("Model name")).
 				Suggestions([]string{"gpt-4o", "claude-3-opus", "gemini-1.5-pro", "llama3"}).
 				Value(&model),
 
 			huh.NewInput().
-				Title("System Prompt Template").
-				Description("Custom system prompt (leave empty for default)").
+				Title(i18n.T("System Prompt Template")).
+				Description(i18n.T("Custom system prompt (leave empty for default)")).
 				Value(&promptTemplate),
 		),
 
 		huh.NewGroup(
 			huh.NewInput().
-				Title("Recent Commits").
-				Description("Number of recent commits to include").
+				Title(i18n.T("Recent Commits")).
+				Description(i18n.T("Number of recent commits to include")).
 				Value(&recentNStr).
 				Validate(func(s string) error {
 					_, err := strconv.Atoi(s)
@@ -91,8 +92,8 @@ func runConfigInteractive(cfg Config) (Config, bool, error) {
 				}),
 
 			huh.NewInput().
-				Title("Max Files").
-				Description("Max staged files to verify").
+				Title(i18n.T("Max Files")).
+				Description(i18n.T("Max staged files to verify")).
 				Value(&maxFilesStr).
 				Validate(func(s string) error {
 					_, err := strconv.Atoi(s)
@@ -100,8 +101,8 @@ func runConfigInteractive(cfg Config) (Config, bool, error) {
 				}),
 
 			huh.NewInput().
-				Title("Temperature").
-				Description("LLM Temperature (0.0 - 2.0)").
+				Title(i18n.T("Temperature")).
+				Description(i18n.T("LLM Temperature (0.0 - 2.0)")).
 				Value(&tempStr).
 				Validate(func(s string) error {
 					v, err := strconv.ParseFloat(s, 64)
@@ -109,7 +110,7 @@ func runConfigInteractive(cfg Config) (Config, bool, error) {
 						return err
 					}
 					if v < 0 || v > 2.0 {
-						return fmt.Errorf("must be between 0.0 and 2.0")
+						return i18n.Errorf("must be between 0.0 and 2.0")
 					}
 					return nil
 				}),
@@ -117,25 +118,25 @@ func runConfigInteractive(cfg Config) (Config, bool, error) {
 
 		huh.NewGroup(
 			huh.NewConfirm().
-				Title("Summarize Changes").
-				Description("Summarize file content for larger files?").
+				Title(i18n.T("Summarize Changes")).
+				Description(i18n.T("Summarize file content for larger files?")).
 				Value(&summarize),
 
 			huh.NewConfirm().
-				Title("Conventional Commits").
-				Description("Enforce Conventional Commits specification?").
+				Title(i18n.T("Conventional Commits")).
+				Description(i18n.T("Enforce Conventional Commits specification?")).
 				Value(&conventional),
 		),
 
 		huh.NewGroup(
 			huh.NewInput().
-				Title("Ignored Files").
-				Description("Glob patterns (comma separated)").
+				Title(i18n.T("Ignored Files")).
+				Description(i18n.T("Glob patterns (comma separated)")).
 				Value(&ignoredFilesStr),
 		),
 	)
 
-	err := form.Run()
+	err := form.WithAccessible(accessibleMode(cfg)).Run()
 	if err != nil {
 		return cfg, false, err
 	}
@@ -174,4 +175,3 @@ func runConfigInteractive(cfg Config) (Config, bool, error) {
 
 	return cfg, true, nil
 }
-
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+// filterDiff returns diff without the files the prompt leaves out: those that look
+// like they hold secrets, unless cfg.AllowSensitive is set, and ignored ones. It is
+// for the diffs sent as they are, outside generate.BuildData.
+func filterDiff(cfg Config, diff string) string {
+	ignores := append(slices.Clip(generate.DefaultIgnores), cfg.IgnoredFiles...)
+	var b strings.Builder
+	for _, ch := range gitx.ParseUnifiedDiff(diff) {
+		if !cfg.AllowSensitive && generate.IsSensitive(ch.Path) {
+			slog.Warn("leaving out a file that looks like it holds secrets; pass --allow-sensitive to include it", "path", ch.Path)
+			continue
+		}
+		if generate.ShouldIgnore(ch.Path, ignores) {
+			continue
+		}
+		b.WriteString(ch.Diff)
+	}
+	return b.String()
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterDiff(t *testing.T) {
+	diff := "diff --git a/app.go b/app.go\n--- a/app.go\n+++ b/app.go\n@@ -1 +1 @@\n-package a\n+package app\n" +
+		"diff --git a/.env b/.env\n--- a/.env\n+++ b/.env\n@@ -1 +1 @@\n+API_KEY=sk-live-123\n" +
+		"diff --git a/dist/app.min.js b/dist/app.min.js\n--- a/dist/app.min.js\n+++ b/dist/app.min.js\n@@ -1 +1 @@\n+x\n"
+
+	got := filterDiff(Config{}, diff)
+	if strings.Contains(got, "sk-live") || strings.Contains(got, "app.min.js") || !strings.Contains(got, "+package app") {
+		t.Errorf("filterDiff = %q; want only app.go", got)
+	}
+	if got := filterDiff(Config{AllowSensitive: true}, diff); !strings.Contains(got, "sk-live") {
+		t.Errorf("with AllowSensitive: %q; want .env kept", got)
+	}
+}
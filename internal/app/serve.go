@@ -0,0 +1,325 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+)
+
+// serveCacheSize bounds the number of generated messages the server remembers.
+const serveCacheSize = 128
+
+type suggestRequest struct {
+	Repo  string `json:"repo,omitempty"`  // path to a repository; its staged changes are used
+	Diff  string `json:"diff,omitempty"`  // unified diff; used instead of staged changes
+	Model string `json:"model,omitempty"` // overrides the configured model; see serveModels
+	Fresh bool   `json:"fresh,omitempty"` // skip the cache (e.g. to regenerate)
+}
+
+type suggestResponse struct {
+	Message string `json:"message"`
+	Cached  bool   `json:"cached"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// server answers suggestion requests with a provider and cache kept warm across requests.
+type server struct {
+	cfg      Config
+	provider ai.Provider
+
+	// newProvider builds a provider for a request that overrides the model.
+	newProvider func(context.Context, Config) (ai.Provider, error)
+	models      map[string]bool // the models a request may name
+
+	mu    sync.Mutex
+	cache map[string]string
+	order []string // cache keys, oldest first
+
+	metrics *serveMetrics
+}
+
+func newServer(cfg Config, provider ai.Provider) *server {
+	return &server{
+		cfg:         cfg,
+		provider:    provider,
+		newProvider: newProvider,
+		models:      serveModels(cfg),
+		cache:       map[string]string{},
+		metrics:     newServeMetrics(cfg),
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": Version})
+	})
+	mux.HandleFunc("POST /suggest", s.metrics.instrument(s.handleSuggest))
+	mux.Handle("GET /metrics", s.metrics)
+	return s.checkHost(s.authorize(mux))
+}
+
+// serveModels returns the models a request may name: cfg's model and those in
+// cfg.OtherModels of the same provider. Others are refused, so that a request
+// can't spend on any model the key can reach.
+func serveModels(cfg Config) map[string]bool {
+	models := map[string]bool{cfg.Model: true}
+	for _, entry := range cfg.OtherModels {
+		if t, err := compareTarget(cfg, entry); err == nil && strings.EqualFold(cmp.Or(t.Provider, "openai"), cmp.Or(cfg.Provider, "openai")) {
+			models[t.Model] = true
+		}
+	}
+	return models
+}
+
+// checkHost refuses requests for a host other than a loopback one or the host of
+// cfg.ServeAddr, so that a web page can't reach the server by DNS rebinding. On an
+// unspecified address such as 0.0.0.0, any host is accepted when a token is set.
+func (s *server) checkHost(next http.Handler) http.Handler {
+	listen, _, _ := net.SplitHostPort(s.cfg.ServeAddr)
+	anyHost := false
+	if ip := net.ParseIP(listen); listen == "" || ip != nil && ip.IsUnspecified() {
+		anyHost = s.cfg.ServeToken != ""
+		listen = ""
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		ip := net.ParseIP(host)
+		if !anyHost && !strings.EqualFold(host, "localhost") && (ip == nil || !ip.IsLoopback()) && (listen == "" || !strings.EqualFold(host, listen)) {
+			writeJSON(w, http.StatusForbidden, errorResponse{Error: "host not allowed: " + r.Host})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize requires "Authorization: Bearer <token>" when a server token is configured.
+func (s *server) authorize(next http.Handler) http.Handler {
+	if s.cfg.ServeToken == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.cfg.ServeToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	// A web page can send a form or plain text without asking first, but not JSON.
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		writeJSON(w, http.StatusUnsupportedMediaType, errorResponse{Error: "Content-Type must be application/json"})
+		return
+	}
+	var req suggestRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<20)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON: " + err.Error()})
+		return
+	}
+	if req.Model != "" && !s.models[req.Model] {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("model %q is not allowed; add it to other_models", req.Model)})
+		return
+	}
+	cfg, provider, err := withModel(r.Context(), s.cfg, s.provider, req.Model, s.newProvider)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx := r.Context()
+	pr, err := promptFor(ctx, cfg, req.Repo, req.Diff)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if cfg, provider, err = s.withBudget(ctx, cfg, provider, pr); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	key := s.cacheKey(cfg, pr)
+	if !req.Fresh {
+		if msg, ok := s.cached(key); ok {
+			s.metrics.cacheHit()
+			writeJSON(w, http.StatusOK, suggestResponse{Message: msg, Cached: true})
+			return
+		}
+	}
+
+	genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	genCtx, usage := ai.WithUsage(genCtx)
+	start := time.Now()
+	msg, err := generate.Message(genCtx, forPrompt(genCtx, provider, pr, cfg), pr.msgs, cfg.Temperature, cfg.Conventional)
+	promptTokens, completionTokens := usage.Totals()
+	s.metrics.generated(cmp.Or(strings.ToLower(cfg.Provider), "openai"), cfg.Model, time.Since(start), err, promptTokens, completionTokens)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	msg = strings.TrimSpace(msg)
+	s.store(key, msg)
+
+	repoName := ""
+	if pr.repoRoot != "" {
+		repoName = gitx.RepoNameFromRoot(pr.repoRoot)
+	}
+	_ = history.Append(cfg.HistoryPath, history.Entry{
+		Repo:     repoName,
+		Root:     pr.repoRoot,
+		DiffHash: pr.diffHash(),
+		Status:   history.StatusGenerated,
+		Message:  msg,
+	})
+
+	writeJSON(w, http.StatusOK, suggestResponse{Message: msg})
+}
+
+// withModel returns cfg and provider switched to model, or unchanged if model is empty.
+func withModel(ctx context.Context, cfg Config, provider ai.Provider, model string, build func(context.Context, Config) (ai.Provider, error)) (Config, ai.Provider, error) {
+	if model == "" || model == cfg.Model {
+		return cfg, provider, nil
+	}
+	cfg.Model = model
+	p, err := build(ctx, cfg)
+	if err != nil {
+		return cfg, nil, err
+	}
+	return cfg, p, nil
+}
+
+// withBudget applies cfg's spend limits to a request for pr, returning the config
+// and provider to generate with: those given, or the budget_fallback's.
+func (s *server) withBudget(ctx context.Context, cfg Config, provider ai.Provider, pr prompt) (Config, ai.Provider, error) {
+	budgeted, err := applyBudget(cfg, pr.tokens(), true)
+	switch {
+	case err != nil:
+		return cfg, nil, err
+	case budgeted.NoAI:
+		msg, err := templateMessage(budgeted, pr.data)
+		if err != nil {
+			return cfg, nil, err
+		}
+		return budgeted, templateProvider{message: msg}, nil
+	case budgeted.Provider != cfg.Provider || budgeted.Model != cfg.Model:
+		p, err := s.newProvider(ctx, budgeted)
+		if err != nil {
+			return cfg, nil, err
+		}
+		return budgeted, p, nil
+	}
+	return cfg, provider, nil
+}
+
+// promptFor builds the prompt for the staged changes in repo, or for diff when given
+// (repo then only adds context). Used by the serve and rpc front ends.
+func promptFor(ctx context.Context, cfg Config, repo, diff string) (prompt, error) {
+	if strings.TrimSpace(repo) == "" && strings.TrimSpace(diff) == "" {
+		return prompt{}, errors.New("either repo or diff is required")
+	}
+	cfg.RepoArg = repo
+	if diff != "" {
+		repoRoot := ""
+		if repo != "" {
+			repoRoot, _ = gitx.ResolveRepoRoot(ctx, repo)
+		}
+		return preparePromptFromDiff(ctx, cfg, repoRoot, diff)
+	}
+	return preparePrompt(ctx, cfg)
+}
+
+// cacheKey identifies a generation by its prompt and the settings that affect the output.
+func (s *server) cacheKey(cfg Config, pr prompt) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%g\x00%t\x00", cfg.Provider, cfg.Model, cfg.Temperature, cfg.Conventional)
+	for _, m := range pr.msgs {
+		for _, part := range m.Content {
+			h.Write([]byte(part.Text))
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *server) cached(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.cache[key]
+	return msg, ok
+}
+
+func (s *server) store(key, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cache[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.cache[key] = msg
+	for len(s.order) > serveCacheSize {
+		delete(s.cache, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Serve runs the HTTP API on cfg.ServeAddr until ctx is cancelled.
+func Serve(ctx context.Context, cfg Config) error {
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", cfg.ServeAddr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           newServer(cfg, provider).handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	infof("commitgen listening on http://%s\n", ln.Addr())
+	slog.Debug("serve", "addr", ln.Addr().String(), "provider", cfg.Provider, "model", cfg.Model)
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-var x = 1
++var x = 2
+`
+
+func TestServeSuggest(t *testing.T) {
+	stub := &scriptedProvider{reply: "```text\nfix: bump x\n```"}
+	cfg := Config{MaxFiles: 10, Timeout: time.Second, HistoryPath: filepath.Join(t.TempDir(), "h.jsonl"), ServeToken: "secret"}
+	ts := httptest.NewServer(newServer(cfg, stub).handler())
+	defer ts.Close()
+
+	post := func(body, token string) (*http.Response, suggestResponse) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/suggest", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out suggestResponse
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+
+	body, _ := json.Marshal(suggestRequest{Diff: testDiff})
+
+	if resp, _ := post(string(body), "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad token: status = %d; want 401", resp.StatusCode)
+	}
+
+	resp, out := post(string(body), "secret")
+	if resp.StatusCode != http.StatusOK || out.Message != "fix: bump x" || out.Cached {
+		t.Fatalf("first request: status %d, %+v", resp.StatusCode, out)
+	}
+
+	_, out = post(string(body), "secret")
+	if !out.Cached || stub.calls() != 1 {
+		t.Errorf("second request should be cached: %+v, calls = %d", out, stub.calls())
+	}
+
+	if resp, _ := post(`{}`, "secret"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("empty request: status = %d; want 400", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	metrics, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`commitgen_requests_total{code="200"} 2`,
+		`commitgen_requests_total{code="400"} 1`,
+		`commitgen_cache_hits_total 1`,
+		`commitgen_generations_total{provider="openai",model="",result="ok"} 1`,
+		`commitgen_generation_duration_seconds_count{provider="openai",model=""} 1`,
+	} {
+		if !strings.Contains(string(metrics), want+"\n") {
+			t.Errorf("metrics lack %q:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestServeRefuses(t *testing.T) {
+	stub := &scriptedProvider{reply: "fix: bump x"}
+	cfg := Config{MaxFiles: 10, Timeout: time.Second, HistoryPath: filepath.Join(t.TempDir(), "h.jsonl"), ServeAddr: "127.0.0.1:7878", Model: "gpt-4o"}
+	ts := httptest.NewServer(newServer(cfg, stub).handler())
+	defer ts.Close()
+
+	body, _ := json.Marshal(suggestRequest{Diff: testDiff})
+	model, _ := json.Marshal(suggestRequest{Diff: testDiff, Model: "o1-pro"})
+	tests := []struct {
+		name, host, contentType, body string
+		want                          int
+	}{
+		{"rebound host", "evil.example:7878", "application/json", string(body), http.StatusForbidden},
+		{"plain text", "", "text/plain", string(body), http.StatusUnsupportedMediaType},
+		{"form", "", "", string(body), http.StatusUnsupportedMediaType},
+		{"unlisted model", "", "application/json", string(model), http.StatusBadRequest},
+		{"localhost", "localhost:7878", "application/json; charset=utf-8", string(body), http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/suggest", strings.NewReader(tt.body))
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d; want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	if stub.calls() != 1 {
+		t.Errorf("provider called %d times; want once, for the allowed request", stub.calls())
+	}
+}
+
+func TestServeMetricsModels(t *testing.T) {
+	m := newServeMetrics(Config{Model: "gpt-4o", OtherModels: []string{"gpt-4o-mini"}})
+	for _, model := range []string{"gpt-4o", "gpt-4o-mini", "made-up-1", "made-up-2"} {
+		m.generated("openai", model, time.Second, nil, 0, 0)
+	}
+	var b strings.Builder
+	m.write(&b)
+	for _, want := range []string{
+		`commitgen_generations_total{provider="openai",model="gpt-4o",result="ok"} 1`,
+		`commitgen_generations_total{provider="openai",model="gpt-4o-mini",result="ok"} 1`,
+		`commitgen_generations_total{provider="openai",model="other",result="ok"} 2`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("metrics lack %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "made-up") {
+		t.Errorf("unconfigured model labeled by name:\n%s", b.String())
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+	"github.com/hoanghonghuy/commitgen/internal/tracex"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// meteredProvider records every successful generation (tokens, latency) in the stats file
+// and marks failures with ErrProvider.
+type meteredProvider struct {
+	ai.Provider
+	provider string
+	model    string
+	path     string
+}
+
+func (p *meteredProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	ctx, usage := ai.WithUsage(ctx)
+	start := time.Now()
+	out, err := p.Provider.GenerateCommitMessage(ctx, msgs, temp)
+	if err != nil {
+		return out, fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+	promptTokens, completionTokens := usage.Totals()
+	// Stats are best-effort, so errors are ignored.
+	_ = stats.Append(p.path, stats.Event{
+		Provider:         p.provider,
+		Model:            p.model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		LatencyMs:        time.Since(start).Milliseconds(),
+	})
+	return out, nil
+}
+
+// String names the provider and model, for logs.
+func (p *meteredProvider) String() string {
+	return p.provider + "/" + p.model
+}
+
+// tracedProvider records a span for each request to the provider, when tracing is on.
+type tracedProvider struct {
+	ai.Provider
+	provider string
+	model    string
+}
+
+func (p tracedProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	ctx, span := tracex.Start(ctx, "generate "+p.model, tracex.KindClient,
+		tracex.String("gen_ai.system", p.provider),
+		tracex.String("gen_ai.request.model", p.model),
+		tracex.Float("gen_ai.request.temperature", temp))
+	ctx, usage := ai.WithUsage(ctx)
+	out, err := p.Provider.GenerateCommitMessage(ctx, msgs, temp)
+	promptTokens, completionTokens := usage.Totals()
+	span.SetAttrs(tracex.Int("gen_ai.usage.input_tokens", promptTokens), tracex.Int("gen_ai.usage.output_tokens", completionTokens))
+	span.End(err)
+	return out, err
+}
+
+// ContextWindow passes the provider's context window through, if it has one.
+func (p tracedProvider) ContextWindow(ctx context.Context) int {
+	if cw, ok := p.Provider.(ai.ContextWindower); ok {
+		return cw.ContextWindow(ctx)
+	}
+	return 0
+}
+
+// recordOutcome notes what the user did with the last suggestion from provider.
+func recordOutcome(provider ai.Provider, outcome string) {
+	p, ok := provider.(*meteredProvider)
+	if !ok {
+		return
+	}
+	_ = stats.Append(p.path, stats.Event{Provider: p.provider, Model: p.model, Outcome: outcome})
+}
+
+// acceptOutcome compares the committed message with the suggestion: OutcomeAccepted
+// if unchanged, OutcomeEdited if it keeps at least half of the suggestion's words,
+// and OutcomeReplaced otherwise.
+func acceptOutcome(suggested, committed string) string {
+	if strings.TrimSpace(suggested) == strings.TrimSpace(committed) {
+		return stats.OutcomeAccepted
+	}
+	have := map[string]bool{}
+	for _, w := range strings.Fields(strings.ToLower(committed)) {
+		have[w] = true
+	}
+	words := strings.Fields(strings.ToLower(suggested))
+	kept := 0
+	for _, w := range words {
+		if have[w] {
+			kept++
+		}
+	}
+	if len(words) > 0 && 2*kept >= len(words) {
+		return stats.OutcomeEdited
+	}
+	return stats.OutcomeReplaced
+}
+
+// Stats prints acceptance rate, latency and token spend per provider and model.
+func Stats(cfg Config) error {
+	events, err := stats.Load(cfg.StatsPath)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if cfg.StatsSince > 0 {
+		since = time.Now().Add(-cfg.StatsSince)
+	}
+	sums := stats.Summarize(events, since)
+	if len(sums) == 0 {
+		fmt.Println(i18n.T("No usage recorded yet."))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tMODEL\tRUNS\tACCEPTED\tEDITED\tREPLACED\tREGEN\tREJECTED\tACCEPT RATE\tAVG LATENCY\tTOKENS IN\tTOKENS OUT")
+	var total stats.Summary
+	for _, s := range sums {
+		rate := "-"
+		if s.Decisions() > 0 {
+			rate = fmt.Sprintf("%.0f%%", 100*s.AcceptanceRate())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%d\n",
+			s.Provider, s.Model, s.Generations, s.Accepted, s.Edited, s.Replaced, s.Regenerated, s.Rejected,
+			rate, s.AvgLatency().Round(100*time.Millisecond), s.PromptTokens, s.CompletionTokens)
+		total.Generations += s.Generations
+		total.PromptTokens += s.PromptTokens
+		total.CompletionTokens += s.CompletionTokens
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Print("\n" + i18n.Tf("Total: %d runs, %d prompt + %d completion tokens\n", total.Generations, total.PromptTokens, total.CompletionTokens))
+	return nil
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+)
+
+func TestAcceptOutcome(t *testing.T) {
+	suggested := "fix(parser): handle empty input\n\nReturn an empty AST instead of panicking."
+	tests := []struct{ committed, want string }{
+		{suggested + "\n", stats.OutcomeAccepted},
+		{"fix(parser): handle empty input gracefully\n\nReturn an empty AST instead of a panic.", stats.OutcomeEdited},
+		{"wip", stats.OutcomeReplaced},
+		{"Parser fixes for the demo", stats.OutcomeReplaced},
+	}
+	for _, tt := range tests {
+		if got := acceptOutcome(suggested, tt.committed); got != tt.want {
+			t.Errorf("acceptOutcome(%q) = %q; want %q", tt.committed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// templateProvider stands in for the AI in --no-ai mode: it always answers with the
+// message rendered from the template, so the rest of the flow is unchanged.
+type templateProvider struct {
+	message string
+}
+
+func (p templateProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	return p.message, nil
+}
+
+// templateMessage renders the message template (cfg.MessageTemplatePath, else
+// cfg.MessageTemplate, else a built-in one) with facts computed from the changes,
+// and data's type if it has one.
+func templateMessage(cfg Config, data vscodeprompt.Data) (string, error) {
+	tmpl := cfg.MessageTemplate
+	if cfg.MessageTemplatePath != "" {
+		b, err := os.ReadFile(cfg.MessageTemplatePath)
+		if err != nil {
+			return "", fmt.Errorf("read message template: %w", err)
+		}
+		tmpl = string(b)
+	}
+	if tmpl == "" {
+		tmpl = commitmsg.DefaultPlainTemplate
+		if cfg.Conventional {
+			tmpl = commitmsg.DefaultTemplate
+		}
+	}
+
+	facts := commitmsg.ComputeFacts(diffFiles(data), data.BranchName)
+	if data.Type != "" {
+		facts.Type = data.Type
+	}
+	return commitmsg.Render(tmpl, facts)
+}
+
+// inferredType returns the conventional type data's changed files make clear, if it
+// is one of the allowed types, or "".
+func inferredType(cfg Config, data vscodeprompt.Data) string {
+	t := commitmsg.InferType(commitmsg.ComputeFacts(diffFiles(data), "").Files)
+	if len(cfg.AllowedTypes) > 0 && !slices.Contains(cfg.AllowedTypes, t) {
+		return ""
+	}
+	return t
+}
+
+func diffFiles(data vscodeprompt.Data) []commitmsg.DiffFile {
+	files := make([]commitmsg.DiffFile, 0, len(data.Changes))
+	for _, ch := range data.Changes {
+		files = append(files, commitmsg.DiffFile{Path: ch.Path, Diff: ch.Diff})
+	}
+	return files
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+// trailerSet is what gets appended to accepted messages.
+type trailerSet struct {
+	fixed       []string // "Key: value", in order
+	generatedBy bool     // add Generated-by: commitgen/<model> for AI-written messages
+	changeID    string   // Gerrit Change-Id to keep, from the message being replaced
+
+	// Jira smart-commit line; the comment comes from the accepted message
+	jira commitmsg.SmartCommit
+	// Linear magic words, e.g. "Fixes ENG-123"
+	linear string
+}
+
+// configTrailers returns the trailers cfg asks for: Signed-off-by (cfg.Signoff), then
+// Co-authored-by for each of cfg.CoAuthors, then cfg.Trailers as given.
+func configTrailers(ctx context.Context, cfg Config, repoRoot string) (trailerSet, error) {
+	ts := trailerSet{generatedBy: cfg.GeneratedBy}
+	if cfg.Signoff {
+		ident, err := gitx.CommitterIdent(ctx, repoRoot)
+		if err != nil {
+			return ts, fmt.Errorf("signoff: %w", err)
+		}
+		ts.fixed = append(ts.fixed, "Signed-off-by: "+ident)
+	}
+	for _, a := range cfg.CoAuthors {
+		ts.fixed = append(ts.fixed, "Co-authored-by: "+a)
+	}
+	ts.fixed = append(ts.fixed, cfg.Trailers...)
+	if (cfg.JiraSmartCommit || cfg.Linear) && repoRoot != "" {
+		branch, _ := gitx.CurrentBranch(ctx, repoRoot)
+		if cfg.JiraSmartCommit {
+			if key := commitmsg.JiraKey(branch, cfg.JiraProjects); key != "" {
+				ts.jira = commitmsg.SmartCommit{Key: key, Time: cfg.JiraTime, Transition: cfg.JiraTransition}
+			} else {
+				slog.Debug("no Jira issue key in branch name", "branch", branch)
+			}
+		}
+		if key, _ := linearIssue(ctx, cfg, branch); key != "" {
+			ts.linear = cfg.LinearMagicWord + " " + key
+		}
+	}
+	return ts, nil
+}
+
+// apply appends the issue references (Jira smart commit, Linear magic words) and
+// the trailers to msg, which provider generated.
+func (ts trailerSet) apply(ctx context.Context, repoRoot, msg string, provider ai.Provider) (string, error) {
+	trailers := ts.fixed
+	if ts.generatedBy {
+		if mp, ok := provider.(*meteredProvider); ok {
+			trailers = append(trailers[:len(trailers):len(trailers)], "Generated-by: commitgen/"+mp.model)
+		}
+	}
+	var refs []string
+	if ts.jira.Key != "" {
+		sc := ts.jira
+		sc.Comment = commitmsg.SmartComment(msg)
+		refs = append(refs, sc.String())
+	}
+	if ts.linear != "" && !strings.Contains(strings.ToLower(msg), strings.ToLower(ts.linear)) {
+		refs = append(refs, ts.linear)
+	}
+	if len(refs) > 0 {
+		msg = strings.TrimRight(msg, "\n") + "\n\n" + strings.Join(refs, "\n")
+	}
+	if len(trailers) > 0 {
+		var err error
+		if msg, err = gitx.InterpretTrailers(ctx, repoRoot, msg, trailers); err != nil {
+			return "", err
+		}
+	}
+	if ts.changeID != "" {
+		msg = commitmsg.WithChangeID(msg, ts.changeID)
+	}
+	return msg, nil
+}
+
+// hookChangeID returns the Gerrit Change-Id already in the hook's message file, as
+// when amending a commit that was pushed for review. Keeping it keeps the new
+// patch set on the same change.
+func hookChangeID(hookFile string) string {
+	b, err := os.ReadFile(hookFile)
+	if err != nil {
+		return ""
+	}
+	return commitmsg.ChangeID(string(b))
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+)
+
+func TestTrailers(t *testing.T) {
+	t.Setenv("GIT_COMMITTER_NAME", "Ann Author")
+	t.Setenv("GIT_COMMITTER_EMAIL", "ann@example.com")
+	ctx := context.Background()
+	cfg := Config{
+		Signoff:     true,
+		CoAuthors:   []string{"Pat <pat@example.com>"},
+		Trailers:    []string{"Refs: PROJ-123"},
+		GeneratedBy: true,
+	}
+	ts, err := configTrailers(ctx, cfg, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := &meteredProvider{Provider: &scriptedProvider{reply: "unused"}, model: "gpt-4o"}
+	got, err := ts.apply(ctx, "", "fix: handle empty input\n\nRefs: PROJ-123", gen)
+	want := "fix: handle empty input\n\n" +
+		"Refs: PROJ-123\n" +
+		"Signed-off-by: Ann Author <ann@example.com>\n" +
+		"Co-authored-by: Pat <pat@example.com>\n" +
+		"Generated-by: commitgen/gpt-4o"
+	if err != nil || got != want {
+		t.Fatalf("got %q, %v; want %q", got, err, want)
+	}
+
+	// Template messages are not AI-written.
+	got, err = ts.apply(ctx, "", "docs: add f.txt", templateProvider{})
+	if err != nil || got != "docs: add f.txt\n\nSigned-off-by: Ann Author <ann@example.com>\nCo-authored-by: Pat <pat@example.com>\nRefs: PROJ-123" {
+		t.Errorf("template message: got %q, %v", got, err)
+	}
+
+	if got, _ := (trailerSet{}).apply(ctx, "", "fix: x", gen); got != "fix: x" {
+		t.Errorf("no trailers: got %q", got)
+	}
+
+	// A Gerrit Change-Id from the replaced message goes before Signed-off-by.
+	id := "I0123456789abcdef0123456789abcdef01234567"
+	ts.changeID = id
+	got, err = ts.apply(ctx, "", "docs: add f.txt", templateProvider{})
+	if err != nil || got != "docs: add f.txt\n\nChange-Id: "+id+"\nSigned-off-by: Ann Author <ann@example.com>\nCo-authored-by: Pat <pat@example.com>\nRefs: PROJ-123" {
+		t.Errorf("change id: got %q, %v", got, err)
+	}
+
+	jira := trailerSet{jira: commitmsg.SmartCommit{Key: "PROJ-7", Time: "1h"}}
+	if got, _ := jira.apply(ctx, "", "fix(api): reject empty ids", gen); got != "fix(api): reject empty ids\n\nPROJ-7 #comment reject empty ids #time 1h" {
+		t.Errorf("jira: got %q", got)
+	}
+
+	linear := trailerSet{linear: "Fixes ENG-12"}
+	if got, _ := linear.apply(ctx, "", "fix: x", gen); got != "fix: x\n\nFixes ENG-12" {
+		t.Errorf("linear: got %q", got)
+	}
+	if got, _ := linear.apply(ctx, "", "fix: x\n\nfixes ENG-12", gen); got != "fix: x\n\nfixes ENG-12" {
+		t.Errorf("linear already mentioned: got %q", got)
+	}
+}
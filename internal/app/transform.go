@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// transformTimeout bounds each run of a pre_prompt_command or post_message_command.
+const transformTimeout = 30 * time.Second
+
+// promptPayload is what a pre_prompt_command reads on stdin and prints back, changed
+// as it likes. Only messages and temperature are read back.
+type promptPayload struct {
+	Provider    string                       `json:"provider"`
+	Model       string                       `json:"model"`
+	Temperature float64                      `json:"temperature"`
+	Messages    []vscodeprompt.OpenAIMessage `json:"messages"`
+}
+
+// promptCommandProvider passes each request through cfg.PrePromptCommand before
+// sending it.
+type promptCommandProvider struct {
+	ai.Provider
+	command         string
+	dir             string
+	provider, model string
+}
+
+func (p promptCommandProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	in, _ := json.Marshal(promptPayload{Provider: p.provider, Model: p.model, Temperature: temp, Messages: vscodeprompt.ToOpenAIMessages(msgs)})
+	out, err := runTransform(ctx, p.dir, p.command, string(in))
+	if err != nil {
+		return "", fmt.Errorf("pre_prompt_command: %w", err)
+	}
+	payload := promptPayload{Temperature: temp}
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		return "", fmt.Errorf("pre_prompt_command: invalid JSON output: %w", err)
+	}
+	if len(payload.Messages) == 0 {
+		return "", errors.New("pre_prompt_command: output has no messages")
+	}
+	msgs = make([]vscodeprompt.VSCodeMessage, len(payload.Messages))
+	for i, m := range payload.Messages {
+		role := vscodeprompt.RoleUser
+		switch m.Role {
+		case "system":
+			role = vscodeprompt.RoleSystem
+		case "assistant":
+			role = vscodeprompt.RoleAssistant
+		}
+		msgs[i] = vscodeprompt.VSCodeMessage{Role: role, Content: []vscodeprompt.VSCodeContentPart{{Type: 1, Text: m.Content}}}
+	}
+	return p.Provider.GenerateCommitMessage(ctx, msgs, payload.Temperature)
+}
+
+// ContextWindow passes the provider's context window through, if it has one.
+func (p promptCommandProvider) ContextWindow(ctx context.Context) int {
+	if cw, ok := p.Provider.(ai.ContextWindower); ok {
+		return cw.ContextWindow(ctx)
+	}
+	return 0
+}
+
+// messageCommandProvider passes each generated message through cfg.PostMessageCommand.
+type messageCommandProvider struct {
+	ai.Provider
+	command string
+	dir     string
+}
+
+func (p messageCommandProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	raw, err := p.Provider.GenerateCommitMessage(ctx, msgs, temp)
+	if err != nil {
+		return "", err
+	}
+	msg, ok := vscodeprompt.ExtractOneTextCodeBlock(raw)
+	if !ok {
+		msg = raw
+	}
+	out, err := runTransform(ctx, p.dir, p.command, strings.TrimSpace(msg)+"\n")
+	if err != nil {
+		return "", fmt.Errorf("post_message_command: %w", err)
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", errors.New("post_message_command: printed no message")
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// runTransform runs command with the shell in dir, with stdin as its input, and
+// returns what it printed.
+func runTransform(ctx context.Context, dir, command, stdin string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, transformTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestTransformCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh commands")
+	}
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "k.go", Diff: "+const key = \"SECRET-1234\"\n"}}}
+	pr := prompt{data: data, msgs: vscodeprompt.BuildVSCodeMessages(data)}
+	cfg := Config{
+		PrePromptCommand:   `sed -e 's/SECRET-[0-9]*/[redacted]/g' -e 's/"temperature":[0-9.]*/"temperature":0.2/'`,
+		PostMessageCommand: `sed '1s/$/ [PROJ-1]/'`,
+	}
+
+	ep := &scriptedProvider{reply: "```text\nfix: rotate the key\n```"}
+	msg, err := generate.Message(context.Background(), forPrompt(context.Background(), ep, pr, cfg), pr.msgs, 0.7, false)
+	if err != nil || msg != "fix: rotate the key [PROJ-1]" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(ep.users) != 1 || strings.Contains(ep.users[0], "SECRET") || !strings.Contains(ep.users[0], "[redacted]") {
+		t.Errorf("request not rewritten: %q", ep.users)
+	}
+	if ep.temps[0] != 0.2 {
+		t.Errorf("temperature = %v; want 0.2", ep.temps[0])
+	}
+
+	cfg = Config{PrePromptCommand: "echo not json"}
+	if _, err := generate.Message(context.Background(), forPrompt(context.Background(), ep, pr, cfg), pr.msgs, 0.7, false); err == nil || !strings.Contains(err.Error(), "pre_prompt_command") {
+		t.Errorf("bad pre_prompt_command output: err = %v", err)
+	}
+	cfg = Config{PostMessageCommand: "echo oops >&2; exit 3"}
+	if _, err := generate.Message(context.Background(), forPrompt(context.Background(), ep, pr, cfg), pr.msgs, 0.7, false); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("failing post_message_command: err = %v", err)
+	}
+}
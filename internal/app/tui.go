@@ -2,9 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -13,7 +18,13 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
 	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/logx"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
 	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
 )
 
@@ -29,6 +40,11 @@ var (
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(lipgloss.Color("245")).
 				Padding(0, 1)
+	styleRisk   = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).MarginLeft(2)
+	styleStream = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("99")).
+			Padding(0, 1)
 )
 
 // msgContentStyle is width-dependent so it's a helper, not a global var.
@@ -43,13 +59,32 @@ func msgContentStyle(width int) lipgloss.Style {
 type tuiState int
 
 const (
-	stateGenerating  tuiState = iota // AI đang tạo commit message
-	stateCommitting                  // Đang thực hiện git commit
+	stateGenerating tuiState = iota // AI đang tạo commit message
+	stateCommitting                 // Đang thực hiện git commit
 	stateConfirm
 	stateEditing
+	statePicking // choosing one of the previous suggestions
+	stateModels  // choosing another model to generate the message with
+	stateFiles   // choosing which staged files the message covers, before generating
+	stateDiff    // browsing the diff of one file from stateFiles
+	stateCompare // generating with several models and picking one of their messages
 	stateDone
 )
 
+// confirmOptions are the actions offered in stateConfirm, in display order.
+var confirmOptions = []string{"Commit (Apply)", "Regenerate", "Edit", "Edit in $EDITOR", "Previous suggestions", "Change type", "Try another model", "Cancel"}
+
+const (
+	actionCommit = iota
+	actionRegenerate
+	actionEdit
+	actionEditor
+	actionPrevious
+	actionType
+	actionModel
+	actionCancel
+)
+
 type tuiModel struct {
 	state  tuiState
 	width  int
@@ -63,6 +98,25 @@ type tuiModel struct {
 	conventional bool
 	hookFile     string
 	repoRoot     string
+	diffHash     string
+	historyPath  string
+	paths        []string // the changed files, recorded with accepted messages
+	trailers     trailerSet
+	policy       commitmsg.Policy
+	rules        commitmsg.Rules
+	riskCheck    riskChecker
+	inflight     *inflight
+	keys         keyMap        // shortcuts for the confirm screen's actions
+	quick        bool          // no action list: Enter commits
+	accessible   bool          // plain prompts instead of the full-screen TUI
+	deadline     time.Time     // in hook mode, when to stop waiting for the first message
+	fallback     string        // the message written to hookFile if deadline passes
+	ctype        string        // the conventional type asked for, chosen or guessed from the changed files
+	typeChosen   bool          // ctype was picked with actionType: every message gets it
+	dryRun       bool          // show the git command instead of committing
+	models       []compareSide // the models actionModel offers, the one in use first
+	modelIndex   int           // the one of models in use
+	modelCursor  int
 
 	// Components
 	spinner       spinner.Model
@@ -72,32 +126,83 @@ type tuiModel struct {
 	needsScroll   bool // true khi content vượt quá inner height
 
 	// Data
-	commitMsg      string
-	cachedContent  string // built once in Update, read in View — avoids per-frame rebuild
-	cursor         int
-	err            error
-	quitting       bool
+	commitMsg     string
+	streamed      string // text of the running request received so far, if the provider streams
+	suggested     string // the suggestion commitMsg started from, to tell edits apart
+	pending       bool   // the accepted message was left for the post-commit hook
+	cachedContent string // built once in Update, read in View — avoids per-frame rebuild
+	cursor        int
+	err           error
+	quitting      bool
+	command       string // the git command a dry run would have run
+
+	// Previous suggestions
+	generated  []string // messages generated in this session, oldest first
+	rejected   []string // messages regenerated away from, oldest first; see withRejected
+	previous   []string // picker entries, newest first
+	pickCursor int
+	notice     string
+
+	// File selection before generating (--select-files)
+	files      []fileEntry
+	fileCursor int
+	fileData   vscodeprompt.Data
+	rebuild    reprompter
+	diffView   viewport.Model // the diff of the file under the cursor, in stateDiff
+
+	// Reasons to look twice before committing, shown as a banner
+	risks []string
+
+	// Side-by-side comparison (--compare)
+	compare       []compareSide
+	compareCursor int
 }
 
 type commitResultMsg struct {
+	seq     int
 	content string
 	err     error
 }
 
+// streamMsg carries the next piece of a streamed response.
+type streamMsg struct {
+	seq   int
+	delta string
+}
+
+// interruptMsg is sent to the program when the process receives SIGINT.
+type interruptMsg struct{}
+
+// inflight tracks the running generation request. It is shared by all copies of the
+// model so that Init, which cannot return a modified model, can start one too.
+type inflight struct {
+	seq    int // incremented per request; results with an older seq are stale
+	cancel context.CancelFunc
+	stream chan string // pieces of the response, closed when the request ends
+}
+
+// riskResultMsg carries the risks found by the model pass.
+type riskResultMsg struct {
+	risks []string
+	err   error
+}
+
 type commitDoneMsg struct {
-	err error
+	err     error
+	command string // set by a dry run, which commits nothing
 }
 
-func newTuiModel(repoRoot string, provider ai.Provider, msgs []vscodeprompt.VSCodeMessage, temp float64, timeout time.Duration, conventional bool, hookFile string) tuiModel {
+func newTuiModel(repoRoot string, provider ai.Provider, msgs []vscodeprompt.VSCodeMessage, temp float64, timeout time.Duration, conventional bool, hookFile, diffHash, historyPath string) tuiModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = styleSelected // reuse pre-computed style
 
 	ta := textarea.New()
-	ta.Placeholder = "Enter commit message..."
+	ta.Placeholder = i18n.T("Enter commit message...")
 	ta.Focus()
 	ta.SetWidth(80)
 	ta.SetHeight(5)
+	keys, _ := parseKeybindings(nil)
 
 	return tuiModel{
 		state:        stateGenerating,
@@ -108,57 +213,330 @@ func newTuiModel(repoRoot string, provider ai.Provider, msgs []vscodeprompt.VSCo
 		conventional: conventional,
 		hookFile:     hookFile,
 		repoRoot:     repoRoot,
+		diffHash:     diffHash,
+		historyPath:  historyPath,
+		inflight:     &inflight{},
+		keys:         keys,
 		spinner:      s,
 		textarea:     ta,
 	}
 }
 
+// withMessage starts the model on the confirm screen with msg (e.g. pre-generated
+// by `commitgen watch`) instead of generating one first.
+func (m tuiModel) withMessage(msg string) tuiModel {
+	m.commitMsg = msg
+	m.suggested = msg
+	m.generated = append(m.generated, msg)
+	m.state = stateConfirm
+	return m
+}
+
+// withDeadline makes the first generation give up at deadline and write fallback, a
+// message guessed without the model, to the hook's message file, so that a slow
+// provider doesn't hold up git commit.
+func (m tuiModel) withDeadline(deadline time.Time, fallback string) tuiModel {
+	m.deadline, m.fallback = deadline, fallback
+	return m
+}
+
+// withType sets typ, the conventional type the prompt asks for. A guessed one is shown
+// above the message; a chosen one is given to every message. actionType changes it.
+func (m tuiModel) withType(typ string, chosen bool) tuiModel {
+	m.ctype, m.typeChosen = typ, chosen
+	return m
+}
+
+// nextType moves to the allowed conventional type after the current message's, and
+// gives it to the message and to the ones generated after.
+func (m tuiModel) nextType() tuiModel {
+	types := m.rules.Types
+	if len(types) == 0 {
+		types = commitmsg.DefaultTypes
+	}
+	current := m.ctype
+	if h, ok := commitmsg.ParseHeader(commitmsg.Subject(m.commitMsg)); ok {
+		current = h.Type
+	}
+	m.ctype = types[(slices.Index(types, current)+1)%len(types)]
+	m.typeChosen = true
+	if m.commitMsg != "" {
+		m.commitMsg = setSubjectType(m.commitMsg, m.ctype)
+	}
+	return m
+}
+
+// setSubjectType gives msg's subject the conventional type typ.
+func setSubjectType(msg, typ string) string {
+	subject, body, hasBody := strings.Cut(strings.TrimSpace(msg), "\n")
+	subject = commitmsg.SetType(subject, typ)
+	if hasBody {
+		return subject + "\n" + body
+	}
+	return subject
+}
+
+// withPaths sets the changed files recorded in history with the accepted message.
+func (m tuiModel) withPaths(paths []string) tuiModel {
+	m.paths = paths
+	return m
+}
+
+// withTrailers sets the trailers appended to the message when it is accepted.
+func (m tuiModel) withTrailers(ts trailerSet) tuiModel {
+	m.trailers = ts
+	return m
+}
+
+// withPolicy sets the message policy; a message that breaks it cannot be committed.
+func (m tuiModel) withPolicy(p commitmsg.Policy) tuiModel {
+	m.policy = p
+	return m
+}
+
+// withRules sets the lint rules; a generated message that still breaks them after
+// the repair attempts gets a notice.
+func (m tuiModel) withRules(r commitmsg.Rules) tuiModel {
+	m.rules = r
+	return m
+}
+
+// withKeys sets the confirm screen's shortcuts, and with quick replaces its action
+// list with them, so that Enter commits.
+func (m tuiModel) withKeys(keys keyMap, quick bool) tuiModel {
+	m.keys = keys
+	m.quick = quick
+	return m
+}
+
+// withDryRun makes committing set the git command it would run, instead of running
+// it. Nothing is recorded as accepted.
+func (m tuiModel) withDryRun(on bool) tuiModel {
+	m.dryRun = on
+	return m
+}
+
+// withModels sets the models actionModel offers. models[0] is the one in use.
+func (m tuiModel) withModels(models []compareSide) tuiModel {
+	m.models = models
+	return m
+}
+
+// useModel switches to the i-th of m.models, which stays in use for the rest of the
+// session, to generate a new message with. The current message is rejected, as by
+// actionRegenerate.
+func (m tuiModel) useModel(i int) tuiModel {
+	m.record(history.StatusRejected, m.commitMsg)
+	recordOutcome(m.provider, stats.OutcomeRegenerated)
+	m = m.reject()
+	m.provider, m.modelIndex = m.models[i].provider, i
+	m.state = stateGenerating
+	m.streamed = ""
+	return m
+}
+
+// withRisks sets the risk banner, and a model pass that may add to it while the
+// message is generated.
+func (m tuiModel) withRisks(risks []string, check riskChecker) tuiModel {
+	m.risks = risks
+	m.riskCheck = check
+	return m
+}
+
 func (m tuiModel) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, m.generateCommitCmd())
+	cmds := []tea.Cmd{m.spinner.Tick}
+	if m.riskCheck != nil {
+		cmds = append(cmds, m.checkRisksCmd())
+	}
+	switch m.state {
+	case stateCompare:
+		cmds = append(cmds, m.generateCompareCmd())
+	case stateGenerating:
+		cmds = append(cmds, m.startGeneration())
+	}
+	return tea.Batch(cmds...)
 }
 
-func (m tuiModel) generateCommitCmd() tea.Cmd {
+func (m tuiModel) checkRisksCmd() tea.Cmd {
 	return func() tea.Msg {
-		currentMsgs := make([]vscodeprompt.VSCodeMessage, len(m.initialMsgs))
-		copy(currentMsgs, m.initialMsgs)
-
-		if m.conventional {
-			reminderMsg := vscodeprompt.VSCodeMessage{
-				Role: vscodeprompt.RoleUser,
-				Content: []vscodeprompt.VSCodeContentPart{
-					{Type: 1, Text: "CRITICAL INSTRUCTION: You must strictly follow the Conventional Commits specification (e.g. 'feat: add spinner', 'fix: resolve bug').\nDo not just describe the change; prefix it with the type."},
-				},
-			}
-			currentMsgs = append(currentMsgs, reminderMsg)
-		}
-
 		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 		defer cancel()
+		risks, err := m.riskCheck(ctx)
+		return riskResultMsg{risks: risks, err: err}
+	}
+}
 
-		raw, err := m.provider.GenerateCommitMessage(ctx, currentMsgs, m.temp)
-		if err != nil {
-			return commitResultMsg{err: err}
+// startGeneration starts a generation request and shows its output as it streams in.
+func (m tuiModel) startGeneration() tea.Cmd {
+	gen := m.generateCommitCmd()
+	return tea.Batch(gen, waitForStream(m.inflight.seq, m.inflight.stream))
+}
+
+// generateCommitCmd starts a generation request that interrupt can abort.
+func (m tuiModel) generateCommitCmd() tea.Cmd {
+	m.inflight.seq++
+	seq := m.inflight.seq
+	timeout := m.timeout
+	if !m.deadline.IsZero() {
+		timeout = min(timeout, time.Until(m.deadline))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	m.inflight.cancel = cancel
+	stream := make(chan string, 64)
+	m.inflight.stream = stream
+	ctx = ai.WithStream(ctx, func(delta string) {
+		select {
+		case stream <- delta:
+		case <-ctx.Done():
 		}
+	})
 
-		msg, ok := vscodeprompt.ExtractOneTextCodeBlock(raw)
+	return func() tea.Msg {
+		defer cancel()
+		defer close(stream)
+		msg, err := generate.Message(ctx, m.provider, withRejected(m.initialMsgs, m.rejected), m.temp, m.conventional)
+		return commitResultMsg{seq: seq, content: msg, err: err}
+	}
+}
+
+// waitForStream delivers the next piece of the response of request seq.
+func waitForStream(seq int, stream <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		delta, ok := <-stream
 		if !ok {
-			msg = raw
+			return nil
+		}
+		return streamMsg{seq: seq, delta: delta}
+	}
+}
+
+// interrupt handles Ctrl-C: while generating it aborts only the request and goes
+// back to the actions; anywhere else it quits.
+func (m tuiModel) interrupt() (tuiModel, tea.Cmd) {
+	if m.state == stateCompare && m.comparePending() {
+		m.inflight.cancel()
+		m.inflight.seq++ // drop the canceled requests' results
+		for i := range m.compare {
+			if !m.compare[i].done {
+				m.compare[i].done, m.compare[i].err = true, ErrCanceled
+			}
 		}
-		return commitResultMsg{content: msg}
+		return m, nil
+	}
+	if m.state != stateGenerating {
+		m.quitting = true
+		return m, tea.Quit
 	}
+	if m.inflight.cancel != nil {
+		m.inflight.cancel()
+	}
+	m.inflight.seq++ // drop the canceled request's result
+	m.state = stateConfirm
+	m.cursor = actionCommit
+	if m.commitMsg == "" {
+		m.cursor = actionRegenerate
+	}
+	m.notice = i18n.T("Generation canceled.")
+	return m.refreshViewport(), nil
+}
+
+// maxRejectedTurns caps the rejected messages sent back on regenerate, so a long
+// session doesn't outgrow a small model's context.
+const maxRejectedTurns = 3
+
+// reject remembers the current message as one the user turned down by regenerating.
+func (m tuiModel) reject() tuiModel {
+	if m.commitMsg != "" {
+		m.rejected = append(m.rejected, m.commitMsg)
+	}
+	return m
+}
+
+// withRejected returns msgs followed by the latest rejected messages, each as the
+// model's earlier answer and the user's request for another. Without them, a
+// regenerated message tends to be the same one reworded.
+func withRejected(msgs []vscodeprompt.VSCodeMessage, rejected []string) []vscodeprompt.VSCodeMessage {
+	if len(rejected) == 0 {
+		return msgs
+	}
+	out := append([]vscodeprompt.VSCodeMessage(nil), msgs...)
+	for _, r := range rejected[max(len(rejected)-maxRejectedTurns, 0):] {
+		out = append(out,
+			vscodeprompt.VSCodeMessage{
+				Role:    vscodeprompt.RoleAssistant,
+				Content: []vscodeprompt.VSCodeContentPart{{Type: 1, Text: "```text\n" + r + "\n```"}},
+			},
+			vscodeprompt.VSCodeMessage{
+				Role:    vscodeprompt.RoleUser,
+				Content: []vscodeprompt.VSCodeContentPart{{Type: 1, Text: "The developer rejected that message. Write a genuinely different one for the same CODE CHANGES: another angle, emphasis or wording, not a rephrasing. ONLY return a single markdown code block."}},
+			})
+	}
+	return out
 }
 
 func (m tuiModel) commitCmd() tea.Cmd {
 	return func() tea.Msg {
+		msg, err := m.trailers.apply(context.Background(), m.repoRoot, m.commitMsg, m.provider)
+		if err != nil {
+			return commitDoneMsg{err: err}
+		}
 		if m.hookFile != "" {
-			err := os.WriteFile(m.hookFile, []byte(m.commitMsg), 0644)
+			err := os.WriteFile(m.hookFile, []byte(msg), 0644)
 			return commitDoneMsg{err: err}
 		}
-		err := gitx.Commit(context.Background(), m.repoRoot, m.commitMsg)
+		if m.dryRun {
+			return commitDoneMsg{command: gitx.CommandLine(gitx.CommitArgs(msg)...)}
+		}
+		err = commit(context.Background(), m.repoRoot, msg)
 		return commitDoneMsg{err: err}
 	}
 }
 
+// applied reports whether the message was committed (or written to the hook file).
+func (m tuiModel) applied() bool {
+	return m.state == stateDone && m.err == nil && !m.quitting
+}
+
+// record appends msg to the history file. History is best-effort, so errors are ignored.
+func (m tuiModel) record(status, msg string) {
+	e := history.Entry{
+		Repo:     gitx.RepoNameFromRoot(m.repoRoot),
+		Root:     m.repoRoot,
+		DiffHash: m.diffHash,
+		Status:   status,
+		Message:  msg,
+	}
+	if status == history.StatusAccepted {
+		e.Paths = m.paths
+		e.Edited = strings.TrimSpace(msg) != strings.TrimSpace(m.suggested)
+	}
+	_ = history.Append(m.historyPath, e)
+}
+
+// previousSuggestions merges this session's messages with those stored in history
+// for the same diff, newest first, excluding the message currently shown.
+func (m tuiModel) previousSuggestions() []string {
+	var all []string
+	for i := len(m.generated) - 1; i >= 0; i-- {
+		all = append(all, m.generated[i])
+	}
+	if entries, err := history.Load(m.historyPath); err == nil {
+		all = append(all, history.ForDiff(entries, m.diffHash)...)
+	}
+
+	seen := map[string]bool{strings.TrimSpace(m.commitMsg): true}
+	var out []string
+	for _, s := range all {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
 // innerWidth returns usable width inside the outer border+padding (border=2, padding=2 → 4 total).
 func (m tuiModel) innerWidth() int {
 	w := m.width - 4
@@ -185,6 +563,110 @@ func countLines(s string) int {
 	return strings.Count(s, "\n") + 1
 }
 
+// buildStreamContent shows the response of the running request as it arrives, keeping
+// the latest lines in view when it outgrows the window.
+func (m tuiModel) buildStreamContent() string {
+	text := streamPreview(m.streamed)
+	width := max(m.innerWidth()-4, 10)
+	panel := styleStream.Width(width).Render(text)
+	if h := m.innerHeight() - 4; h > 0 {
+		if lines := strings.Split(panel, "\n"); len(lines) > h {
+			panel = strings.Join(lines[len(lines)-h:], "\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(styleMsgTitle.Render(m.spinner.View() + " " + i18n.T("Generating commit message")))
+	b.WriteString("\n")
+	b.WriteString(panel)
+	b.WriteString("\n")
+	b.WriteString(styleHint.Render(" " + i18n.T("Esc or Ctrl-C to cancel")))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// streamPreview drops the opening code fence the prompt asks for, so a partial
+// response reads like the finished message.
+func streamPreview(s string) string {
+	s = strings.TrimLeft(s, " \n")
+	if strings.HasPrefix(s, "```") {
+		_, rest, ok := strings.Cut(s, "\n")
+		if !ok {
+			return ""
+		}
+		s = rest
+	}
+	s = strings.TrimSuffix(strings.TrimRight(s, " \n"), "```")
+	return strings.TrimRight(s, " \n")
+}
+
+// runAction does one of the confirm screen's actions.
+func (m tuiModel) runAction(action int) (tuiModel, tea.Cmd) {
+	switch action {
+	case actionCommit:
+		if strings.TrimSpace(m.commitMsg) == "" {
+			m.notice = i18n.T("There is no message yet; regenerate or edit one first.")
+			m = m.refreshViewport()
+			return m, nil
+		}
+		if issues := m.policy.Check(m.commitMsg); len(issues) > 0 {
+			m.notice = i18n.Tf("Policy: %s; edit or regenerate the message.", issues[0].Message)
+			m = m.refreshViewport()
+			return m, nil
+		}
+		// Left before committing, for the hook to find; recorded once committed.
+		if !m.dryRun {
+			m.pending = pendAccepted(context.Background(), m.repoRoot, m.provider, m.suggested, m.commitMsg)
+		}
+		m.state = stateCommitting
+		return m, m.commitCmd()
+	case actionRegenerate:
+		m.record(history.StatusRejected, m.commitMsg)
+		recordOutcome(m.provider, stats.OutcomeRegenerated)
+		m = m.reject()
+		m.state = stateGenerating
+		m.streamed = ""
+		return m, m.startGeneration()
+	case actionEdit:
+		m.state = stateEditing
+		m.textarea.SetValue(m.commitMsg)
+		return m, textarea.Blink
+	case actionEditor:
+		return m, openInEditor(m.repoRoot, m.commitMsg)
+	case actionPrevious:
+		m.previous = m.previousSuggestions()
+		if len(m.previous) == 0 {
+			m.notice = i18n.T("No previous suggestions for these changes.")
+			m = m.refreshViewport()
+			return m, nil
+		}
+		m.pickCursor = 0
+		m.state = statePicking
+		return m, nil
+	case actionType:
+		m = m.nextType()
+		m.notice = i18n.Tf("Type set to %s; regenerated messages keep it.", m.ctype)
+		m = m.refreshViewport()
+		return m, nil
+	case actionModel:
+		if len(m.models) < 2 {
+			m.notice = i18n.T("No other models to try; list them in the other_models setting.")
+			m = m.refreshViewport()
+			return m, nil
+		}
+		m.modelCursor = m.modelIndex
+		m.state = stateModels
+		return m, nil
+	case actionCancel:
+		m.record(history.StatusRejected, m.commitMsg)
+		recordOutcome(m.provider, stats.OutcomeRejected)
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
 // buildConfirmContent builds the full string for stateConfirm.
 // Uses pre-computed package-level styles where possible.
 // Called from Update() only — result is cached in m.cachedContent.
@@ -192,24 +674,115 @@ func (m tuiModel) buildConfirmContent() string {
 	var b strings.Builder
 
 	b.WriteString("\n")
-	b.WriteString(styleMsgTitle.Render("Generated Commit Message"))
+	if len(m.risks) > 0 {
+		b.WriteString(styleRisk.Render("⚠ " + i18n.T("Check before committing")))
+		b.WriteString("\n")
+		for _, r := range m.risks {
+			b.WriteString(msgContentStyle(m.innerWidth() - 6).Render(r))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(styleMsgTitle.Render(i18n.T("Generated Commit Message")))
 	b.WriteString("\n")
-	b.WriteString(msgContentStyle(m.innerWidth() - 6).Render(m.commitMsg))
+	if m.ctype != "" && !m.typeChosen {
+		b.WriteString(styleHint.Render("  " + i18n.Tf("Type %s, guessed from the changed files", m.ctype)))
+		b.WriteString("\n")
+	}
+	if m.commitMsg == "" {
+		b.WriteString(styleHint.Render("  " + i18n.T("(no message yet)")))
+	} else {
+		b.WriteString(msgContentStyle(m.innerWidth() - 6).Render(m.commitMsg))
+	}
 	b.WriteString("\n\n") // blank line before Action section
 
-	b.WriteString(styleActionTitle.Render("Action"))
+	if m.quick {
+		hints := []string{i18n.T("Enter to commit")}
+		for action, name := range actionNames {
+			if keys := m.keys.keysFor(action); len(keys) > 0 && action != actionCommit {
+				hints = append(hints, strings.Join(keys, "/")+" "+i18n.T(name))
+			}
+		}
+		b.WriteString(styleHint.Render(" " + strings.Join(hints, " • ")))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(styleActionTitle.Render(i18n.T("Action")))
+		b.WriteString("\n")
+
+		barStr := styleBar.Render("┃")
+		for i, opt := range confirmOptions {
+			opt = i18n.T(opt)
+			if keys := m.keys.keysFor(i); len(keys) > 0 {
+				opt += styleHint.Render("  " + strings.Join(keys, "/"))
+			}
+			if m.cursor == i {
+				b.WriteString(fmt.Sprintf("%s > %s\n", barStr, styleSelected.Render(opt)))
+			} else {
+				b.WriteString(fmt.Sprintf("%s   %s\n", barStr, opt))
+			}
+		}
+	}
+
+	if m.notice != "" {
+		b.WriteString(styleHint.Render(" " + m.notice))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// buildPickerContent lists previous suggestions by subject line.
+func (m tuiModel) buildPickerContent() string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(styleMsgTitle.Render(i18n.T("Previous Suggestions")))
 	b.WriteString("\n")
 
-	options := []string{"Commit (Apply)", "Regenerate", "Edit", "Cancel"}
 	barStr := styleBar.Render("┃")
-	for i, opt := range options {
-		if m.cursor == i {
-			b.WriteString(fmt.Sprintf("%s > %s\n", barStr, styleSelected.Render(opt)))
+	maxW := m.innerWidth() - 6
+	for i, s := range m.previous {
+		subject, _, _ := strings.Cut(s, "\n")
+		if maxW > 1 && len([]rune(subject)) > maxW {
+			subject = string([]rune(subject)[:maxW-1]) + "…"
+		}
+		if m.pickCursor == i {
+			b.WriteString(fmt.Sprintf("%s > %s\n", barStr, styleSelected.Render(subject)))
 		} else {
-			b.WriteString(fmt.Sprintf("%s   %s\n", barStr, opt))
+			b.WriteString(fmt.Sprintf("%s   %s\n", barStr, subject))
 		}
 	}
 
+	b.WriteString("\n")
+	b.WriteString(styleHint.Render(" " + i18n.T("Enter to use • Esc to go back")))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// buildModelsContent lists the models actionModel offers.
+func (m tuiModel) buildModelsContent() string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(styleMsgTitle.Render(i18n.T("Try Another Model")))
+	b.WriteString("\n")
+
+	barStr := styleBar.Render("┃")
+	for i, side := range m.models {
+		name := side.name
+		if i == m.modelIndex {
+			name += " " + i18n.T("(in use)")
+		}
+		if m.modelCursor == i {
+			b.WriteString(fmt.Sprintf("%s > %s\n", barStr, styleSelected.Render(name)))
+		} else {
+			b.WriteString(fmt.Sprintf("%s   %s\n", barStr, name))
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styleHint.Render(" " + i18n.T("Enter to generate with it • Esc to go back")))
+	b.WriteString("\n")
 	return b.String()
 }
 
@@ -217,7 +790,7 @@ func (m tuiModel) buildConfirmContent() string {
 // and auto-scrolls to keep the current action cursor visible.
 // Must be called from Update() only (modifies model state).
 func (m tuiModel) refreshViewport() tuiModel {
-	if !m.viewportReady || m.state != stateConfirm || m.commitMsg == "" {
+	if !m.viewportReady || m.state != stateConfirm {
 		return m
 	}
 	content := m.buildConfirmContent()
@@ -227,11 +800,17 @@ func (m tuiModel) refreshViewport() tuiModel {
 
 	if m.needsScroll {
 		m.viewport.SetContent(content)
+		if m.quick {
+			return m // no action list to keep in view
+		}
 
 		// Auto-scroll to keep cursor action item in view.
-		// Action lines are at the end of content:
-		//   cursor=0 → 4th from end, cursor=1 → 3rd, cursor=2 → 2nd, cursor=3 → last
-		lineFromEnd := 4 - m.cursor
+		// Action lines are at the end of content, followed by one trailing empty line:
+		//   cursor=0 → first action line, cursor=len-1 → last action line
+		lineFromEnd := len(confirmOptions) - m.cursor
+		if m.notice != "" {
+			lineFromEnd++
+		}
 		cursorLine := totalLines - 1 - lineFromEnd // 0-indexed
 
 		viewTop := m.viewport.YOffset
@@ -248,14 +827,30 @@ func (m tuiModel) refreshViewport() tuiModel {
 func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			m.quitting = true
-			return m, tea.Quit
+		if msg.String() == "ctrl+c" {
+			return m.interrupt()
 		}
 
 		switch m.state {
+		case stateFiles:
+			return m.updateFiles(msg)
+
+		case stateDiff:
+			return m.updateDiff(msg)
+
+		case stateCompare:
+			return m.updateCompare(msg)
+
+		case stateGenerating:
+			if msg.String() == "esc" {
+				return m.interrupt()
+			}
+
 		case stateConfirm:
+			if action, ok := m.keys[msg.String()]; ok {
+				m.notice = ""
+				return m.runAction(action)
+			}
 			switch msg.String() {
 			case "up", "k":
 				if m.cursor > 0 {
@@ -263,7 +858,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m = m.refreshViewport()
 				}
 			case "down", "j":
-				if m.cursor < 3 {
+				if m.cursor < len(confirmOptions)-1 {
 					m.cursor++
 					m = m.refreshViewport()
 				}
@@ -276,23 +871,55 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.viewport.HalfViewDown()
 				}
 			case "enter":
-				switch m.cursor {
-				case 0: // Commit
-					m.state = stateCommitting
-					return m, m.commitCmd()
-				case 1: // Regenerate
-					m.state = stateGenerating
-					return m, m.generateCommitCmd()
-				case 2: // Edit
-					m.state = stateEditing
-					m.textarea.SetValue(m.commitMsg)
-					return m, textarea.Blink
-				case 3: // Cancel
-					m.quitting = true
-					return m, tea.Quit
+				m.notice = ""
+				action := m.cursor
+				if m.quick {
+					action = actionCommit
 				}
+				return m.runAction(action)
 			}
 
+		case statePicking:
+			switch msg.String() {
+			case "up", "k":
+				if m.pickCursor > 0 {
+					m.pickCursor--
+				}
+			case "down", "j":
+				if m.pickCursor < len(m.previous)-1 {
+					m.pickCursor++
+				}
+			case "enter":
+				m.commitMsg = m.previous[m.pickCursor]
+				m.suggested = m.commitMsg
+				m.state = stateConfirm
+				m.cursor = actionCommit
+				m = m.refreshViewport()
+			case "esc", "q":
+				m.state = stateConfirm
+				m = m.refreshViewport()
+			}
+			return m, nil
+
+		case stateModels:
+			switch msg.String() {
+			case "up", "k":
+				if m.modelCursor > 0 {
+					m.modelCursor--
+				}
+			case "down", "j":
+				if m.modelCursor < len(m.models)-1 {
+					m.modelCursor++
+				}
+			case "enter":
+				m = m.useModel(m.modelCursor)
+				return m, m.startGeneration()
+			case "esc", "q":
+				m.state = stateConfirm
+				m = m.refreshViewport()
+			}
+			return m, nil
+
 		case stateEditing:
 			if msg.String() == "esc" {
 				m.commitMsg = m.textarea.Value()
@@ -305,7 +932,13 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+	case interruptMsg:
+		return m.interrupt()
+
 	case tea.MouseMsg:
+		if m.state == stateDiff {
+			return m.updateDiff(msg)
+		}
 		// Only handle mouse when viewport scroll is active.
 		if m.state == stateConfirm && m.needsScroll && m.viewportReady {
 			var cmd tea.Cmd
@@ -326,6 +959,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.Width = m.innerWidth()
 			m.viewport.Height = vpHeight
 		}
+		m.diffView.Width, m.diffView.Height = m.innerWidth(), m.diffHeight()
 		m = m.refreshViewport()
 
 	case spinner.TickMsg:
@@ -333,21 +967,92 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case streamMsg:
+		if msg.seq != m.inflight.seq {
+			return m, nil // canceled with Ctrl-C
+		}
+		m.streamed += msg.delta
+		return m, waitForStream(msg.seq, m.inflight.stream)
+
+	case compareResultMsg:
+		if msg.seq != m.inflight.seq || m.state != stateCompare {
+			return m, nil
+		}
+		side := &m.compare[msg.side]
+		side.done, side.err = true, msg.err
+		if msg.err == nil {
+			side.message = msg.content
+			m.record(history.StatusGenerated, msg.content)
+		}
+		return m, nil
+
 	case commitResultMsg:
+		if msg.seq != m.inflight.seq {
+			return m, nil // canceled with Ctrl-C
+		}
+		m.streamed = ""
+		deadline := m.deadline
+		m.deadline = time.Time{} // only the first message is waited for
+		if msg.err != nil && !deadline.IsZero() && m.hookFile != "" && errors.Is(msg.err, context.DeadlineExceeded) {
+			m.err = writeHookFallback(m.hookFile, m.fallback)
+			m.state = stateDone
+			return m, tea.Quit
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			m.state = stateDone
 			return m, tea.Quit
 		}
+		if m.typeChosen {
+			msg.content = setSubjectType(msg.content, m.ctype)
+		}
 		m.commitMsg = msg.content
+		m.suggested = msg.content
+		m.generated = append(m.generated, msg.content)
+		m.record(history.StatusGenerated, msg.content)
 		m.state = stateConfirm
 		m.cursor = 0
+		if issues := append(commitmsg.Lint(msg.content, m.rules), m.policy.Check(msg.content)...); len(issues) > 0 {
+			m.notice = i18n.Tf("Check before committing: %s.", issues[0].Message)
+		}
 		m = m.refreshViewport()
 
-	case commitDoneMsg:
+	case riskResultMsg:
 		if msg.err != nil {
+			slog.Debug("risk check failed", "err", msg.err)
+			return m, nil
+		}
+		for _, r := range msg.risks {
+			if !slices.Contains(m.risks, r) {
+				m.risks = append(m.risks, r)
+			}
+		}
+		m = m.refreshViewport()
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.notice = i18n.Tf("Editor failed: %v", msg.err)
+		} else if strings.TrimSpace(msg.content) != "" {
+			m.commitMsg = msg.content
+		} else {
+			m.notice = i18n.T("Editor returned an empty message; keeping the previous one.")
+		}
+		m = m.refreshViewport()
+
+	case commitDoneMsg:
+		switch {
+		case msg.err != nil:
 			m.err = msg.err
+			if !m.dryRun {
+				m.record(history.StatusFailed, m.commitMsg)
+			}
+		case !m.dryRun:
+			m.record(history.StatusAccepted, m.commitMsg)
+			if !m.pending {
+				recordOutcome(m.provider, acceptOutcome(m.suggested, m.commitMsg))
+			}
 		}
+		m.command = msg.command
 		m.state = stateDone
 		return m, tea.Quit
 	}
@@ -364,10 +1069,20 @@ func (m tuiModel) View() string {
 
 	switch m.state {
 	case stateGenerating:
-		inner = fmt.Sprintf("\n %s Generating commit message...\n", m.spinner.View())
+		if m.streamed != "" {
+			inner = m.buildStreamContent()
+		} else {
+			inner = fmt.Sprintf("\n %s %s\n\n%s\n", m.spinner.View(), i18n.T("Generating commit message..."), styleHint.Render(" "+i18n.T("Esc or Ctrl-C to cancel")))
+			if m.ctype != "" {
+				inner += styleHint.Render(" "+i18n.Tf("Type: %s", m.ctype)) + "\n"
+			}
+			if len(m.models) > 1 {
+				inner += styleHint.Render(" "+i18n.Tf("Model: %s", m.models[m.modelIndex].name)) + "\n"
+			}
+		}
 
 	case stateCommitting:
-		inner = fmt.Sprintf("\n %s Committing...\n", m.spinner.View())
+		inner = fmt.Sprintf("\n %s %s\n", m.spinner.View(), i18n.T("Committing..."))
 
 	case stateConfirm:
 		if m.needsScroll && m.viewportReady {
@@ -376,9 +1091,9 @@ func (m tuiModel) View() string {
 			var hint string
 			switch {
 			case m.viewport.AtTop():
-				hint = fmt.Sprintf(" ↓ PgDn/Scroll  %d%% ", pct)
+				hint = i18n.Tf(" ↓ PgDn/Scroll  %d%% ", pct)
 			case m.viewport.AtBottom():
-				hint = fmt.Sprintf(" ↑ PgUp/Scroll  %d%% ", pct)
+				hint = i18n.Tf(" ↑ PgUp/Scroll  %d%% ", pct)
 			default:
 				hint = fmt.Sprintf(" ↑↓ PgUp/PgDn  %d%% ", pct)
 			}
@@ -388,19 +1103,38 @@ func (m tuiModel) View() string {
 			inner = m.cachedContent
 		}
 
+	case statePicking:
+		inner = m.buildPickerContent()
+
+	case stateModels:
+		inner = m.buildModelsContent()
+
+	case stateFiles:
+		inner = m.buildFilesContent()
+
+	case stateDiff:
+		inner = m.buildDiffContent()
+
+	case stateCompare:
+		inner = m.buildCompareContent()
+
 	case stateEditing:
 		var b strings.Builder
-		b.WriteString(styleEditTitle.Render("Edit Commit Message"))
+		b.WriteString(styleEditTitle.Render(i18n.T("Edit Commit Message")))
 		b.WriteString("\n")
 		b.WriteString(m.textarea.View())
-		b.WriteString("\n\n (Press Esc to finish editing)\n")
+		b.WriteString("\n\n " + i18n.T("(Press Esc to finish editing)") + "\n")
 		inner = b.String()
 
 	case stateDone:
 		if m.err != nil {
-			inner = fmt.Sprintf("\n ✗ Error: %v\n", m.err)
+			inner = "\n ✗ " + i18n.Tf("Error: %v", m.err) + "\n"
+		} else if m.hookFile != "" {
+			inner = "\n ✓ " + i18n.T("Commit message saved.") + "\n"
+		} else if m.command != "" {
+			inner = "\n ✓ " + i18n.T("Dry run: nothing was committed.") + "\n"
 		} else {
-			inner = "\n ✓ Committed successfully!\n"
+			inner = "\n ✓ " + i18n.T("Committed successfully!") + "\n"
 		}
 	}
 
@@ -410,9 +1144,53 @@ func (m tuiModel) View() string {
 
 	// styleWindow is pre-computed; only add Width and conditional Height here.
 	ws := styleWindow.Width(m.width - 2)
-	if m.needsScroll && m.height > 0 {
+	if (m.needsScroll || m.state == stateDiff) && m.height > 0 {
 		ws = ws.Height(m.height - 2)
 	}
 
 	return ws.Render(inner)
-}
\ No newline at end of file
+}
+
+// runProgram runs the TUI full-screen until it quits, holding back log output meanwhile,
+// or asks with plain prompts in accessible mode.
+// SIGINT is delivered to the model like Ctrl-C so that it can cancel just the running
+// request; SIGTERM quits.
+func runProgram(m tuiModel) (tuiModel, error) {
+	if m.accessible {
+		return runPlain(m, os.Stdin, os.Stdout), nil
+	}
+	p := tea.NewProgram(
+		m,
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+		tea.WithoutSignalHandler(),
+	)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case s := <-sigs:
+				if s == os.Interrupt {
+					p.Send(interruptMsg{})
+				} else {
+					p.Quit()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	release := logx.Hold()
+	defer release()
+	final, err := p.Run()
+	if fm, ok := final.(tuiModel); ok {
+		m = fm
+	}
+	return m, err
+}
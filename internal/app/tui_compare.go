@@ -0,0 +1,243 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/stats"
+)
+
+var (
+	stylePanel = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("237")).
+			Padding(0, 1)
+	stylePanelSelected = stylePanel.BorderForeground(lipgloss.Color("42"))
+)
+
+// compareSide is one of the models generating side by side (--compare).
+type compareSide struct {
+	name     string // "provider/model"
+	provider ai.Provider
+	message  string
+	err      error
+	done     bool
+}
+
+// compareResultMsg is the result of one side of a comparison.
+type compareResultMsg struct {
+	seq     int
+	side    int
+	content string
+	err     error
+}
+
+// compareTarget returns the config for one --compare entry: a model of the configured
+// provider ("gpt-4o-mini"), or "provider" or "provider:model" as for bench.
+func compareTarget(cfg Config, entry string) (Config, error) {
+	entry = strings.TrimSpace(entry)
+	name, _, _ := strings.Cut(entry, ":")
+	if _, ok := defaultModels[strings.ToLower(name)]; ok {
+		return benchTarget(cfg, entry)
+	}
+	if entry == "" {
+		return cfg, fmt.Errorf("empty entry in --compare")
+	}
+	cfg.Model = entry
+	return cfg, nil
+}
+
+// otherModels returns the models the "Try another model" action offers, starting
+// with current, which generates with cfg: those in cfg.OtherModels, or else each other
+// provider that has a key, with its default model. Entries that can't be set up are
+// left out with a warning. It returns nil when there is nothing to switch to.
+func otherModels(ctx context.Context, cfg Config, pr prompt, current ai.Provider) []compareSide {
+	if cfg.NoAI {
+		return nil
+	}
+	entries := cfg.OtherModels
+	if len(entries) == 0 {
+		keys := map[string]string{"openai": cfg.APIKey, "anthropic": cfg.AnthropicKey, "gemini": cfg.GeminiKey}
+		for _, name := range []string{"openai", "anthropic", "gemini"} {
+			if keys[name] != "" && name != strings.ToLower(cmp.Or(cfg.Provider, "openai")) {
+				entries = append(entries, name)
+			}
+		}
+	}
+
+	sides := []compareSide{{name: cmp.Or(cfg.Provider, "openai") + "/" + cfg.Model, provider: current}}
+	for _, entry := range entries {
+		t, err := compareTarget(cfg, entry)
+		if err == nil && strings.EqualFold(t.Provider, cfg.Provider) && t.Model == cfg.Model {
+			continue
+		}
+		var p ai.Provider
+		if err == nil {
+			p, err = newProvider(ctx, t)
+		}
+		if err != nil {
+			slog.Warn("leaving a model out of Try another model", "model", entry, "err", err)
+			continue
+		}
+		sides = append(sides, compareSide{name: cmp.Or(t.Provider, "openai") + "/" + t.Model, provider: forPrompt(ctx, p, pr, t)})
+	}
+	if len(sides) < 2 {
+		return nil
+	}
+	return sides
+}
+
+// withCompare starts the model on the comparison screen, generating with every side.
+func (m tuiModel) withCompare(sides []compareSide) tuiModel {
+	m.compare = sides
+	m.state = stateCompare
+	return m
+}
+
+// generateCompareCmd starts one request per side; all share the cancel in m.inflight.
+func (m tuiModel) generateCompareCmd() tea.Cmd {
+	m.inflight.seq++
+	seq := m.inflight.seq
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	m.inflight.cancel = cancel
+
+	cmds := make([]tea.Cmd, len(m.compare))
+	for i, side := range m.compare {
+		cmds[i] = func() tea.Msg {
+			msg, err := generate.Message(ctx, side.provider, m.initialMsgs, m.temp, m.conventional)
+			return compareResultMsg{seq: seq, side: i, content: msg, err: err}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// comparePending reports whether any side is still generating.
+func (m tuiModel) comparePending() bool {
+	for _, s := range m.compare {
+		if !s.done {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCompare handles keys on the comparison screen.
+func (m tuiModel) updateCompare(msg tea.KeyMsg) (tuiModel, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "left", "h", "shift+tab":
+		if m.compareCursor > 0 {
+			m.compareCursor--
+		}
+	case "right", "l", "tab":
+		if m.compareCursor < len(m.compare)-1 {
+			m.compareCursor++
+		}
+	case "enter":
+		return m.pickCompared(m.compareCursor)
+	case "esc", "q":
+		for _, s := range m.compare {
+			if s.done && s.err == nil {
+				m.record(history.StatusRejected, s.message)
+				recordOutcome(s.provider, stats.OutcomeRejected)
+			}
+		}
+		if m.inflight.cancel != nil {
+			m.inflight.cancel()
+		}
+		m.quitting = true
+		return m, tea.Quit
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(m.compare) {
+			return m.pickCompared(int(key[0] - '1'))
+		}
+	}
+	return m, nil
+}
+
+// pickCompared continues with side i's message and provider; the other messages count
+// as rejected.
+func (m tuiModel) pickCompared(i int) (tuiModel, tea.Cmd) {
+	side := m.compare[i]
+	if !side.done || side.err != nil {
+		m.notice = i18n.Tf("%s has no message to pick.", side.name)
+		return m, nil
+	}
+	if m.inflight.cancel != nil {
+		m.inflight.cancel()
+	}
+	m.inflight.seq++ // drop results still on their way
+	for j, s := range m.compare {
+		if j != i && s.done && s.err == nil {
+			recordOutcome(s.provider, stats.OutcomeRejected)
+		}
+	}
+
+	m.provider = side.provider
+	m.commitMsg = side.message
+	m.suggested = side.message
+	m.generated = append(m.generated, side.message)
+	m.compare = nil
+	m.notice = ""
+	m.state = stateConfirm
+	m.cursor = actionCommit
+	return m.refreshViewport(), nil
+}
+
+// buildCompareContent shows the messages next to each other, or stacked when the
+// window is too narrow.
+func (m tuiModel) buildCompareContent() string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(styleMsgTitle.Render(i18n.T("Compare Models")))
+	b.WriteString("\n")
+
+	n := len(m.compare)
+	sideBySide := m.innerWidth() >= 40*n
+	width := m.innerWidth() - 4
+	if sideBySide {
+		width = m.innerWidth()/n - 4
+	}
+
+	panels := make([]string, n)
+	for i, s := range m.compare {
+		var body string
+		switch {
+		case !s.done:
+			body = m.spinner.View() + " " + i18n.T("Generating...")
+		case s.err != nil:
+			body = styleHint.Render("✗ " + s.err.Error())
+		default:
+			body = s.message
+		}
+		title := fmt.Sprintf("%d. %s", i+1, s.name)
+		style := stylePanel
+		if i == m.compareCursor {
+			title = styleSelected.Render(title)
+			style = stylePanelSelected
+		}
+		panels[i] = style.Width(width).Render(title + "\n\n" + body)
+	}
+	if sideBySide {
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, panels...))
+	} else {
+		b.WriteString(lipgloss.JoinVertical(lipgloss.Left, panels...))
+	}
+	b.WriteString("\n")
+
+	if m.notice != "" {
+		b.WriteString(styleHint.Render(" " + m.notice))
+		b.WriteString("\n")
+	}
+	b.WriteString(styleHint.Render(" " + i18n.Tf("←/→ to choose • Enter or 1-%d to pick • Esc to quit", n)))
+	b.WriteString("\n")
+	return b.String()
+}
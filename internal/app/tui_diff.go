@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+)
+
+var (
+	styleHunk    = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	styleDiffHdr = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Bold(true)
+)
+
+// openDiff shows the diff of the file under the cursor on the file selection screen.
+func (m tuiModel) openDiff() tuiModel {
+	m.diffView = viewport.New(m.innerWidth(), m.diffHeight())
+	m.diffView.SetContent(highlightDiff(m.files[m.fileCursor].change.Diff))
+	m.state = stateDiff
+	return m
+}
+
+// diffHeight is the height of the diff viewport: the window less the title and hints.
+func (m tuiModel) diffHeight() int {
+	return max(m.innerHeight()-5, 3)
+}
+
+// updateDiff handles keys in the diff viewer. Keys it doesn't use scroll the diff.
+func (m tuiModel) updateDiff(msg tea.Msg) (tuiModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case " ", "x":
+			m.files[m.fileCursor].selected = !m.files[m.fileCursor].selected
+			return m, nil
+		case "n", "tab":
+			if m.fileCursor < len(m.files)-1 {
+				m.fileCursor++
+				return m.openDiff(), nil
+			}
+			return m, nil
+		case "p", "shift+tab":
+			if m.fileCursor > 0 {
+				m.fileCursor--
+				return m.openDiff(), nil
+			}
+			return m, nil
+		case "enter":
+			return m.generateSelected()
+		case "esc", "q":
+			m.state = stateFiles
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.diffView, cmd = m.diffView.Update(msg)
+	return m, cmd
+}
+
+// buildDiffContent shows the diff of one file as it goes into the prompt.
+func (m tuiModel) buildDiffContent() string {
+	f := m.files[m.fileCursor]
+	var b strings.Builder
+
+	check := "[ ]"
+	if f.selected {
+		check = "[x]"
+	}
+	b.WriteString("\n")
+	b.WriteString(styleMsgTitle.Render(fmt.Sprintf("%s %s (%d/%d)", check, f.change.Path, m.fileCursor+1, len(m.files))))
+	b.WriteString(" " + styleAdded.Render(fmt.Sprintf("+%d", f.fact.Insertions)) + " " + styleDeleted.Render(fmt.Sprintf("-%d", f.fact.Deletions)))
+	b.WriteString("\n")
+	b.WriteString(m.diffView.View())
+	b.WriteString("\n")
+	hint := " " + i18n.T("Space to toggle • n/p for next/previous file • Enter to generate • Esc for the list")
+	if f.change.OriginalCode != "" {
+		hint += "\n " + i18n.T("The file's previous contents are sent too.")
+	} else {
+		hint += "\n"
+	}
+	b.WriteString(styleHint.Render(hint))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// highlightDiff colors a unified diff: additions, deletions, hunk headers, and
+// file headers.
+func highlightDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	inHunks := false // past a file's header, where "--- " and "+++ " are lines too
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			inHunks = false
+			lines[i] = styleDiffHdr.Render(l)
+		case !inHunks && (strings.HasPrefix(l, "+++ ") || strings.HasPrefix(l, "--- ") || strings.HasPrefix(l, "index ")):
+			lines[i] = styleDiffHdr.Render(l)
+		case strings.HasPrefix(l, "@@"):
+			inHunks = true
+			lines[i] = styleHunk.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = styleAdded.Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = styleDeleted.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+var (
+	styleAdded   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	styleDeleted = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
+
+// fileEntry is one staged file on the file selection screen.
+type fileEntry struct {
+	change   vscodeprompt.Change
+	fact     commitmsg.FileFact
+	summary  string
+	selected bool
+}
+
+// reprompter rebuilds the prompt, and the provider to use with it, for a subset of the changes.
+type reprompter func(data vscodeprompt.Data) (prompt, ai.Provider, error)
+
+// withFileSelection starts the model on a screen listing the changes in data, where
+// files can be left out of the message before it is generated.
+func (m tuiModel) withFileSelection(data vscodeprompt.Data, rebuild reprompter) tuiModel {
+	files := make([]commitmsg.DiffFile, len(data.Changes))
+	for i, ch := range data.Changes {
+		files[i] = commitmsg.DiffFile{Path: ch.Path, Diff: ch.Diff}
+	}
+	facts := commitmsg.ComputeFacts(files, data.BranchName)
+	m.files = make([]fileEntry, len(data.Changes))
+	for i, ch := range data.Changes {
+		ff := facts.Files[i]
+		m.files[i] = fileEntry{change: ch, fact: ff, summary: commitmsg.DescribeFile(ff), selected: true}
+	}
+	m.fileData = data
+	m.rebuild = rebuild
+	m.state = stateFiles
+	return m
+}
+
+// updateFiles handles keys on the file selection screen.
+func (m tuiModel) updateFiles(msg tea.KeyMsg) (tuiModel, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.fileCursor > 0 {
+			m.fileCursor--
+		}
+	case "down", "j":
+		if m.fileCursor < len(m.files)-1 {
+			m.fileCursor++
+		}
+	case " ", "x":
+		m.files[m.fileCursor].selected = !m.files[m.fileCursor].selected
+	case "a":
+		all := true
+		for _, f := range m.files {
+			all = all && f.selected
+		}
+		for i := range m.files {
+			m.files[i].selected = !all
+		}
+	case "d", "right", "l":
+		return m.openDiff(), nil
+	case "enter":
+		return m.generateSelected()
+	case "esc", "q":
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// generateSelected starts generating for the selected files, rebuilding the prompt
+// if some were left out.
+func (m tuiModel) generateSelected() (tuiModel, tea.Cmd) {
+	m, ok := m.useSelected()
+	if !ok {
+		return m, nil
+	}
+	return m, m.startGeneration()
+}
+
+// useSelected narrows the prompt to the selected files and moves on to generating.
+// With no file selected, or when the prompt can't be rebuilt, it stays on the file
+// selection screen with a notice.
+func (m tuiModel) useSelected() (tuiModel, bool) {
+	kept := make([]vscodeprompt.Change, 0, len(m.files))
+	for _, f := range m.files {
+		if f.selected {
+			kept = append(kept, f.change)
+		}
+	}
+	if len(kept) == 0 {
+		m.notice = i18n.T("Select at least one file.")
+		m.state = stateFiles
+		return m, false
+	}
+	if len(kept) < len(m.files) {
+		data := m.fileData
+		data.Changes = kept
+		pr, provider, err := m.rebuild(data)
+		if err != nil {
+			m.notice = i18n.Tf("Could not leave out the files: %v", err)
+			m.state = stateFiles
+			return m, false
+		}
+		m.initialMsgs, m.provider, m.diffHash = pr.msgs, provider, pr.diffHash()
+	}
+	m.notice = ""
+	m.state = stateGenerating
+	return m, true
+}
+
+// buildFilesContent lists the staged files with their diffstat and a short description,
+// scrolled so the cursor stays in view.
+func (m tuiModel) buildFilesContent() string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(styleMsgTitle.Render(i18n.Tf("Staged Files (%d)", len(m.files))))
+	b.WriteString("\n")
+
+	rows := max(m.innerHeight()-6, 1)
+	first := 0
+	if m.fileCursor >= rows {
+		first = m.fileCursor - rows + 1
+	}
+	last := min(first+rows, len(m.files))
+
+	barStr := styleBar.Render("┃")
+	maxW := m.innerWidth() - 8
+	for i := first; i < last; i++ {
+		f := m.files[i]
+		check := "[ ]"
+		if f.selected {
+			check = "[x]"
+		}
+		stat := styleAdded.Render(fmt.Sprintf("+%d", f.fact.Insertions)) + " " + styleDeleted.Render(fmt.Sprintf("-%d", f.fact.Deletions))
+		line := fmt.Sprintf("%s %s", check, f.change.Path)
+		plainLen := len([]rune(line)) + len(fmt.Sprintf(" +%d -%d", f.fact.Insertions, f.fact.Deletions))
+		summary := ""
+		if room := maxW - plainLen - 3; room > 10 {
+			summary = f.summary
+			if len([]rune(summary)) > room {
+				summary = string([]rune(summary)[:room-1]) + "…"
+			}
+			summary = styleHint.Render(" · " + summary)
+		}
+		if m.fileCursor == i {
+			line = styleSelected.Render(line)
+			b.WriteString(fmt.Sprintf("%s > %s %s%s\n", barStr, line, stat, summary))
+		} else {
+			b.WriteString(fmt.Sprintf("%s   %s %s%s\n", barStr, line, stat, summary))
+		}
+	}
+
+	b.WriteString("\n")
+	if m.notice != "" {
+		b.WriteString(styleHint.Render(" " + m.notice))
+		b.WriteString("\n")
+	}
+	b.WriteString(styleHint.Render(" " + i18n.T("Space to toggle • a for all • d to view the diff • Enter to generate • Esc to quit")))
+	b.WriteString("\n")
+	b.WriteString(styleHint.Render(" " + i18n.T("Deselected files are left out of the message but stay staged.")))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,433 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestCtrlCCancelsGeneration(t *testing.T) {
+	m := newTuiModel("", blockingProvider(), nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	cmd := m.generateCommitCmd()
+
+	done := make(chan tea.Msg)
+	go func() { done <- cmd() }()
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = next.(tuiModel)
+	if m.quitting || m.state != stateConfirm || m.cursor != actionRegenerate {
+		t.Fatalf("after Ctrl-C: quitting=%v state=%v cursor=%d", m.quitting, m.state, m.cursor)
+	}
+
+	var res tea.Msg
+	select {
+	case res = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("request was not canceled")
+	}
+	if r := res.(commitResultMsg); !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("result err = %v; want context.Canceled", r.err)
+	}
+
+	// The canceled request's result must not end the session.
+	next, _ = m.Update(res)
+	m = next.(tuiModel)
+	if m.state != stateConfirm || m.err != nil {
+		t.Fatalf("stale result changed state to %v (err %v)", m.state, m.err)
+	}
+
+	// A second Ctrl-C, outside generation, quits.
+	next, _ = m.Update(interruptMsg{})
+	if !next.(tuiModel).quitting {
+		t.Error("Ctrl-C on the actions did not quit")
+	}
+}
+
+func TestStreamedOutput(t *testing.T) {
+	// The message is streamed in two pieces.
+	streaming := &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		if stream := ai.StreamFunc(ctx); stream != nil {
+			stream("```text\nfeat: add ")
+			stream("streaming\n```")
+		}
+		return "```text\nfeat: add streaming\n```", nil
+	}}
+	m := newTuiModel("", streaming, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m.width, m.height = 80, 24
+	cmd := m.generateCommitCmd()
+	wait := waitForStream(m.inflight.seq, m.inflight.stream)
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	for i := 0; i < 2; i++ {
+		msg := wait()
+		if _, ok := msg.(streamMsg); !ok {
+			t.Fatalf("piece %d: got %#v; want streamMsg", i, msg)
+		}
+		next, c := m.Update(msg)
+		m, wait = next.(tuiModel), c
+	}
+	if got := streamPreview(m.streamed); got != "feat: add streaming" {
+		t.Errorf("preview = %q", got)
+	}
+	if v := m.View(); !strings.Contains(v, "feat: add streaming") {
+		t.Errorf("view does not show the streamed text:\n%s", v)
+	}
+
+	next, _ := m.Update(<-done)
+	m = next.(tuiModel)
+	if m.state != stateConfirm || m.commitMsg != "feat: add streaming" || m.streamed != "" {
+		t.Fatalf("after result: state=%v msg=%q streamed=%q", m.state, m.commitMsg, m.streamed)
+	}
+}
+
+func TestFileSelection(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{
+		{Path: "a.go", Diff: "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@ func A() {\n-x\n+y\n"},
+		{Path: "vendor/b.go", Diff: "--- a/vendor/b.go\n+++ b/vendor/b.go\n@@ -1 +1 @@\n-x\n+y\n"},
+	}}
+	var got vscodeprompt.Data
+	rebuilt := blockingProvider()
+	rebuild := func(d vscodeprompt.Data) (prompt, ai.Provider, error) {
+		got = d
+		return prompt{data: d, msgs: vscodeprompt.BuildVSCodeMessages(d)}, rebuilt, nil
+	}
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withFileSelection(data, rebuild)
+	m.width, m.height = 100, 30
+
+	if v := m.View(); !strings.Contains(v, "changes in func A()") || !strings.Contains(v, "vendor/b.go") {
+		t.Errorf("file list:\n%s", v)
+	}
+
+	for _, k := range []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeySpace}, {Type: tea.KeyEnter}} {
+		next, _ := m.Update(k)
+		m = next.(tuiModel)
+	}
+	if m.state != stateGenerating {
+		t.Fatalf("state = %v; want generating", m.state)
+	}
+	if len(got.Changes) != 1 || got.Changes[0].Path != "a.go" {
+		t.Fatalf("prompt rebuilt for %+v; want only a.go", got.Changes)
+	}
+	if m.provider != rebuilt {
+		t.Error("provider not replaced")
+	}
+	m.inflight.cancel()
+
+	// A prompt that can't be rebuilt keeps the file list, and the old provider unused.
+	m = newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withFileSelection(data, func(vscodeprompt.Data) (prompt, ai.Provider, error) {
+		return prompt{}, nil, errors.New("no template")
+	})
+	for _, k := range []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeySpace}, {Type: tea.KeyEnter}} {
+		next, _ := m.Update(k)
+		m = next.(tuiModel)
+	}
+	if m.state != stateFiles || !strings.Contains(m.notice, "no template") {
+		t.Errorf("state = %v, notice %q; want the file list with the error", m.state, m.notice)
+	}
+}
+
+func TestDiffViewer(t *testing.T) {
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{
+		{Path: "a.go", Diff: "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-old line\n+new line\n"},
+		{Path: "b.go", Diff: "--- a/b.go\n+++ b/b.go\n@@ -1 +1 @@\n-x\n+y\n"},
+	}}
+	var got vscodeprompt.Data
+	rebuild := func(d vscodeprompt.Data) (prompt, ai.Provider, error) {
+		got = d
+		return prompt{data: d, msgs: vscodeprompt.BuildVSCodeMessages(d)}, blockingProvider(), nil
+	}
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withFileSelection(data, rebuild)
+	m.width, m.height = 100, 30
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = next.(tuiModel)
+	if v := m.View(); m.state != stateDiff || !strings.Contains(v, "new line") || !strings.Contains(v, "a.go (1/2)") {
+		t.Fatalf("diff viewer (state %v):\n%s", m.state, v)
+	}
+
+	// Leave out the second file from its diff, then generate.
+	for _, k := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("n")}, {Type: tea.KeySpace}, {Type: tea.KeyEnter}} {
+		next, _ = m.Update(k)
+		m = next.(tuiModel)
+	}
+	if m.state != stateGenerating || len(got.Changes) != 1 || got.Changes[0].Path != "a.go" {
+		t.Fatalf("state = %v, prompt rebuilt for %+v; want generating for a.go", m.state, got.Changes)
+	}
+	m.inflight.cancel()
+}
+
+func TestHighlightDiff(t *testing.T) {
+	diff := "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n ctx\n-x\n+y\n"
+	if got := highlightDiff(diff); strings.Count(got, "\n") != 5 || !strings.Contains(got, " ctx") {
+		t.Errorf("highlightDiff changed the lines:\n%s", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	small := &scriptedProvider{reply: "feat: small model"}
+	m = m.withCompare([]compareSide{
+		{name: "openai/gpt-4o", provider: &scriptedProvider{reply: "feat: big model"}},
+		{name: "openai/gpt-4o-mini", provider: small},
+	})
+	m.width, m.height = 120, 30
+
+	batch, ok := m.generateCompareCmd()().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected one request per side, got %#v", batch)
+	}
+	for _, cmd := range batch {
+		next, _ := m.Update(cmd())
+		m = next.(tuiModel)
+	}
+	if v := m.View(); !strings.Contains(v, "feat: big model") || !strings.Contains(v, "feat: small model") {
+		t.Errorf("comparison does not show both messages:\n%s", v)
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = next.(tuiModel)
+	if m.state != stateConfirm || m.commitMsg != "feat: small model" || m.provider != small {
+		t.Fatalf("after picking 2: state=%v msg=%q provider=%v", m.state, m.commitMsg, m.provider)
+	}
+}
+
+func TestCompareTarget(t *testing.T) {
+	cfg := Config{Provider: "ollama", Model: "llama3.1", BaseURL: "http://gpu:11434"}
+	tests := map[string]Config{
+		"qwen2.5-coder:7b":   {Provider: "ollama", Model: "qwen2.5-coder:7b", BaseURL: "http://gpu:11434"},
+		"ollama:llama3.2:3b": {Provider: "ollama", Model: "llama3.2:3b", BaseURL: "http://gpu:11434"},
+		"openai:gpt-4o-mini": {Provider: "openai", Model: "gpt-4o-mini"},
+	}
+	for entry, want := range tests {
+		got, err := compareTarget(cfg, entry)
+		if err != nil || got.Provider != want.Provider || got.Model != want.Model || got.BaseURL != want.BaseURL {
+			t.Errorf("compareTarget(%q) = %+v, %v; want %+v", entry, got, err, want)
+		}
+	}
+}
+
+func TestPolicyBlocksCommit(t *testing.T) {
+	policy, err := commitmsg.NewPolicy(nil, nil, []string{"[core]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withPolicy(policy).withMessage("fix: handle empty input")
+	m.width, m.height = 100, 30
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(tuiModel)
+	if m.state != stateConfirm || cmd != nil || !strings.Contains(m.notice, "subject must start with one of: [core]") {
+		t.Fatalf("state=%v notice=%q; want the commit refused", m.state, m.notice)
+	}
+}
+
+func TestInvalidResultNotice(t *testing.T) {
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, true, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withRules(commitmsg.Rules{Conventional: true})
+	m.width, m.height = 100, 30
+
+	next, _ := m.Update(commitResultMsg{seq: m.inflight.seq, content: "fix:handle nil"})
+	m = next.(tuiModel)
+	if m.state != stateConfirm || !strings.Contains(m.notice, "expected a space after ':'") {
+		t.Errorf("state=%v notice=%q", m.state, m.notice)
+	}
+}
+
+func TestChangeType(t *testing.T) {
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, true, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withRules(commitmsg.Rules{Conventional: true, Types: []string{"feat", "fix", "test"}}).withType("test", false)
+	m.width, m.height = 100, 30
+
+	next, _ := m.Update(commitResultMsg{seq: m.inflight.seq, content: "feat: cover the parser"})
+	m = next.(tuiModel)
+	if !strings.Contains(m.buildConfirmContent(), "Type test, guessed from the changed files") {
+		t.Errorf("guessed type not shown:\n%s", m.buildConfirmContent())
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	m = next.(tuiModel)
+	if m.commitMsg != "fix: cover the parser" {
+		t.Errorf("after t: %q; want the next type, fix", m.commitMsg)
+	}
+	next, _ = m.Update(commitResultMsg{seq: m.inflight.seq, content: "feat: test the parser\n\nBody."})
+	if got := next.(tuiModel).commitMsg; got != "fix: test the parser\n\nBody." {
+		t.Errorf("regenerated: %q; want the chosen type kept", got)
+	}
+}
+
+func TestTryAnotherModel(t *testing.T) {
+	hookFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	cheap := &scriptedProvider{reply: "fix: change things"}
+	m := newTuiModel("", cheap, nil, 0, time.Minute, false, hookFile, "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m.width, m.height = 100, 30
+	m, _ = m.runAction(actionModel)
+	if m.state == stateModels || !strings.Contains(m.notice, "other_models") {
+		t.Errorf("without other models: state=%v notice=%q", m.state, m.notice)
+	}
+
+	m = m.withModels([]compareSide{
+		{name: "openai/gpt-4o-mini", provider: cheap},
+		{name: "anthropic/claude-3-5-sonnet-latest", provider: &scriptedProvider{reply: "fix(parser): handle empty input"}},
+	})
+	var out strings.Builder
+	m = runPlain(m, strings.NewReader("7\n2\n1\n"), &out)
+	if !m.applied() {
+		t.Fatalf("not applied: state=%v err=%v\n%s", m.state, m.err, out.String())
+	}
+	if b, _ := os.ReadFile(hookFile); string(b) != "fix(parser): handle empty input" {
+		t.Errorf("committed %q; want the other model's message", b)
+	}
+	if !strings.Contains(out.String(), "1. openai/gpt-4o-mini (in use)") || !strings.Contains(out.String(), "Model: anthropic/claude-3-5-sonnet-latest") {
+		t.Errorf("models not listed or switched:\n%s", out.String())
+	}
+	if m.modelIndex != 1 || len(m.rejected) != 1 {
+		t.Errorf("modelIndex=%d rejected=%q", m.modelIndex, m.rejected)
+	}
+}
+
+func TestQuickMode(t *testing.T) {
+	keys, err := parseKeybindings([]string{"regenerate=ctrl+r"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTuiModel("", &scriptedProvider{reply: "unused"}, nil, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = m.withKeys(keys, true).withMessage("fix: handle empty input")
+	m.width, m.height = 100, 30
+	m.cursor = actionCancel // ignored in quick mode
+
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = next.(tuiModel)
+	if v := m.View(); strings.Contains(v, "Action") || !strings.Contains(v, "ctrl+r regenerate") {
+		t.Errorf("quick mode view:\n%s", v)
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if next.(tuiModel).state != stateGenerating || cmd == nil {
+		t.Errorf("ctrl+r: state = %v; want generating", next.(tuiModel).state)
+	}
+	next.(tuiModel).inflight.cancel()
+
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if next.(tuiModel).state != stateCommitting || cmd == nil {
+		t.Errorf("Enter: state = %v; want committing", next.(tuiModel).state)
+	}
+}
+
+func TestPlainMode(t *testing.T) {
+	hookFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	m := newTuiModel("", &scriptedProvider{reply: "```text\nfeat: add parser\n```"}, nil, 0, time.Minute, false, hookFile, "h", filepath.Join(t.TempDir(), "h.jsonl"))
+
+	// Regenerate, then edit the message and commit it.
+	in := strings.NewReader("2\n3\nfix: handle empty input\n\nEmpty input used to panic.\n.\ny\n")
+	var out strings.Builder
+	m = runPlain(m, in, &out)
+	if !m.applied() {
+		t.Fatalf("not applied: state=%v err=%v\n%s", m.state, m.err, out.String())
+	}
+	if b, err := os.ReadFile(hookFile); err != nil || string(b) != "fix: handle empty input\n\nEmpty input used to panic." {
+		t.Errorf("hook file = %q (%v)", b, err)
+	}
+	if got := strings.Count(out.String(), "Generating commit message..."); got != 2 {
+		t.Errorf("generated %d times; want 2", got)
+	}
+	if !strings.Contains(out.String(), "1. Commit (Apply), shortcut y") {
+		t.Errorf("actions are not numbered:\n%s", out.String())
+	}
+
+	// End of input cancels.
+	m = newTuiModel("", &scriptedProvider{reply: "feat: add parser"}, nil, 0, time.Minute, false, hookFile, "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = runPlain(m.withKeys(m.keys, true), strings.NewReader("x\n"), &out)
+	if !m.quitting || m.applied() {
+		t.Errorf("after end of input: quitting=%v applied=%v", m.quitting, m.applied())
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "h.jsonl")
+	m := newTuiModel(t.TempDir(), &scriptedProvider{reply: "fix: don't crash"}, nil, 0, time.Minute, false, "", "h", historyPath).
+		withTrailers(trailerSet{fixed: []string{"Refs: PROJ-1"}}).withDryRun(true)
+	var out strings.Builder
+	m = runPlain(m, strings.NewReader("1\n"), &out)
+	if !m.applied() {
+		t.Fatalf("not applied: state=%v err=%v\n%s", m.state, m.err, out.String())
+	}
+	if want := `git commit -m 'fix: don'\''t crash
+
+Refs: PROJ-1'`; m.command != want {
+		t.Errorf("command = %s\nwant %s", m.command, want)
+	}
+	if strings.Contains(out.String(), "Committing...") {
+		t.Errorf("dry run said it was committing:\n%s", out.String())
+	}
+	entries, _ := history.Load(historyPath)
+	for _, e := range entries {
+		if e.Status == history.StatusAccepted {
+			t.Errorf("dry run recorded an accepted message: %+v", e)
+		}
+	}
+}
+
+func TestCommitRecordedWhenDone(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "h.jsonl")
+	m := newTuiModel("", &scriptedProvider{reply: "fix: x"}, nil, 0, time.Minute, false, "", "h", historyPath)
+	m.commitMsg = "fix: x"
+	m.Update(commitDoneMsg{err: errors.New("pre-commit hook failed")})
+	m.Update(commitDoneMsg{})
+
+	entries, _ := history.Load(historyPath)
+	var statuses []string
+	for _, e := range entries {
+		statuses = append(statuses, e.Status)
+	}
+	if !slices.Equal(statuses, []string{history.StatusFailed, history.StatusAccepted}) {
+		t.Errorf("statuses = %v; want failed, then accepted", statuses)
+	}
+}
+
+func TestRegenerateSendsRejected(t *testing.T) {
+	// Answers are numbered.
+	p := &scriptedProvider{script: func(ctx context.Context, user string, n int) (string, error) {
+		return fmt.Sprintf("```text\nfix: attempt %d\n```", n+1), nil
+	}}
+	msgs := vscodeprompt.BuildVSCodeMessages(vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "a.go", Diff: "+a\n"}}})
+	m := newTuiModel("", p, msgs, 0, time.Minute, false, "", "h", filepath.Join(t.TempDir(), "h.jsonl"))
+	m = update(m, m.generateCommitCmd()())
+	if len(p.last) != len(msgs) {
+		t.Fatalf("first request has %d messages; want %d", len(p.last), len(msgs))
+	}
+
+	for range maxRejectedTurns + 1 {
+		m, _ = m.runAction(actionRegenerate)
+		m = update(m, m.generateCommitCmd()())
+	}
+	if m.commitMsg != "fix: attempt 5" {
+		t.Fatalf("message = %q", m.commitMsg)
+	}
+	got := p.last[len(msgs):]
+	if len(got) != 2*maxRejectedTurns {
+		t.Fatalf("%d extra messages; want %d", len(got), 2*maxRejectedTurns)
+	}
+	if got[0].Role != vscodeprompt.RoleAssistant || got[0].Content[0].Text != "```text\nfix: attempt 2\n```" {
+		t.Errorf("oldest rejected turn = %+v; want attempt 2", got[0])
+	}
+	if last := got[len(got)-1]; last.Role != vscodeprompt.RoleUser || !strings.Contains(last.Content[0].Text, "rejected") {
+		t.Errorf("last turn = %+v; want the request for another message", last)
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/history"
+)
+
+// Watch regenerates a message in the background whenever the staged content changes.
+// Messages are stored in the history file, where Suggest picks them up instead of
+// waiting on the AI.
+//
+// The index is polled rather than watched with inotify & co: git replaces it by
+// renaming a lock file, and a stat every couple of seconds is cheap and portable.
+func Watch(ctx context.Context, cfg Config) error {
+	provider, err := newProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	repoRoot, err := gitx.ResolveRepoRoot(ctx, cfg.RepoArg)
+	if err != nil {
+		return err
+	}
+	cfg.RepoArg = repoRoot
+
+	indexPath, err := gitx.GitPath(ctx, repoRoot, "index")
+	if err != nil {
+		return err
+	}
+
+	interval := cfg.WatchInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	infof("Watching %s for staged changes (Ctrl+C to stop)\n", gitx.RepoNameFromRoot(repoRoot))
+
+	var lastMod time.Time
+	var lastSize int64
+	lastHash := ""
+	pending := true // generate once for whatever is staged right now
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		st, err := os.Stat(indexPath)
+		if err == nil && (!st.ModTime().Equal(lastMod) || st.Size() != lastSize) {
+			// Wait for the index to settle for one interval before generating.
+			lastMod, lastSize = st.ModTime(), st.Size()
+			pending = true
+		} else if pending {
+			pending = false
+			lastHash = watchGenerate(ctx, cfg, provider, lastHash)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchGenerate generates a message for the staged changes unless their hash equals
+// lastHash, and returns the hash of what is staged now.
+func watchGenerate(ctx context.Context, cfg Config, provider ai.Provider, lastHash string) string {
+	pr, err := preparePrompt(ctx, cfg)
+	if err != nil {
+		slog.Debug("watch: nothing to generate", "err", err)
+		return ""
+	}
+	hash := pr.diffHash()
+	if hash == lastHash {
+		return hash
+	}
+
+	genCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	msg, err := generate.Message(genCtx, forPrompt(genCtx, provider, pr, cfg), pr.msgs, cfg.Temperature, cfg.Conventional)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("watch: generation failed", "err", err)
+		}
+		return lastHash
+	}
+	msg = strings.TrimSpace(msg)
+
+	if err := history.Append(cfg.HistoryPath, history.Entry{
+		Repo:     gitx.RepoNameFromRoot(pr.repoRoot),
+		Root:     pr.repoRoot,
+		DiffHash: hash,
+		Status:   history.StatusGenerated,
+		Source:   history.SourceWatch,
+		Message:  msg,
+	}); err != nil {
+		slog.Warn("watch: could not save message", "err", err)
+	}
+
+	fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), commitmsg.Subject(msg))
+	return hash
+}
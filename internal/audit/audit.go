@@ -0,0 +1,120 @@
+// Package audit keeps an append-only log of what commitgen sends to model
+// providers, with secrets redacted, for teams that must account for what leaves
+// developer machines.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Events of an Entry. Each request is logged before it is sent, and its outcome
+// after, under the same ID.
+const (
+	EventRequest  = "request"
+	EventResponse = "response"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Endpoint   string    `json:"endpoint"`
+	User       string    `json:"user,omitempty"`
+	Messages   []Message `json:"messages,omitempty"`   // request: what was sent
+	Response   string    `json:"response,omitempty"`   // response: what came back
+	Error      string    `json:"error,omitempty"`      // response: why the request failed
+	LatencyMs  int64     `json:"latency_ms,omitempty"` // response
+	Redactions int       `json:"redactions,omitempty"` // secrets replaced in this entry
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+var mu sync.Mutex
+
+// Append writes e to the log at path, creating it if needed. The file is only
+// ever appended to.
+func Append(path string, e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Placeholder replaces each redacted secret.
+const Placeholder = "[REDACTED]"
+
+// secretPatterns match common credentials. Where a pattern has a group, only the
+// group is replaced, so the name of an assigned secret stays readable.
+var secretPatterns = []string{
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`,                             // OpenAI, Anthropic
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,                                      // AWS access key IDs
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{60,}`,      // GitHub
+	`\bglpat-[A-Za-z0-9_-]{20,}`,                                         // GitLab
+	`\bxox[abpors]-[A-Za-z0-9-]{10,}`,                                    // Slack
+	`\bAIza[0-9A-Za-z_-]{35}\b`,                                          // Google
+	`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`, // JWTs
+	`(?i)\b[a-z0-9_.-]*(?:password|passwd|secret|token|api[_-]?key)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([^\s"'\[=][^\s"']{5,})`,
+	`(?i)://[^/\s:@]+:([^/\s@]+)@`, // passwords in URLs
+}
+
+// Redactor replaces secrets in text with Placeholder.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor for the built-in patterns and the extra regular
+// expressions in extra.
+func NewRedactor(extra []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range append(append([]string{}, secretPatterns...), extra...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("audit_redact: invalid pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with secrets replaced, and how many were.
+func (r *Redactor) Redact(s string) (string, int) {
+	n := 0
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(m string) string {
+			n++
+			sub := re.FindStringSubmatchIndex(m)
+			if len(sub) >= 4 && sub[2] >= 0 {
+				return m[:sub[2]] + Placeholder + m[sub[3]:]
+			}
+			return Placeholder
+		})
+	}
+	return s, n
+}
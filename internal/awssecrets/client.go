@@ -0,0 +1,188 @@
+// Package awssecrets reads secrets from AWS Secrets Manager and SSM Parameter
+// Store with the ambient AWS credentials, found the way the AWS CLI finds them.
+package awssecrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Region  string // default: the ARN's region, AWS_REGION, AWS_DEFAULT_REGION, or the profile's
+	Profile string // default: AWS_PROFILE, else "default"
+}
+
+// Client reads secrets, keeping each one in memory for the life of the process.
+type Client struct {
+	cfg     Config
+	http    *http.Client
+	imdsURL string
+	// endpoint returns the URL of service in region; replaced in tests.
+	endpoint func(service, region string) string
+
+	mu      sync.Mutex
+	creds   credentials
+	secrets map[string]string
+}
+
+func New(cfg Config) *Client {
+	return &Client{
+		cfg:     cfg,
+		http:    &http.Client{Timeout: 30 * time.Second},
+		imdsURL: "http://169.254.169.254",
+		endpoint: func(service, region string) string {
+			return "https://" + service + "." + region + ".amazonaws.com/"
+		},
+		secrets: map[string]string{},
+	}
+}
+
+// Secret returns a Secrets Manager secret's string: "NAME#KEY" or "ARN#KEY", where
+// #KEY picks a key of a secret stored as JSON.
+func (c *Client) Secret(ctx context.Context, ref string) (string, error) {
+	id, key, _ := strings.Cut(ref, "#")
+	if id == "" {
+		return "", fmt.Errorf("aws: invalid secret reference %q (want NAME or ARN, then optionally #KEY)", ref)
+	}
+	v, err := c.cached(ctx, "secretsmanager", id, func(ctx context.Context, region string) (string, error) {
+		var out struct {
+			SecretString string
+		}
+		err := c.call(ctx, "secretsmanager", region, "secretsmanager.GetSecretValue", map[string]any{"SecretId": id}, &out)
+		return out.SecretString, err
+	})
+	if err != nil || key == "" {
+		return v, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(v), &fields); err != nil {
+		return "", fmt.Errorf("aws: secret %s is not JSON, so it has no key %q", id, key)
+	}
+	s, ok := fields[key].(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("aws: secret %s has no key %q", id, key)
+	}
+	return s, nil
+}
+
+// Parameter returns the value of an SSM parameter, decrypted if it is a
+// SecureString: a name such as "/commitgen/openai", or an ARN.
+func (c *Client) Parameter(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("aws: empty parameter name")
+	}
+	return c.cached(ctx, "ssm", name, func(ctx context.Context, region string) (string, error) {
+		var out struct {
+			Parameter struct {
+				Value string
+			}
+		}
+		err := c.call(ctx, "ssm", region, "AmazonSSM.GetParameter", map[string]any{"Name": name, "WithDecryption": true}, &out)
+		return out.Parameter.Value, err
+	})
+}
+
+// cached returns the value fetch gets for id from service, fetching it once.
+func (c *Client) cached(ctx context.Context, service, id string, fetch func(context.Context, string) (string, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := service + ":" + id
+	if v, ok := c.secrets[key]; ok {
+		return v, nil
+	}
+	region := c.region(id)
+	if region == "" {
+		return "", fmt.Errorf("aws: no region for %s. Set aws_region, or env AWS_REGION", id)
+	}
+	if err := checkRegion(region); err != nil {
+		return "", err
+	}
+	v, err := fetch(ctx, region)
+	if err != nil {
+		return "", fmt.Errorf("aws: read %s: %w", id, err)
+	}
+	if v == "" {
+		return "", fmt.Errorf("aws: %s has no string value", id)
+	}
+	c.secrets[key] = v
+	return v, nil
+}
+
+// reRegion matches a region name. The region becomes part of the host requests go
+// to, so anything else, such as "evil.example/x", is refused.
+var reRegion = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// checkRegion returns an error if region is not a region name.
+func checkRegion(region string) error {
+	if !reRegion.MatchString(region) {
+		return fmt.Errorf("aws: invalid region %q", region)
+	}
+	return nil
+}
+
+// region returns the region of an ARN, else the configured one.
+func (c *Client) region(id string) string {
+	if parts := strings.Split(id, ":"); len(parts) >= 6 && parts[0] == "arn" && parts[3] != "" {
+		return parts[3]
+	}
+	return c.defaultRegion()
+}
+
+func (c *Client) defaultRegion() string {
+	for _, r := range []string{c.cfg.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")} {
+		if r != "" {
+			return r
+		}
+	}
+	return c.profileSettings()["region"]
+}
+
+// call makes a signed AWS JSON 1.1 API request. c.mu must be held.
+func (c *Client) call(ctx context.Context, service, region, target string, in, out any) error {
+	if !c.creds.valid() {
+		creds, err := c.loadCredentials(ctx)
+		if err != nil {
+			return err
+		}
+		c.creds = creds
+	}
+	body, _ := json.Marshal(in)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(service, region), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	sign(req, body, c.creds, region, service, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+			Upper   string `json:"Message"`
+		}
+		_ = json.Unmarshal(b, &e)
+		typ := e.Type[strings.LastIndex(e.Type, "#")+1:]
+		msg := e.Message + e.Upper
+		if typ == "" && msg == "" {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("status %d: %s: %s", resp.StatusCode, typ, msg)
+	}
+	return json.Unmarshal(b, out)
+}
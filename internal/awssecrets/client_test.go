@@ -0,0 +1,118 @@
+package awssecrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretAndParameter(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	var regions []string
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("unsigned request: %q", auth)
+		}
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			switch in["SecretId"] {
+			case "commitgen/keys", "arn:aws:secretsmanager:us-east-2:123456789012:secret:commitgen/keys-AbCdEf":
+				_, _ = w.Write([]byte(`{"SecretString":"{\"openai\":\"sk-1\",\"anthropic\":\"sk-2\"}"}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","Message":"Secrets Manager can't find the specified secret."}`))
+			}
+		case "AmazonSSM.GetParameter":
+			if in["WithDecryption"] != true {
+				t.Error("parameter read without decryption")
+			}
+			_, _ = w.Write([]byte(`{"Parameter":{"Name":"/commitgen/gemini","Type":"SecureString","Value":"g-1"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{})
+	c.endpoint = func(service, region string) string {
+		regions = append(regions, service+"/"+region)
+		return srv.URL + "/"
+	}
+	ctx := context.Background()
+	for _, tt := range []struct{ ref, want string }{
+		{"commitgen/keys#openai", "sk-1"},
+		{"arn:aws:secretsmanager:us-east-2:123456789012:secret:commitgen/keys-AbCdEf#anthropic", "sk-2"},
+	} {
+		if got, err := c.Secret(ctx, tt.ref); err != nil || got != tt.want {
+			t.Errorf("Secret(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+		}
+	}
+	if got, err := c.Parameter(ctx, "/commitgen/gemini"); err != nil || got != "g-1" {
+		t.Errorf("Parameter = %q, %v", got, err)
+	}
+	if got, err := c.Secret(ctx, "commitgen/keys"); err != nil || !strings.Contains(got, `"openai"`) {
+		t.Errorf("whole secret = %q, %v", got, err)
+	}
+	if calls != 3 {
+		t.Errorf("%d API calls, want 3 (one per secret)", calls)
+	}
+	if want := "secretsmanager/eu-west-1 secretsmanager/us-east-2 ssm/eu-west-1"; strings.Join(regions, " ") != want {
+		t.Errorf("regions = %v", regions)
+	}
+
+	if _, err := c.Secret(ctx, "arn:aws:secretsmanager:evil.example/x:123456789012:secret:k"); err == nil || !strings.Contains(err.Error(), "invalid region") {
+		t.Errorf("ARN with a host as its region: err = %v", err)
+	}
+	if _, err := c.Secret(ctx, "commitgen/keys#gitlab"); err == nil {
+		t.Error("missing key: expected an error")
+	}
+	_, err := c.Secret(ctx, "missing")
+	if err == nil || err.Error() != "aws: read missing: status 400: ResourceNotFoundException: Secrets Manager can't find the specified secret." {
+		t.Errorf("missing secret: err = %v", err)
+	}
+}
+
+func TestProfileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "work")
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config", "[default]\nregion = us-east-1\n\n[profile work]\nregion = ap-southeast-1\n\n[profile sso]\ncredential_process = echo '{\"Version\":1,\"AccessKeyId\":\"AKSSO\",\"SecretAccessKey\":\"s\",\"SessionToken\":\"t\"}'\n")
+	write("credentials", "[default]\naws_access_key_id = AKDEFAULT\naws_secret_access_key = d\n\n[work]\n# team account\naws_access_key_id = AKWORK\naws_secret_access_key = w\n")
+
+	c := New(Config{})
+	creds, err := c.loadCredentials(context.Background())
+	if err != nil || creds.AccessKeyID != "AKWORK" || creds.SecretAccessKey != "w" {
+		t.Errorf("work profile: %+v, %v", creds, err)
+	}
+	if r := c.defaultRegion(); r != "ap-southeast-1" {
+		t.Errorf("region = %q", r)
+	}
+
+	c = New(Config{Profile: "sso"})
+	creds, err = c.loadCredentials(context.Background())
+	if err != nil || creds.AccessKeyID != "AKSSO" || creds.SessionToken != "t" {
+		t.Errorf("credential_process: %+v, %v", creds, err)
+	}
+}
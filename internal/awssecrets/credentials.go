@@ -0,0 +1,327 @@
+package awssecrets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// refreshBefore is how long before they expire cached credentials are replaced.
+const refreshBefore = 5 * time.Minute
+
+type credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero for long-lived keys
+}
+
+func (c credentials) valid() bool {
+	return c.AccessKeyID != "" && (c.Expires.IsZero() || time.Until(c.Expires) > refreshBefore)
+}
+
+// loadCredentials finds credentials the way the AWS CLI and SDKs do: environment
+// variables, a web identity token (EKS), the profile in the shared credentials and
+// config files (keys or credential_process), the ECS container endpoint, then the
+// EC2 instance metadata service.
+func (c *Client) loadCredentials(ctx context.Context) (credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return credentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	if tokenFile, role := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && role != "" {
+		return c.webIdentity(ctx, tokenFile, role)
+	}
+	if p := c.profileSettings(); p != nil {
+		if p["aws_access_key_id"] != "" && p["aws_secret_access_key"] != "" {
+			return credentials{AccessKeyID: p["aws_access_key_id"], SecretAccessKey: p["aws_secret_access_key"], SessionToken: p["aws_session_token"]}, nil
+		}
+		if command := p["credential_process"]; command != "" {
+			return credentialProcess(ctx, command)
+		}
+	}
+	if rel, full := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"), os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); rel != "" || full != "" {
+		return c.containerCredentials(ctx, rel, full)
+	}
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		if creds, err := c.instanceCredentials(ctx); err == nil {
+			return creds, nil
+		}
+	}
+	return credentials{}, errors.New("no AWS credentials found (environment, profile, container, or instance metadata)")
+}
+
+// profile returns the profile in use: Config.Profile, AWS_PROFILE, or "default".
+func (c *Client) profile() string {
+	if c.cfg.Profile != "" {
+		return c.cfg.Profile
+	}
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// profileSettings merges the profile's sections of the shared config and
+// credentials files, the latter winning, or returns nil if neither has it.
+func (c *Client) profileSettings() map[string]string {
+	home, _ := os.UserHomeDir()
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = filepath.Join(home, ".aws", "config")
+	}
+	credsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credsFile == "" {
+		credsFile = filepath.Join(home, ".aws", "credentials")
+	}
+
+	name := c.profile()
+	configSection := "profile " + name
+	if name == "default" {
+		configSection = "default"
+	}
+	settings := readINISection(configFile, configSection)
+	for k, v := range readINISection(credsFile, name) {
+		if settings == nil {
+			settings = map[string]string{}
+		}
+		settings[k] = v
+	}
+	return settings
+}
+
+// readINISection returns the keys of [section] in an AWS-style INI file, or nil.
+func readINISection(path, section string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var out map[string]string
+	in := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			in = strings.TrimSpace(line[1:len(line)-1]) == section
+			if in && out == nil {
+				out = map[string]string{}
+			}
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); in && ok {
+			out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+// credentialProcess runs a profile's credential_process, which prints credentials
+// as JSON; `aws configure export-credentials --format process` bridges SSO logins.
+func credentialProcess(ctx context.Context, command string) (credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return credentials{}, fmt.Errorf("credential_process: %v: %s", err, msg)
+		}
+		return credentials{}, fmt.Errorf("credential_process: %w", err)
+	}
+	var out struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		SessionToken    string
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return credentials{}, fmt.Errorf("credential_process: invalid output: %w", err)
+	}
+	return credentials{AccessKeyID: out.AccessKeyID, SecretAccessKey: out.SecretAccessKey, SessionToken: out.SessionToken, Expires: out.Expiration}, nil
+}
+
+// webIdentity exchanges the OIDC token in tokenFile for the role's credentials
+// with STS, as on EKS with IAM roles for service accounts.
+func (c *Client) webIdentity(ctx context.Context, tokenFile, role string) (credentials, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return credentials{}, fmt.Errorf("web identity token: %w", err)
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "commitgen"
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {role},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := "https://sts.amazonaws.com/"
+	if region := c.defaultRegion(); region != "" {
+		if err := checkRegion(region); err != nil {
+			return credentials{}, err
+		}
+		endpoint = c.endpoint("sts", region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return credentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return credentials{}, fmt.Errorf("assume role with web identity: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `xml:"Error>Message"`
+		}
+		_ = xml.Unmarshal(b, &e)
+		return credentials{}, fmt.Errorf("assume role with web identity: status %d: %s", resp.StatusCode, e.Message)
+	}
+	var out struct {
+		Creds struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(b, &out); err != nil {
+		return credentials{}, fmt.Errorf("assume role with web identity: %w", err)
+	}
+	cr := out.Creds
+	return credentials{AccessKeyID: cr.AccessKeyID, SecretAccessKey: cr.SecretAccessKey, SessionToken: cr.SessionToken, Expires: cr.Expiration}, nil
+}
+
+// metadataCredentials is the JSON of the container and instance endpoints.
+type metadataCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      time.Time
+}
+
+func (m metadataCredentials) credentials() credentials {
+	return credentials{AccessKeyID: m.AccessKeyID, SecretAccessKey: m.SecretAccessKey, SessionToken: m.Token, Expires: m.Expiration}
+}
+
+// containerCredentials asks the ECS (or EKS Pod Identity) credentials endpoint.
+func (c *Client) containerCredentials(ctx context.Context, rel, full string) (credentials, error) {
+	u := full
+	if rel != "" {
+		u = "http://169.254.170.2" + rel
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return credentials{}, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if path := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return credentials{}, fmt.Errorf("container credentials: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	var m metadataCredentials
+	if err := c.getJSON(req, &m); err != nil {
+		return credentials{}, fmt.Errorf("container credentials: %w", err)
+	}
+	return m.credentials(), nil
+}
+
+// instanceCredentials asks the EC2 instance metadata service (IMDSv2) for the
+// instance role's credentials. It gives up quickly off EC2.
+func (c *Client) instanceCredentials(ctx context.Context) (credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	base := c.imdsURL
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/latest/api/token", nil)
+	if err != nil {
+		return credentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return credentials{}, err
+	}
+	token, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return credentials{}, fmt.Errorf("instance metadata token: status %d", resp.StatusCode)
+	}
+
+	get := func(path string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err == nil {
+			req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		}
+		return req, err
+	}
+	req, err = get("")
+	if err != nil {
+		return credentials{}, err
+	}
+	resp, err = c.http.Do(req)
+	if err != nil {
+		return credentials{}, err
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	role, _, _ := strings.Cut(strings.TrimSpace(string(b)), "\n")
+	if resp.StatusCode != http.StatusOK || role == "" {
+		return credentials{}, errors.New("no instance role")
+	}
+	if req, err = get(role); err != nil {
+		return credentials{}, err
+	}
+	var m metadataCredentials
+	if err := c.getJSON(req, &m); err != nil {
+		return credentials{}, fmt.Errorf("instance credentials: %w", err)
+	}
+	return m.credentials(), nil
+}
+
+func (c *Client) getJSON(req *http.Request, out any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,83 @@
+package awssecrets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sign adds an AWS Signature Version 4 Authorization header to req, whose body is
+// payload. Host, X-Amz-Date, and any Content-Type and X-Amz-* headers are signed.
+func sign(req *http.Request, payload []byte, creds credentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req),
+		canonHeaders.String(),
+		signed,
+		hexSHA256(payload),
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonical))
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	for _, s := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signed+", Signature="+sig)
+}
+
+// canonicalQuery returns the query sorted by key, with spaces encoded as %20.
+func canonicalQuery(req *http.Request) string {
+	q := req.URL.Query()
+	if len(q) == 0 {
+		return ""
+	}
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,19 @@
+package awssecrets
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// The "get-vanilla" case of the AWS Signature Version 4 test suite.
+func TestSignGetVanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	sign(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resource is the Azure OpenAI (Cognitive Services) resource tokens are requested for.
+const Resource = "https://cognitiveservices.azure.com"
+
+// refreshBefore is how long before it expires a cached token is replaced.
+const refreshBefore = 5 * time.Minute
+
+// Auth values for Config.Auth. The other credentials of the Azure SDKs, such as
+// workload identity and the Azure CLI's login, are not supported.
+const (
+	AuthClientCredentials = "client_credentials" // an app registration's client ID and secret
+	AuthManagedIdentity   = "managed_identity"   // the identity of the Azure VM, App Service, container, ...
+)
+
+type Config struct {
+	Auth         string // AuthClientCredentials or AuthManagedIdentity
+	TenantID     string // client credentials
+	ClientID     string // client credentials; for managed identity, selects a user-assigned identity
+	ClientSecret string // client credentials
+}
+
+// Credential gets Microsoft Entra ID (Azure AD) access tokens for Azure OpenAI and
+// keeps them until shortly before they expire, so long-running commands such as
+// serve and watch keep working without long-lived keys.
+type Credential struct {
+	cfg  Config
+	http *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func New(cfg Config) (*Credential, error) {
+	switch cfg.Auth {
+	case AuthClientCredentials:
+		if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, errors.New("azure client credentials need a tenant ID, client ID and client secret")
+		}
+	case AuthManagedIdentity:
+	default:
+		return nil, fmt.Errorf("unknown azure auth %q (use: %s | %s)", cfg.Auth, AuthClientCredentials, AuthManagedIdentity)
+	}
+	return &Credential{cfg: cfg, http: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// Token returns a valid access token, requesting a new one when needed.
+func (c *Credential) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Until(c.expires) > refreshBefore {
+		return c.token, nil
+	}
+	var tok tokenResponse
+	var err error
+	if c.cfg.Auth == AuthClientCredentials {
+		tok, err = c.clientCredentials(ctx)
+	} else {
+		tok, err = c.managedIdentity(ctx)
+	}
+	if err != nil {
+		return "", fmt.Errorf("azure: %w", err)
+	}
+	c.token, c.expires = tok.AccessToken, tok.expiry()
+	return c.token, nil
+}
+
+type tokenResponse struct {
+	AccessToken string          `json:"access_token"`
+	ExpiresIn   json.RawMessage `json:"expires_in"` // seconds; a number or a string depending on the endpoint
+	ExpiresOn   json.RawMessage `json:"expires_on"` // Unix time, from managed identity endpoints
+	Error       string          `json:"error"`
+	Description string          `json:"error_description"`
+}
+
+// expiry returns when the token expires, erring early if the response doesn't say.
+func (t tokenResponse) expiry() time.Time {
+	if on := jsonInt(t.ExpiresOn); on > 0 {
+		return time.Unix(on, 0)
+	}
+	if in := jsonInt(t.ExpiresIn); in > 0 {
+		return time.Now().Add(time.Duration(in) * time.Second)
+	}
+	return time.Now().Add(refreshBefore + time.Minute)
+}
+
+func jsonInt(raw json.RawMessage) int64 {
+	n, _ := strconv.ParseInt(strings.Trim(string(raw), `"`), 10, 64)
+	return n
+}
+
+// clientCredentials uses the OAuth 2.0 client credentials grant of the tenant.
+// AZURE_AUTHORITY_HOST selects a sovereign cloud, as with the Azure SDKs.
+func (c *Credential) clientCredentials(ctx context.Context) (tokenResponse, error) {
+	authority := strings.TrimRight(os.Getenv("AZURE_AUTHORITY_HOST"), "/")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.cfg.ClientID},
+		"client_secret": {c.cfg.ClientSecret},
+		"scope":         {Resource + "/.default"},
+	}
+	u := authority + "/" + url.PathEscape(c.cfg.TenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.do(req)
+}
+
+// managedIdentity asks the platform for a token: the App Service, Functions and
+// Container Apps endpoint when the environment names one, else the VM's instance
+// metadata service.
+func (c *Credential) managedIdentity(ctx context.Context) (tokenResponse, error) {
+	q := url.Values{"resource": {Resource}}
+	if c.cfg.ClientID != "" {
+		q.Set("client_id", c.cfg.ClientID)
+	}
+	var u, header, value string
+	if endpoint, secret := os.Getenv("IDENTITY_ENDPOINT"), os.Getenv("IDENTITY_HEADER"); endpoint != "" && secret != "" {
+		q.Set("api-version", "2019-08-01")
+		u, header, value = endpoint+"?"+q.Encode(), "X-IDENTITY-HEADER", secret
+	} else {
+		q.Set("api-version", "2018-02-01")
+		u, header, value = "http://169.254.169.254/metadata/identity/oauth2/token?"+q.Encode(), "Metadata", "true"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set(header, value)
+	return c.do(req)
+}
+
+func (c *Credential) do(req *http.Request) (tokenResponse, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	var tok tokenResponse
+	if err := json.Unmarshal(b, &tok); err != nil {
+		return tokenResponse{}, fmt.Errorf("decode token response (status %d): %w", resp.StatusCode, err)
+	}
+	if tok.Error != "" {
+		return tokenResponse{}, fmt.Errorf("%s: %s", tok.Error, tok.Description)
+	}
+	if resp.StatusCode >= 300 || tok.AccessToken == "" {
+		return tokenResponse{}, fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+	return tok, nil
+}
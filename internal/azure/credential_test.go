@@ -0,0 +1,95 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Auth: AuthClientCredentials, TenantID: "t", ClientID: "c"}); err == nil {
+		t.Error("client credentials without a secret accepted")
+	}
+	if _, err := New(Config{Auth: "azure_cli"}); err == nil || !strings.Contains(err.Error(), "unknown azure auth") {
+		t.Errorf("unknown auth: err = %v", err)
+	}
+	if _, err := New(Config{Auth: AuthManagedIdentity}); err != nil {
+		t.Errorf("managed identity: %v", err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" || r.PostForm.Get("grant_type") != "client_credentials" ||
+			r.PostForm.Get("client_id") != "app" || r.PostForm.Get("client_secret") != "s3cret" ||
+			r.PostForm.Get("scope") != Resource+"/.default" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.PostForm)
+		}
+		fmt.Fprintf(w, `{"access_token":"tok%d","expires_in":3600}`, requests)
+	}))
+	defer srv.Close()
+	t.Setenv("AZURE_AUTHORITY_HOST", srv.URL+"/")
+
+	c, err := New(Config{Auth: AuthClientCredentials, TenantID: "tenant", ClientID: "app", ClientSecret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if tok, err := c.Token(context.Background()); err != nil || tok != "tok1" {
+			t.Fatalf("Token = %q, %v; want tok1, cached the second time", tok, err)
+		}
+	}
+
+	// A token about to expire is replaced.
+	c.expires = time.Now().Add(refreshBefore - time.Second)
+	if tok, err := c.Token(context.Background()); err != nil || tok != "tok2" {
+		t.Errorf("Token near expiry = %q, %v; want a new one", tok, err)
+	}
+}
+
+func TestManagedIdentity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Header.Get("X-IDENTITY-HEADER") != "hdr" || q.Get("resource") != Resource || q.Get("client_id") != "user-assigned" {
+			t.Errorf("unexpected request %s, header %q", r.URL, r.Header.Get("X-IDENTITY-HEADER"))
+		}
+		fmt.Fprintf(w, `{"access_token":"mi","expires_on":"%d"}`, time.Now().Add(time.Hour).Unix())
+	}))
+	defer srv.Close()
+	t.Setenv("IDENTITY_ENDPOINT", srv.URL)
+	t.Setenv("IDENTITY_HEADER", "hdr")
+
+	c, err := New(Config{Auth: AuthManagedIdentity, ClientID: "user-assigned"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok, err := c.Token(context.Background()); err != nil || tok != "mi" {
+		t.Errorf("Token = %q, %v; want mi", tok, err)
+	}
+	if time.Until(c.expires) < 50*time.Minute {
+		t.Errorf("expires = %v; want the expires_on of the response", c.expires)
+	}
+}
+
+func TestTokenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad secret"}`)
+	}))
+	defer srv.Close()
+	t.Setenv("AZURE_AUTHORITY_HOST", srv.URL)
+
+	c, _ := New(Config{Auth: AuthClientCredentials, TenantID: "t", ClientID: "c", ClientSecret: "wrong"})
+	if _, err := c.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid_client: bad secret") {
+		t.Errorf("err = %v; want the error of the response", err)
+	}
+}
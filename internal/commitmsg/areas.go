@@ -0,0 +1,121 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Area is a group of changed files that look related, under one or more top-level
+// directories.
+type Area struct {
+	Name  string // the directories, e.g. "api/" or "api/, cmd/"
+	Paths []string
+}
+
+var (
+	reDeclared   = regexp.MustCompile(`\b(?:func|type|class|def|function|interface|struct|enum|trait|fn|const|let|var|module|message|service)\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w{2,})`)
+	reIdentifier = regexp.MustCompile(`[A-Za-z_]\w{2,}`)
+)
+
+// UnrelatedAreas splits files into areas that look unrelated, such as a backend fix
+// staged with an unrelated tool: their top-level directories differ and nothing
+// declared in one area's diff is mentioned in another's. Each directory must declare
+// something, or there is no telling. Files at the repository
+// root, such as a README or go.mod, go with any change and join no area. It returns
+// nil when the files form a single area.
+func UnrelatedAreas(files []DiffFile) []Area {
+	var dirs []string
+	paths := map[string][]string{}
+	declared := map[string]map[string]bool{}  // per directory
+	mentioned := map[string]map[string]bool{} // per directory
+	for _, f := range files {
+		dir, _, nested := strings.Cut(f.Path, "/")
+		if !nested {
+			continue
+		}
+		if _, seen := paths[dir]; !seen {
+			dirs = append(dirs, dir)
+			declared[dir], mentioned[dir] = map[string]bool{}, map[string]bool{}
+		}
+		paths[dir] = append(paths[dir], f.Path)
+		for _, ln := range strings.Split(f.Diff, "\n") {
+			if strings.HasPrefix(ln, "+++ ") || strings.HasPrefix(ln, "--- ") {
+				continue
+			}
+			for _, m := range reDeclared.FindAllStringSubmatch(ln, -1) {
+				declared[dir][m[1]] = true
+			}
+			if strings.HasPrefix(ln, "+") || strings.HasPrefix(ln, "-") || strings.HasPrefix(ln, " ") {
+				for _, id := range reIdentifier.FindAllString(ln[1:], -1) {
+					mentioned[dir][id] = true
+				}
+			}
+		}
+	}
+	if len(dirs) < 2 {
+		return nil
+	}
+	// A directory whose diff declares nothing can't be told apart from the others.
+	for _, d := range dirs {
+		if len(declared[d]) == 0 {
+			return nil
+		}
+	}
+
+	// Directories sharing a symbol end up in the same group.
+	group := make(map[string]string, len(dirs))
+	var find func(d string) string
+	find = func(d string) string {
+		if group[d] == "" || group[d] == d {
+			return d
+		}
+		return find(group[d])
+	}
+	for _, a := range dirs {
+		for _, b := range dirs {
+			if a == b || find(a) == find(b) {
+				continue
+			}
+			for sym := range declared[a] {
+				if mentioned[b][sym] {
+					group[find(b)] = find(a)
+					break
+				}
+			}
+		}
+	}
+
+	var areas []Area
+	index := map[string]int{}
+	for _, d := range dirs {
+		root := find(d)
+		i, ok := index[root]
+		if !ok {
+			i = len(areas)
+			index[root] = i
+			areas = append(areas, Area{})
+		}
+		if areas[i].Name != "" {
+			areas[i].Name += ", "
+		}
+		areas[i].Name += d + "/"
+		areas[i].Paths = append(areas[i].Paths, paths[d]...)
+	}
+	if len(areas) < 2 {
+		return nil
+	}
+	return areas
+}
+
+// DescribeAreas names areas with their file counts, e.g. "api/ (3 files) and web/ (1 file)".
+func DescribeAreas(areas []Area) string {
+	parts := make([]string, len(areas))
+	for i, a := range areas {
+		parts[i] = fmt.Sprintf("%s (%d file%s)", a.Name, len(a.Paths), plural(len(a.Paths)))
+	}
+	if len(parts) <= 2 {
+		return strings.Join(parts, " and ")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
@@ -0,0 +1,39 @@
+package commitmsg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnrelatedAreas(t *testing.T) {
+	names := func(files ...DiffFile) []string {
+		var out []string
+		for _, a := range UnrelatedAreas(files) {
+			out = append(out, a.Name)
+		}
+		return out
+	}
+
+	api := DiffFile{"api/users.go", "+func ListUsers(w http.ResponseWriter) {\n+}\n"}
+	cli := DiffFile{"cmd/users.go", "+\tusers := api.ListUsers(w)\n"}
+	script := DiffFile{"scripts/release.py", "+def bump_version(path):\n+    pass\n"}
+	readme := DiffFile{"README.md", "+Release notes\n"}
+
+	if got := names(api, script, readme); !reflect.DeepEqual(got, []string{"api/", "scripts/"}) {
+		t.Errorf("unrelated: %v", got)
+	}
+	if got := names(api, DiffFile{"cmd/users.go", "+func main() {\n+\tapi.ListUsers(nil)\n+}\n"}, script); !reflect.DeepEqual(got, []string{"api/, cmd/", "scripts/"}) {
+		t.Errorf("linked by a symbol: %v", got)
+	}
+	if got := names(api, cli, readme); got != nil {
+		t.Errorf("one area flagged: %v", got)
+	}
+	if got := names(api, DiffFile{"docs/guide.md", "+Some prose\n"}); got != nil {
+		t.Errorf("directory without declarations flagged: %v", got)
+	}
+
+	areas := []Area{{Name: "api/", Paths: []string{"a", "b", "c"}}, {Name: "web/", Paths: []string{"d"}}}
+	if got := DescribeAreas(areas); got != "api/ (3 files) and web/ (1 file)" {
+		t.Errorf("DescribeAreas = %q", got)
+	}
+}
@@ -0,0 +1,67 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// asciiReplacer maps typographic punctuation and accented Latin letters to ASCII.
+var asciiReplacer = buildASCIIReplacer()
+
+func buildASCIIReplacer() *strings.Replacer {
+	pairs := []string{
+		"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+		"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`, "«", `"`, "»", `"`,
+		"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "―", "-", "−", "-",
+		"…", "...", "•", "-", "·", "-",
+		"→", "->", "←", "<-", "⇒", "=>", "↔", "<->",
+		"≥", ">=", "≤", "<=", "≠", "!=", "×", "x",
+		" ", " ", " ", " ", " ", " ",
+		"ß", "ss", "Æ", "AE", "æ", "ae", "Œ", "OE", "œ", "oe",
+	}
+	// Letters that differ from their base letter only by a diacritic.
+	for base, accented := range map[string]string{
+		"A": "ÀÁÂÃÄÅĀĂĄẠẢẤẦẨẪẬẮẰẲẴẶ", "a": "àáâãäåāăąạảấầẩẫậắằẳẵặ",
+		"C": "ÇĆĈĊČ", "c": "çćĉċč", "D": "ĎĐ", "d": "ďđ",
+		"E": "ÈÉÊËĒĔĖĘĚẸẺẼẾỀỂỄỆ", "e": "èéêëēĕėęěẹẻẽếềểễệ",
+		"G": "ĜĞĠĢ", "g": "ĝğġģ", "I": "ÌÍÎÏĨĪĬĮİỈỊ", "i": "ìíîïĩīĭįıỉị",
+		"L": "ĹĻĽĿŁ", "l": "ĺļľŀł", "N": "ÑŃŅŇ", "n": "ñńņň",
+		"O": "ÒÓÔÕÖØŌŎŐƠỌỎỐỒỔỖỘỚỜỞỠỢ", "o": "òóôõöøōŏőơọỏốồổỗộớờởỡợ",
+		"R": "ŔŖŘ", "r": "ŕŗř", "S": "ŚŜŞŠ", "s": "śŝşš", "T": "ŢŤ", "t": "ţť",
+		"U": "ÙÚÛÜŨŪŬŮŰŲƯỤỦỨỪỬỮỰ", "u": "ùúûüũūŭůűųưụủứừửữự",
+		"Y": "ÝŸŶỲỴỶỸ", "y": "ýÿŷỳỵỷỹ", "Z": "ŹŻŽ", "z": "źżž",
+	} {
+		for _, r := range accented {
+			pairs = append(pairs, string(r), base)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+var reSpaces = regexp.MustCompile(` {2,}`)
+
+// ASCII makes msg ASCII-only: typographic punctuation and accented letters become
+// their plain equivalents, and emoji and any other non-ASCII characters are removed.
+func ASCII(msg string) string {
+	msg = asciiReplacer.Replace(msg)
+	lines := strings.Split(msg, "\n")
+	for i, ln := range lines {
+		text := strings.TrimLeft(ln, " \t")
+		indent := ln[:len(ln)-len(text)]
+		var b strings.Builder
+		dropped := false
+		for _, r := range text {
+			if r < 0x80 {
+				b.WriteRune(r)
+			} else {
+				dropped = true
+			}
+		}
+		if !dropped {
+			continue
+		}
+		// Close the gap a dropped emoji leaves, keeping the indent.
+		lines[i] = indent + strings.TrimSpace(reSpaces.ReplaceAllString(b.String(), " "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,19 @@
+package commitmsg
+
+import "testing"
+
+func TestASCII(t *testing.T) {
+	tests := map[string]string{
+		"feat: ✨ add “smart” caching — finally…":       `feat: add "smart" caching - finally...`,
+		"🐛 fix: don’t crash on empty input":            "fix: don't crash on empty input",
+		"docs: credit José and Nguyễn Văn Đức":         "docs: credit Jose and Nguyen Van Duc",
+		"refactor: map a → b\n\n  • keep the indent 🚀": "refactor: map a -> b\n\n  - keep the indent",
+		"fix: plain ascii  stays  as is":               "fix: plain ascii  stays  as is",
+		"chore: drop 中文 text":                          "chore: drop text",
+	}
+	for in, want := range tests {
+		if got := ASCII(in); got != want {
+			t.Errorf("ASCII(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
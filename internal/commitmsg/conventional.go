@@ -0,0 +1,170 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Commit is a message parsed by the Conventional Commits 1.0.0 grammar.
+type Commit struct {
+	Header
+	Body    string
+	Footers []Footer
+}
+
+// Footer is one git trailer-style footer, such as "Refs: #12" or "BREAKING CHANGE: ...".
+type Footer struct {
+	Token string
+	Value string
+}
+
+// SyntaxError is where and why a message does not follow the grammar.
+type SyntaxError struct {
+	Rule string // the lint rule it violates: conventional-format or description-empty
+	Line int    // 1-based
+	Col  int    // 1-based, in runes
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+func (e *SyntaxError) issue() Issue {
+	return Issue{Rule: e.Rule, Line: e.Line, Message: fmt.Sprintf("%s (col %d; expected 'type(scope): description')", e.Msg, e.Col)}
+}
+
+// reFooter matches the first line of a footer: "Token: value" or "Token #value".
+var reFooter = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z][\w-]*)(?:: | #)(.*)$`)
+
+// reLooseBreaking matches a breaking-change footer written in the wrong case or form.
+var reLooseBreaking = regexp.MustCompile(`(?i)^breaking[ _-]?changes?\s*:`)
+
+// ParseConventional parses msg (already cleaned) by the Conventional Commits grammar:
+// a "type(scope)!: description" header, then optionally a body and footers, each
+// after a blank line. Unlike ParseHeader, it rejects near misses such as "feat:add",
+// "feat (api): x" and "feat: " with an error that says what is wrong.
+func ParseConventional(msg string) (Commit, error) {
+	lines := strings.Split(msg, "\n")
+	h, err := parseHeaderStrict(lines[0])
+	if err != nil {
+		return Commit{}, err
+	}
+	c := Commit{Header: h}
+	if len(lines) == 1 {
+		return c, nil
+	}
+	if strings.TrimSpace(lines[1]) != "" {
+		return Commit{}, &SyntaxError{Rule: "conventional-format", Line: 2, Col: 1, Msg: "the header must be followed by a blank line"}
+	}
+
+	// Footers are the last paragraph, if its first line is a footer.
+	rest := lines[2:]
+	start := len(rest)
+	for i := len(rest) - 1; i >= 0 && strings.TrimSpace(rest[i]) != ""; i-- {
+		start = i
+	}
+	if start < len(rest) && !reFooter.MatchString(rest[start]) {
+		start = len(rest)
+	}
+	if err := checkBreakingFooters(lines); err != nil {
+		return Commit{}, err
+	}
+	c.Body = strings.TrimSpace(strings.Join(rest[:start], "\n"))
+	for _, ln := range rest[start:] {
+		if m := reFooter.FindStringSubmatch(ln); m != nil {
+			c.Footers = append(c.Footers, Footer{Token: m[1], Value: m[2]})
+		} else if len(c.Footers) > 0 {
+			f := &c.Footers[len(c.Footers)-1]
+			f.Value += "\n" + ln
+		}
+	}
+	for _, f := range c.Footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			c.Breaking = true
+		}
+	}
+	return c, nil
+}
+
+// checkBreakingFooters rejects breaking-change footers spelled other than the
+// grammar's upper-case "BREAKING CHANGE: " (or "BREAKING-CHANGE: ").
+func checkBreakingFooters(lines []string) error {
+	for i, ln := range lines[1:] {
+		if reLooseBreaking.MatchString(ln) && !strings.HasPrefix(ln, "BREAKING CHANGE: ") && !strings.HasPrefix(ln, "BREAKING-CHANGE: ") {
+			return &SyntaxError{Rule: "conventional-format", Line: i + 2, Col: 1, Msg: "a breaking change footer must start with 'BREAKING CHANGE: '"}
+		}
+	}
+	return nil
+}
+
+// parseHeaderStrict scans a header, reporting the first place it leaves the grammar.
+func parseHeaderStrict(subject string) (Header, error) {
+	r := []rune(subject)
+	fail := func(i int, rule, format string, args ...any) (Header, error) {
+		return Header{}, &SyntaxError{Rule: rule, Line: 1, Col: i + 1, Msg: fmt.Sprintf(format, args...)}
+	}
+	isTypeRune := func(c rune) bool {
+		return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+	}
+
+	i := 0
+	for i < len(r) && isTypeRune(r[i]) {
+		i++
+	}
+	if i == 0 {
+		return fail(0, "conventional-format", "expected a type such as 'feat' at the start of the subject")
+	}
+	h := Header{Type: string(r[:i])}
+
+	if i < len(r) && r[i] == '(' {
+		end := i + 1
+		for end < len(r) && r[end] != ')' {
+			end++
+		}
+		if end == len(r) {
+			return fail(i, "conventional-format", "scope is missing its closing ')'")
+		}
+		scope := r[i+1 : end]
+		switch {
+		case len(scope) == 0:
+			return fail(i+1, "conventional-format", "scope must not be empty; leave out the parentheses instead")
+		case strings.ContainsRune(string(scope), '('):
+			return fail(i+1, "conventional-format", "scope must not contain '('")
+		case strings.TrimSpace(string(scope)) != string(scope):
+			return fail(i+1, "conventional-format", "scope must not start or end with a space")
+		}
+		h.Scope = string(scope)
+		i += len(scope) + 2
+	}
+	if i < len(r) && r[i] == '!' {
+		h.Breaking = true
+		i++
+	}
+
+	switch {
+	case i == len(r):
+		return fail(i, "conventional-format", "expected ': ' and a description after the type")
+	case r[i] == ' ' || r[i] == '(':
+		return fail(i, "conventional-format", "expected ':' right after the type and scope")
+	case r[i] != ':':
+		return fail(i, "conventional-format", "unexpected %q in the type; expected ':' after it", r[i])
+	}
+	i++
+	if i == len(r) {
+		return fail(i, "description-empty", "description must not be empty")
+	}
+	if r[i] != ' ' {
+		return fail(i, "conventional-format", "expected a space after ':'")
+	}
+	i++
+	if i == len(r) || strings.TrimSpace(string(r[i:])) == "" {
+		return fail(i, "description-empty", "description must not be empty")
+	}
+	if r[i] == ' ' || r[i] == '\t' {
+		return fail(i, "conventional-format", "expected exactly one space after ':'")
+	}
+	h.Description = string(r[i:])
+	return h, nil
+}
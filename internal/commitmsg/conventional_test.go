@@ -0,0 +1,53 @@
+package commitmsg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseConventional(t *testing.T) {
+	c, err := ParseConventional("feat(api): add endpoint\n\nServes v2 of the schema.\n\nRefs: #12\nBREAKING CHANGE: the v1 endpoint\nis gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Commit{
+		Header:  Header{Type: "feat", Scope: "api", Breaking: true, Description: "add endpoint"},
+		Body:    "Serves v2 of the schema.",
+		Footers: []Footer{{"Refs", "#12"}, {"BREAKING CHANGE", "the v1 endpoint\nis gone"}},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got %+v\nwant %+v", c, want)
+	}
+
+	c, err = ParseConventional("fix: handle nil\n\nNil maps: now handled.")
+	if err != nil || c.Body != "Nil maps: now handled." || c.Footers != nil {
+		t.Errorf("body that is not a footer: %+v, %v", c, err)
+	}
+}
+
+func TestParseConventionalErrors(t *testing.T) {
+	tests := []struct {
+		msg, rule string
+		line, col int
+	}{
+		{"add endpoint", "conventional-format", 1, 4},
+		{": add endpoint", "conventional-format", 1, 1},
+		{"feat add endpoint", "conventional-format", 1, 5},
+		{"feat (api): add", "conventional-format", 1, 5},
+		{"feat(api: add", "conventional-format", 1, 5},
+		{"feat(): add", "conventional-format", 1, 6},
+		{"feat:add", "conventional-format", 1, 6},
+		{"feat:  add", "conventional-format", 1, 7},
+		{"feat:", "description-empty", 1, 6},
+		{"feat: add\nbody", "conventional-format", 2, 1},
+		{"feat: add\n\nbreaking-change: gone", "conventional-format", 3, 1},
+	}
+	for _, tt := range tests {
+		_, err := ParseConventional(tt.msg)
+		var se *SyntaxError
+		if !errors.As(err, &se) || se.Rule != tt.rule || se.Line != tt.line || se.Col != tt.col {
+			t.Errorf("ParseConventional(%q) = %v; want %s at %d:%d", tt.msg, err, tt.rule, tt.line, tt.col)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package commitmsg
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// File status values in FileFact.
+const (
+	StatusAdded    = "added"
+	StatusModified = "modified"
+	StatusDeleted  = "deleted"
+	StatusRenamed  = "renamed"
+)
+
+// FileFact describes one changed file, as computed from its diff.
+type FileFact struct {
+	Path       string
+	OldPath    string // for renames
+	Status     string
+	Insertions int
+	Deletions  int
+	Contexts   []string // enclosing functions or sections named in the hunk headers, without repeats
+}
+
+// Facts are what can be said about a change without an AI. They are the data
+// for message templates.
+type Facts struct {
+	Type         string // guessed conventional type: docs, test, ci, build, feat or chore
+	Scope        string // shared top-level area of the changed files, if any
+	Summary      string // e.g. "add parser.go" or "update 3 files"
+	Branch       string
+	Files        []FileFact
+	FilesChanged int
+	Insertions   int
+	Deletions    int
+}
+
+// DiffFile is the input to ComputeFacts: a path and its unified diff.
+type DiffFile struct {
+	Path string
+	Diff string
+}
+
+// ComputeFacts derives Facts from the diffs of the changed files.
+func ComputeFacts(files []DiffFile, branch string) Facts {
+	f := Facts{Branch: branch}
+	for _, d := range files {
+		ff := FileFact{Path: d.Path, Status: StatusModified}
+		inHunks := false // past the file header, where "--- " and "+++ " are lines too
+		for _, ln := range strings.Split(d.Diff, "\n") {
+			switch {
+			case !inHunks && (strings.HasPrefix(ln, "+++ ") || strings.HasPrefix(ln, "--- ")):
+			case strings.HasPrefix(ln, "@@"):
+				inHunks = true
+				ff.Contexts = appendContext(ff.Contexts, ln)
+			case strings.HasPrefix(ln, "+"):
+				ff.Insertions++
+			case strings.HasPrefix(ln, "-"):
+				ff.Deletions++
+			case strings.HasPrefix(ln, "new file mode"):
+				ff.Status = StatusAdded
+			case strings.HasPrefix(ln, "deleted file mode"):
+				ff.Status = StatusDeleted
+			case strings.HasPrefix(ln, "rename from "):
+				ff.Status = StatusRenamed
+				ff.OldPath = strings.TrimPrefix(ln, "rename from ")
+			}
+		}
+		f.Files = append(f.Files, ff)
+		f.Insertions += ff.Insertions
+		f.Deletions += ff.Deletions
+	}
+	f.FilesChanged = len(f.Files)
+	f.Scope = DetectScope(f.Files)
+	f.Type = DetectType(f.Files)
+	f.Summary = summarize(f)
+	return f
+}
+
+// appendContext adds the section heading of a hunk header ("@@ -1,2 +1,3 @@ func main() {")
+// to contexts unless it is empty or already there.
+func appendContext(contexts []string, header string) []string {
+	i := strings.Index(header[2:], "@@")
+	if i < 0 {
+		return contexts
+	}
+	c := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(header[i+4:]), "{"))
+	if c == "" {
+		return contexts
+	}
+	for _, have := range contexts {
+		if have == c {
+			return contexts
+		}
+	}
+	return append(contexts, c)
+}
+
+// DescribeFile is a one-line description of the change to one file, e.g.
+// "renamed from old.go" or "changes in func Parse(s string) error".
+func DescribeFile(ff FileFact) string {
+	switch ff.Status {
+	case StatusAdded:
+		return fmt.Sprintf("new file, %d lines", ff.Insertions)
+	case StatusDeleted:
+		return fmt.Sprintf("deleted, %d lines", ff.Deletions)
+	case StatusRenamed:
+		if ff.Insertions+ff.Deletions == 0 {
+			return "renamed from " + ff.OldPath
+		}
+		return fmt.Sprintf("renamed from %s and edited", ff.OldPath)
+	}
+	switch len(ff.Contexts) {
+	case 0:
+		return fmt.Sprintf("%d lines changed", ff.Insertions+ff.Deletions)
+	case 1, 2:
+		return "changes in " + strings.Join(ff.Contexts, ", ")
+	default:
+		return fmt.Sprintf("changes in %s and %d more", strings.Join(ff.Contexts[:2], ", "), len(ff.Contexts)-2)
+	}
+}
+
+// containerDirs hold the real areas of a project one level down (internal/app → app).
+var containerDirs = map[string]bool{
+	"internal": true, "cmd": true, "pkg": true, "src": true, "lib": true,
+	"apps": true, "packages": true, "services": true, "crates": true, "modules": true,
+}
+
+// scopeOf returns the area a path belongs to, or "" for files at the root.
+func scopeOf(p string) string {
+	parts := strings.Split(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	if containerDirs[parts[0]] && len(parts) > 2 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// DetectScope returns the scope shared by all files, or "" if they span several.
+func DetectScope(files []FileFact) string {
+	scope := ""
+	for i, f := range files {
+		s := scopeOf(f.Path)
+		if s == "" || (i > 0 && s != scope) {
+			return ""
+		}
+		scope = s
+	}
+	return scope
+}
+
+// DetectType guesses the conventional type from which kinds of files changed,
+// falling back to chore.
+func DetectType(files []FileFact) string {
+	if t := InferType(files); t != "" {
+		return t
+	}
+	return "chore"
+}
+
+// InferType returns the conventional type the kinds of changed files make clear, or
+// "" if they don't: docs when only documentation changed, test for only tests, ci,
+// build, and feat for new files only or a new command under cmd/.
+func InferType(files []FileFact) string {
+	if len(files) == 0 {
+		return ""
+	}
+	all := func(pred func(p string) bool) bool {
+		for _, f := range files {
+			if !pred(strings.ToLower(f.Path)) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case all(isDocPath):
+		return "docs"
+	case all(isTestPath):
+		return "test"
+	case all(isCIPath):
+		return "ci"
+	case all(isBuildPath):
+		return "build"
+	}
+	added, command := true, false
+	for _, f := range files {
+		added = added && f.Status == StatusAdded
+		command = command || f.Status == StatusAdded && strings.HasPrefix(f.Path, "cmd/")
+	}
+	if added || command {
+		return "feat"
+	}
+	return ""
+}
+
+func isDocPath(p string) bool {
+	return strings.HasSuffix(p, ".md") || strings.HasSuffix(p, ".rst") || strings.HasSuffix(p, ".txt") ||
+		strings.HasPrefix(p, "docs/") || strings.Contains(p, "/docs/")
+}
+
+func isTestPath(p string) bool {
+	base := path.Base(p)
+	return strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") || strings.HasPrefix(p, "test/") || strings.HasPrefix(p, "tests/") ||
+		strings.Contains(p, "/testdata/") || strings.HasPrefix(p, "testdata/")
+}
+
+func isCIPath(p string) bool {
+	return strings.HasPrefix(p, ".github/workflows/") || strings.HasPrefix(p, ".circleci/") ||
+		p == ".gitlab-ci.yml" || p == ".travis.yml" || p == "jenkinsfile" || p == "azure-pipelines.yml"
+}
+
+func isBuildPath(p string) bool {
+	switch path.Base(p) {
+	case "go.mod", "go.sum", "package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+		"cargo.toml", "cargo.lock", "makefile", "dockerfile", "pyproject.toml", "requirements.txt",
+		"build.gradle", "pom.xml", ".goreleaser.yml", ".goreleaser.yaml":
+		return true
+	}
+	return false
+}
+
+func summarize(f Facts) string {
+	if len(f.Files) == 1 {
+		ff := f.Files[0]
+		name := path.Base(ff.Path)
+		switch ff.Status {
+		case StatusAdded:
+			return "add " + name
+		case StatusDeleted:
+			return "remove " + name
+		case StatusRenamed:
+			return fmt.Sprintf("rename %s to %s", path.Base(ff.OldPath), name)
+		}
+		return "update " + name
+	}
+	verb := "update"
+	switch {
+	case allStatus(f.Files, StatusAdded):
+		verb = "add"
+	case allStatus(f.Files, StatusDeleted):
+		verb = "remove"
+	}
+	return fmt.Sprintf("%s %d files", verb, len(f.Files))
+}
+
+func allStatus(files []FileFact, status string) bool {
+	for _, f := range files {
+		if f.Status != status {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultTemplate and DefaultPlainTemplate are used when no message template is configured.
+const (
+	DefaultTemplate = `{{.Type}}{{with .Scope}}({{.}}){{end}}: {{.Summary}}
+
+{{range .Files}}- {{.Status}} {{.Path}} (+{{.Insertions}}/-{{.Deletions}})
+{{end}}`
+	DefaultPlainTemplate = `{{.Summary | capitalize}}
+
+{{range .Files}}- {{.Status}} {{.Path}} (+{{.Insertions}}/-{{.Deletions}})
+{{end}}`
+)
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"base":  path.Base,
+	"capitalize": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+}
+
+// Render fills the Go template tmpl with f. The result is trimmed.
+func Render(tmpl string, f Facts) (string, error) {
+	t, err := template.New("message").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse message template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, f); err != nil {
+		return "", fmt.Errorf("render message template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,92 @@
+package commitmsg
+
+import "testing"
+
+func TestComputeFacts(t *testing.T) {
+	files := []DiffFile{
+		{Path: "internal/app/run.go", Diff: "diff --git a/internal/app/run.go b/internal/app/run.go\n--- a/internal/app/run.go\n+++ b/internal/app/run.go\n@@ -1,2 +1,3 @@\n-a\n+b\n+c\n"},
+		{Path: "internal/app/bench.go", Diff: "diff --git a/internal/app/bench.go b/internal/app/bench.go\nnew file mode 100644\n--- /dev/null\n+++ b/internal/app/bench.go\n@@ -0,0 +1 @@\n+package app\n"},
+	}
+	f := ComputeFacts(files, "main")
+	if f.Scope != "app" || f.Type != "chore" || f.Insertions != 3 || f.Deletions != 1 || f.FilesChanged != 2 {
+		t.Errorf("facts = %+v", f)
+	}
+	if f.Files[1].Status != StatusAdded || f.Summary != "update 2 files" {
+		t.Errorf("file = %+v, summary = %q", f.Files[1], f.Summary)
+	}
+
+	msg, err := Render(DefaultTemplate, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "chore(app): update 2 files\n\n- modified internal/app/run.go (+2/-1)\n- added internal/app/bench.go (+1/-0)"
+	if msg != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", msg, want)
+	}
+
+	if _, err := Render("{{.Nope}}", f); err == nil {
+		t.Error("expected error for unknown field")
+	}
+
+	// In a hunk, "--- " and "+++ " are removed and added lines, such as SQL comments.
+	sql := DiffFile{Path: "schema.sql", Diff: "--- a/schema.sql\n+++ b/schema.sql\n@@ -1,2 +1,2 @@\n--- old note\n+++ new note\n select 1;\n"}
+	if ff := ComputeFacts([]DiffFile{sql}, "").Files[0]; ff.Insertions != 1 || ff.Deletions != 1 {
+		t.Errorf("lines in hunk: +%d/-%d; want +1/-1", ff.Insertions, ff.Deletions)
+	}
+}
+
+func TestDetectType(t *testing.T) {
+	tests := []struct {
+		paths  []string
+		status string
+		want   string
+	}{
+		{[]string{"README.md", "docs/usage.md"}, StatusModified, "docs"},
+		{[]string{"internal/app/run_test.go", "testdata/x.json"}, StatusModified, "test"},
+		{[]string{".github/workflows/ci.yml"}, StatusModified, "ci"},
+		{[]string{"go.mod", "go.sum"}, StatusModified, "build"},
+		{[]string{"internal/x/new.go"}, StatusAdded, "feat"},
+		{[]string{"main.go", "README.md"}, StatusModified, "chore"},
+	}
+	for _, tt := range tests {
+		var files []FileFact
+		for _, p := range tt.paths {
+			files = append(files, FileFact{Path: p, Status: tt.status})
+		}
+		if got := DetectType(files); got != tt.want {
+			t.Errorf("DetectType(%v) = %s; want %s", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestInferType(t *testing.T) {
+	if got := InferType([]FileFact{{Path: "main.go", Status: StatusModified}, {Path: "README.md", Status: StatusModified}}); got != "" {
+		t.Errorf("mixed change: %q; want no guess", got)
+	}
+	if got := InferType([]FileFact{{Path: "cmd/tool/main.go", Status: StatusAdded}, {Path: "go.mod", Status: StatusModified}}); got != "feat" {
+		t.Errorf("new command: %q; want feat", got)
+	}
+	if got := InferType([]FileFact{{Path: "internal/app/run_test.go", Status: StatusModified}}); got != "test" {
+		t.Errorf("tests only: %q; want test", got)
+	}
+}
+
+func TestDescribeFile(t *testing.T) {
+	diff := "--- a/p.go\n+++ b/p.go\n@@ -1,2 +1,2 @@ func Parse(s string) error {\n-a\n+b\n@@ -9 +9 @@ func Parse(s string) error {\n-c\n+d\n@@ -20 +20 @@\n-e\n+f\n"
+	f := ComputeFacts([]DiffFile{{Path: "p.go", Diff: diff}}, "")
+	if got, want := DescribeFile(f.Files[0]), "changes in func Parse(s string) error"; got != want {
+		t.Errorf("DescribeFile() = %q; want %q", got, want)
+	}
+
+	tests := map[string]FileFact{
+		"new file, 4 lines":          {Status: StatusAdded, Insertions: 4},
+		"renamed from a.go":          {Status: StatusRenamed, OldPath: "a.go"},
+		"6 lines changed":            {Status: StatusModified, Insertions: 3, Deletions: 3},
+		"changes in a, b and 1 more": {Status: StatusModified, Contexts: []string{"a", "b", "c"}},
+	}
+	for want, ff := range tests {
+		if got := DescribeFile(ff); got != want {
+			t.Errorf("DescribeFile(%+v) = %q; want %q", ff, got, want)
+		}
+	}
+}
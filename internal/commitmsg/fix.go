@@ -0,0 +1,182 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fixes configures Fix, the local clean-up applied to generated messages.
+type Fixes struct {
+	Imperative bool     // rewrite the subject into imperative mood and drop its trailing period
+	Wrap       int      // hard-wrap body lines at this column (0 leaves them)
+	Spelling   bool     // correct common misspellings
+	Dictionary []string // words Spelling leaves as written
+	ASCII      bool     // replace or remove emoji and other non-ASCII characters
+	Type       string   // use this conventional type in the subject
+}
+
+// Enabled reports whether f changes anything.
+func (f Fixes) Enabled() bool {
+	return f.Imperative || f.Wrap > 0 || f.Spelling || f.ASCII || f.Type != ""
+}
+
+// Fix applies f to msg. Messages git generates itself (merges, reverts, fixups) are
+// left alone.
+func Fix(msg string, f Fixes) string {
+	msg = strings.TrimSpace(msg)
+	if isExempt(Subject(msg)) {
+		return msg
+	}
+	if f.Spelling {
+		msg = Spell(msg, f.Dictionary)
+	}
+	if f.ASCII {
+		msg = strings.TrimSpace(ASCII(msg))
+	}
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	if f.Imperative {
+		subject = Imperative(subject)
+	}
+	if f.Type != "" {
+		subject = SetType(subject, f.Type)
+	}
+	if hasBody {
+		if f.Wrap > 0 {
+			body = Wrap(body, f.Wrap)
+		}
+		return subject + "\n" + body
+	}
+	return subject
+}
+
+// SetType gives a subject line the conventional type typ, replacing the one it has
+// ("fix(api): x" → "feat(api): x") or adding one ("Add x" → "feat: add x").
+func SetType(subject, typ string) string {
+	if h, ok := ParseHeader(subject); ok {
+		return typ + subject[len(h.Type):]
+	}
+	return typ + ": " + setFirstCase(subject, false)
+}
+
+// reNarration matches openings that narrate the change instead of stating it:
+// "This commit adds", "This PR will fix", "I added", "We have updated".
+var reNarration = regexp.MustCompile(`(?i)^(?:this (?:commit|change|changeset|patch|pr|pull request|mr)(?: will)?|(?:i|we)(?: have)?)\s+`)
+
+// Imperative rewrites the description of a subject line into imperative mood
+// ("Added X" → "Add X", "feat: adds X" → "feat: add X") and drops a trailing period.
+func Imperative(subject string) string {
+	prefix, desc := "", subject
+	if h, ok := ParseHeader(subject); ok {
+		prefix = subject[:len(subject)-len(h.Description)]
+		desc = h.Description
+	}
+
+	upper := startsUpper(desc)
+	desc = reNarration.ReplaceAllString(desc, "")
+	word, rest, _ := strings.Cut(desc, " ")
+	if base, ok := imperativeOf[strings.ToLower(word)]; ok {
+		word = base
+	}
+	desc = word
+	if rest != "" {
+		desc += " " + rest
+	}
+	desc = setFirstCase(desc, upper)
+
+	if strings.HasSuffix(desc, ".") && !strings.HasSuffix(desc, "..") {
+		desc = strings.TrimSuffix(desc, ".")
+	}
+	return prefix + desc
+}
+
+func startsUpper(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r)
+}
+
+// setFirstCase upper- or lower-cases the first letter of s, unless the first word
+// looks like an identifier or acronym ("README", "iOS") that must keep its case.
+func setFirstCase(s string, upper bool) string {
+	if s == "" {
+		return s
+	}
+	word, _, _ := strings.Cut(s, " ")
+	for _, r := range word[min(1, len(word)):] {
+		if unicode.IsUpper(r) {
+			return s
+		}
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	if upper {
+		return string(unicode.ToUpper(r)) + s[n:]
+	}
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+// imperativeVerbs are verbs commit subjects commonly start with. Words that are more
+// often nouns at the start of a subject ("Tests for…", "Docs…", "Logs…") are left out.
+var imperativeVerbs = []string{
+	"add", "adjust", "allow", "apply", "avoid", "bump", "change", "clarify", "clean", "configure",
+	"convert", "correct", "create", "delete", "deprecate", "disable", "document", "downgrade", "drop",
+	"enable", "ensure", "expose", "extract", "fix", "handle", "hide", "implement", "improve", "include",
+	"increase", "initialize", "introduce", "migrate", "move", "optimize", "prevent", "reduce",
+	"refactor", "remove", "rename", "reorganize", "replace", "resolve", "restructure", "revert",
+	"rewrite", "simplify", "skip", "stop", "support", "switch", "tweak", "update", "upgrade", "use",
+	"validate", "wrap",
+}
+
+// irregularForms maps inflected forms that the suffix rules get wrong.
+var irregularForms = map[string]string{
+	"rewrote": "rewrite", "rewritten": "rewrite", "made": "make", "makes": "make", "making": "make",
+}
+
+// imperativeOf maps inflected forms ("adds", "added", "adding") to the base verb.
+var imperativeOf = buildImperativeOf()
+
+func buildImperativeOf() map[string]string {
+	m := map[string]string{}
+	for _, v := range imperativeVerbs {
+		for _, form := range inflect(v) {
+			if form != v {
+				m[form] = v
+			}
+		}
+	}
+	for form, v := range irregularForms {
+		m[form] = v
+	}
+	return m
+}
+
+// inflect returns the third-person, past and -ing forms of a regular verb.
+func inflect(v string) []string {
+	last := v[len(v)-1]
+	var third, past, ing string
+	switch {
+	case strings.HasSuffix(v, "y") && !strings.ContainsRune("aeiou", rune(v[len(v)-2])):
+		stem := v[:len(v)-1]
+		third, past, ing = stem+"ies", stem+"ied", v+"ing"
+	case strings.HasSuffix(v, "e"):
+		third, past, ing = v+"s", v+"d", v[:len(v)-1]+"ing"
+	case strings.HasSuffix(v, "s"), strings.HasSuffix(v, "x"), strings.HasSuffix(v, "ch"), strings.HasSuffix(v, "sh"):
+		third, past, ing = v+"es", v+"ed", v+"ing"
+	case doublesFinal(v):
+		third, past, ing = v+"s", v+string(last)+"ed", v+string(last)+"ing"
+	default:
+		third, past, ing = v+"s", v+"ed", v+"ing"
+	}
+	return []string{third, past, ing}
+}
+
+// doublesFinal reports short consonant-vowel-consonant verbs ("drop", "stop", "skip")
+// whose final consonant doubles before -ed and -ing.
+func doublesFinal(v string) bool {
+	if len(v) < 3 || len(v) > 4 {
+		return false
+	}
+	isVowel := func(c byte) bool { return strings.IndexByte("aeiou", c) >= 0 }
+	a, b, c := v[len(v)-3], v[len(v)-2], v[len(v)-1]
+	return !isVowel(a) && isVowel(b) && !isVowel(c) && strings.IndexByte("wxy", c) < 0
+}
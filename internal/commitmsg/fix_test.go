@@ -0,0 +1,57 @@
+package commitmsg
+
+import "testing"
+
+func TestImperative(t *testing.T) {
+	tests := map[string]string{
+		"Added retry to the uploader.":           "Add retry to the uploader",
+		"Fixes crash on empty input":             "Fix crash on empty input",
+		"This commit adds a --quiet flag":        "Add a --quiet flag",
+		"feat(api): adds pagination":             "feat(api): add pagination",
+		"fix: this PR will fix the login loop.":  "fix: fix the login loop",
+		"We have updated the docs":               "Update the docs",
+		"Dropped support for Go 1.20":            "Drop support for Go 1.20",
+		"Applies the new theme":                  "Apply the new theme",
+		"Refactoring the parser":                 "Refactor the parser",
+		"Tests for the parser":                   "Tests for the parser",
+		"README: describe install...":            "README: describe install...",
+		"chore: updated README":                  "chore: update README",
+		"This commit adds iOS build":             "Add iOS build",
+		"Switches to the new client; removes v1": "Switch to the new client; removes v1",
+	}
+	for in, want := range tests {
+		if got := Imperative(in); got != want {
+			t.Errorf("Imperative(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	msg := "Added a cache.\n\nAdded a cache for the index.\n"
+	if got, want := Fix(msg, Fixes{Imperative: true}), "Add a cache\n\nAdded a cache for the index."; got != want {
+		t.Errorf("Fix() = %q; want %q", got, want)
+	}
+	if got := Fix("Merge branch 'main'.", Fixes{Imperative: true}); got != "Merge branch 'main'." {
+		t.Errorf("merge subject changed: %q", got)
+	}
+	if got, want := Fix("Add a cache\n\nKeep parsed results around between runs.", Fixes{Wrap: 20}), "Add a cache\n\nKeep parsed results\naround between runs."; got != want {
+		t.Errorf("Fix() = %q; want %q", got, want)
+	}
+	if got, want := Fix("✨ feat: added “smart” caching", Fixes{Imperative: true, ASCII: true}), `feat: add "smart" caching`; got != want {
+		t.Errorf("Fix() = %q; want %q", got, want)
+	}
+}
+
+func TestSetType(t *testing.T) {
+	tests := map[string]string{
+		"fix(api): add pagination": "feat(api): add pagination",
+		"chore!: drop Go 1.20":     "feat!: drop Go 1.20",
+		"Add pagination":           "feat: add pagination",
+		"API pagination":           "feat: API pagination",
+	}
+	for in, want := range tests {
+		if got := SetType(in, "feat"); got != want {
+			t.Errorf("SetType(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
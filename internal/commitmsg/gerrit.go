@@ -0,0 +1,80 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reChangeID matches a Gerrit Change-Id trailer line.
+var reChangeID = regexp.MustCompile(`^Change-Id: (I[0-9a-f]{40})\s*$`)
+
+// reTrailer matches a "Token: value" trailer line.
+var reTrailer = regexp.MustCompile(`^[A-Za-z][\w-]*: \S`)
+
+// ChangeID returns the Gerrit Change-Id in msg, such as the one an amended commit
+// already has, or "" if there is none. Comment lines are ignored.
+func ChangeID(msg string) string {
+	lines := strings.Split(Clean(msg), "\n")
+	start := lastParagraph(lines)
+	if start == 0 {
+		return ""
+	}
+	for _, ln := range lines[start:] {
+		if m := reChangeID.FindStringSubmatch(ln); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// WithChangeID returns msg with the trailer "Change-Id: id" where Gerrit looks
+// for it: in the last paragraph, which must hold only trailers and must not be
+// the subject. Like Gerrit's commit-msg hook, it goes before the first
+// Signed-off-by, else last. A Change-Id already there is replaced.
+func WithChangeID(msg, id string) string {
+	trailer := "Change-Id: " + id
+	lines := strings.Split(strings.TrimRight(msg, "\n\t "), "\n")
+	start := lastParagraph(lines)
+	if start == 0 || !allTrailers(lines[start:]) {
+		return strings.Join(lines, "\n") + "\n\n" + trailer
+	}
+
+	out := append([]string(nil), lines[:start]...)
+	placed := false
+	for _, ln := range lines[start:] {
+		if reChangeID.MatchString(ln) {
+			continue
+		}
+		if !placed && strings.HasPrefix(ln, "Signed-off-by: ") {
+			out = append(out, trailer)
+			placed = true
+		}
+		out = append(out, ln)
+	}
+	if !placed {
+		out = append(out, trailer)
+	}
+	return strings.Join(out, "\n")
+}
+
+// lastParagraph returns the index of the first line of the last paragraph of lines,
+// which must not end in a blank line.
+func lastParagraph(lines []string) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// allTrailers reports whether every line of para is a trailer or continues one.
+func allTrailers(para []string) bool {
+	for i, ln := range para {
+		if reTrailer.MatchString(ln) || (i > 0 && (strings.HasPrefix(ln, " ") || strings.HasPrefix(ln, "\t"))) {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package commitmsg
+
+import "testing"
+
+const testChangeID = "I0123456789abcdef0123456789abcdef01234567"
+
+func TestChangeID(t *testing.T) {
+	msg := "fix: old subject\n\nBody.\n\nChange-Id: " + testChangeID + "\n# Please enter the commit message\n"
+	if got := ChangeID(msg); got != testChangeID {
+		t.Errorf("got %q", got)
+	}
+	if got := ChangeID("Change-Id: " + testChangeID); got != "" {
+		t.Errorf("subject taken as a trailer: %q", got)
+	}
+	if got := ChangeID("fix: x\n\nChange-Id: " + testChangeID + "\n\nMore body."); got != "" {
+		t.Errorf("Change-Id outside the last paragraph: %q", got)
+	}
+}
+
+func TestWithChangeID(t *testing.T) {
+	id := "Change-Id: " + testChangeID
+	tests := []struct{ msg, want string }{
+		{"feat: add x", "feat: add x\n\n" + id},
+		{"feat: add x\n\nWhy it matters.\n", "feat: add x\n\nWhy it matters.\n\n" + id},
+		{"feat: add x\n\nBody.\n\nRefs: #12", "feat: add x\n\nBody.\n\nRefs: #12\n" + id},
+		{"feat: add x\n\nRefs: #12\nSigned-off-by: A <a@x>", "feat: add x\n\nRefs: #12\n" + id + "\nSigned-off-by: A <a@x>"},
+		{"feat: add x\n\nChange-Id: Iffffffffffffffffffffffffffffffffffffffff", "feat: add x\n\n" + id},
+		{"feat: add x\n\n```\na\n\n\nb\n```", "feat: add x\n\n```\na\n\n\nb\n```\n\n" + id},
+	}
+	for _, tt := range tests {
+		if got := WithChangeID(tt.msg, testChangeID); got != tt.want {
+			t.Errorf("WithChangeID(%q) =\n%q\nwant\n%q", tt.msg, got, tt.want)
+		}
+	}
+}
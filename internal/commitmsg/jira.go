@@ -0,0 +1,79 @@
+package commitmsg
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// reJiraKey matches an issue key such as PROJ-123 between word boundaries; case is
+// checked by JiraKey.
+var reJiraKey = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])([a-z][a-z0-9_]*-[1-9][0-9]*)(?:$|[^a-z0-9])`)
+
+// notJiraProjects are upper-case names followed by a number that are not issue
+// keys: encodings, standards and hashes, as in "fix/UTF-8-decoding".
+var notJiraProjects = []string{"UTF", "ISO", "RFC", "SHA", "MD", "CVE", "HTTP", "TLS", "SSL", "IPV", "X", "ES", "PEP", "WCAG"}
+
+// maxSmartComment caps the length of a smart-commit comment, in runes.
+const maxSmartComment = 100
+
+// JiraKey returns the first Jira issue key in branch, upper-cased, or "". With
+// projects, only keys of those projects count, in any case ("feature/proj-12-x").
+// Without, a key must be upper case in the branch, so "release-2" is not one, and
+// names such as UTF-8 or SHA-256 don't count.
+func JiraKey(branch string, projects []string) string {
+	for _, m := range reJiraKey.FindAllStringSubmatch(branch, -1) {
+		key := m[1]
+		project, _, _ := strings.Cut(strings.ToUpper(key), "-")
+		switch {
+		case len(projects) > 0 && slices.ContainsFunc(projects, func(p string) bool { return strings.EqualFold(p, project) }):
+			return strings.ToUpper(key)
+		case len(projects) == 0 && key == strings.ToUpper(key) && !slices.Contains(notJiraProjects, project):
+			return key
+		}
+	}
+	return ""
+}
+
+// SmartCommit is a Jira smart-commit line: commands Jira runs on the issue Key when
+// the commit reaches a connected repository.
+type SmartCommit struct {
+	Key        string
+	Comment    string // #comment, added to the issue
+	Time       string // #time, logged as work, e.g. "2h 30m"
+	Transition string // moves the issue through this workflow transition, e.g. "resolve"
+}
+
+// String renders the line, e.g. "PROJ-123 #comment Fix the parser #time 2h #resolve".
+func (s SmartCommit) String() string {
+	parts := []string{s.Key}
+	if c := smartArg(s.Comment); c != "" {
+		parts = append(parts, "#comment "+c)
+	}
+	if t := smartArg(s.Time); t != "" {
+		parts = append(parts, "#time "+t)
+	}
+	if tr := strings.Join(strings.Fields(smartArg(s.Transition)), "-"); tr != "" {
+		parts = append(parts, "#"+strings.ToLower(tr))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SmartComment returns a short comment for msg: its subject without the
+// conventional type prefix, capped at maxSmartComment runes.
+func SmartComment(msg string) string {
+	subject := Subject(Clean(msg))
+	if h, ok := ParseHeader(subject); ok {
+		subject = h.Description
+	}
+	if r := []rune(subject); len(r) > maxSmartComment {
+		subject = strings.TrimSpace(string(r[:maxSmartComment-3])) + "..."
+	}
+	return subject
+}
+
+// smartArg makes s safe inside a smart-commit line: one line, and no '#', which
+// would start another command.
+func smartArg(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "#", "")), " ")
+}
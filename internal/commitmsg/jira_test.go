@@ -0,0 +1,42 @@
+package commitmsg
+
+import "testing"
+
+func TestJiraKey(t *testing.T) {
+	tests := []struct {
+		branch   string
+		projects []string
+		want     string
+	}{
+		{"feature/PROJ-123-add-login", nil, "PROJ-123"},
+		{"PROJ-9", nil, "PROJ-9"},
+		{"release-2", nil, ""},
+		{"feature/proj-123-add-login", nil, ""},
+		{"feature/proj-123-add-login", []string{"PROJ"}, "PROJ-123"},
+		{"fix/UTF-8-decoding", []string{"PROJ"}, ""},
+		{"fix/UTF-8-decoding", nil, ""},
+		{"fix/SHA-256-then-PROJ-7", nil, "PROJ-7"},
+		{"ABC-1-and-PROJ-2", []string{"proj"}, "PROJ-2"},
+		{"main", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := JiraKey(tt.branch, tt.projects); got != tt.want {
+			t.Errorf("JiraKey(%q, %v) = %q; want %q", tt.branch, tt.projects, got, tt.want)
+		}
+	}
+}
+
+func TestSmartCommit(t *testing.T) {
+	sc := SmartCommit{
+		Key:        "PROJ-123",
+		Comment:    SmartComment("fix(parser): handle #empty input\n\nBody."),
+		Time:       "2h 30m",
+		Transition: "Start Progress",
+	}
+	if got, want := sc.String(), "PROJ-123 #comment handle empty input #time 2h 30m #start-progress"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if got := (SmartCommit{Key: "PROJ-1"}).String(); got != "PROJ-1" {
+		t.Errorf("key only: %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reLinearBranch matches the branch names Linear suggests, "user/eng-123-short-title"
+// or "eng-123-short-title", when no teams are configured: a team key is letters
+// only, so "http2-1" is not one.
+var reLinearBranch = regexp.MustCompile(`^(?:[^/]+/)?([A-Za-z]{1,7})-([0-9]+)(?:-|$)`)
+
+// LinearKey returns the Linear issue identifier in a Linear-style branch name,
+// upper-cased ("ENG-123"), or "". With teams, the branch must start with one of
+// those teams' keys.
+func LinearKey(branch string, teams []string) string {
+	re := reLinearBranch
+	if len(teams) > 0 {
+		keys := make([]string, len(teams))
+		for i, t := range teams {
+			keys[i] = regexp.QuoteMeta(t)
+		}
+		re = regexp.MustCompile(`(?i)^(?:[^/]+/)?(` + strings.Join(keys, "|") + `)-([0-9]+)(?:-|$)`)
+	}
+	m := re.FindStringSubmatch(branch)
+	if m == nil {
+		return ""
+	}
+	return strings.ToUpper(m[1]) + "-" + m[2]
+}
@@ -0,0 +1,26 @@
+package commitmsg
+
+import "testing"
+
+func TestLinearKey(t *testing.T) {
+	tests := []struct {
+		branch string
+		teams  []string
+		want   string
+	}{
+		{"ann/eng-123-fix-login", nil, "ENG-123"},
+		{"eng-42", nil, "ENG-42"},
+		{"ann/ENG-7-x", []string{"eng"}, "ENG-7"},
+		{"ann/ops-7-x", []string{"ENG"}, ""},
+		{"feature/add-login", nil, ""},
+		{"a/b/eng-1-x", nil, ""},
+		{"fix/http2-1-frames", nil, ""},
+		{"ann/eng2-5-x", []string{"ENG2"}, "ENG2-5"},
+		{"fix/http2-1-frames", []string{"ENG"}, ""},
+	}
+	for _, tt := range tests {
+		if got := LinearKey(tt.branch, tt.teams); got != tt.want {
+			t.Errorf("LinearKey(%q, %v) = %q; want %q", tt.branch, tt.teams, got, tt.want)
+		}
+	}
+}
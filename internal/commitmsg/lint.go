@@ -0,0 +1,147 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTypes are the Conventional Commits types accepted when none are configured.
+var DefaultTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// Rules configures Lint. Zero values disable the corresponding length check.
+type Rules struct {
+	Conventional      bool
+	Types             []string // allowed conventional types (default: DefaultTypes)
+	MaxSubjectLength  int
+	MaxBodyLineLength int
+}
+
+// Issue is a single rule violation.
+type Issue struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"` // 1-based line in the message
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s (%s)", i.Line, i.Message, i.Rule)
+}
+
+// Header is the parsed first line of a conventional commit.
+type Header struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+var reHeader = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: (.*)$`)
+
+// ParseHeader parses a conventional commit subject line.
+func ParseHeader(subject string) (Header, bool) {
+	m := reHeader.FindStringSubmatch(subject)
+	if m == nil {
+		return Header{}, false
+	}
+	return Header{
+		Type:        m[1],
+		Scope:       m[2],
+		Breaking:    m[3] == "!",
+		Description: m[4],
+	}, true
+}
+
+// Clean strips git comment lines and trailing whitespace, as git does before committing.
+func Clean(msg string) string {
+	msg = strings.ReplaceAll(msg, "\r\n", "\n")
+	var lines []string
+	for _, ln := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(ln, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(ln, " \t"))
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// Subject returns the first line of msg.
+func Subject(msg string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	return subject
+}
+
+// isExempt reports messages git generates itself, which are not held to the rules.
+func isExempt(subject string) bool {
+	for _, p := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks msg against r and returns all violations found.
+func Lint(msg string, r Rules) []Issue {
+	msg = Clean(msg)
+	if msg == "" {
+		return []Issue{{Rule: "empty", Line: 1, Message: "message is empty"}}
+	}
+
+	lines := strings.Split(msg, "\n")
+	subject := lines[0]
+	if isExempt(subject) {
+		return nil
+	}
+
+	var issues []Issue
+
+	if r.MaxSubjectLength > 0 {
+		if n := utf8.RuneCountInString(subject); n > r.MaxSubjectLength {
+			issues = append(issues, Issue{Rule: "subject-length", Line: 1, Message: fmt.Sprintf("subject is %d characters, max %d", n, r.MaxSubjectLength)})
+		}
+	}
+	if strings.HasSuffix(subject, ".") {
+		issues = append(issues, Issue{Rule: "subject-full-stop", Line: 1, Message: "subject must not end with a period"})
+	}
+
+	if r.Conventional {
+		issues = append(issues, lintHeader(subject, r)...)
+		if err := checkBreakingFooters(lines); err != nil {
+			issues = append(issues, err.(*SyntaxError).issue())
+		}
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		issues = append(issues, Issue{Rule: "body-leading-blank", Line: 2, Message: "subject and body must be separated by a blank line"})
+	}
+
+	if r.MaxBodyLineLength > 0 {
+		for i, ln := range lines[1:] {
+			if n := utf8.RuneCountInString(ln); n > r.MaxBodyLineLength {
+				issues = append(issues, Issue{Rule: "body-line-length", Line: i + 2, Message: fmt.Sprintf("line is %d characters, max %d", n, r.MaxBodyLineLength)})
+			}
+		}
+	}
+
+	return issues
+}
+
+func lintHeader(subject string, r Rules) []Issue {
+	h, err := parseHeaderStrict(subject)
+	if err != nil {
+		return []Issue{err.(*SyntaxError).issue()}
+	}
+
+	var issues []Issue
+	types := r.Types
+	if len(types) == 0 {
+		types = DefaultTypes
+	}
+	if !slices.Contains(types, h.Type) {
+		issues = append(issues, Issue{Rule: "type-enum", Line: 1, Message: fmt.Sprintf("type %q is not one of: %s", h.Type, strings.Join(types, ", "))})
+	}
+	return issues
+}
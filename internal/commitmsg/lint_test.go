@@ -0,0 +1,55 @@
+package commitmsg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	rules := Rules{Conventional: true, MaxSubjectLength: 50, MaxBodyLineLength: 72}
+
+	tests := []struct {
+		name  string
+		msg   string
+		rules Rules
+		want  []string // rule names, in order
+	}{
+		{"valid", "feat(tui): add picker\n\nLets users recover old suggestions.", rules, nil},
+		{"breaking", "refactor!: drop -cmd flag", rules, nil},
+		{"empty", "# only a comment\n", rules, []string{"empty"}},
+		{"no type", "add picker", rules, []string{"conventional-format"}},
+		{"bad type", "feature: add picker", rules, []string{"type-enum"}},
+		{"custom types", "feature: add picker", Rules{Conventional: true, Types: []string{"feature"}}, nil},
+		{"empty description", "fix:  ", rules, []string{"description-empty"}},
+		{"no space", "fix:handle nil", rules, []string{"conventional-format"}},
+		{"lower-case breaking footer", "feat: drop v1\n\nBreaking change: v1 is gone", rules, []string{"conventional-format"}},
+		{"full stop", "fix: handle nil.", rules, []string{"subject-full-stop"}},
+		{"too long", "fix: " + strings.Repeat("x", 60), rules, []string{"subject-length"}},
+		{"no blank line", "fix: a\nbody", rules, []string{"body-leading-blank"}},
+		{"merge exempt", "Merge branch 'main' into dev", rules, nil},
+		{"not conventional", "Add picker", Rules{}, nil},
+	}
+
+	for _, tt := range tests {
+		got := Lint(tt.msg, tt.rules)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Lint() = %v; want rules %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Rule != tt.want[i] {
+				t.Errorf("%s: issue %d rule = %q; want %q", tt.name, i, got[i].Rule, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	h, ok := ParseHeader("feat(api)!: add endpoint")
+	if !ok || h.Type != "feat" || h.Scope != "api" || !h.Breaking || h.Description != "add endpoint" {
+		t.Errorf("ParseHeader = %+v, %v", h, ok)
+	}
+	if _, ok := ParseHeader("just words"); ok {
+		t.Error("expected non-conventional subject to fail")
+	}
+}
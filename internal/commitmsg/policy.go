@@ -0,0 +1,84 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Policy is a set of local rules on message content, beyond the format checks of Lint.
+// The zero Policy allows everything.
+type Policy struct {
+	banned   []*regexp.Regexp
+	words    []string
+	deny     []*regexp.Regexp
+	prefixes []string
+}
+
+// NewPolicy compiles a policy. bannedWords are matched as whole words, ignoring case
+// (profanity, internal codenames); denyPatterns are regular expressions the message
+// must not match; and when requiredPrefixes is not empty the subject must start with
+// one of them.
+func NewPolicy(bannedWords, denyPatterns, requiredPrefixes []string) (Policy, error) {
+	var p Policy
+	for _, w := range bannedWords {
+		if w = strings.TrimSpace(w); w == "" {
+			continue
+		}
+		p.banned = append(p.banned, regexp.MustCompile(`(?i)(?:^|\W)`+regexp.QuoteMeta(w)+`(?:\W|$)`))
+		p.words = append(p.words, w)
+	}
+	for _, pat := range denyPatterns {
+		re, err := regexp.Compile(pat)
+		if err != nil {
+			return Policy{}, fmt.Errorf("deny pattern %q: %w", pat, err)
+		}
+		p.deny = append(p.deny, re)
+	}
+	p.prefixes = requiredPrefixes
+	return p, nil
+}
+
+// Empty reports whether p has no rules.
+func (p Policy) Empty() bool {
+	return len(p.banned) == 0 && len(p.deny) == 0 && len(p.prefixes) == 0
+}
+
+// Check returns the issues msg has with p. Messages git generates itself are exempt.
+func (p Policy) Check(msg string) []Issue {
+	msg = Clean(msg)
+	if p.Empty() || isExempt(Subject(msg)) {
+		return nil
+	}
+
+	var issues []Issue
+	for i, re := range p.banned {
+		if loc := re.FindStringIndex(msg); loc != nil {
+			issues = append(issues, Issue{Rule: "banned-word", Line: lineAt(msg, loc[0]), Message: fmt.Sprintf("%q must not appear in the message", p.words[i])})
+		}
+	}
+	for _, re := range p.deny {
+		if loc := re.FindStringIndex(msg); loc != nil {
+			issues = append(issues, Issue{Rule: "deny-pattern", Line: lineAt(msg, loc[0]), Message: fmt.Sprintf("%q must not appear in the message (matches %s)", msg[loc[0]:loc[1]], re)})
+		}
+	}
+	if len(p.prefixes) > 0 {
+		subject := Subject(msg)
+		ok := false
+		for _, pre := range p.prefixes {
+			if strings.HasPrefix(subject, pre) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			issues = append(issues, Issue{Rule: "required-prefix", Line: 1, Message: "subject must start with one of: " + strings.Join(p.prefixes, ", ")})
+		}
+	}
+	return issues
+}
+
+// lineAt returns the 1-based line of msg that byte offset i falls on.
+func lineAt(msg string, i int) int {
+	return strings.Count(msg[:i], "\n") + 1
+}
@@ -0,0 +1,46 @@
+package commitmsg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	p, err := NewPolicy([]string{"Capybara", "wtf"}, []string{`(?i)\bhotfix\b`, `JIRA-\d+`}, []string{"[core]", "[api]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		msg   string
+		rules []string
+	}{
+		{"[core] add cache", nil},
+		{"add cache", []string{"required-prefix"}},
+		{"[api] wire up capybara endpoint", []string{"banned-word"}},
+		{"[api] add endpoints\n\nWTF, this was broken.\nSee JIRA-42.", []string{"banned-word", "deny-pattern"}},
+		{"[core] Hotfix the parser", []string{"deny-pattern"}},
+		{"[core] add capybaras", nil}, // whole words only
+		{"Merge branch 'capybara'", nil},
+	}
+	for _, tt := range tests {
+		var rules []string
+		for _, is := range p.Check(tt.msg) {
+			rules = append(rules, is.Rule)
+		}
+		if !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("Check(%q) rules = %v; want %v", tt.msg, rules, tt.rules)
+		}
+	}
+
+	issues := p.Check("[core] fix it\n\nSee JIRA-42.")
+	if len(issues) != 1 || issues[0].Line != 3 || issues[0].Message != `"JIRA-42" must not appear in the message (matches JIRA-\d+)` {
+		t.Errorf("issue = %+v", issues)
+	}
+
+	if _, err := NewPolicy(nil, []string{"("}, nil); err == nil {
+		t.Error("bad deny pattern accepted")
+	}
+	if !(Policy{}).Empty() || (Policy{}).Check("wtf") != nil {
+		t.Error("zero policy is not empty")
+	}
+}
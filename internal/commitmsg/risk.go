@@ -0,0 +1,91 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Risk is one reason to look twice at a change before committing it.
+type Risk struct {
+	Kind   string // migration, auth, deletions, todo, mixed
+	Detail string
+}
+
+func (r Risk) String() string {
+	return r.Detail
+}
+
+var (
+	reMigrationPath = regexp.MustCompile(`(?i)(^|/)(migrations?|migrate|alembic|flyway|liquibase)/|(^|/)db/(schema|structure)\.|\.sql$`)
+	// reAuthPath matches whole words in a path, ending at a separator or a capital
+	// letter ("LoginForm.tsx"), so "docs/author.md" is not one.
+	reAuthPath = regexp.MustCompile(`(^|[/._-])(?i:auth[nz]?|authenticat(?:e|ion|or)|authori[sz](?:e|ation|er)|log(?:in|out)|passw(?:or)?d|sessions?|oauth2?|jwt|saml|sso|crypto|permissions?|rbac|secur(?:e|ity)|csrf|credentials?|secrets?)($|[/._-]|[A-Z0-9])`)
+	reTodo     = regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK)\b`)
+)
+
+// deletionHeavyMin is the number of deleted lines from which a mostly-deleting change is flagged.
+const deletionHeavyMin = 100
+
+// AssessRisks flags changes that deserve a second look: database migrations, code
+// that handles authentication or secrets, deletion-heavy diffs, newly added
+// TODO/FIXME markers, and unrelated changes staged together. It only looks at
+// paths and diffs, so it is cheap but rough.
+func AssessRisks(files []DiffFile) []Risk {
+	var risks []Risk
+	var migrations, auth []string
+	todos, deleted := 0, 0
+	facts := ComputeFacts(files, "")
+	for i, d := range files {
+		switch {
+		case reMigrationPath.MatchString(d.Path):
+			migrations = append(migrations, d.Path)
+		case reAuthPath.MatchString(d.Path) && !isTestPath(d.Path) && !isDocPath(d.Path):
+			auth = append(auth, d.Path)
+		}
+		for _, ln := range strings.Split(d.Diff, "\n") {
+			if strings.HasPrefix(ln, "+") && !strings.HasPrefix(ln, "+++ ") && reTodo.MatchString(ln) {
+				todos++
+			}
+		}
+		if facts.Files[i].Status == StatusDeleted {
+			deleted++
+		}
+	}
+
+	if len(migrations) > 0 {
+		risks = append(risks, Risk{Kind: "migration", Detail: "Database migration or schema change: " + listPaths(migrations)})
+	}
+	if len(auth) > 0 {
+		risks = append(risks, Risk{Kind: "auth", Detail: "Touches authentication or security code: " + listPaths(auth)})
+	}
+	if facts.Deletions >= deletionHeavyMin && facts.Deletions > 3*facts.Insertions {
+		detail := fmt.Sprintf("Deletion-heavy: %d lines removed, %d added", facts.Deletions, facts.Insertions)
+		if deleted > 0 {
+			detail += fmt.Sprintf(", %d file%s deleted", deleted, plural(deleted))
+		}
+		risks = append(risks, Risk{Kind: "deletions", Detail: detail})
+	}
+	if todos > 0 {
+		risks = append(risks, Risk{Kind: "todo", Detail: fmt.Sprintf("Adds %d TODO/FIXME marker%s", todos, plural(todos))})
+	}
+	if areas := UnrelatedAreas(files); len(areas) > 1 {
+		risks = append(risks, Risk{Kind: "mixed", Detail: "Unrelated changes staged together: " + DescribeAreas(areas) + "; consider a commit for each"})
+	}
+	return risks
+}
+
+// listPaths lists up to three paths, then how many more there are.
+func listPaths(paths []string) string {
+	if len(paths) <= 3 {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:3], ", "), len(paths)-3)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
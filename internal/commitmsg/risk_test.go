@@ -0,0 +1,41 @@
+package commitmsg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssessRisks(t *testing.T) {
+	kinds := func(files ...DiffFile) []string {
+		var out []string
+		for _, r := range AssessRisks(files) {
+			out = append(out, r.Kind)
+		}
+		return out
+	}
+
+	if got := kinds(DiffFile{"db/migrations/0042_drop_users.sql", "+DROP TABLE users;\n"}); !reflect.DeepEqual(got, []string{"migration"}) {
+		t.Errorf("migration: %v", got)
+	}
+	if got := kinds(DiffFile{"internal/auth/jwt.go", "+return true\n"}, DiffFile{"web/LoginForm.tsx", "+x\n"}); !reflect.DeepEqual(got, []string{"auth"}) {
+		t.Errorf("auth: %v", got)
+	}
+	if got := kinds(DiffFile{"docs/author.md", "+x\n"}, DiffFile{"ui/sessionless-banner.tsx", "+x\n"}); got != nil {
+		t.Errorf("author: %v", got)
+	}
+	if got := kinds(DiffFile{"internal/auth/jwt_test.go", "+x\n"}, DiffFile{"lexer/tokenizer.go", "+x\n"}); got != nil {
+		t.Errorf("tests and lookalikes flagged: %v", got)
+	}
+	if got := kinds(DiffFile{"a.go", "+// TODO: handle errors\n-// FIXME: old\n+x := 1 // FIXME\n"}); !reflect.DeepEqual(got, []string{"todo"}) {
+		t.Errorf("todo: %v", got)
+	}
+	big := "deleted file mode 100644\n" + strings.Repeat("-old line\n", 150)
+	risks := AssessRisks([]DiffFile{{"legacy.go", big}, {"new.go", "+x\n"}})
+	if len(risks) != 1 || risks[0].Detail != "Deletion-heavy: 150 lines removed, 1 added, 1 file deleted" {
+		t.Errorf("deletions: %v", risks)
+	}
+	if got := kinds(DiffFile{"a.go", strings.Repeat("-x\n", 150) + strings.Repeat("+y\n", 100)}); got != nil {
+		t.Errorf("balanced rewrite flagged: %v", got)
+	}
+}
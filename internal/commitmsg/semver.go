@@ -0,0 +1,191 @@
+package commitmsg
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Bump is how much a release changes the version, by Semantic Versioning.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// CommitBump returns the release a commit message calls for under the default
+// semantic-release rules (the conventional-changelog angular preset): a breaking
+// change is major, feat is minor, fix, perf and revert are patch, and anything else,
+// including messages that are not Conventional Commits, is none. why says which
+// rule applied.
+//
+// Near misses such as "feat:add" or "Fix (api): x", which semantic-release would
+// also skip, are read leniently, since a release that drops them is more surprising
+// than one that counts them.
+func CommitBump(msg string) (b Bump, why string) {
+	c, ok := ParseLenient(msg)
+	if !ok {
+		return BumpNone, "not a conventional commit"
+	}
+	switch {
+	case c.Breaking:
+		return BumpMajor, "breaking change"
+	case c.Type == "feat":
+		return BumpMinor, "feat"
+	case c.Type == "fix", c.Type == "perf", c.Type == "revert":
+		return BumpPatch, c.Type
+	}
+	return BumpNone, c.Type
+}
+
+// ParseLenient parses msg as CommitBump reads it: by the Conventional Commits
+// grammar, or else leniently, so that near misses still count. ok is false for a
+// message that is not recognizably a conventional commit.
+func ParseLenient(msg string) (c Commit, ok bool) {
+	msg = Clean(msg)
+	c, err := ParseConventional(msg)
+	if err != nil {
+		return parseLoose(msg)
+	}
+	return c, true
+}
+
+// reLooseHeader matches a header that is recognizably Conventional Commits but
+// breaks the grammar's spacing or case.
+var reLooseHeader = regexp.MustCompile(`^(\w+)\s*(?:\(([^()]*)\))?\s*(!)?\s*:\s*(\S.*)$`)
+
+// parseLoose reads a message ParseConventional rejects: the header by reLooseHeader,
+// with the type lower-cased, and a breaking change from "!" or a loosely spelled
+// breaking-change footer.
+func parseLoose(msg string) (Commit, bool) {
+	lines := strings.Split(msg, "\n")
+	m := reLooseHeader.FindStringSubmatch(lines[0])
+	if m == nil {
+		return Commit{}, false
+	}
+	c := Commit{Header: Header{Type: strings.ToLower(m[1]), Scope: strings.TrimSpace(m[2]), Breaking: m[3] == "!", Description: m[4]}}
+	for _, ln := range lines[1:] {
+		if reLooseBreaking.MatchString(ln) {
+			c.Breaking = true
+		}
+	}
+	return c, true
+}
+
+// Version is a parsed semantic version, keeping the tag's "v" prefix if it had one.
+type Version struct {
+	Prefix              string
+	Major, Minor, Patch int
+	Pre                 string // pre-release, without the '-'
+}
+
+var reSemver = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// ParseVersion parses a tag such as "v1.2.3" or "1.2.3-rc.1". Build metadata is dropped.
+func ParseVersion(s string) (Version, error) {
+	m := reSemver.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Version{}, fmt.Errorf("%q is not a semantic version", s)
+	}
+	v := Version{Prefix: m[1], Pre: m[5]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Less reports whether v comes before w, by SemVer precedence: a pre-release comes
+// before its release, and pre-releases compare identifier by identifier, numeric
+// ones as numbers, so rc.2 comes before rc.10.
+func (v Version) Less(w Version) bool {
+	switch {
+	case v.Major != w.Major:
+		return v.Major < w.Major
+	case v.Minor != w.Minor:
+		return v.Minor < w.Minor
+	case v.Patch != w.Patch:
+		return v.Patch < w.Patch
+	case v.Pre == "" || w.Pre == "":
+		return v.Pre != "" && w.Pre == ""
+	}
+	return comparePre(v.Pre, w.Pre) < 0
+}
+
+// comparePre compares two pre-releases by SemVer §11: numeric identifiers by value
+// and below alphanumeric ones, others in ASCII order, and a shorter list first when
+// it is a prefix of the longer one.
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xerr := strconv.ParseUint(as[i], 10, 64)
+		y, yerr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case xerr == nil && yerr == nil:
+			if x != y {
+				return cmp.Compare(x, y)
+			}
+		case xerr == nil:
+			return -1
+		case yerr == nil:
+			return 1
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// Bump returns the version after a release of kind b. A pre-release is released as
+// is when its own version already carries a bump that large, so 2.0.0-rc.1 with a
+// feat becomes 2.0.0 but 1.2.1-rc.1 with a feat becomes 1.3.0.
+func (v Version) Bump(b Bump) Version {
+	if b == BumpNone {
+		return v
+	}
+	release := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	if v.Pre != "" {
+		carried := BumpMajor
+		switch {
+		case v.Patch > 0:
+			carried = BumpPatch
+		case v.Minor > 0:
+			carried = BumpMinor
+		}
+		if b <= carried {
+			return release
+		}
+	}
+	switch b {
+	case BumpMajor:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case BumpMinor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	}
+	release.Patch++
+	return release
+}
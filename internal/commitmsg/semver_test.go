@@ -0,0 +1,68 @@
+package commitmsg
+
+import "testing"
+
+func TestCommitBump(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want Bump
+	}{
+		{"feat(api): add pagination", BumpMinor},
+		{"fix: handle empty input", BumpPatch},
+		{"perf: cache parsed templates", BumpPatch},
+		{"docs: fix typo", BumpNone},
+		{"refactor!: drop the v1 client", BumpMajor},
+		{"chore: bump deps\n\nBREAKING CHANGE: requires Go 1.25", BumpMajor},
+		{"Update README", BumpNone},
+		{"feat:add retries", BumpMinor},
+		{"Fix (api): handle empty input", BumpPatch},
+		{"chore: drop node 16\n\nbreaking change: requires node 18", BumpMajor},
+	}
+	for _, tt := range tests {
+		if got, why := CommitBump(tt.msg); got != tt.want {
+			t.Errorf("CommitBump(%q) = %v (%s); want %v", tt.msg, got, why, tt.want)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		from string
+		bump Bump
+		want string
+	}{
+		{"v1.2.3", BumpPatch, "v1.2.4"},
+		{"v1.2.3", BumpMinor, "v1.3.0"},
+		{"1.2.3", BumpMajor, "2.0.0"},
+		{"v1.2.3", BumpNone, "v1.2.3"},
+		{"v2.0.0-rc.1", BumpMinor, "v2.0.0"},
+		{"v1.3.0-beta.2", BumpMajor, "v2.0.0"},
+		{"v1.2.1-rc.1", BumpMinor, "v1.3.0"},
+		{"v1.2.1-rc.1+build.5", BumpPatch, "v1.2.1"},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Bump(tt.bump).String(); got != tt.want {
+			t.Errorf("%s + %v = %s; want %s", tt.from, tt.bump, got, tt.want)
+		}
+	}
+	if _, err := ParseVersion("release-2024"); err == nil {
+		t.Error("parsed a non-semver tag")
+	}
+	a, _ := ParseVersion("v1.0.0-rc.1")
+	b, _ := ParseVersion("v1.0.0")
+	if !a.Less(b) || b.Less(a) {
+		t.Error("a pre-release must sort before its release")
+	}
+	order := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0-rc.2", "1.0.0-rc.10", "1.0.0"}
+	for i := 1; i < len(order); i++ {
+		a, _ := ParseVersion(order[i-1])
+		b, _ := ParseVersion(order[i])
+		if !a.Less(b) || b.Less(a) {
+			t.Errorf("%s must sort before %s", order[i-1], order[i])
+		}
+	}
+}
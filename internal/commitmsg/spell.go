@@ -0,0 +1,232 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// misspellings maps common misspellings, in lower case, to their correction. Only
+// words listed here are changed, so identifiers and jargon are never "corrected"
+// into something else.
+var misspellings = map[string]string{
+	"accomodate":      "accommodate",
+	"accross":         "across",
+	"acheive":         "achieve",
+	"adress":          "address",
+	"agressive":       "aggressive",
+	"alot":            "a lot",
+	"algorithim":      "algorithm",
+	"allready":        "already",
+	"alltogether":     "altogether",
+	"ammount":         "amount",
+	"apparantly":      "apparently",
+	"appearence":      "appearance",
+	"arguement":       "argument",
+	"asynchonous":     "asynchronous",
+	"asyncronous":     "asynchronous",
+	"attribtue":       "attribute",
+	"authenication":   "authentication",
+	"authetication":   "authentication",
+	"availabe":        "available",
+	"availible":       "available",
+	"becuase":         "because",
+	"beggining":       "beginning",
+	"begining":        "beginning",
+	"beleive":         "believe",
+	"calender":        "calendar",
+	"charachter":      "character",
+	"charater":        "character",
+	"comparision":     "comparison",
+	"compatability":   "compatibility",
+	"compatable":      "compatible",
+	"compatibilty":    "compatibility",
+	"compitable":      "compatible",
+	"completly":       "completely",
+	"concurent":       "concurrent",
+	"configuartion":   "configuration",
+	"configuraiton":   "configuration",
+	"connnection":     "connection",
+	"consistant":      "consistent",
+	"containg":        "containing",
+	"correclty":       "correctly",
+	"corrent":         "correct",
+	"curent":          "current",
+	"currenly":        "currently",
+	"defenition":      "definition",
+	"definately":      "definitely",
+	"definitly":       "definitely",
+	"delimeter":       "delimiter",
+	"dependancy":      "dependency",
+	"dependancies":    "dependencies",
+	"dependecy":       "dependency",
+	"desciption":      "description",
+	"descripton":      "description",
+	"diffrent":        "different",
+	"dissapear":       "disappear",
+	"documentaion":    "documentation",
+	"documention":     "documentation",
+	"doesnt":          "doesn't",
+	"duplciate":       "duplicate",
+	"enviroment":      "environment",
+	"enviornment":     "environment",
+	"exection":        "execution",
+	"existance":       "existence",
+	"existant":        "existent",
+	"explicitely":     "explicitly",
+	"faciliate":       "facilitate",
+	"familar":         "familiar",
+	"functionaility":  "functionality",
+	"funtion":         "function",
+	"funtionality":    "functionality",
+	"gaurantee":       "guarantee",
+	"guarentee":       "guarantee",
+	"handeling":       "handling",
+	"heirarchy":       "hierarchy",
+	"identifer":       "identifier",
+	"immediatly":      "immediately",
+	"implemenation":   "implementation",
+	"implmentation":   "implementation",
+	"incomming":       "incoming",
+	"independant":     "independent",
+	"informations":    "information",
+	"initalize":       "initialize",
+	"intial":          "initial",
+	"intialize":       "initialize",
+	"intialization":   "initialization",
+	"lenght":          "length",
+	"maintainance":    "maintenance",
+	"managment":       "management",
+	"mesage":          "message",
+	"messsage":        "message",
+	"mispelled":       "misspelled",
+	"neccessary":      "necessary",
+	"necesary":        "necessary",
+	"occassion":       "occasion",
+	"occured":         "occurred",
+	"occurence":       "occurrence",
+	"occuring":        "occurring",
+	"paramter":        "parameter",
+	"paramters":       "parameters",
+	"parrallel":       "parallel",
+	"performace":      "performance",
+	"permision":       "permission",
+	"persistant":      "persistent",
+	"posible":         "possible",
+	"preceeding":      "preceding",
+	"prefered":        "preferred",
+	"previos":         "previous",
+	"proccess":        "process",
+	"proccessing":     "processing",
+	"programatically": "programmatically",
+	"properites":      "properties",
+	"propery":         "property",
+	"recieve":         "receive",
+	"recieved":        "received",
+	"reciever":        "receiver",
+	"recieves":        "receives",
+	"recursivly":      "recursively",
+	"refered":         "referred",
+	"refering":        "referring",
+	"relevent":        "relevant",
+	"remaing":         "remaining",
+	"repositry":       "repository",
+	"repostiory":      "repository",
+	"requried":        "required",
+	"respone":         "response",
+	"responsed":       "responded",
+	"retreive":        "retrieve",
+	"retrive":         "retrieve",
+	"seperate":        "separate",
+	"seperated":       "separated",
+	"seperator":       "separator",
+	"sucess":          "success",
+	"succesful":       "successful",
+	"succesfully":     "successfully",
+	"successfull":     "successful",
+	"sucessfully":     "successfully",
+	"supress":         "suppress",
+	"synchonous":      "synchronous",
+	"teh":             "the",
+	"threshhold":      "threshold",
+	"tranform":        "transform",
+	"truely":          "truly",
+	"unecessary":      "unnecessary",
+	"unneccessary":    "unnecessary",
+	"untill":          "until",
+	"upadte":          "update",
+	"usefull":         "useful",
+	"validaton":       "validation",
+	"varible":         "variable",
+	"verison":         "version",
+	"visiblity":       "visibility",
+	"wich":            "which",
+	"writting":        "writing",
+}
+
+var reWord = regexp.MustCompile(`\b[A-Za-z]+\b`)
+
+// KnownMisspellings returns the words of text, as written, that Spell would correct.
+// Callers add those found in code to the dictionary, so a misspelled identifier the
+// message refers to is left as it is.
+func KnownMisspellings(text string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, w := range reWord.FindAllString(text, -1) {
+		if _, ok := misspellings[strings.ToLower(w)]; ok && !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// Spell corrects common misspellings in msg, keeping each word's case. Words in
+// dictionary (exact case), code spans, and fenced or indented code are left alone.
+func Spell(msg string, dictionary []string) string {
+	known := make(map[string]bool, len(dictionary))
+	for _, w := range dictionary {
+		known[w] = true
+	}
+	lines := strings.Split(msg, "\n")
+	fenced := false
+	for i, ln := range lines {
+		trimmed := strings.TrimSpace(ln)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced || strings.HasPrefix(ln, "\t") || strings.HasPrefix(ln, "    ") {
+			continue
+		}
+		// Odd parts are inside `code spans`.
+		parts := strings.Split(ln, "`")
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = reWord.ReplaceAllStringFunc(parts[j], func(w string) string {
+				fix, ok := misspellings[strings.ToLower(w)]
+				if !ok || known[w] {
+					return w
+				}
+				return matchCase(fix, w)
+			})
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// matchCase returns fix in the case of w: lower, Title, or UPPER. Words in any other
+// case (camelCase) look like identifiers and are kept as written.
+func matchCase(fix, w string) string {
+	switch {
+	case w == strings.ToLower(w):
+		return fix
+	case w == strings.ToUpper(w) && len(w) > 1:
+		return strings.ToUpper(fix)
+	case w[1:] == strings.ToLower(w[1:]):
+		r, n := utf8.DecodeRuneInString(fix)
+		return string(unicode.ToUpper(r)) + fix[n:]
+	}
+	return w
+}
@@ -0,0 +1,32 @@
+package commitmsg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpell(t *testing.T) {
+	tests := []struct {
+		msg, want string
+		dict      []string
+	}{
+		{msg: "fix: handle recieved messages seperately", want: "fix: handle received messages seperately"},
+		{msg: "Recieve events\n\nTeh RECIEVE loop occured twice.", want: "Receive events\n\nThe RECEIVE loop occurred twice."},
+		{msg: "fix: rename `recieve` to receive", want: "fix: rename `recieve` to receive"},
+		{msg: "feat: add Recieve\n\n```\nrecieve()\n```\n    recieve()", want: "feat: add Receive\n\n```\nrecieve()\n```\n    recieve()"},
+		{msg: "fix: keep recieve_msg and onRecieve", want: "fix: keep recieve_msg and onRecieve"},
+		{msg: "fix: call Recieve on the queue", want: "fix: call Recieve on the queue", dict: []string{"Recieve"}},
+	}
+	for _, tt := range tests {
+		if got := Spell(tt.msg, tt.dict); got != tt.want {
+			t.Errorf("Spell(%q) = %q; want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestKnownMisspellings(t *testing.T) {
+	got := KnownMisspellings("+func (q *Queue) Recieve() {}\n+// recieve and recieve again\n")
+	if want := []string{"Recieve", "recieve"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("KnownMisspellings = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// reListItem matches the marker of a bullet or numbered list item, with its indent.
+var reListItem = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+`)
+
+// reTrailerLine matches git trailers such as "Signed-off-by: ..." and "Change-Id: ...",
+// which must stay on one line.
+var reTrailerLine = regexp.MustCompile(`^[A-Za-z]+(?:-[A-Za-z]+)+: \S`)
+
+// Wrap hard-wraps the lines of body that are longer than width runes at word
+// boundaries. Continuation lines of a list item are indented under its text. Code
+// spans, fenced and indented code blocks, trailers, and words longer than width
+// (URLs) are never broken.
+func Wrap(body string, width int) string {
+	if width <= 0 {
+		return body
+	}
+	lines := strings.Split(body, "\n")
+	out := make([]string, 0, len(lines))
+	fenced := false
+	for _, ln := range lines {
+		trimmed := strings.TrimSpace(ln)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			out = append(out, ln)
+			continue
+		}
+		if fenced || utf8.RuneCountInString(ln) <= width ||
+			strings.HasPrefix(ln, "\t") || strings.HasPrefix(ln, "    ") || reTrailerLine.MatchString(ln) {
+			out = append(out, ln)
+			continue
+		}
+		out = append(out, wrapLine(ln, width)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapLine breaks one long line. The first line keeps ln's indent and list marker;
+// the rest are indented to where the text started.
+func wrapLine(ln string, width int) []string {
+	lead := ln[:len(ln)-len(strings.TrimLeft(ln, " \t"))]
+	if m := reListItem.FindString(ln); m != "" {
+		lead = m
+	}
+	hang := strings.Repeat(" ", utf8.RuneCountInString(lead))
+
+	var out []string
+	cur, n, empty := lead, utf8.RuneCountInString(lead), true
+	for _, w := range words(ln[len(lead):]) {
+		wn := utf8.RuneCountInString(w)
+		if !empty && n+1+wn > width {
+			out = append(out, cur)
+			cur, n, empty = hang, len(hang), true
+		}
+		if !empty {
+			cur += " "
+			n++
+		}
+		cur += w
+		n += wn
+		empty = false
+	}
+	return append(out, cur)
+}
+
+// words splits s at spaces, keeping `code spans` that contain spaces in one piece.
+func words(s string) []string {
+	var out []string
+	open := false
+	for _, f := range strings.Fields(s) {
+		if open {
+			out[len(out)-1] += " " + f
+		} else {
+			out = append(out, f)
+		}
+		if strings.Count(f, "`")%2 == 1 {
+			open = !open
+		}
+	}
+	return out
+}
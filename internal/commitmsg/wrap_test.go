@@ -0,0 +1,50 @@
+package commitmsg
+
+import "testing"
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{
+			name: "paragraph",
+			body: "The parser used to panic on empty input because it indexed the first token without checking.",
+			want: "The parser used to panic on empty input because it indexed the first\ntoken without checking.",
+		},
+		{
+			name: "short lines are kept",
+			body: "Short line.\nAnother one.",
+			want: "Short line.\nAnother one.",
+		},
+		{
+			name: "bullet hangs under its text",
+			body: "- Return an empty AST from Parse when the input is empty instead of panicking on the first token",
+			want: "- Return an empty AST from Parse when the input is empty instead of\n  panicking on the first token",
+		},
+		{
+			name: "numbered item",
+			body: "10. Return an empty AST from Parse when the input is empty instead of panicking",
+			want: "10. Return an empty AST from Parse when the input is empty instead of\n    panicking",
+		},
+		{
+			name: "code span is not broken",
+			body: "Callers that passed an empty string will now get `parser.Parse(\"\") == nil` back.",
+			want: "Callers that passed an empty string will now get\n`parser.Parse(\"\") == nil` back.",
+		},
+		{
+			name: "code block and trailer are kept",
+			body: "```\nif s == \"\" { return nil, nil } // a comment that goes well past the wrapping column\n```\nSigned-off-by: Somebody With A Very Long Name <somebody.with.a.very.long.name@example.com>",
+			want: "```\nif s == \"\" { return nil, nil } // a comment that goes well past the wrapping column\n```\nSigned-off-by: Somebody With A Very Long Name <somebody.with.a.very.long.name@example.com>",
+		},
+		{
+			name: "long word stands alone",
+			body: "See https://example.com/a/very/long/link/that/cannot/be/broken/anywhere/at/all/really for details.",
+			want: "See\nhttps://example.com/a/very/long/link/that/cannot/be/broken/anywhere/at/all/really\nfor details.",
+		},
+	}
+	for _, tt := range tests {
+		if got := Wrap(tt.body, 72); got != tt.want {
+			t.Errorf("%s: Wrap =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 type FileConfig struct {
@@ -16,26 +23,219 @@ type FileConfig struct {
 	AnthropicKey string `json:"anthropic_key,omitempty"`
 	GeminiKey    string `json:"gemini_key,omitempty"`
 
+	// OpenAI organization and project requests are attributed to (OpenAI-Organization
+	// and OpenAI-Project headers), for keys scoped to a project
+	OpenAIOrg     string `json:"openai_org,omitempty"`
+	OpenAIProject string `json:"openai_project,omitempty"`
+	// OpenAI endpoint: chat (/chat/completions, the default) or responses (/responses)
+	OpenAIAPI string `json:"openai_api,omitempty"`
+
+	// Microsoft Entra ID tokens instead of an API key for Azure OpenAI: azure_auth is
+	// client_credentials (tenant, client ID and secret) or managed_identity (client ID
+	// optional, for a user-assigned identity)
+	AzureAuth         string `json:"azure_auth,omitempty"`
+	AzureTenantID     string `json:"azure_tenant_id,omitempty"`
+	AzureClientID     string `json:"azure_client_id,omitempty"`
+	AzureClientSecret string `json:"azure_client_secret,omitempty"`
+
+	// HashiCorp Vault, for "vault:PATH#FIELD" key references: the server, an optional
+	// namespace, and a token (else ~/.vault-token) or an AppRole role and secret ID
+	VaultAddr      string `json:"vault_addr,omitempty"`
+	VaultNamespace string `json:"vault_namespace,omitempty"`
+	VaultToken     string `json:"vault_token,omitempty"`
+	VaultRoleID    string `json:"vault_role_id,omitempty"`
+	VaultSecretID  string `json:"vault_secret_id,omitempty"`
+
+	// AWS, for "aws-secret:" and "aws-ssm:" key references: the region, when not
+	// from the environment, and a profile of the shared config files
+	AWSRegion  string `json:"aws_region,omitempty"`
+	AWSProfile string `json:"aws_profile,omitempty"`
+
+	// GitLab merge requests (commitgen mr): the instance, when not the origin remote's
+	// host, and an access token with the api scope
+	GitLabURL   string `json:"gitlab_url,omitempty"`
+	GitLabToken string `json:"gitlab_token,omitempty"`
+
 	PromptTemplate string `json:"prompt_template,omitempty"`
+	// Custom instructions: files, paths in the repository, or http(s) URLs, joined in order
+	Instructions []string `json:"instructions,omitempty"`
+
+	// Template-only mode: fill MessageTemplate (Go text/template) instead of calling the AI
+	NoAI            *bool  `json:"no_ai,omitempty"`
+	MessageTemplate string `json:"message_template,omitempty"`
 
 	IgnoredFiles []string `json:"ignored_files,omitempty"`
 
+	// Patterns of vendored or generated files, summarized instead of shown; "!dist/"
+	// turns off a built-in one
+	GeneratedFiles []string `json:"generated_files,omitempty"`
+
+	// prepare-commit-msg sources for which the hook does nothing
+	HookSkipSources []string `json:"hook_skip_sources,omitempty"`
+	// Seconds the hook may take to produce a message before it writes one guessed
+	// from the diff (default 45, 0 waits as long as the request timeout)
+	HookTimeout *int `json:"hook_timeout,omitempty"`
+
 	// Advanced Settings
 	RecentN      *int     `json:"recent_n,omitempty"`
 	MaxFiles     *int     `json:"max_files,omitempty"`
 	Summarize    *bool    `json:"summarize,omitempty"`
 	Temperature  *float64 `json:"temperature,omitempty"`
 	Conventional *bool    `json:"conventional,omitempty"`
+
+	// Rewrite generated subjects into imperative mood without a trailing period (default true)
+	Imperative *bool `json:"imperative,omitempty"`
+
+	// Trailers appended to accepted messages
+	Signoff     *bool    `json:"signoff,omitempty"`
+	CoAuthors   []string `json:"co_authors,omitempty"`
+	Trailers    []string `json:"trailers,omitempty"`
+	GeneratedBy *bool    `json:"generated_by,omitempty"`
+
+	// Jira smart commits: append "PROJ-123 #comment ..." for the issue key in the branch
+	// name, optionally only for these projects, with this workflow transition
+	JiraSmartCommit *bool    `json:"jira_smart_commit,omitempty"`
+	JiraProjects    []string `json:"jira_projects,omitempty"`
+	JiraTransition  string   `json:"jira_transition,omitempty"`
+
+	// Linear: for a Linear-style branch ("user/eng-123-title"), add "Fixes ENG-123" (the
+	// magic word is configurable), optionally only for these teams. With an API key, the
+	// issue is fetched for context and keys Linear doesn't know are skipped.
+	Linear          *bool    `json:"linear,omitempty"`
+	LinearTeams     []string `json:"linear_teams,omitempty"`
+	LinearMagicWord string   `json:"linear_magic_word,omitempty"`
+	LinearAPIKey    string   `json:"linear_api_key,omitempty"`
+
+	// Correct common misspellings in generated messages (default true), except dictionary words
+	Spellcheck *bool    `json:"spellcheck,omitempty"`
+	Dictionary []string `json:"dictionary,omitempty"`
+
+	// Strip emoji and non-ASCII punctuation from generated messages
+	ASCII *bool `json:"ascii,omitempty"`
+
+	// Column at which generated message bodies are hard-wrapped (default 72; 0 disables)
+	BodyWrap *int `json:"body_wrap,omitempty"`
+
+	// WASM summarizers for extra file types: "ext[,ext...]=path/to/plugin.wasm"
+	SummarizerPlugins []string `json:"summarizer_plugins,omitempty"`
+
+	// Append-only JSONL log of what is sent to providers, redacting secrets and
+	// matches of audit_redact (regular expressions)
+	AuditLog    string   `json:"audit_log,omitempty"`
+	AuditRedact []string `json:"audit_redact,omitempty"`
+
+	// Shortcuts for the actions after generating, as "action=key[,key...]" (actions:
+	// commit, regenerate, edit, editor, previous, type, model, cancel), and quick
+	// mode, which drops the action list so that Enter commits
+	Keybindings []string `json:"keybindings,omitempty"`
+	Quick       *bool    `json:"quick,omitempty"`
+	// Models offered by the "Try another model" action, each a model of the
+	// configured provider or provider[:model] (default: the other providers with a key)
+	OtherModels []string `json:"other_models,omitempty"`
+
+	// Plain prompts instead of the full-screen TUI, for screen readers and dumb
+	// terminals; also turned on by HUH_ACCESSIBLE, TERM=dumb, or no terminal
+	Accessible *bool `json:"accessible,omitempty"`
+
+	// Language of commitgen's own menus, notices and errors ("en", "vi"); commit
+	// messages are not affected. Defaults to LC_ALL, LC_MESSAGES or LANG
+	Language string `json:"language,omitempty"`
+
+	// Shell commands that transform each request's JSON payload before it is sent, and
+	// the generated message afterwards (stdin to stdout). Not read from team configs.
+	PrePromptCommand   string `json:"pre_prompt_command,omitempty"`
+	PostMessageCommand string `json:"post_message_command,omitempty"`
+
+	// Generate this many candidate messages and keep the best-ranked one (default 1)
+	BestOf *int `json:"best_of,omitempty"`
+
+	// Ask the model to critique and improve each message in a second request
+	Refine *bool `json:"refine,omitempty"`
+
+	// Ask the model to flag risky changes alongside the local heuristics
+	RiskCheck *bool `json:"risk_check,omitempty"`
+
+	// Accepted messages from history shown to the model as examples of my style (default 3; 0 disables)
+	StyleExamples *int `json:"style_examples,omitempty"`
+
+	// Show the staged files for deselection before generating
+	SelectFiles *bool `json:"select_files,omitempty"`
+
+	// Estimated prompt tokens above which files are summarized one by one first (0 disables)
+	ContextBudget *int `json:"context_budget,omitempty"`
+
+	// Context window requested from Ollama (num_ctx); 0 keeps the model's
+	OllamaNumCtx *int `json:"ollama_num_ctx,omitempty"`
+
+	// Spend limits in tokens (0 disables): the estimated prompt of one run, and that
+	// plus today's recorded usage. Over them commitgen warns, and switches to
+	// budget_fallback if set: a cheaper model ("gpt-4o-mini", "ollama:llama3.1") or
+	// "no-ai" for the message template. Local providers (Ollama) are not limited.
+	MaxTokensPerRun *int   `json:"max_tokens_per_run,omitempty"`
+	MaxTokensPerDay *int   `json:"max_tokens_per_day,omitempty"`
+	BudgetFallback  string `json:"budget_fallback,omitempty"`
+
+	// Spend limits in US dollars (0 disables), the same way, estimated from
+	// model_prices: "model=input/output" entries, each the price per million
+	// tokens, e.g. "gpt-4o=2.5/10". Models without a price cost nothing.
+	MaxCostPerRun *float64 `json:"max_cost_per_run,omitempty"`
+	MaxCostPerDay *float64 `json:"max_cost_per_day,omitempty"`
+	ModelPrices   []string `json:"model_prices,omitempty"`
+
+	// Message rules (used by lint)
+	MaxSubjectLength  *int     `json:"max_subject_length,omitempty"`
+	MaxBodyLineLength *int     `json:"max_body_line_length,omitempty"`
+	AllowedTypes      []string `json:"allowed_types,omitempty"`
+
+	// Times a generated message that breaks the rules is sent back to be repaired (default 2)
+	RepairAttempts *int `json:"repair_attempts,omitempty"`
+
+	// Message policy (used by lint, and enforced on generated messages)
+	BannedWords      []string `json:"banned_words,omitempty"`
+	DenyPatterns     []string `json:"deny_patterns,omitempty"`
+	RequiredPrefixes []string `json:"required_prefixes,omitempty"`
+}
+
+// DefaultPath returns the config file to use when no --config is given: the first of
+// $XDG_CONFIG_HOME/commitgen/config.{yaml,yml,toml,json} (XDG_CONFIG_HOME defaults to
+// ~/.config) that exists, else ~/.commitgen.json.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	for _, name := range []string{"config.yaml", "config.yml", "config.toml", "config.json"} {
+		p := filepath.Join(xdg, "commitgen", name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(home, ".commitgen.json")
+}
+
+// format returns the file format for path, chosen by extension: yaml, toml or json.
+func format(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "json"
+	}
 }
 
 func Load(path string) (FileConfig, error) {
 	var cfg FileConfig
 	if path == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
+		path = DefaultPath()
+		if path == "" {
 			return cfg, nil
 		}
-		path = filepath.Join(home, ".commitgen.json")
 	}
 
 	b, err := os.ReadFile(path)
@@ -46,19 +246,42 @@ func Load(path string) (FileConfig, error) {
 		return cfg, err
 	}
 
+	// YAML and TOML go through a generic map so that the json tags stay the only
+	// definition of the setting names.
+	switch format(path) {
+	case "yaml":
+		var m map[string]any
+		if err := yaml.Unmarshal(b, &m); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+		if b, err = json.Marshal(m); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+	case "toml":
+		var m map[string]any
+		if err := toml.Unmarshal(b, &m); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+		if b, err = json.Marshal(m); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
 	if err := json.Unmarshal(b, &cfg); err != nil {
-		return cfg, err
+		return cfg, fmt.Errorf("%s: %w", path, err)
 	}
 	return cfg, nil
 }
 
+// Save writes cfg to path in the format of its extension. Comments in an existing
+// YAML or TOML file are not preserved. A new file is readable by the owner only, and
+// so is an existing one once it holds a credential.
 func Save(cfg FileConfig, path string) error {
 	if path == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
+		path = DefaultPath()
+		if path == "" {
+			return errors.New("cannot locate home directory; use --config")
 		}
-		path = filepath.Join(home, ".commitgen.json")
 	}
 
 	b, err := json.MarshalIndent(cfg, "", "  ")
@@ -66,7 +289,39 @@ func Save(cfg FileConfig, path string) error {
 		return err
 	}
 
-	return os.WriteFile(path, b, 0644)
+	if f := format(path); f != "json" {
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+		for k, v := range m {
+			if v == "" {
+				delete(m, k) // base_url, api_key and model are always in the JSON
+			}
+		}
+		if f == "yaml" {
+			b, err = yaml.Marshal(m)
+		} else {
+			var buf bytes.Buffer
+			err = toml.NewEncoder(&buf).Encode(m)
+			b = buf.Bytes()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, which may be readable by others.
+	if cfg.hasSecret() {
+		return os.Chmod(path, 0600)
+	}
+	return nil
 }
 
 func ResolveString(flagVal, envVal, fileVal, defVal string) string {
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestLoadFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"c.yaml": "# team defaults\nmodel: gpt-4o-mini\nrecent_n: 3\nconventional: false\nallowed_types: [feat, fix]\n",
+		"c.toml": "# team defaults\nmodel = \"gpt-4o-mini\"\nrecent_n = 3\nconventional = false\nallowed_types = [\"feat\", \"fix\"]\n",
+		"c.json": `{"model": "gpt-4o-mini", "recent_n": 3, "conventional": false, "allowed_types": ["feat", "fix"]}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.Model != "gpt-4o-mini" || cfg.RecentN == nil || *cfg.RecentN != 3 ||
+			cfg.Conventional == nil || *cfg.Conventional || !slices.Equal(cfg.AllowedTypes, []string{"feat", "fix"}) {
+			t.Errorf("%s: got %+v", name, cfg)
+		}
+
+		// Saving in the same format must round-trip.
+		cfg.Provider = "ollama"
+		if err := Save(cfg, path); err != nil {
+			t.Fatalf("%s: save: %v", name, err)
+		}
+		again, err := Load(path)
+		if err != nil || again.Provider != "ollama" || *again.RecentN != 3 {
+			t.Errorf("%s: after save: %+v, %v", name, again, err)
+		}
+	}
+}
+
+func TestSaveMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix file modes")
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("model: gpt-4o\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(FileConfig{Model: "gpt-4o"}, path); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0644 {
+		t.Errorf("without a key: mode %v; want it kept", fi.Mode().Perm())
+	}
+	if err := Save(FileConfig{Model: "gpt-4o", APIKey: "sk-123"}, path); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("with a key: mode %v; want 0600", fi.Mode().Perm())
+	}
+
+	fresh := filepath.Join(t.TempDir(), "config.json")
+	if err := Save(FileConfig{Model: "gpt-4o"}, fresh); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(fresh); fi.Mode().Perm() != 0600 {
+		t.Errorf("new file: mode %v; want 0600", fi.Mode().Perm())
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if got, want := DefaultPath(), filepath.Join(home, ".commitgen.json"); got != want {
+		t.Errorf("without XDG file: %s; want %s", got, want)
+	}
+
+	xdg := filepath.Join(home, ".config", "commitgen", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(xdg), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(xdg, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultPath(); got != xdg {
+		t.Errorf("with XDG file: %s; want %s", got, xdg)
+	}
+}
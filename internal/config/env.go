@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// EnvPrefix starts the environment variable for every setting: COMMITGEN_ + the
+// upper-cased setting name, e.g. COMMITGEN_BASE_URL or COMMITGEN_IGNORED_FILES.
+const EnvPrefix = "COMMITGEN_"
+
+// legacyEnv are the variable names used before EnvPrefix; they still work.
+var legacyEnv = map[string]string{
+	"base_url":      "COMMITAI_BASE_URL",
+	"api_key":       "COMMITAI_API_KEY",
+	"model":         "COMMITAI_MODEL",
+	"provider":      "COMMITAI_PROVIDER",
+	"anthropic_key": "COMMITAI_ANTHROPIC_KEY",
+	"gemini_key":    "COMMITAI_GEMINI_KEY",
+}
+
+// EnvName returns the environment variable for setting key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// EnvSource returns the environment variable that sets key, EnvName(key) or its
+// legacy name, or "" if neither is set.
+func EnvSource(key string, getenv func(string) string) string {
+	if name := EnvName(key); getenv(name) != "" {
+		return name
+	}
+	if name := legacyEnv[key]; name != "" && getenv(name) != "" {
+		return name
+	}
+	return ""
+}
+
+// ApplyEnv overrides the settings in cfg with those set in the environment. Values are
+// parsed like `config set` ones, so lists are comma-separated.
+func ApplyEnv(cfg *FileConfig, getenv func(string) string) error {
+	var errs []string
+	for _, key := range Keys() {
+		name := EnvSource(key, getenv)
+		if name == "" {
+			continue
+		}
+		if err := cfg.Set(key, getenv(name)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// LoadDotEnv reads ~/.commitgen.env, then .commitgen.env and .env from the
+// repository containing dir, and returns a getenv that looks a variable up in the
+// process environment, then in those files. Only COMMITGEN_ and COMMITAI_
+// variables are taken, and earlier files win over later ones, so a developer's own
+// file overrides the repository's. The process environment is left alone, so that
+// each repository of a --repos run sees only its own files.
+// Repository files cannot set credentials, commands or endpoints, or use secret
+// references such as "cmd:", so a checkout cannot run commands, read secrets or
+// send a key elsewhere. Their instructions, as in a team config, must be files in
+// the repository.
+func LoadDotEnv(dir string) func(string) string {
+	vars := map[string]string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		loadDotEnvFile(filepath.Join(home, ".commitgen.env"), "", vars)
+	}
+	if root := repoRoot(dir); root != "" {
+		loadDotEnvFile(filepath.Join(root, ".commitgen.env"), root, vars)
+		loadDotEnvFile(filepath.Join(root, ".env"), root, vars)
+	}
+	return func(name string) string {
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return vars[name]
+	}
+}
+
+// envKey returns the setting that environment variable name sets, or "".
+func envKey(name string) string {
+	for key, legacy := range legacyEnv {
+		if legacy == name {
+			return key
+		}
+	}
+	key := strings.ToLower(strings.TrimPrefix(name, EnvPrefix))
+	if _, err := field(&FileConfig{}, key); err != nil || EnvName(key) != name {
+		return ""
+	}
+	return key
+}
+
+// repoRoot walks up from dir to the directory holding .git, or returns "". .git may
+// be a file, as in linked worktrees. GIT_WORK_TREE, when set, is the root.
+func repoRoot(dir string) string {
+	if wt := os.Getenv("GIT_WORK_TREE"); wt != "" {
+		abs, _ := filepath.Abs(wt)
+		return abs
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadDotEnvFile adds to vars the variables in the file at path that neither vars
+// nor the process environment has yet. A file in the repository at root, when root
+// is set, is untrusted.
+func loadDotEnvFile(path, root string, vars map[string]string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		name, value, ok := parseDotEnvLine(sc.Text())
+		if !ok || !(strings.HasPrefix(name, EnvPrefix) || strings.HasPrefix(name, "COMMITAI_")) {
+			continue
+		}
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		if _, set := vars[name]; set {
+			continue
+		}
+		if root != "" {
+			key := envKey(name)
+			var what string
+			switch {
+			case IsSecretReference(value):
+				what = "secret reference"
+			case IsSecret(key):
+				what = "credential"
+			case slices.Contains(commandKeys, key):
+				what = "command"
+			case slices.Contains(endpointKeys, key):
+				what = "endpoint"
+			}
+			if what != "" {
+				slog.Warn("ignoring "+what+" from repository env file; put it in ~/.commitgen.env", "file", path, "var", name)
+				continue
+			}
+			if key == "instructions" {
+				if value = strings.Join(teamInstructions(root, path, strings.Split(value, ",")), ","); value == "" {
+					continue
+				}
+			}
+		}
+		vars[name] = value
+	}
+	slog.Debug("loaded env file", "path", path)
+}
+
+// parseDotEnvLine parses `[export] NAME=value`, where value may be single- or
+// double-quoted; unquoted values end at " #".
+func parseDotEnvLine(line string) (name, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	name, value, ok = strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		value = strings.ReplaceAll(value[1:len(value)-1], `\n`, "\n")
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		value = value[1 : len(value)-1]
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return name, value, name != ""
+}
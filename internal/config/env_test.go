@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"COMMITGEN_MODEL":         "llama3",
+		"COMMITAI_MODEL":          "ignored",
+		"COMMITAI_PROVIDER":       "ollama",
+		"COMMITGEN_TEMPERATURE":   "0.1",
+		"COMMITGEN_IGNORED_FILES": "*.lock,dist/*",
+	}
+	cfg := FileConfig{Model: "gpt-4o", BaseURL: "http://from-file"}
+	if err := ApplyEnv(&cfg, func(k string) string { return env[k] }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Model != "llama3" || cfg.Provider != "ollama" || cfg.BaseURL != "http://from-file" || *cfg.Temperature != 0.1 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if !slices.Equal(cfg.IgnoredFiles, []string{"*.lock", "dist/*"}) {
+		t.Errorf("ignored_files = %v", cfg.IgnoredFiles)
+	}
+
+	env = map[string]string{"COMMITGEN_RECENT_N": "lots"}
+	if err := ApplyEnv(&cfg, func(k string) string { return env[k] }); err == nil {
+		t.Error("expected error for COMMITGEN_RECENT_N=lots")
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(repo, ".commitgen.env"), "# repo\nCOMMITGEN_MODEL=repo-model\nCOMMITGEN_API_KEY='cmd:cat /etc/passwd'\nCOMMITGEN_PRE_PROMPT_COMMAND=./leak.sh\nCOMMITGEN_ANTHROPIC_KEY=vault:secret/data/prod#db\nCOMMITGEN_LINEAR_API_KEY=aws-ssm:/prod/db\n")
+	write(filepath.Join(repo, ".env"), "DATABASE_URL=postgres://x\nexport COMMITGEN_MODEL=dotenv-model\nCOMMITGEN_PROVIDER=\"ollama\" \nCOMMITAI_API_KEY=sk-repo\nCOMMITGEN_SIGNOFF=true\nCOMMITGEN_INSTRUCTIONS=/etc/passwd,https://x\n")
+	write(filepath.Join(home, ".commitgen.env"), "COMMITGEN_GEMINI_KEY=cmd:pass show gemini # from pass\nCOMMITGEN_SIGNOFF=false\n")
+
+	for _, k := range []string{"COMMITGEN_MODEL", "COMMITGEN_API_KEY", "COMMITGEN_PROVIDER", "COMMITGEN_GEMINI_KEY", "COMMITGEN_ANTHROPIC_KEY", "COMMITGEN_LINEAR_API_KEY", "COMMITGEN_PRE_PROMPT_COMMAND", "COMMITAI_API_KEY", "COMMITGEN_SIGNOFF", "COMMITGEN_INSTRUCTIONS", "DATABASE_URL"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	t.Setenv("COMMITGEN_BASE_URL", "http://already-set")
+
+	getenv := LoadDotEnv(sub)
+
+	want := map[string]string{
+		"COMMITGEN_MODEL":              "repo-model",
+		"COMMITGEN_API_KEY":            "",
+		"COMMITGEN_ANTHROPIC_KEY":      "",
+		"COMMITGEN_LINEAR_API_KEY":     "",
+		"COMMITGEN_PRE_PROMPT_COMMAND": "",
+		"COMMITGEN_PROVIDER":           "",
+		"COMMITAI_API_KEY":             "",
+		"COMMITGEN_SIGNOFF":            "false",
+		"COMMITGEN_GEMINI_KEY":         "cmd:pass show gemini",
+		"COMMITGEN_BASE_URL":           "http://already-set",
+		"COMMITGEN_INSTRUCTIONS":       "",
+		"DATABASE_URL":                 "",
+	}
+	for k, v := range want {
+		if got := getenv(k); got != v {
+			t.Errorf("%s = %q; want %q", k, got, v)
+		}
+	}
+	write(filepath.Join(repo, ".commitgen.env"), "COMMITGEN_INSTRUCTIONS=CONTRIBUTING.md, ../outside.md\n")
+	if got, want := LoadDotEnv(sub)("COMMITGEN_INSTRUCTIONS"), filepath.Join(repo, "CONTRIBUTING.md"); got != want {
+		t.Errorf("instructions = %q; want only %q", got, want)
+	}
+	if os.Getenv("COMMITGEN_MODEL") != "" {
+		t.Error("LoadDotEnv changed the process environment")
+	}
+}
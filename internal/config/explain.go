@@ -0,0 +1,40 @@
+package config
+
+// Layer is one of the places settings are read from, for Explain.
+type Layer struct {
+	Cfg FileConfig
+	// Source describes where the layer's value for key comes from, e.g.
+	// "env COMMITGEN_MODEL" or "repo config .commitgen.yaml".
+	Source func(key string) string
+}
+
+// Origin is where the value of a setting comes from.
+type Origin struct {
+	Key    string
+	Value  string // as set by Source, in the format of Get
+	Source string // "" if no layer sets it, so the default applies
+	// Shadowed are the sources of lower layers that set it too and lose.
+	Shadowed []string
+}
+
+// Explain returns the origin of every setting, in Keys order, given layers from
+// the highest precedence to the lowest.
+func Explain(layers []Layer) []Origin {
+	var out []Origin
+	for _, key := range Keys() {
+		o := Origin{Key: key}
+		for _, l := range layers {
+			v, set, _ := l.Cfg.Get(key)
+			if !set {
+				continue
+			}
+			if o.Source == "" {
+				o.Value, o.Source = v, l.Source(key)
+			} else {
+				o.Shadowed = append(o.Shadowed, l.Source(key))
+			}
+		}
+		out = append(out, o)
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	env := map[string]string{"COMMITAI_MODEL": "from-env"}
+	var envCfg FileConfig
+	if err := ApplyEnv(&envCfg, func(k string) string { return env[k] }); err != nil {
+		t.Fatal(err)
+	}
+	five := 5
+	layers := []Layer{
+		{Cfg: FileConfig{Provider: "ollama"}, Source: func(key string) string { return "flag" }},
+		{Cfg: envCfg, Source: func(key string) string { return "env " + EnvSource(key, func(k string) string { return env[k] }) }},
+		{Cfg: FileConfig{Model: "personal", Provider: "anthropic"}, Source: func(string) string { return "global config" }},
+		{Cfg: FileConfig{Model: "team", RecentN: &five}, Source: func(string) string { return "repo config" }},
+	}
+
+	got := map[string]Origin{}
+	for _, o := range Explain(layers) {
+		got[o.Key] = o
+	}
+	if o := got["model"]; o.Value != "from-env" {
+		t.Errorf("model = %q, want the env value", o.Value)
+	}
+	if len(got) != len(Keys()) {
+		t.Errorf("Explain() covers %d settings, want %d", len(got), len(Keys()))
+	}
+	for key, want := range map[string]Origin{
+		"model":     {Source: "env COMMITAI_MODEL", Shadowed: []string{"global config", "repo config"}},
+		"provider":  {Source: "flag", Shadowed: []string{"global config"}},
+		"recent_n":  {Source: "repo config"},
+		"max_files": {},
+	} {
+		o := got[key]
+		if o.Source != want.Source || !slices.Equal(o.Shadowed, want.Shadowed) {
+			t.Errorf("%s: source %q, shadowed %v; want %q, %v", key, o.Source, o.Shadowed, want.Source, want.Shadowed)
+		}
+	}
+}
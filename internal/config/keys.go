@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// secretKeys are the settings hidden by Redacted.
+var secretKeys = []string{"api_key", "anthropic_key", "gemini_key", "gitlab_token", "linear_api_key", "azure_client_secret", "vault_token", "vault_secret_id"}
+
+// commandKeys are the settings that run commands. Like "cmd:" values, they are not
+// taken from files in a repository, so a checkout cannot run commands.
+var commandKeys = []string{"pre_prompt_command", "post_message_command"}
+
+// endpointKeys are the settings that decide where requests go and which
+// credentials are sent with them. They are not taken from team configs or repository env
+// files, so a checkout cannot send a developer's key to a server of its choosing.
+var endpointKeys = []string{"base_url", "provider", "gitlab_url", "vault_addr", "azure_auth", "azure_tenant_id", "azure_client_id", "aws_region", "aws_profile"}
+
+// Keys returns the setting names accepted by Get and Set, in file order.
+func Keys() []string {
+	t := reflect.TypeOf(FileConfig{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, jsonName(t.Field(i)))
+	}
+	return keys
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// field returns the settable field of cfg named key.
+func field(cfg *FileConfig, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown setting %q (known: %s)", key, strings.Join(Keys(), ", "))
+}
+
+// Get returns the value of setting key as text, and whether it is set in the file.
+// Lists are comma-separated.
+func (cfg FileConfig) Get(key string) (string, bool, error) {
+	f, err := field(&cfg, key)
+	if err != nil {
+		return "", false, err
+	}
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), f.String() != "", nil
+	case reflect.Slice:
+		return strings.Join(f.Interface().([]string), ","), f.Len() > 0, nil
+	case reflect.Pointer:
+		if f.IsNil() {
+			return "", false, nil
+		}
+		return fmt.Sprint(f.Elem().Interface()), true, nil
+	}
+	return "", false, fmt.Errorf("setting %q has unsupported type %s", key, f.Type())
+}
+
+// Set parses value for setting key and stores it. An empty value removes the setting,
+// so that the default applies again. Lists are comma-separated.
+func (cfg *FileConfig) Set(key, value string) error {
+	f, err := field(cfg, key)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		f.SetZero()
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Slice:
+		var items []string
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		f.Set(reflect.ValueOf(items))
+	case reflect.Pointer:
+		p := reflect.New(f.Type().Elem())
+		switch p.Elem().Kind() {
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a whole number", key, value)
+			}
+			p.Elem().SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not true or false", key, value)
+			}
+			p.Elem().SetBool(b)
+		case reflect.Float64:
+			x, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", key, value)
+			}
+			p.Elem().SetFloat(x)
+		default:
+			return fmt.Errorf("setting %q has unsupported type %s", key, f.Type())
+		}
+		f.Set(p)
+	default:
+		return fmt.Errorf("setting %q has unsupported type %s", key, f.Type())
+	}
+	return nil
+}
+
+// IsSecret reports whether setting key holds a credential.
+func IsSecret(key string) bool {
+	return slices.Contains(secretKeys, key)
+}
+
+// hasSecret reports whether cfg holds a credential itself rather than a reference to one.
+func (cfg FileConfig) hasSecret() bool {
+	for _, key := range secretKeys {
+		if v, set, _ := cfg.Get(key); set && v != "" && !IsSecretReference(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of cfg with credentials replaced by a placeholder.
+// References such as "cmd:" are kept, since they name where the key is rather
+// than hold it.
+func (cfg FileConfig) Redacted() FileConfig {
+	for _, key := range secretKeys {
+		if v, set, _ := cfg.Get(key); set && !IsSecretReference(v) {
+			_ = cfg.Set(key, "<redacted>")
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetGet(t *testing.T) {
+	var cfg FileConfig
+	sets := map[string]string{
+		"provider":      "ollama",
+		"recent_n":      "3",
+		"summarize":     "false",
+		"temperature":   "0.2",
+		"allowed_types": "feat, fix,,docs",
+	}
+	for k, v := range sets {
+		if err := cfg.Set(k, v); err != nil {
+			t.Fatalf("Set(%s, %s): %v", k, v, err)
+		}
+	}
+	if cfg.Provider != "ollama" || *cfg.RecentN != 3 || *cfg.Summarize || *cfg.Temperature != 0.2 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if !slices.Equal(cfg.AllowedTypes, []string{"feat", "fix", "docs"}) {
+		t.Errorf("allowed_types = %v", cfg.AllowedTypes)
+	}
+
+	if v, set, _ := cfg.Get("allowed_types"); !set || v != "feat,fix,docs" {
+		t.Errorf("Get(allowed_types) = %q, %v", v, set)
+	}
+	if _, set, _ := cfg.Get("max_files"); set {
+		t.Error("max_files reported as set")
+	}
+
+	if err := cfg.Set("recent_n", ""); err != nil || cfg.RecentN != nil {
+		t.Errorf("clearing recent_n: %v, %v", err, cfg.RecentN)
+	}
+	if err := cfg.Set("recent_n", "many"); err == nil {
+		t.Error("expected error for a non-numeric recent_n")
+	}
+	if err := cfg.Set("colour", "blue"); err == nil {
+		t.Error("expected error for an unknown setting")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := FileConfig{APIKey: "sk-123", GeminiKey: "vault:secret/data/commitgen#gemini", Model: "gpt-4o"}
+	r := cfg.Redacted()
+	if r.APIKey != "<redacted>" || r.AnthropicKey != "" || r.GeminiKey != cfg.GeminiKey || r.Model != "gpt-4o" {
+		t.Errorf("Redacted() = %+v", r)
+	}
+	if cfg.APIKey != "sk-123" {
+		t.Error("Redacted modified the original")
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	if v, err := ResolveSecret("sk-plain"); err != nil || v != "sk-plain" {
+		t.Errorf("plain value: %q, %v", v, err)
+	}
+	if v, err := ResolveSecret("cmd: echo '  sk-from-cmd  '"); err != nil || v != "sk-from-cmd" {
+		t.Errorf("command value: %q, %v", v, err)
+	}
+	if _, err := ResolveSecret("cmd:echo oops >&2; exit 3"); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("failing command: %v", err)
+	}
+}
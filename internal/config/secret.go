@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretCmdPrefix marks a key setting whose value is a shell command printing the key,
+// e.g. "cmd:op read op://vault/openai/key".
+const secretCmdPrefix = "cmd:"
+
+// secretTimeout bounds a secret command; password managers may wait for an unlock prompt.
+const secretTimeout = 2 * time.Minute
+
+var (
+	secretMu    sync.Mutex
+	secretCache = map[string]string{} // command → output, so each runs once per process
+)
+
+// secretVaultPrefix marks a key setting read from HashiCorp Vault at runtime, e.g.
+// "vault:secret/data/commitgen#openai".
+const secretVaultPrefix = "vault:"
+
+// IsVaultSecret reports whether v is a "vault:" secret reference.
+func IsVaultSecret(v string) bool {
+	return strings.HasPrefix(v, secretVaultPrefix)
+}
+
+// VaultSecretPath returns the "PATH#FIELD" of a "vault:" secret reference.
+func VaultSecretPath(v string) string {
+	return strings.TrimSpace(strings.TrimPrefix(v, secretVaultPrefix))
+}
+
+// Prefixes of key settings read from AWS at runtime: a Secrets Manager secret, e.g.
+// "aws-secret:commitgen/keys#openai", or an SSM parameter, e.g.
+// "aws-ssm:/commitgen/openai".
+const (
+	secretAWSPrefix = "aws-secret:"
+	secretSSMPrefix = "aws-ssm:"
+)
+
+// AWSSecret returns the reference of an "aws-secret:" value, and whether v is one.
+func AWSSecret(v string) (string, bool) {
+	return strings.TrimSpace(strings.TrimPrefix(v, secretAWSPrefix)), strings.HasPrefix(v, secretAWSPrefix)
+}
+
+// SSMParameter returns the name in an "aws-ssm:" value, and whether v is one.
+func SSMParameter(v string) (string, bool) {
+	return strings.TrimSpace(strings.TrimPrefix(v, secretSSMPrefix)), strings.HasPrefix(v, secretSSMPrefix)
+}
+
+// IsSecretReference reports whether v names where a key is ("cmd:", "vault:",
+// "aws-secret:", or "aws-ssm:") rather than holding it.
+func IsSecretReference(v string) bool {
+	for _, prefix := range []string{secretCmdPrefix, secretVaultPrefix, secretAWSPrefix, secretSSMPrefix} {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecretCommand reports whether v is a "cmd:" secret reference.
+func IsSecretCommand(v string) bool {
+	return strings.HasPrefix(v, secretCmdPrefix)
+}
+
+// ResolveSecret returns v, or, for "cmd:COMMAND", the trimmed output of running
+// COMMAND with the shell. The output is kept in memory only.
+func ResolveSecret(v string) (string, error) {
+	if !IsSecretCommand(v) {
+		return v, nil
+	}
+	command := strings.TrimSpace(strings.TrimPrefix(v, secretCmdPrefix))
+	if command == "" {
+		return "", fmt.Errorf("empty secret command")
+	}
+
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if out, ok := secretCache[command]; ok {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("secret command %q failed: %s", command, msg)
+	}
+	out := strings.TrimSpace(stdout.String())
+	if out == "" {
+		return "", fmt.Errorf("secret command %q printed nothing", command)
+	}
+	secretCache[command] = out
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// teamFiles are the names a team config committed in the repository may have: at
+// its root, or in TeamDir.
+var teamFiles = []string{
+	".commitgen.yaml", ".commitgen.yml", ".commitgen.toml", ".commitgen.json",
+	".commitgen/config.yaml", ".commitgen/config.yml", ".commitgen/config.toml", ".commitgen/config.json",
+}
+
+// TeamDir is the directory, at the repository root, of the files a team shares, as
+// created by commitgen init.
+const TeamDir = ".commitgen"
+
+// Files in TeamDir that apply without a setting naming them.
+const (
+	TeamPromptFile = "prompt.tmpl" // the prompt_template, unless the team config sets one
+	TeamIgnoreFile = "ignore"      // ignored_files patterns, one per line, after the team config's
+)
+
+// LoadTeam loads the team config committed in the repository containing dir, if any,
+// and returns it with the path it came from. Credentials, commands and endpoints in
+// it are dropped: they belong in each developer's own config. The prompt template and ignore patterns in
+// TeamDir are added to it.
+func LoadTeam(dir string) (FileConfig, string, error) {
+	root := repoRoot(dir)
+	if root == "" {
+		return FileConfig{}, "", nil
+	}
+	var cfg FileConfig
+	source := ""
+	for _, name := range teamFiles {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		var err error
+		if cfg, err = Load(path); err != nil {
+			return FileConfig{}, path, err
+		}
+		for _, key := range secretKeys {
+			if _, set, _ := cfg.Get(key); set {
+				slog.Warn("ignoring credential in team config; set it in your own config or env", "file", path, "setting", key)
+				_ = cfg.Set(key, "")
+			}
+		}
+		for _, key := range commandKeys {
+			if _, set, _ := cfg.Get(key); set {
+				slog.Warn("ignoring command in team config; set it in your own config", "file", path, "setting", key)
+				_ = cfg.Set(key, "")
+			}
+		}
+		for _, key := range endpointKeys {
+			if _, set, _ := cfg.Get(key); set {
+				slog.Warn("ignoring endpoint in team config; set it in your own config", "file", path, "setting", key)
+				_ = cfg.Set(key, "")
+			}
+		}
+		cfg.Instructions = teamInstructions(root, path, cfg.Instructions)
+		source = path
+		break
+	}
+
+	teamDir := filepath.Join(root, TeamDir)
+	if b, err := os.ReadFile(filepath.Join(teamDir, TeamPromptFile)); err == nil {
+		if cfg.PromptTemplate == "" {
+			cfg.PromptTemplate = string(b)
+		}
+		source = cmp.Or(source, teamDir)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return FileConfig{}, teamDir, err
+	}
+	if b, err := os.ReadFile(filepath.Join(teamDir, TeamIgnoreFile)); err == nil {
+		for _, ln := range strings.Split(string(b), "\n") {
+			if ln = strings.TrimSpace(ln); ln != "" && !strings.HasPrefix(ln, "#") {
+				cfg.IgnoredFiles = append(cfg.IgnoredFiles, ln)
+			}
+		}
+		source = cmp.Or(source, teamDir)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return FileConfig{}, teamDir, err
+	}
+	return cfg, source, nil
+}
+
+// teamInstructions keeps the instructions sources in a team config that are files
+// inside root, as paths from root, so that a checkout cannot have commitgen fetch a
+// URL or read a file elsewhere into the prompt. URLs belong in each developer's own
+// config or flags.
+func teamInstructions(root, path string, sources []string) []string {
+	var kept []string
+	for _, src := range sources {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		if strings.Contains(src, "://") {
+			slog.Warn("ignoring instructions URL in team config; set it in your own config", "file", path, "instructions", src)
+			continue
+		}
+		if !filepath.IsLocal(src) || !insideDir(root, filepath.Join(root, src)) {
+			slog.Warn("ignoring instructions outside the repository in team config", "file", path, "instructions", src)
+			continue
+		}
+		kept = append(kept, filepath.Join(root, src))
+	}
+	return kept
+}
+
+// insideDir reports whether path, with symlinks resolved, is within dir. A path
+// that does not exist is judged as written.
+func insideDir(dir, path string) bool {
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		path = p
+		if d, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = d
+		}
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// Merge returns base with every setting that over sets replacing base's.
+// Lists are replaced, not appended to.
+func Merge(base, over FileConfig) FileConfig {
+	b := reflect.ValueOf(&base).Elem()
+	o := reflect.ValueOf(over)
+	for i := 0; i < o.NumField(); i++ {
+		if !o.Field(i).IsZero() {
+			b.Field(i).Set(o.Field(i))
+		}
+	}
+	return base
+}
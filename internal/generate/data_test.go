@@ -0,0 +1,55 @@
+package generate
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+func TestShouldIgnore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ignores []string
+		want    bool
+	}{
+		{"go.sum", []string{"go.sum"}, true},
+		{"pkg/go.sum", []string{"go.sum"}, true}, // base match
+		{"README.md", []string{"go.sum"}, false},
+		{"foo.map", []string{"*.map"}, true},
+		{"bar.svg", []string{"*.svg"}, true},
+		{"src/logo.svg", []string{"*.svg"}, true},
+		{"pnpm-lock.yaml", []string{"pnpm-lock.yaml"}, true},
+	}
+
+	for _, tt := range tests {
+		got := ShouldIgnore(tt.pattern, tt.ignores)
+		if got != tt.want {
+			t.Errorf("ShouldIgnore(%q, %v) = %v; want %v", tt.pattern, tt.ignores, got, tt.want)
+		}
+	}
+}
+
+func TestTruncationsRecorded(t *testing.T) {
+	big := "diff --git a/big.go b/big.go\n--- a/big.go\n+++ b/big.go\n@@ -1,1 +1,20000 @@\n" + strings.Repeat("+x := 1 // a long line\n", 20000)
+	changes := []gitx.StagedChange{
+		{Path: "big.go", Diff: big},
+		{Path: "a.go", Diff: "+a\n"},
+		{Path: "b.go", Diff: "+b\n"},
+		{Path: "go.sum", Diff: "+sum\n"},
+	}
+	data, err := BuildData(context.Background(), "", changes, Options{MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []vscodeprompt.Truncation{{Part: "diff", Shown: 2000, Total: len(big)}}
+	if !slices.Equal(data.Changes[0].Truncated, want) {
+		t.Errorf("truncated = %+v; want %+v", data.Changes[0].Truncated, want)
+	}
+	if !slices.Equal(data.OmittedFiles, []string{"b.go"}) {
+		t.Errorf("omitted = %q; want b.go, without the ignored go.sum", data.OmittedFiles)
+	}
+}
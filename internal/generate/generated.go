@@ -0,0 +1,145 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// generatedFiles are patterns of vendored and generated files. Their diffs are long,
+// written by a tool, and say little about why they changed, so the prompt gets a
+// line counting the changed lines instead, enough for "regenerate protobuf stubs".
+// A pattern ending in / matches a directory anywhere in the path; others match the
+// base name, or the whole path.
+var generatedFiles = []string{
+	"vendor/", "node_modules/", "dist/",
+	"*.pb.go", "*_pb.go", "*_pb2.py", "*_pb2_grpc.py", "*.pb.h", "*.pb.cc",
+}
+
+// generatedMarkers are the comments tools put at the top of the files they write,
+// such as Go's "// Code generated ... DO NOT EDIT.".
+var generatedMarkers = []string{"DO NOT EDIT", "@generated"}
+
+// GeneratedNote starts the line BuildData puts in place of a generated file's hunks.
+const GeneratedNote = "[Generated or vendored file: "
+
+// generatedHeaderSize is how much of a file is searched for a generatedMarker.
+const generatedHeaderSize = 1024
+
+// generatedPatterns returns the built-in patterns with the configured ones: extra
+// patterns are added, and one starting with ! turns off the same built-in pattern,
+// such as "!dist/" for a repository whose dist/ is written by hand.
+func generatedPatterns(configured []string) []string {
+	patterns := append([]string(nil), generatedFiles...)
+	for _, p := range configured {
+		if off, ok := strings.CutPrefix(p, "!"); ok {
+			patterns = slices.DeleteFunc(patterns, func(p string) bool { return p == off })
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// isGenerated reports whether path, changed by diff, is vendored or generated: it
+// matches one of patterns, or its first lines carry a generated-code marker. Those
+// are read from the diff when it starts at the top of the file, or else from the
+// file in repoRoot, if given.
+func isGenerated(repoRoot, path, diff string, patterns []string) bool {
+	slashed := filepath.ToSlash(path)
+	base := filepath.Base(slashed)
+	for _, p := range patterns {
+		if dir, ok := strings.CutSuffix(p, "/"); ok {
+			if strings.HasPrefix(slashed, dir+"/") || strings.Contains(slashed, "/"+dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, slashed); ok {
+			return true
+		}
+	}
+
+	if head, ok := diffHead(diff); ok {
+		return hasGeneratedMarker(head)
+	}
+	if repoRoot == "" {
+		return false
+	}
+	f, err := os.Open(filepath.Join(repoRoot, path))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, generatedHeaderSize)
+	n, _ := f.Read(buf)
+	return hasGeneratedMarker(string(buf[:n]))
+}
+
+// splitDiff splits a one-file diff into its header and its hunks.
+func splitDiff(diff string) (header, hunks string) {
+	if strings.HasPrefix(diff, "@@ ") {
+		return "", diff
+	}
+	if i := strings.Index(diff, "\n@@ "); i >= 0 {
+		return diff[:i+1], diff[i+1:]
+	}
+	return diff, ""
+}
+
+// diffHead returns the start of the file after diff, when diff's first hunk starts
+// at its first line.
+func diffHead(diff string) (string, bool) {
+	_, hunks := splitDiff(diff)
+	switch {
+	case strings.HasPrefix(hunks, "@@ -0,0 "), strings.HasPrefix(hunks, "@@ -1,"), strings.HasPrefix(hunks, "@@ -1 "):
+	default:
+		return "", false
+	}
+	var b strings.Builder
+	for _, ln := range strings.Split(hunks, "\n")[1:] {
+		if b.Len() >= generatedHeaderSize || strings.HasPrefix(ln, "@@") {
+			break
+		}
+		if strings.HasPrefix(ln, "+") || strings.HasPrefix(ln, " ") {
+			b.WriteString(ln[1:] + "\n")
+		}
+	}
+	return b.String(), true
+}
+
+func hasGeneratedMarker(head string) bool {
+	for _, m := range generatedMarkers {
+		if strings.Contains(head, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedDiff replaces a generated file's diff with its header and a line counting
+// the lines it adds and removes.
+func generatedDiff(diff string) string {
+	header, _ := splitDiff(diff)
+	added, removed := countChangedLines(diff)
+	return header + fmt.Sprintf(GeneratedNote+"%d lines added, %d removed; contents left out]\n", added, removed)
+}
+
+// countChangedLines returns the number of lines diff adds and removes.
+func countChangedLines(diff string) (added, removed int) {
+	_, hunks := splitDiff(diff)
+	for _, ln := range strings.Split(hunks, "\n") {
+		switch {
+		case strings.HasPrefix(ln, "+"):
+			added++
+		case strings.HasPrefix(ln, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+func TestIsGenerated(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "mocks.go"), []byte("// Code generated by mockgen. DO NOT EDIT.\n\npackage mocks\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644)
+	hunk := "@@ -10,3 +10,4 @@\n x\n+y\n"
+
+	patterns := generatedPatterns([]string{"*.gen.ts", "!dist/"})
+	for _, tc := range []struct {
+		path, diff string
+		want       bool
+	}{
+		{"vendor/github.com/x/y/y.go", hunk, true},
+		{"web/node_modules/lib/index.js", hunk, true},
+		{"api/v1/user.pb.go", hunk, true},
+		{"client/api.gen.ts", hunk, true},
+		{"dist/app.js", hunk, false}, // turned off
+		{"mocks.go", hunk, true},     // marker read from the file
+		{"main.go", hunk, false},
+		{"new.go", "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1,2 @@\n+// Code generated by stringer; DO NOT EDIT.\n+package x\n", true},
+		{"vendored.go", "@@ -1,2 +1,2 @@\n-// old\n+// new\n package x\n", false},
+	} {
+		if got := isGenerated(root, tc.path, tc.diff, patterns); got != tc.want {
+			t.Errorf("isGenerated(%q) = %v; want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestGeneratedFilesSummarized(t *testing.T) {
+	changes := []gitx.StagedChange{
+		{Path: "api.proto", Diff: "diff --git a/api.proto b/api.proto\n@@ -1 +1,2 @@\n message A {}\n+message B {}\n"},
+		{Path: "api.pb.go", Diff: "diff --git a/api.pb.go b/api.pb.go\n@@ -5,2 +5,3 @@\n-var x = 1\n+var x = 2\n+var y = 3\n"},
+	}
+	data, err := BuildData(context.Background(), "", changes, Options{MaxFiles: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Changes) != 2 {
+		t.Fatalf("changes = %+v", data.Changes)
+	}
+	want := "diff --git a/api.pb.go b/api.pb.go\n[Generated or vendored file: 2 lines added, 1 removed; contents left out]\n"
+	if got := data.Changes[1].Diff; got != want {
+		t.Errorf("generated diff = %q; want %q", got, want)
+	}
+	if strings.Contains(data.Changes[0].Diff, "Generated") {
+		t.Errorf("api.proto summarized: %q", data.Changes[0].Diff)
+	}
+}
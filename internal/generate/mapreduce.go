@@ -0,0 +1,168 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// summaryWorkers bounds how many per-file summary requests run at once.
+const summaryWorkers = 4
+
+// maxFileChunks bounds how many pieces of one file's diff are summarized; the rest
+// of a larger diff is left out, and the truncation report says so.
+const maxFileChunks = 8
+
+// MapReduce generates a message for a changeset too large for one prompt:
+// it asks for a one-line summary of each file (in parallel), then for the message
+// written from those summaries. A file whose diff doesn't fit one request is
+// summarized in chunks, whose summaries are joined. Summaries are kept, so
+// regenerating repeats only the last step.
+type MapReduce struct {
+	ai.Provider
+	Data         vscodeprompt.Data
+	Base         int // messages in the regular prompt; any after them (e.g. the conventional reminder) are kept
+	MaxDiffChars int // per-file diffs are split into chunks of this size; 0 leaves them whole
+
+	mu        sync.Mutex
+	summaries []vscodeprompt.FileSummary
+	reduced   vscodeprompt.Data // Data, with the diffs cut at maxFileChunks recorded
+}
+
+func (p *MapReduce) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	data, summaries, err := p.fileSummaries(ctx, temp)
+	if err != nil {
+		return "", err
+	}
+	reduce := vscodeprompt.BuildSummarizedMessages(data, summaries)
+	if len(msgs) > p.Base {
+		reduce = append(reduce, msgs[p.Base:]...)
+	}
+	return p.Provider.GenerateCommitMessage(ctx, reduce, temp)
+}
+
+func (p *MapReduce) fileSummaries(ctx context.Context, temp float64) (vscodeprompt.Data, []vscodeprompt.FileSummary, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.summaries != nil {
+		return p.reduced, p.summaries, nil
+	}
+
+	// Only the final message is worth streaming.
+	ctx = ai.WithStream(ctx, nil)
+	type chunk struct {
+		file        int
+		label, diff string
+	}
+	reduced := p.Data
+	reduced.Changes = slices.Clone(p.Data.Changes)
+	changes := reduced.Changes
+	var chunks []chunk
+	parts := make([][]string, len(changes))
+	for i, ch := range changes {
+		diffs := diffChunks(ch.Diff, p.MaxDiffChars)
+		if len(diffs) > maxFileChunks {
+			slog.Debug("diff too large to summarize in full", "path", ch.Path, "chunks", len(diffs))
+			diffs = diffs[:maxFileChunks]
+			shown := 0
+			for _, d := range diffs {
+				shown += len(d)
+			}
+			changes[i].Truncated = append(slices.Clip(ch.Truncated), vscodeprompt.Truncation{Part: "diff", Shown: shown, Total: len(ch.Diff)})
+			diffs[maxFileChunks-1] += "\n...[Diff truncated due to size]..."
+		}
+		parts[i] = make([]string, len(diffs))
+		for j, d := range diffs {
+			label := ch.Path
+			if len(diffs) > 1 {
+				label = fmt.Sprintf("%s (part %d of %d)", ch.Path, j+1, len(diffs))
+			}
+			chunks = append(chunks, chunk{i, label, d})
+		}
+	}
+	summaries := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(summaryWorkers, len(chunks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := chunks[i]
+				out, err := p.Provider.GenerateCommitMessage(ctx, vscodeprompt.BuildFileSummaryMessages(c.label, c.diff), temp)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				summaries[i] = summaryLine(out)
+				slog.Debug("file summary", "path", c.label, "summary", summaries[i])
+			}
+		}()
+	}
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return vscodeprompt.Data{}, nil, err
+		}
+	}
+	out := make([]vscodeprompt.FileSummary, len(changes))
+	for i, ch := range changes {
+		out[i].Path = ch.Path
+	}
+	for i, c := range chunks {
+		if out[c.file].Summary != "" {
+			out[c.file].Summary += "; "
+		}
+		out[c.file].Summary += summaries[i]
+	}
+	p.summaries, p.reduced = out, reduced
+	return reduced, out, nil
+}
+
+// diffChunks splits diff into pieces of at most limit bytes, preferably at hunk
+// boundaries. A limit of 0 leaves it whole.
+func diffChunks(diff string, limit int) []string {
+	if limit <= 0 || len(diff) <= limit {
+		return []string{diff}
+	}
+	var chunks []string
+	var cur strings.Builder
+	for _, ln := range strings.SplitAfter(diff, "\n") {
+		if len(ln) > limit {
+			ln = ln[:limit-1] + "\n"
+		}
+		if cur.Len()+len(ln) > limit || (strings.HasPrefix(ln, "@@") && cur.Len() > limit/2) {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+		}
+		cur.WriteString(ln)
+	}
+	if cur.Len() > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+// summaryLine returns the first line of a summary response, unwrapped from any code block.
+func summaryLine(s string) string {
+	s, _ = vscodeprompt.ExtractOneTextCodeBlock(s)
+	for _, ln := range strings.Split(s, "\n") {
+		if ln = strings.TrimSpace(ln); ln != "" {
+			return ln
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// recordingProvider answers summary prompts with a summary and anything else with a
+// message, keeping the user text of every request.
+type recordingProvider struct {
+	mu    sync.Mutex
+	users []string
+}
+
+func (p *recordingProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	user := msgs[1].Content[0].Text
+	p.users = append(p.users, user)
+	if strings.HasPrefix(user, "<file>\n") {
+		path, _, _ := strings.Cut(strings.TrimPrefix(user, "<file>\n"), "\n")
+		return "update " + path + "\nignored second line", nil
+	}
+	return "```text\nfeat: big change\n```", nil
+}
+
+func TestMapReduceReportsCut(t *testing.T) {
+	var diff strings.Builder
+	for i := range maxFileChunks + 2 {
+		fmt.Fprintf(&diff, "@@ -%d,1 +%d,1 @@\n%s", i*100, i*100, strings.Repeat("+line of code\n", 60))
+	}
+	data := vscodeprompt.Data{Changes: []vscodeprompt.Change{{Path: "big.go", Diff: diff.String()}}}
+	rp := &recordingProvider{}
+	p := &MapReduce{Provider: rp, Data: data, MaxDiffChars: 1000}
+	if _, err := p.GenerateCommitMessage(context.Background(), nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	final := rp.users[len(rp.users)-1]
+	if !strings.Contains(final, "<truncation-report>") || !strings.Contains(final, "- big.go: diff cut to ") {
+		t.Errorf("final prompt does not report the cut diff:\n%s", final)
+	}
+	if len(data.Changes[0].Truncated) != 0 {
+		t.Error("the prompt's data was changed")
+	}
+}
+
+func TestDiffChunks(t *testing.T) {
+	diff := "@@ -1 +1 @@\n+a\n+b\n@@ -9 +9 @@\n+c\n"
+	if got := diffChunks(diff, 0); len(got) != 1 {
+		t.Errorf("no limit: got %q", got)
+	}
+	got := diffChunks(diff, 20)
+	want := []string{"@@ -1 +1 @@\n+a\n+b\n", "@@ -9 +9 @@\n+c\n"}
+	if !slices.Equal(got, want) {
+		t.Errorf("diffChunks = %q; want %q", got, want)
+	}
+	if strings.Join(got, "") != diff {
+		t.Error("chunks don't add up to the diff")
+	}
+}
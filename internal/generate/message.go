@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// CharsPerToken is the rough prompt size estimate used against a context budget.
+const CharsPerToken = 4
+
+// answerTokens is the room left in a model's context window for its answer.
+const answerTokens = 512
+
+// windowTimeout bounds the request asking a provider for its context window.
+const windowTimeout = 5 * time.Second
+
+// ContextBudget returns budget, lowered to fit the model's context window when
+// provider reports one, as Ollama does. windowed reports whether the window set
+// it: past it a prompt is cut off, not just expensive. The size estimate is rough,
+// so a quarter of the window is kept spare.
+func ContextBudget(ctx context.Context, provider ai.Provider, budget int) (_ int, windowed bool) {
+	if budget <= 0 {
+		return budget, false
+	}
+	cw, ok := provider.(ai.ContextWindower)
+	if !ok {
+		return budget, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, windowTimeout)
+	defer cancel()
+	window := cw.ContextWindow(ctx)
+	if window <= 0 {
+		return budget, false
+	}
+	window = max(window, 2*answerTokens)
+	if fit := (window - answerTokens) * 3 / 4; fit < budget {
+		return fit, true
+	}
+	return budget, false
+}
+
+// Tokens estimates the size of msgs in tokens.
+func Tokens(msgs []vscodeprompt.VSCodeMessage) int {
+	size := 0
+	for _, m := range msgs {
+		for _, part := range m.Content {
+			size += len(part.Text)
+		}
+	}
+	return size / CharsPerToken
+}
+
+// Diff returns the diffs of data's changes as one, cut to half of budget (in
+// tokens) if set, for follow-up prompts that send the message back with the changes.
+func Diff(data vscodeprompt.Data, budget int) string {
+	diffs := make([]string, 0, len(data.Changes))
+	for _, ch := range data.Changes {
+		diffs = append(diffs, strings.TrimRight(ch.Diff, "\n"))
+	}
+	diff := strings.Join(diffs, "\n")
+	if limit := budget * CharsPerToken / 2; budget > 0 && len(diff) > limit {
+		diff = diff[:limit] + "\n...[Diff truncated due to size]..."
+	}
+	return diff
+}
+
+// Message asks provider for a commit message and unwraps it from its code block.
+// A provider that is a fmt.Stringer is logged by that name.
+func Message(ctx context.Context, provider ai.Provider, msgs []vscodeprompt.VSCodeMessage, temp float64, conventional bool) (string, error) {
+	currentMsgs := make([]vscodeprompt.VSCodeMessage, len(msgs))
+	copy(currentMsgs, msgs)
+
+	if conventional {
+		reminderMsg := vscodeprompt.VSCodeMessage{
+			Role: vscodeprompt.RoleUser,
+			Content: []vscodeprompt.VSCodeContentPart{
+				{Type: 1, Text: "CRITICAL INSTRUCTION: You must strictly follow the Conventional Commits specification (e.g. 'feat: add spinner', 'fix: resolve bug').\nDo not just describe the change; prefix it with the type."},
+			},
+		}
+		currentMsgs = append(currentMsgs, reminderMsg)
+	}
+
+	name := fmt.Sprintf("%T", provider)
+	if s, ok := provider.(fmt.Stringer); ok {
+		name = s.String()
+	}
+	start := time.Now()
+	raw, err := provider.GenerateCommitMessage(ctx, currentMsgs, temp)
+	slog.Debug("provider response", "provider", name, "duration", time.Since(start), "chars", len(raw), "ok", err == nil)
+	if err != nil {
+		return "", err
+	}
+
+	msg, ok := vscodeprompt.ExtractOneTextCodeBlock(raw)
+	if !ok {
+		msg = raw
+	}
+	return msg, nil
+}
+
+// Fixing applies local fixes to the messages of the provider it wraps.
+type Fixing struct {
+	ai.Provider
+	Fixes commitmsg.Fixes
+}
+
+func (p Fixing) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	raw, err := p.Provider.GenerateCommitMessage(ctx, msgs, temp)
+	if err != nil {
+		return "", err
+	}
+	msg, ok := vscodeprompt.ExtractOneTextCodeBlock(raw)
+	if !ok {
+		msg = raw
+	}
+	return commitmsg.Fix(msg, p.Fixes), nil
+}
@@ -0,0 +1,86 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/anthropic"
+	"github.com/hoanghonghuy/commitgen/internal/gemini"
+	"github.com/hoanghonghuy/commitgen/internal/ollama"
+	"github.com/hoanghonghuy/commitgen/internal/openai"
+)
+
+// ErrMissingKey is returned by NewProvider for a provider that needs an API key
+// and was given none.
+var ErrMissingKey = errors.New("missing API key")
+
+// ProviderConfig selects and configures a built-in provider. Keys are used as
+// given: resolving references to secret stores is up to the caller.
+type ProviderConfig struct {
+	Provider string // openai (default), anthropic, gemini, or ollama
+	Model    string
+	BaseURL  string // the API endpoint, for openai and ollama
+	APIKey   string // the key for Provider; not needed for ollama
+
+	NumCtx       int                                   // ollama's context window
+	OpenAIAPI    string                                // openai.APIChat (default) or openai.APIResponses
+	Organization string                                // OpenAI organization
+	Project      string                                // OpenAI project
+	Token        func(context.Context) (string, error) // bearer tokens for openai instead of APIKey, e.g. from Azure
+}
+
+// NewProvider returns the provider pc selects.
+func NewProvider(pc ProviderConfig) (ai.Provider, error) {
+	if strings.TrimSpace(pc.Model) == "" {
+		return nil, errors.New("missing model")
+	}
+
+	switch strings.ToLower(pc.Provider) {
+	case "ollama":
+		return ollama.New(ollama.Config{
+			BaseURL: pc.BaseURL,
+			Model:   pc.Model,
+			NumCtx:  pc.NumCtx,
+		}), nil
+	case "anthropic":
+		if pc.APIKey == "" {
+			return nil, fmt.Errorf("anthropic: %w", ErrMissingKey)
+		}
+		return anthropic.New(anthropic.Config{
+			APIKey: pc.APIKey,
+			Model:  pc.Model,
+		}), nil
+	case "gemini":
+		if pc.APIKey == "" {
+			return nil, fmt.Errorf("gemini: %w", ErrMissingKey)
+		}
+		return gemini.New(gemini.Config{
+			APIKey: pc.APIKey,
+			Model:  pc.Model,
+		}), nil
+	case "openai", "":
+		// A custom endpoint, such as a local OpenAI-compatible server, may need no key.
+		if strings.TrimSpace(pc.BaseURL) == "" && strings.TrimSpace(pc.APIKey) == "" && pc.Token == nil {
+			return nil, fmt.Errorf("openai: %w", ErrMissingKey)
+		}
+		switch pc.OpenAIAPI {
+		case "", openai.APIChat, openai.APIResponses:
+		default:
+			return nil, fmt.Errorf("unknown openai_api %q (use: %s | %s)", pc.OpenAIAPI, openai.APIChat, openai.APIResponses)
+		}
+		return openai.New(openai.Config{
+			BaseURL:      pc.BaseURL,
+			APIKey:       pc.APIKey,
+			Model:        pc.Model,
+			API:          pc.OpenAIAPI,
+			Organization: pc.Organization,
+			Project:      pc.Project,
+			Token:        pc.Token,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown provider: %s (supported: openai, ollama, anthropic, gemini)", pc.Provider)
+	}
+}
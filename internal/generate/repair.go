@@ -0,0 +1,104 @@
+package generate
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// Repair checks each generated message and, while it has issues, sends it back to
+// the model with the issues explained: a subject over the configured length (to be
+// shortened, with the detail moved to the body), Conventional Commits syntax errors,
+// and policy violations. If issues remain after the allowed attempts, the last
+// answer is returned as is.
+type Repair struct {
+	ai.Provider             // answers the repair prompt
+	draft       ai.Provider // writes the message
+	attempts    int
+	checker     Checker
+	data        vscodeprompt.FixData
+}
+
+// repairRules are the lint rules worth another request: the subject length and the
+// Conventional Commits grammar. The rest, such as a trailing period, are fixed
+// locally or left to commitgen lint.
+var repairRules = []string{"subject-length", "conventional-format", "description-empty", "type-enum"}
+
+// Checker finds the issues in generated messages that are worth another request.
+type Checker struct {
+	rules  commitmsg.Rules
+	policy commitmsg.Policy
+}
+
+// NewChecker returns a Checker for the repairRules among rules, and for policy.
+func NewChecker(rules commitmsg.Rules, policy commitmsg.Policy) Checker {
+	return Checker{rules: rules, policy: policy}
+}
+
+// NewRepair returns a Repair of the messages draft writes, asking base for up to
+// attempts repairs. diff is sent along so the model can tell what the message describes.
+func NewRepair(base, draft ai.Provider, attempts int, rules commitmsg.Rules, policy commitmsg.Policy, diff string) *Repair {
+	types := rules.Types
+	if rules.Conventional && len(types) == 0 {
+		types = commitmsg.DefaultTypes
+	}
+	return &Repair{
+		Provider: base,
+		draft:    draft,
+		attempts: attempts,
+		checker:  NewChecker(rules, policy),
+		data: vscodeprompt.FixData{
+			Conventional:     rules.Conventional,
+			Types:            types,
+			MaxSubjectLength: rules.MaxSubjectLength,
+			Diff:             diff,
+		},
+	}
+}
+
+func (p *Repair) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	raw, err := p.draft.GenerateCommitMessage(ctx, msgs, temp)
+	if err != nil {
+		return "", err
+	}
+	// The draft was streamed already; a repaired answer would be appended to it.
+	ctx = ai.WithStream(ctx, nil)
+	for attempt := 1; attempt <= p.attempts; attempt++ {
+		msg, ok := vscodeprompt.ExtractOneTextCodeBlock(raw)
+		if !ok {
+			msg = raw
+		}
+		issues := p.checker.Issues(msg)
+		if len(issues) == 0 {
+			return raw, nil
+		}
+		slog.Debug("message has issues, asking for a repair", "attempt", attempt, "issues", issues)
+
+		d := p.data
+		d.Message = commitmsg.Clean(msg)
+		d.Issues = issues
+		raw, err = p.Provider.GenerateCommitMessage(ctx, vscodeprompt.BuildFixMessages(d), temp)
+		if err != nil {
+			return "", err
+		}
+	}
+	return raw, nil
+}
+
+// Issues returns what is wrong with msg, as text for the model.
+func (p Checker) Issues(msg string) []string {
+	var out []string
+	for _, is := range commitmsg.Lint(msg, p.rules) {
+		if slices.Contains(repairRules, is.Rule) {
+			out = append(out, is.String())
+		}
+	}
+	for _, is := range p.policy.Check(msg) {
+		out = append(out, is.String())
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package generate
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// sensitiveFiles are base-name patterns of files that usually hold secrets. Staged,
+// they are left out of the prompt, name and all, unless --allow-sensitive is given:
+// their diff would send the secret, and even a name like prod-db.pem tells something.
+var sensitiveFiles = []string{
+	".env", ".env.*", "*.env",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore", "*.kdbx",
+	"credentials.json", "client_secret*.json", "service-account*.json",
+	".netrc", ".pgpass", ".htpasswd", ".npmrc", ".pypirc",
+	"*.tfstate", "*.tfstate.backup",
+}
+
+// templateSuffixes mark copies of sensitive files that hold placeholders, such as
+// .env.example, which are meant to be committed.
+var templateSuffixes = []string{".example", ".sample", ".template", ".dist"}
+
+// IsSensitive reports whether path looks like a file holding secrets.
+func IsSensitive(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	for _, suffix := range templateSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return false
+		}
+	}
+	for _, pattern := range sensitiveFiles {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+)
+
+func TestIsSensitive(t *testing.T) {
+	for path, want := range map[string]bool{
+		".env":                     true,
+		"deploy/.env.production":   true,
+		"config/prod.env":          true,
+		"home/.ssh/id_ed25519":     true,
+		"certs/server.PEM":         true,
+		"gcp/credentials.json":     true,
+		"infra/terraform.tfstate":  true,
+		".env.example":             false,
+		"id_rsa.pub":               false,
+		"docs/credentials.md":      false,
+		"internal/env/env.go":      false,
+		"keys/README.md":           false,
+		"src/components/Key.tsx":   false,
+		"config/prod.env.template": false,
+	} {
+		if got := IsSensitive(path); got != want {
+			t.Errorf("IsSensitive(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+func TestSensitiveFilesLeftOut(t *testing.T) {
+	changes := []gitx.StagedChange{
+		{Path: "app.go", Diff: "+package generate\n"},
+		{Path: ".env", Diff: "+API_KEY=sk-live-123\n"},
+	}
+	data, err := BuildData(context.Background(), "", changes, Options{MaxFiles: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Changes) != 1 || data.Changes[0].Path != "app.go" {
+		t.Errorf("changes = %+v, want only app.go", data.Changes)
+	}
+
+	data, err = BuildData(context.Background(), "", changes, Options{MaxFiles: 10, AllowSensitive: true})
+	if err != nil || len(data.Changes) != 2 {
+		t.Errorf("with allowSensitive: %d changes, %v; want 2", len(data.Changes), err)
+	}
+}
@@ -0,0 +1,158 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	BaseURL string // default: https://api.github.com (GITHUB_API_URL on Enterprise)
+	Token   string
+}
+
+// Client is a minimal GitHub REST client covering what the Action needs.
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+func New(cfg Config) *Client {
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		cfg.BaseURL = "https://api.github.com"
+	}
+	return &Client{
+		cfg: cfg,
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// NewPullRequest is what CreatePullRequest sends.
+type NewPullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Head  string `json:"head"` // branch with the changes
+	Base  string `json:"base"` // branch to merge into
+	Draft bool   `json:"draft,omitempty"`
+}
+
+type Commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// PullRequestDiff returns the unified diff of pull request n in repo ("owner/name").
+func (c *Client) PullRequestDiff(ctx context.Context, repo string, n int) (string, error) {
+	b, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", repo, n), "application/vnd.github.diff", nil)
+	return string(b), err
+}
+
+// PullRequestCommits returns the commits of pull request n, oldest first (first 100).
+func (c *Client) PullRequestCommits(ctx context.Context, repo string, n int) ([]Commit, error) {
+	var out []Commit
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d/commits?per_page=100", repo, n), nil, &out)
+	return out, err
+}
+
+// CreatePullRequest opens a pull request in repo ("owner/name").
+func (c *Client) CreatePullRequest(ctx context.Context, repo string, pr NewPullRequest) (PullRequest, error) {
+	var out PullRequest
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls", repo), pr, &out)
+	return out, err
+}
+
+// UpdatePullRequestBody replaces the description of pull request n.
+func (c *Client) UpdatePullRequestBody(ctx context.Context, repo string, n int, body string) error {
+	return c.doJSON(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/pulls/%d", repo, n), map[string]string{"body": body}, nil)
+}
+
+// IssueComments returns the comments on issue or pull request n (first 100).
+func (c *Client) IssueComments(ctx context.Context, repo string, n int) ([]Comment, error) {
+	var out []Comment
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100", repo, n), nil, &out)
+	return out, err
+}
+
+// CreateComment adds a comment to issue or pull request n.
+func (c *Client) CreateComment(ctx context.Context, repo string, n int, body string) error {
+	return c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, n), map[string]string{"body": body}, nil)
+}
+
+// UpdateComment replaces the text of comment id.
+func (c *Client) UpdateComment(ctx context.Context, repo string, id int64, body string) error {
+	return c.doJSON(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), map[string]string{"body": body}, nil)
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, _ := json.Marshal(in)
+		body = bytes.NewReader(payload)
+	}
+	b, err := c.do(ctx, method, path, "application/vnd.github+json", body)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("github: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, accept string, body io.Reader) ([]byte, error) {
+	url := strings.TrimRight(c.cfg.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if strings.TrimSpace(c.cfg.Token) != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(b, &e) == nil && e.Message != "" {
+			return nil, fmt.Errorf("github: %s %s: %s (%d)", method, path, e.Message, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("github: %s %s: status %d", method, path, resp.StatusCode)
+	}
+	return b, nil
+}
@@ -0,0 +1,108 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	BaseURL string // instance URL, default: https://gitlab.com
+	Token   string // personal, project or group access token with the api scope
+}
+
+// Client is a minimal GitLab REST (v4) client covering what merge requests need.
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+func New(cfg Config) *Client {
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		cfg.BaseURL = "https://gitlab.com"
+	}
+	return &Client{
+		cfg: cfg,
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// NewMergeRequest is what CreateMergeRequest sends.
+type NewMergeRequest struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+}
+
+type MergeRequest struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	WebURL string `json:"web_url"`
+}
+
+// CreateMergeRequest opens a merge request in project ("group/subgroup/name").
+func (c *Client) CreateMergeRequest(ctx context.Context, project string, mr NewMergeRequest) (MergeRequest, error) {
+	var out MergeRequest
+	err := c.doJSON(ctx, http.MethodPost, "/projects/"+url.PathEscape(project)+"/merge_requests", mr, &out)
+	return out, err
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, _ := json.Marshal(in)
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.cfg.BaseURL, "/")+"/api/v4"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if strings.TrimSpace(c.cfg.Token) != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.cfg.Token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		// GitLab reports errors as {"message": "..."} or {"message": ["...", ...]}, or {"error": "..."}.
+		var e struct {
+			Message any    `json:"message"`
+			Error   string `json:"error"`
+		}
+		if json.Unmarshal(b, &e) == nil {
+			msg := e.Error
+			if e.Message != nil {
+				msg = fmt.Sprint(e.Message)
+			}
+			if msg != "" {
+				return fmt.Errorf("gitlab: %s %s: %s (%d)", method, path, msg, resp.StatusCode)
+			}
+		}
+		return fmt.Errorf("gitlab: %s %s: status %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("gitlab: decode %s: %w", path, err)
+	}
+	return nil
+}
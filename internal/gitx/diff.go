@@ -0,0 +1,115 @@
+package gitx
+
+import "strings"
+
+// ParseUnifiedDiff splits a multi-file unified diff (as printed by `git diff` or `diff -u`)
+// into one StagedChange per file.
+func ParseUnifiedDiff(s string) []StagedChange {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+
+	gitStyle := strings.HasPrefix(s, "diff --git ") || strings.Contains(s, "\ndiff --git ")
+
+	// Find the line index where each file section starts.
+	var starts []int
+	for i, ln := range lines {
+		if gitStyle {
+			if strings.HasPrefix(ln, "diff --git ") {
+				starts = append(starts, i)
+			}
+		} else if strings.HasPrefix(ln, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			starts = append(starts, i)
+		}
+	}
+
+	var out []StagedChange
+	for k, start := range starts {
+		end := len(lines)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		section := lines[start:end]
+		path := diffSectionPath(section)
+		if path == "" {
+			continue
+		}
+		out = append(out, StagedChange{
+			Path: path,
+			Diff: strings.TrimRight(strings.Join(section, "\n"), "\n") + "\n",
+		})
+	}
+	return out
+}
+
+// diffSectionPath returns the file a diff section applies to, preferring the new name.
+func diffSectionPath(section []string) string {
+	var oldPath, newPath string
+	for _, ln := range section {
+		switch {
+		case strings.HasPrefix(ln, "+++ "):
+			newPath = diffHeaderPath(strings.TrimPrefix(ln, "+++ "))
+		case strings.HasPrefix(ln, "--- "):
+			oldPath = diffHeaderPath(strings.TrimPrefix(ln, "--- "))
+		case strings.HasPrefix(ln, "@@"):
+			// Headers are done once hunks start.
+			if newPath != "" {
+				return newPath
+			}
+			return oldPath
+		}
+	}
+	if newPath != "" {
+		return newPath
+	}
+	if oldPath != "" {
+		return oldPath
+	}
+
+	// Binary files and pure renames/mode changes have no ---/+++ lines.
+	if len(section) > 0 && strings.HasPrefix(section[0], "diff --git ") {
+		fields := strings.Fields(section[0])
+		if len(fields) >= 4 {
+			return strings.TrimPrefix(fields[3], "b/")
+		}
+	}
+	return ""
+}
+
+func diffHeaderPath(p string) string {
+	// Drop a trailing timestamp ("file\t2024-01-01 ...") written by diff -u.
+	p, _, _ = strings.Cut(p, "\t")
+	p = strings.TrimSpace(p)
+	if p == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+// NewFile splits the diff of an added file into its header (the lines before the
+// first hunk) and the file's contents. ok is false for diffs of other changes.
+func NewFile(diff string) (header, content string, ok bool) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	hunks := false
+	for i, ln := range lines {
+		switch {
+		case !hunks && strings.HasPrefix(ln, "@@"):
+			header = strings.Join(lines[:i], "\n") + "\n"
+			hunks = true
+		case !hunks && strings.HasPrefix(ln, "--- "):
+			ok = diffHeaderPath(strings.TrimPrefix(ln, "--- ")) == ""
+		case hunks && strings.HasPrefix(ln, "+"):
+			b.WriteString(ln[1:])
+			b.WriteByte('\n')
+		case hunks && strings.HasPrefix(ln, "-"):
+			return "", "", false // a new file only adds lines
+		}
+	}
+	if !hunks || !ok {
+		return "", "", false
+	}
+	return header, b.String(), true
+}
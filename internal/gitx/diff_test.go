@@ -0,0 +1,60 @@
+package gitx
+
+import "testing"
+
+func TestParseUnifiedDiff_Git(t *testing.T) {
+	in := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	got := ParseUnifiedDiff(in)
+	want := []string{"main.go", "old.txt", "logo.png"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(got), len(want), got)
+	}
+	for i, p := range want {
+		if got[i].Path != p {
+			t.Errorf("change %d path = %q; want %q", i, got[i].Path, p)
+		}
+	}
+	if got[0].Diff[:len("diff --git")] != "diff --git" {
+		t.Errorf("diff should keep its header, got %q", got[0].Diff)
+	}
+}
+
+func TestParseUnifiedDiff_Plain(t *testing.T) {
+	in := "--- a.txt\t2024-01-01 00:00:00\n+++ a.txt\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-a\n+b\n"
+	got := ParseUnifiedDiff(in)
+	if len(got) != 1 || got[0].Path != "a.txt" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	added := "diff --git a/gen.go b/gen.go\nnew file mode 100644\nindex 0000000..1111111\n--- /dev/null\n+++ b/gen.go\n@@ -0,0 +1,2 @@\n+package gen\n+var x = 1\n"
+	header, content, ok := NewFile(added)
+	if !ok || content != "package gen\nvar x = 1\n" {
+		t.Fatalf("NewFile() = %q, %v", content, ok)
+	}
+	if header != "diff --git a/gen.go b/gen.go\nnew file mode 100644\nindex 0000000..1111111\n--- /dev/null\n+++ b/gen.go\n" {
+		t.Errorf("header = %q", header)
+	}
+
+	modified := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1,2 @@\n package main\n+var x = 1\n"
+	if _, _, ok := NewFile(modified); ok {
+		t.Error("NewFile() accepted a modified file")
+	}
+}
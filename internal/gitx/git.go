@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/tracex"
 )
 
 type StagedChange struct {
@@ -15,14 +20,43 @@ type StagedChange struct {
 	Diff string
 }
 
+// waitDelay bounds how long a canceled git is waited for once it is killed. Its own
+// children (hooks, credential helpers, a shell alias) may keep its output open, and
+// would otherwise hold up the return until they exit.
+const waitDelay = 2 * time.Second
+
 func Git(ctx context.Context, repoRoot string, args ...string) (string, error) {
+	return GitInput(ctx, repoRoot, "", args...)
+}
+
+// GitInput runs git like Git, with stdin as its standard input.
+func GitInput(ctx context.Context, repoRoot, stdin string, args ...string) (string, error) {
+	name := "git"
+	if len(args) > 0 {
+		name += " " + args[0]
+	}
+	ctx, span := tracex.Start(ctx, name, tracex.KindInternal, tracex.Strings("git.args", args))
 	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoRoot}, args...)...)
+	cmd.WaitDelay = waitDelay
+	cmd.Stdin = strings.NewReader(stdin)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("git %v failed: %v\n%s", args, err, stderr.String())
+	start := time.Now()
+	err := cmd.Run()
+	slog.Debug("git", "args", args, "duration", time.Since(start), "ok", err == nil)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		// Killed for the timeout or interrupt, which is what callers need to know.
+		err = fmt.Errorf("git %v: %w", args, ctxErr)
+		span.End(err)
+		return "", err
 	}
+	if err != nil {
+		err = fmt.Errorf("git %v failed: %v\n%s", args, err, stderr.String())
+		span.End(err)
+		return "", err
+	}
+	span.End(nil)
 	return stdout.String(), nil
 }
 
@@ -42,6 +76,16 @@ func GitConfig(ctx context.Context, repoRoot, key string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// GitConfigAll returns every value of a key that may be set more than once, such as
+// with git config --add.
+func GitConfigAll(ctx context.Context, repoRoot, key string) ([]string, error) {
+	out, err := Git(ctx, repoRoot, "config", "--get-all", key)
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmptyLines(out), nil
+}
+
 func RecentCommits(ctx context.Context, repoRoot string, n int) ([]string, error) {
 	if n <= 0 {
 		return nil, nil
@@ -53,36 +97,205 @@ func RecentCommits(ctx context.Context, repoRoot string, n int) ([]string, error
 	return splitNonEmptyLines(out), nil
 }
 
-func RecentCommitsByAuthor(ctx context.Context, repoRoot string, n int, author string) ([]string, error) {
-	if n <= 0 || strings.TrimSpace(author) == "" {
+// RecentCommitsByAuthor returns the subjects of the last n commits by any of authors,
+// each matched as a plain string against the author's name and email, as .mailmap
+// maps them.
+func RecentCommitsByAuthor(ctx context.Context, repoRoot string, n int, authors ...string) ([]string, error) {
+	args := []string{"log", fmt.Sprintf("-n %d", n), "--use-mailmap", "--fixed-strings"}
+	for _, a := range authors {
+		if a = strings.TrimSpace(a); a != "" {
+			args = append(args, "--author="+a)
+		}
+	}
+	if n <= 0 || len(args) == 4 {
+		return nil, nil
+	}
+	out, err := Git(ctx, repoRoot, append(args, "--pretty=format:%s")...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmptyLines(out), nil
+}
+
+// CommitSubjects returns the subjects of the last n commits in revRange (e.g.
+// "origin/main..HEAD"), newest first.
+func CommitSubjects(ctx context.Context, repoRoot, revRange string, n int) ([]string, error) {
+	if n <= 0 {
 		return nil, nil
 	}
-	out, err := Git(ctx, repoRoot, "log", fmt.Sprintf("-n %d", n), fmt.Sprintf("--author=%s", author), "--pretty=format:%s")
+	out, err := Git(ctx, repoRoot, "log", fmt.Sprintf("-n %d", n), "--pretty=format:%s", revRange, "--")
 	if err != nil {
 		return nil, err
 	}
 	return splitNonEmptyLines(out), nil
 }
 
-func StagedChanges(ctx context.Context, repoRoot string, maxFiles int) ([]StagedChange, error) {
+// CommitMessage is the full message of one commit.
+type CommitMessage struct {
+	Hash    string
+	Message string
+}
+
+// CommitMessages returns the messages of the commits in revRange (e.g. "main..HEAD"), newest first.
+func CommitMessages(ctx context.Context, repoRoot, revRange string) ([]CommitMessage, error) {
+	// %x1e separates records, %x1f separates hash from body.
+	out, err := Git(ctx, repoRoot, "log", "--format=%H%x1f%B%x1e", revRange, "--")
+	if err != nil {
+		return nil, err
+	}
+	var msgs []CommitMessage
+	for _, rec := range strings.Split(out, "\x1e") {
+		rec = strings.TrimLeft(rec, "\n")
+		hash, body, ok := strings.Cut(rec, "\x1f")
+		if !ok {
+			continue
+		}
+		msgs = append(msgs, CommitMessage{Hash: hash, Message: strings.TrimSpace(body)})
+	}
+	return msgs, nil
+}
+
+// MergedTags returns the tags reachable from HEAD.
+func MergedTags(ctx context.Context, repoRoot string) ([]string, error) {
+	out, err := Git(ctx, repoRoot, "tag", "--merged", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmptyLines(out), nil
+}
+
+// patchArgs fix the format of the patches ParseUnifiedDiff reads, whatever the
+// user's diff settings (diff.mnemonicPrefix, diff.noprefix, diff.relative): paths
+// from the repository root, behind a/ and b/.
+var patchArgs = []string{"--no-color", "--no-ext-diff", "--no-relative", "--src-prefix=a/", "--dst-prefix=b/"}
+
+// CommitDiff returns the patch introduced by commit (against its first parent).
+func CommitDiff(ctx context.Context, repoRoot, commit string) (string, error) {
+	return Git(ctx, repoRoot, append([]string{"show", "--format=", "--patch", "--first-parent"}, append(patchArgs, commit)...)...)
+}
+
+// ChangeState reports whether repoRoot has staged changes, and whether it has any
+// changes at all, untracked files included.
+func ChangeState(ctx context.Context, repoRoot string) (staged, dirty bool, err error) {
+	out, err := Git(ctx, repoRoot, "status", "--porcelain")
+	if err != nil {
+		return false, false, err
+	}
+	// Lines are "XY path"; X is the index status, ' ' or '?' when nothing is staged.
+	for _, ln := range strings.Split(out, "\n") {
+		if len(ln) < 3 {
+			continue
+		}
+		dirty = true
+		if ln[0] != ' ' && ln[0] != '?' {
+			staged = true
+		}
+	}
+	return staged, dirty, nil
+}
+
+// RangeDiff returns the changes on HEAD since it branched off base (git diff base...HEAD).
+func RangeDiff(ctx context.Context, repoRoot, base string) (string, error) {
+	return Git(ctx, repoRoot, append(append([]string{"diff"}, patchArgs...), base+"...HEAD")...)
+}
+
+// StagedDiff returns the whole staged diff.
+func StagedDiff(ctx context.Context, repoRoot string) (string, error) {
+	return Git(ctx, repoRoot, append([]string{"diff", "--staged"}, patchArgs...)...)
+}
+
+// DefaultBranch returns remote's default branch as "remote/name", from its HEAD ref,
+// or "" if the remote has none recorded.
+func DefaultBranch(ctx context.Context, repoRoot, remote string) string {
+	out, err := Git(ctx, repoRoot, "symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// RemoteURL returns the fetch URL of remote.
+func RemoteURL(ctx context.Context, repoRoot, remote string) (string, error) {
+	out, err := Git(ctx, repoRoot, "remote", "get-url", remote)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// PushBranch pushes the current branch to remote and sets it as the upstream.
+func PushBranch(ctx context.Context, repoRoot, remote string) error {
+	_, err := Git(ctx, repoRoot, "push", "--set-upstream", remote, "HEAD")
+	return err
+}
+
+// StagedChanges returns the staged diff split per file, keeping at most maxFiles entries.
+// The whole patch comes from a single git invocation; spawning one process per file made
+// large commits noticeably slow. pathspecs, if any, limit the diff as on the git command line.
+func StagedChanges(ctx context.Context, repoRoot string, maxFiles int, pathspecs ...string) ([]StagedChange, error) {
 	if maxFiles <= 0 {
 		maxFiles = 10
 	}
-	filesOut, err := Git(ctx, repoRoot, "diff", "--staged", "--name-only")
+	args := append([]string{"diff", "--staged", "--patch"}, patchArgs...)
+	if len(pathspecs) > 0 {
+		args = append(append(args, "--"), pathspecs...)
+	}
+	out, err := Git(ctx, repoRoot, args...)
 	if err != nil {
 		return nil, err
 	}
-	files := splitNonEmptyLines(filesOut)
-	if len(files) > maxFiles {
-		files = files[:maxFiles]
+	changes := ParseUnifiedDiff(out)
+	if len(changes) > maxFiles {
+		changes = changes[:maxFiles]
 	}
+	return changes, nil
+}
 
-	var out []StagedChange
-	for _, f := range files {
-		diff, _ := Git(ctx, repoRoot, "diff", "--staged", "--", f)
-		out = append(out, StagedChange{Path: f, Diff: diff})
+// ExcludePathspec turns a pathspec into one that excludes what it matched, keeping any
+// magic it already has (e.g. ":(glob)vendor/**" becomes ":(exclude,glob)vendor/**").
+// One that already excludes, such as ":!vendor", is returned as is.
+func ExcludePathspec(p string) string {
+	switch {
+	case strings.HasPrefix(p, ":("):
+		magic, _, _ := strings.Cut(p[2:], ")")
+		if slices.Contains(strings.Split(magic, ","), "exclude") {
+			return p
+		}
+		return ":(exclude," + p[2:]
+	case strings.HasPrefix(p, ":"):
+		if magic := p[1 : len(p)-len(strings.TrimLeft(p[1:], "/!^"))]; strings.ContainsAny(magic, "!^") {
+			return p
+		}
+		return ":!" + p[1:]
+	default:
+		return ":(exclude)" + p
 	}
-	return out, nil
+}
+
+// SetAside unstages paths, keeping their changes in the working tree, so the next
+// commit leaves them out. The returned function stages them again exactly as they
+// were, partly staged files included; tree is the index before, which
+// "git read-tree <tree>" restores should that function never run.
+func SetAside(ctx context.Context, repoRoot string, paths []string) (tree string, restore func(context.Context) error, err error) {
+	if len(paths) == 0 {
+		return "", func(context.Context) error { return nil }, nil
+	}
+	tree, err = Git(ctx, repoRoot, "write-tree")
+	if err != nil {
+		return "", nil, err
+	}
+	tree = strings.TrimSpace(tree)
+	specs := make([]string, len(paths))
+	for i, p := range paths {
+		specs[i] = ":(literal)" + p
+	}
+	if _, err := Git(ctx, repoRoot, append([]string{"reset", "-q", "--"}, specs...)...); err != nil {
+		return "", nil, err
+	}
+	return tree, func(ctx context.Context) error {
+		_, err := Git(ctx, repoRoot, append([]string{"restore", "--staged", "--source=" + tree, "--"}, specs...)...)
+		return err
+	}, nil
 }
 
 func OriginalFileAtHEAD(ctx context.Context, repoRoot, relPath string) (string, error) {
@@ -94,6 +307,31 @@ func OriginalFileAtHEAD(ctx context.Context, repoRoot, relPath string) (string,
 	return out, nil
 }
 
+// HeadBlobs returns the blob ID of each path in HEAD. Paths missing from HEAD (new files)
+// are left out of the map.
+func HeadBlobs(ctx context.Context, repoRoot string, paths []string) (map[string]string, error) {
+	blobs := make(map[string]string, len(paths))
+	if len(paths) == 0 {
+		return blobs, nil
+	}
+	out, err := Git(ctx, repoRoot, append([]string{"ls-tree", "-z", "--full-tree", "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	// Records look like "<mode> blob <id>\t<path>", NUL-terminated.
+	for _, rec := range strings.Split(out, "\x00") {
+		meta, path, ok := strings.Cut(rec, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) == 3 && fields[1] == "blob" {
+			blobs[path] = fields[2]
+		}
+	}
+	return blobs, nil
+}
+
 func ReadWorkingTreeFile(repoRoot, relPath string) (string, error) {
 	p := filepath.Join(repoRoot, relPath)
 	b, err := os.ReadFile(p)
@@ -104,14 +342,81 @@ func ReadWorkingTreeFile(repoRoot, relPath string) (string, error) {
 }
 
 func Commit(ctx context.Context, repoRoot, message string) error {
-	msg := strings.TrimSpace(message)
-	if msg == "" {
+	if strings.TrimSpace(message) == "" {
 		return fmt.Errorf("commit message cannot be empty")
 	}
-	_, err := Git(ctx, repoRoot, "commit", "-m", msg)
+	_, err := Git(ctx, repoRoot, CommitArgs(message)...)
 	return err
 }
 
+// CommitArgs returns the git arguments Commit runs to commit message.
+func CommitArgs(message string) []string {
+	return []string{"commit", "-m", strings.TrimSpace(message)}
+}
+
+// CommandLine formats git with args as a POSIX shell command, quoting the arguments
+// that need it, for the user to read or run.
+func CommandLine(args ...string) string {
+	var b strings.Builder
+	b.WriteString("git")
+	for _, a := range args {
+		b.WriteByte(' ')
+		if a != "" && strings.Trim(a, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=@%+,") == "" {
+			b.WriteString(a)
+			continue
+		}
+		b.WriteString("'" + strings.ReplaceAll(a, "'", `'\''`) + "'")
+	}
+	return b.String()
+}
+
+// CommitterIdent returns "Name <email>" of the committer, as used by git commit --signoff.
+func CommitterIdent(ctx context.Context, repoRoot string) (string, error) {
+	out, err := Git(ctx, repoRoot, "var", "GIT_COMMITTER_IDENT")
+	if err != nil {
+		return "", err
+	}
+	// "Name <email> 1700000000 +0000": drop the timestamp and zone.
+	ident := strings.TrimSpace(out)
+	if i := strings.LastIndex(ident, ">"); i >= 0 {
+		ident = ident[:i+1]
+	}
+	return ident, nil
+}
+
+// InterpretTrailers appends trailers ("Key: value") to message the way
+// git interpret-trailers does: into the existing trailer block if there is one,
+// skipping any that are already there with the same value.
+func InterpretTrailers(ctx context.Context, repoRoot, message string, trailers []string) (string, error) {
+	args := []string{"interpret-trailers", "--if-exists", "addIfDifferent"}
+	for _, t := range trailers {
+		args = append(args, "--trailer", t)
+	}
+	out, err := GitInput(ctx, repoRoot, strings.TrimSpace(message)+"\n", args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Editor returns the editor command git would use for commit messages
+// (GIT_EDITOR, core.editor, VISUAL, EDITOR, then vi). repoRoot may be empty.
+func Editor(ctx context.Context, repoRoot string) string {
+	if repoRoot != "" {
+		if out, err := Git(ctx, repoRoot, "var", "GIT_EDITOR"); err == nil {
+			if e := strings.TrimSpace(out); e != "" {
+				return e
+			}
+		}
+	}
+	for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); e != "" {
+			return e
+		}
+	}
+	return "vi"
+}
+
 func splitNonEmptyLines(s string) []string {
 	s = strings.ReplaceAll(s, "\r\n", "\n")
 	var out []string
@@ -0,0 +1,197 @@
+package gitx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExcludePathspec(t *testing.T) {
+	tests := map[string]string{
+		"vendor":           ":(exclude)vendor",
+		":(glob)vendor/**": ":(exclude,glob)vendor/**",
+		":/docs":           ":!/docs",
+		":!vendor":         ":!vendor",
+		":/^docs":          ":/^docs",
+		":(exclude)vendor": ":(exclude)vendor",
+		":(glob,exclude)x": ":(glob,exclude)x",
+	}
+	for in, want := range tests {
+		if got := ExcludePathspec(in); got != want {
+			t.Errorf("ExcludePathspec(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestGitCanceled(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	// The alias runs sleep in a shell under git, so killing git alone leaves sleep
+	// holding its output open, as a hung hook or helper would.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Git(ctx, t.TempDir(), "-c", "alias.hang=!sleep 30", "hang")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want a deadline error", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Git returned after %v, want it to give up on the hung command", d)
+	}
+}
+
+func TestSetAside(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := Git(ctx, dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	write("api/a.go", "one\n")
+	write("web/b.js", "one\n")
+	run("add", ".")
+	run("-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "init")
+	write("api/a.go", "two\n")
+	write("web/b.js", "two\n")
+	write("web/new.js", "new\n")
+	run("add", ".")
+	write("web/b.js", "three\n") // partly staged: the index has "two"
+
+	tree, restore, err := SetAside(ctx, dir, []string{"web/b.js", "web/new.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := run("ls-tree", "-r", "--name-only", tree); got != "api/a.go\nweb/b.js\nweb/new.js\n" {
+		t.Errorf("tree = %q; want the index before setting aside", got)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "api/a.go\n" {
+		t.Errorf("staged after SetAside = %q; want only api/a.go", got)
+	}
+	if err := restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "api/a.go\nweb/b.js\nweb/new.js\n" {
+		t.Errorf("staged after restore = %q; want all three", got)
+	}
+	if got := run("show", ":web/b.js"); got != "two\n" {
+		t.Errorf("staged web/b.js = %q; want the staged version, two", got)
+	}
+}
+
+func TestStagedChangesPrefixes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "diff.mnemonicPrefix", "true"},
+		{"config", "diff.noprefix", "true"},
+	} {
+		if _, err := Git(ctx, dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"f.txt", "a/x.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("one\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Git(ctx, dir, "add", "."); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := StagedChanges(ctx, dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, ch := range changes {
+		paths = append(paths, ch.Path)
+	}
+	if !slices.Equal(paths, []string{"a/x.txt", "f.txt"}) {
+		t.Errorf("paths = %q; want a/x.txt and f.txt", paths)
+	}
+}
+
+func TestRecentCommitsByAuthor(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	if _, err := Git(ctx, dir, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ email, subject string }{
+		{"ann+old@example.com", "fix: old address"},
+		{"bob@example.com", "feat: someone else"},
+		{"ann@example.com", "docs: new address"},
+	} {
+		if _, err := Git(ctx, dir, "-c", "user.name=n", "-c", "user.email="+c.email, "commit", "-q", "--allow-empty", "-m", c.subject); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := RecentCommitsByAuthor(ctx, dir, 10, "ann@example.com", "ann+old@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docs: new address", "fix: old address"}; !slices.Equal(got, want) {
+		t.Errorf("RecentCommitsByAuthor() = %q; want %q", got, want)
+	}
+	if got, _ := RecentCommitsByAuthor(ctx, dir, 10, "", " "); got != nil {
+		t.Errorf("RecentCommitsByAuthor() without authors = %q", got)
+	}
+}
+
+func TestMailmapAliases(t *testing.T) {
+	dir := t.TempDir()
+	mailmap := "# people\nAnn <ann@example.com> <ann+old@example.com>\n<ann@example.com> Ann Old <ANN@corp.example>\nBob <bob@example.com>\nCarol <carol@example.com> <ann-typo@example.com>\n"
+	if err := os.WriteFile(filepath.Join(dir, ".mailmap"), []byte(mailmap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := MailmapAliases(dir, []string{"Ann@Example.com"}), []string{"ann+old@example.com", "ann@corp.example"}; !slices.Equal(got, want) {
+		t.Errorf("MailmapAliases(ann) = %q; want %q", got, want)
+	}
+	if got, want := MailmapAliases(dir, []string{"ann+old@example.com"}), []string{"ann@corp.example", "ann@example.com"}; !slices.Equal(got, want) {
+		t.Errorf("MailmapAliases(old) = %q; want %q", got, want)
+	}
+	if got := MailmapAliases(dir, []string{"bob@example.com"}); got != nil {
+		t.Errorf("MailmapAliases(bob) = %q", got)
+	}
+	if got := MailmapAliases(t.TempDir(), []string{"ann@example.com"}); got != nil {
+		t.Errorf("MailmapAliases without .mailmap = %q", got)
+	}
+}
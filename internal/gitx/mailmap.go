@@ -0,0 +1,59 @@
+package gitx
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var mailmapEmail = regexp.MustCompile(`<([^>]*)>`)
+
+// MailmapAliases returns the addresses that the repository's .mailmap maps to the
+// same person as any of emails, such as the old work address of someone whose
+// commits are listed under their new one. emails themselves are not included.
+func MailmapAliases(repoRoot string, emails []string) []string {
+	b, err := os.ReadFile(filepath.Join(repoRoot, ".mailmap"))
+	if err != nil {
+		return nil
+	}
+
+	// Each person's proper address, with the addresses their commits use.
+	used := map[string][]string{}
+	for _, ln := range strings.Split(string(b), "\n") {
+		if i := strings.Index(ln, "#"); i >= 0 {
+			ln = ln[:i]
+		}
+		m := mailmapEmail.FindAllStringSubmatch(ln, 2)
+		if len(m) == 0 {
+			continue
+		}
+		proper := strings.ToLower(strings.TrimSpace(m[0][1]))
+		commit := proper
+		if len(m) == 2 {
+			commit = strings.ToLower(strings.TrimSpace(m[1][1]))
+		}
+		used[proper] = append(used[proper], commit)
+	}
+
+	var out []string
+	add := func(e string) {
+		if e != "" && !slices.Contains(out, e) && !slices.ContainsFunc(emails, func(u string) bool { return strings.EqualFold(u, e) }) {
+			out = append(out, e)
+		}
+	}
+	for _, e := range emails {
+		e = strings.ToLower(strings.TrimSpace(e))
+		for proper, commits := range used {
+			if proper == e || slices.Contains(commits, e) {
+				add(proper)
+				for _, c := range commits {
+					add(c)
+				}
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
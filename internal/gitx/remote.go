@@ -0,0 +1,39 @@
+package gitx
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RemoteProject returns remote's normalized project path, the host and repository
+// path such as "github.com/org/repo", or "" if remote isn't a hosted repository.
+func RemoteProject(remote string) string {
+	web, path, ok := SplitRemote(remote)
+	if !ok {
+		return ""
+	}
+	u, err := url.Parse(web)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname() + "/" + path
+}
+
+// SplitRemote splits a remote URL in any of git's forms, https://host/owner/name.git,
+// ssh://git@host/owner/name or git@host:owner/name.git, into the web URL of its host
+// and the repository path without ".git".
+func SplitRemote(remote string) (web, path string, ok bool) {
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		web, path = "https://"+u.Hostname(), u.Path
+		if u.Scheme == "http" || u.Scheme == "https" {
+			web = u.Scheme + "://" + u.Host
+		}
+	} else if host, p, found := strings.Cut(remote, ":"); found && !strings.Contains(host, "/") {
+		web, path = "https://"+host[strings.LastIndex(host, "@")+1:], p
+	}
+	path = strings.Trim(strings.TrimSuffix(path, ".git"), "/")
+	if path == "" || !strings.Contains(path, "/") || strings.Contains(path, "//") {
+		return "", "", false
+	}
+	return web, path, true
+}
@@ -3,12 +3,20 @@ package gitx
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/i18n"
 )
 
+// ResolveRepoRoot returns the top of the work tree at repoArg, or around the current
+// directory when repoArg is empty. GIT_DIR and GIT_WORK_TREE are honored as git
+// does, and linked worktrees (where .git is a file) resolve to their own root.
 func ResolveRepoRoot(ctx context.Context, repoArg string) (string, error) {
+	absGitEnv()
+
 	if strings.TrimSpace(repoArg) != "" {
 		p, err := filepath.Abs(repoArg)
 		if err != nil {
@@ -18,9 +26,9 @@ func ResolveRepoRoot(ctx context.Context, repoArg string) (string, error) {
 			return "", err
 		}
 		// If user points to subdir, normalize by asking git
-		root, err := Git(ctx, p, "rev-parse", "--show-toplevel")
-		if err == nil {
-			return strings.TrimSpace(root), nil
+		root, err := showToplevel(ctx, p)
+		if err == nil || errors.Is(err, ErrBareRepo) {
+			return root, err
 		}
 		return p, nil
 	}
@@ -31,19 +39,19 @@ func ResolveRepoRoot(ctx context.Context, repoArg string) (string, error) {
 		return "", err
 	}
 	// try git directly from cwd
-	root, err := Git(ctx, cwd, "rev-parse", "--show-toplevel")
-	if err == nil {
-		return strings.TrimSpace(root), nil
+	root, err := showToplevel(ctx, cwd)
+	if err == nil || errors.Is(err, ErrBareRepo) {
+		return root, err
 	}
 
-	// fallback: walk up to find .git (works for normal repos; not perfect for all worktrees)
+	// fallback: walk up to find .git, a directory or, in a linked worktree, a file
 	cur := cwd
 	for {
 		if exists(filepath.Join(cur, ".git")) {
 			// confirm via git
-			root, err := Git(ctx, cur, "rev-parse", "--show-toplevel")
+			root, err := showToplevel(ctx, cur)
 			if err == nil {
-				return strings.TrimSpace(root), nil
+				return root, nil
 			}
 			return cur, nil
 		}
@@ -54,7 +62,52 @@ func ResolveRepoRoot(ctx context.Context, repoArg string) (string, error) {
 		cur = parent
 	}
 
-	return "", errors.New("not inside a git repository. Use --repo /path/to/repo")
+	return "", errors.New(i18n.T("not inside a git repository. Use --repo /path/to/repo"))
+}
+
+// ErrBareRepo is returned by ResolveRepoRoot for a repository without a work tree.
+var ErrBareRepo error = i18n.NewError("bare repository has no work tree to commit from; set GIT_WORK_TREE or use --repo with a checkout")
+
+// showToplevel asks git for the top of the work tree containing dir.
+func showToplevel(ctx context.Context, dir string) (string, error) {
+	out, err := Git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	if bare, berr := Git(ctx, dir, "rev-parse", "--is-bare-repository"); berr == nil && strings.TrimSpace(bare) == "true" {
+		return "", ErrBareRepo
+	}
+	return "", err
+}
+
+// absGitEnv makes relative GIT_DIR and GIT_WORK_TREE absolute against the current
+// directory, where git would read them. Every git call here runs with -C <root>,
+// which would otherwise resolve them against the repository root.
+func absGitEnv() {
+	for _, name := range []string{"GIT_DIR", "GIT_WORK_TREE"} {
+		v := os.Getenv(name)
+		if v == "" || filepath.IsAbs(v) {
+			continue
+		}
+		if abs, err := filepath.Abs(v); err == nil {
+			os.Setenv(name, abs)
+		}
+	}
+}
+
+// GitPath returns the absolute path of name inside the repository's git directory,
+// as resolved by `git rev-parse --git-path`: it honors GIT_DIR, core.hooksPath for
+// "hooks", and the common directory shared by linked worktrees.
+func GitPath(ctx context.Context, repoRoot, name string) (string, error) {
+	out, err := Git(ctx, repoRoot, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", fmt.Errorf("locate %s: %w", name, err)
+	}
+	p := strings.TrimSpace(out)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(repoRoot, p)
+	}
+	return p, nil
 }
 
 func exists(p string) bool {
@@ -0,0 +1,61 @@
+package gitx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRepoRoot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	main := filepath.Join(dir, "main")
+	run := func(args ...string) {
+		t.Helper()
+		if _, err := Git(ctx, dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q", main)
+	run("-C", main, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init")
+	run("-C", main, "worktree", "add", "-q", filepath.Join(dir, "wt"))
+	run("init", "-q", "--bare", filepath.Join(dir, "bare.git"))
+
+	tests := []struct {
+		name, repo, gitDir, workTree string
+		want                         string
+		wantErr                      error
+	}{
+		{name: "checkout", repo: "main", want: "main"},
+		{name: "linked worktree", repo: "wt", want: "wt"},
+		{name: "bare", repo: "bare.git", wantErr: ErrBareRepo},
+		{name: "relative GIT_DIR", repo: "wt", gitDir: "main/.git", workTree: "main", want: "main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(dir)
+			for name, v := range map[string]string{"GIT_DIR": tt.gitDir, "GIT_WORK_TREE": tt.workTree} {
+				t.Setenv(name, v) // restored after the test
+				if v == "" {
+					os.Unsetenv(name)
+				}
+			}
+			got, err := ResolveRepoRoot(ctx, tt.repo)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != filepath.Join(dir, tt.want) {
+				t.Errorf("ResolveRepoRoot(%q) = %q, %v; want %q", tt.repo, got, err, filepath.Join(dir, tt.want))
+			}
+		})
+	}
+}
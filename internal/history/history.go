@@ -0,0 +1,220 @@
+package history
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Status values recorded for each history entry.
+const (
+	StatusGenerated = "generated"
+	StatusAccepted  = "accepted"
+	StatusRejected  = "rejected"
+	StatusFailed    = "failed" // accepted, but git commit failed; see commitgen resume
+)
+
+// Entry is a single generated message as stored in the history file (one JSON object per line).
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo,omitempty"`
+	Root     string    `json:"root,omitempty"` // the repository's directory, which tells apart repositories of the same name
+	DiffHash string    `json:"diff_hash"`
+	Status   string    `json:"status"`
+	Source   string    `json:"source,omitempty"` // e.g. "watch" for messages generated in the background
+	Message  string    `json:"message"`
+
+	// Recorded for accepted messages, to pick style examples by.
+	Paths  []string `json:"paths,omitempty"`  // the changed files
+	Edited bool     `json:"edited,omitempty"` // changed by the user before accepting
+}
+
+// SourceWatch marks messages pre-generated by `commitgen watch`.
+const SourceWatch = "watch"
+
+// DefaultPath returns ~/.commitgen_history.jsonl, next to the global config file.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".commitgen_history.jsonl")
+}
+
+// HashDiffs returns a stable hash identifying a set of staged diffs.
+func HashDiffs(diffs []string) string {
+	h := sha256.New()
+	for _, d := range diffs {
+		h.Write([]byte(d))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Append writes e to the history file at path, creating it if needed.
+func Append(path string, e Entry) error {
+	if path == "" {
+		path = DefaultPath()
+	}
+	if path == "" {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Message = strings.TrimSpace(e.Message)
+	if e.Message == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Load reads all entries from the history file, oldest first.
+// A missing file is not an error; malformed lines are skipped.
+func Load(path string) ([]Entry, error) {
+	if path == "" {
+		path = DefaultPath()
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// Prefetched returns the newest message generated by `commitgen watch` for diffHash.
+func Prefetched(entries []Entry, diffHash string) (string, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.DiffHash == diffHash && e.Source == SourceWatch && e.Status == StatusGenerated {
+			return e.Message, true
+		}
+	}
+	return "", false
+}
+
+// Cached returns the newest message generated or accepted for diffHash and not
+// rejected since, to reuse instead of asking the model again.
+func Cached(entries []Entry, diffHash string) (string, bool) {
+	rejected := map[string]bool{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch {
+		case e.DiffHash != diffHash:
+		case e.Status == StatusRejected:
+			rejected[e.Message] = true
+		case !rejected[e.Message]:
+			return e.Message, true
+		}
+	}
+	return "", false
+}
+
+// ForDiff returns the distinct messages previously generated for diffHash, newest first.
+func ForDiff(entries []Entry, diffHash string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.DiffHash != diffHash || seen[e.Message] {
+			continue
+		}
+		seen[e.Message] = true
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+// Exemplars returns up to n distinct accepted messages to show as examples of the
+// user's style for changes to paths in the repository at root. Only messages from
+// that repository are used, so that one project's commits never reach the prompt
+// of another; entries recorded before roots were kept match by the name, repo.
+// Messages for the same files or directories come first, then ones the user
+// edited, which carry their phrasing most, then the newest.
+func Exemplars(entries []Entry, root, repo string, paths []string, n int) []string {
+	near := map[string]bool{} // the changed files and their directories
+	for _, p := range paths {
+		near[p] = true
+		if d := path.Dir(p); d != "." {
+			near[d] = true
+		}
+	}
+
+	type candidate struct {
+		msg   string
+		score int
+		index int
+	}
+	var cands []candidate
+	seen := map[string]bool{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Status != StatusAccepted || seen[e.Message] {
+			continue
+		}
+		if e.Root != "" && e.Root != root || e.Root == "" && (repo == "" || e.Repo != repo) {
+			continue
+		}
+		seen[e.Message] = true
+		shared := 0
+		for _, p := range e.Paths {
+			if near[p] || near[path.Dir(p)] {
+				shared++
+			}
+		}
+		score := min(shared, 3)
+		if e.Edited {
+			score++
+		}
+		cands = append(cands, candidate{e.Message, score, i})
+	}
+	slices.SortStableFunc(cands, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.index, a.index))
+	})
+
+	var out []string
+	for _, c := range cands[:min(n, len(cands))] {
+		out = append(out, c.msg)
+	}
+	return out
+}
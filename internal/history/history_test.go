@@ -0,0 +1,90 @@
+package history
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAppendLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	entries := []Entry{
+		{DiffHash: "a", Status: StatusGenerated, Message: "feat: first"},
+		{DiffHash: "a", Status: StatusRejected, Message: "feat: first"},
+		{DiffHash: "b", Status: StatusGenerated, Message: "fix: other"},
+		{DiffHash: "a", Status: StatusGenerated, Message: "feat: second"},
+		{DiffHash: "a", Status: StatusGenerated, Message: "   "}, // skipped
+	}
+	for _, e := range entries {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(got))
+	}
+	if got[0].Time.IsZero() {
+		t.Error("expected Append to stamp the time")
+	}
+
+	msgs := ForDiff(got, "a")
+	want := []string{"feat: second", "feat: first"}
+	if len(msgs) != len(want) {
+		t.Fatalf("ForDiff = %v; want %v", msgs, want)
+	}
+	for i := range want {
+		if msgs[i] != want[i] {
+			t.Errorf("ForDiff[%d] = %q; want %q", i, msgs[i], want[i])
+		}
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := Load(filepath.Join(t.TempDir(), "nope.jsonl"))
+	if err != nil || got != nil {
+		t.Errorf("Load(missing) = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestExemplars(t *testing.T) {
+	entries := []Entry{
+		{Repo: "api", Status: StatusAccepted, Message: "fix(auth): expire sessions", Paths: []string{"auth/session.go"}},
+		{Repo: "web", Status: StatusAccepted, Message: "chore: bump deps"},
+		{Repo: "api", Status: StatusGenerated, Message: "feat(auth): not accepted", Paths: []string{"auth/token.go"}},
+		{Repo: "api", Status: StatusAccepted, Message: "docs: typo"},
+		{Repo: "api", Status: StatusAccepted, Message: "refactor(auth): split login", Paths: []string{"auth/login.go"}, Edited: true},
+		{Repo: "api", Status: StatusAccepted, Message: "docs: typo"},
+		{Repo: "api", Root: "/work/other/api", Status: StatusAccepted, Message: "feat(auth): another api's secret project", Paths: []string{"auth/token.go"}},
+		{Repo: "api", Root: "/src/api", Status: StatusAccepted, Message: "test(auth): cover expiry"},
+	}
+
+	got := Exemplars(entries, "/src/api", "api", []string{"auth/token.go"}, 3)
+	want := []string{"refactor(auth): split login", "fix(auth): expire sessions", "test(auth): cover expiry"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Exemplars = %q; want %q", got, want)
+	}
+	if got := Exemplars(entries, "/src/api", "api", nil, 0); len(got) != 0 {
+		t.Errorf("Exemplars(n=0) = %q", got)
+	}
+}
+
+func TestCached(t *testing.T) {
+	entries := []Entry{
+		{DiffHash: "h", Status: StatusGenerated, Message: "feat: first"},
+		{DiffHash: "h", Status: StatusGenerated, Message: "feat: second"},
+		{DiffHash: "h", Status: StatusRejected, Message: "feat: second"},
+		{DiffHash: "other", Status: StatusGenerated, Message: "fix: other diff"},
+	}
+	if msg, ok := Cached(entries, "h"); !ok || msg != "feat: first" {
+		t.Errorf("Cached = %q, %v; want the newest message not rejected", msg, ok)
+	}
+	if _, ok := Cached(entries, "none"); ok {
+		t.Error("Cached found a message for an unknown diff")
+	}
+}
@@ -0,0 +1,96 @@
+// Package i18n translates commitgen's own user-facing text: menus, notices, hook
+// messages, and errors. It does not touch commit messages, which follow the
+// repository's style.
+//
+// Messages are keyed by their English text, as with gettext, so the code reads
+// as before and a message without a translation is shown in English.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// bundles are the translations for each language but English, whose messages are
+// the keys themselves.
+var bundles = map[string]map[string]string{
+	"en": nil,
+	"vi": vi,
+}
+
+var (
+	mu   sync.RWMutex
+	lang = "en"
+)
+
+// Languages returns the supported language codes.
+func Languages() []string {
+	return []string{"en", "vi"}
+}
+
+// Detect returns the language to use: configured if set ("vi", "vi_VN.UTF-8", ...),
+// else the first of LC_ALL, LC_MESSAGES and LANG that is set, as POSIX tools do.
+// Languages without a bundle, and the C locale, are English.
+func Detect(configured string, getenv func(string) string) string {
+	tag := configured
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if tag != "" {
+			break
+		}
+		tag = getenv(name)
+	}
+	code := strings.ToLower(tag)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := bundles[code]; ok {
+		return code
+	}
+	return "en"
+}
+
+// SetLanguage selects the language of T and Tf; see Detect.
+func SetLanguage(code string) {
+	mu.Lock()
+	lang = Detect(code, func(string) string { return "" })
+	mu.Unlock()
+}
+
+// Language returns the selected language.
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return lang
+}
+
+// T returns msg in the selected language.
+func T(msg string) string {
+	mu.RLock()
+	s, ok := bundles[lang][msg]
+	mu.RUnlock()
+	if !ok {
+		return msg
+	}
+	return s
+}
+
+// Tf formats args with format in the selected language.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Errorf is fmt.Errorf with format in the selected language.
+func Errorf(format string, args ...any) error {
+	return fmt.Errorf(T(format), args...)
+}
+
+// Error is an error whose text is translated when it is printed, for errors
+// created before the language is known, such as sentinels. Each one is distinct,
+// so errors.Is never matches two errors by their text.
+type Error struct{ msg string }
+
+// NewError returns an Error with the English text msg.
+func NewError(msg string) *Error { return &Error{msg} }
+
+func (e *Error) Error() string { return T(e.msg) }
@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	tests := []struct {
+		configured string
+		env        map[string]string
+		want       string
+	}{
+		{"", nil, "en"},
+		{"vi", nil, "vi"},
+		{"VI_vn", nil, "vi"},
+		{"", map[string]string{"LANG": "vi_VN.UTF-8"}, "vi"},
+		{"", map[string]string{"LANG": "vi_VN.UTF-8", "LC_ALL": "C"}, "en"},
+		{"", map[string]string{"LANG": "en_US.UTF-8", "LC_MESSAGES": "vi_VN"}, "vi"},
+		{"en", map[string]string{"LANG": "vi_VN.UTF-8"}, "en"},
+		{"fr", nil, "en"},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.configured, env(tt.env)); got != tt.want {
+			t.Errorf("Detect(%q, %v) = %q; want %q", tt.configured, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage("en")
+
+	errNoChanges := NewError("no changes")
+	err := fmt.Errorf("%w: nothing staged", errNoChanges)
+	if T("Cancel") != "Cancel" || err.Error() != "no changes: nothing staged" {
+		t.Errorf("English: %q, %q", T("Cancel"), err)
+	}
+	SetLanguage("vi_VN.UTF-8")
+	if Language() != "vi" {
+		t.Fatalf("Language() = %q", Language())
+	}
+	if got := Tf("Staged Files (%d)", 3); got != "File đã stage (3)" {
+		t.Errorf("Tf = %q", got)
+	}
+	if got := T("a message without a translation"); got != "a message without a translation" {
+		t.Errorf("fallback = %q", got)
+	}
+	// Wrapping formats the text, so the language must be set first, as main does.
+	err = fmt.Errorf("%w: nothing staged", errNoChanges)
+	if !errors.Is(err, errNoChanges) || err.Error() != "không có thay đổi: nothing staged" {
+		t.Errorf("Error: %q", err)
+	}
+	if errors.Is(err, NewError("no changes")) {
+		t.Error("errors.Is matched another error with the same text")
+	}
+}
+
+// Translations must take the same arguments as the English message.
+func TestBundleVerbs(t *testing.T) {
+	verb := regexp.MustCompile(`%[-+# 0*]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+	for _, code := range Languages() {
+		for en, tr := range bundles[code] {
+			if want, got := verb.FindAllString(en, -1), verb.FindAllString(tr, -1); !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v; the English has %v", code, tr, got, want)
+			}
+		}
+	}
+}
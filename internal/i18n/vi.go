@@ -0,0 +1,296 @@
+package i18n
+
+// vi is the Vietnamese bundle. Keep the format verbs of each message, in order.
+var vi = map[string]string{
+	// Usage and help
+	"Usage:":                             "Cách dùng:",
+	"Flags:":                             "Tùy chọn:",
+	"Commands:":                          "Lệnh:",
+	"Usage: commitgen <command> [flags]": "Cách dùng: commitgen <lệnh> [tùy chọn]",
+	"Run 'commitgen help <command>' or 'commitgen <command> -h' for command flags.": "Chạy 'commitgen help <lệnh>' hoặc 'commitgen <lệnh> -h' để xem tùy chọn của lệnh.",
+	"Error: %v":          "Lỗi: %v",
+	"unknown command %q": "không có lệnh %q",
+	"describe takes one commit or patch file, got %d arguments":                                                 "describe nhận một commit hoặc file patch, nhưng có %d tham số",
+	"usage: commitgen config [get KEY | set KEY VALUE | show [--redact-keys] | explain [--all]] (settings: %s)": "cách dùng: commitgen config [get KEY | set KEY VALUE | show [--redact-keys] | explain [--all]] (các cấu hình: %s)",
+	"unknown hook action %q (use: install | uninstall | status)":                                                "không có thao tác hook %q (dùng: install | uninstall | status)",
+
+	// Command summaries
+	"Generate a commit message for staged changes (default)":                                          "Tạo commit message cho các thay đổi đã stage (mặc định)",
+	"Print the prompt that would be sent to the AI as JSON":                                           "In prompt sẽ gửi cho AI, dạng JSON",
+	"Compare providers and models on the staged changes":                                              "So sánh các nhà cung cấp và model trên các thay đổi đã stage",
+	"Pre-generate a message in the background whenever staged changes change":                         "Tạo sẵn message ở chế độ nền mỗi khi thay đổi đã stage thay đổi",
+	"Run an HTTP API for editors and tools (POST /suggest)":                                           "Chạy HTTP API cho trình soạn thảo và công cụ (POST /suggest)",
+	"Speak JSON-RPC on stdin/stdout for editor plugins":                                               "Giao tiếp JSON-RPC qua stdin/stdout cho plugin của trình soạn thảo",
+	"Describe a pull request from inside a GitHub Actions job":                                        "Mô tả pull request từ bên trong một job GitHub Actions",
+	"Edit settings interactively, read and write them from scripts, or explain where each comes from": "Sửa cấu hình tương tác, đọc và ghi cấu hình từ script, hoặc giải thích mỗi giá trị đến từ đâu",
+	"Explain in prose what a commit or patch does and why":                                            "Giải thích bằng lời một commit hoặc patch làm gì và vì sao",
+	"Review staged changes for likely bugs, missing tests and risky spots":                            "Review các thay đổi đã stage để tìm lỗi tiềm ẩn, test còn thiếu và chỗ rủi ro",
+	"Write a pull request title and description for the current branch, and open it":                  "Viết tiêu đề và mô tả pull request cho nhánh hiện tại, rồi mở nó",
+	"Write a GitLab merge request title and description for the current branch, and open it":          "Viết tiêu đề và mô tả merge request GitLab cho nhánh hiện tại, rồi mở nó",
+	"Check commit messages against the configured rules":                                              "Kiểm tra commit message theo các quy tắc đã cấu hình",
+	"Print the next semantic version for the commits since the last release tag":                      "In phiên bản semver tiếp theo cho các commit kể từ tag phát hành gần nhất",
+	"List previously generated messages":                                                              "Liệt kê các message đã tạo trước đây",
+	"Show acceptance rate, latency and token spend per model":                                         "Hiển thị tỷ lệ chấp nhận, độ trễ và lượng token theo từng model",
+	"Manage commitgen's git hooks":                                                                    "Quản lý git hook của commitgen",
+	"Print the commitgen version":                                                                     "In phiên bản commitgen",
+
+	// Flags
+	"Log git commands, included files, prompt size and provider latency to stderr": "Ghi lệnh git, các file được đưa vào, kích thước prompt và độ trễ của nhà cung cấp ra stderr",
+	"Print only the result (no notices or logs)":                                   "Chỉ in kết quả (không thông báo hay log)",
+	"Path to config file": "Đường dẫn file cấu hình",
+	"Path to git repository (default: current directory)": "Đường dẫn repository git (mặc định: thư mục hiện tại)",
+	"Enforce conventional commits":                        "Bắt buộc Conventional Commits",
+	"AI provider base URL":                                "Base URL của nhà cung cấp AI",
+	"AI provider API key":                                 "API key của nhà cung cấp AI",
+	"AI model name":                                       "Tên model AI",
+	"AI provider (openai | ollama | anthropic | gemini)":  "Nhà cung cấp AI (openai | ollama | anthropic | gemini)",
+	"Anthropic API key":                                   "API key của Anthropic",
+	"Gemini API key":                                      "API key của Gemini",
+	"LLM temperature":                                     "Temperature của LLM",
+	"Number of recent commits to include":                 "Số commit gần đây đưa vào prompt",
+	"Max staged files to analyze":                         "Số file đã stage tối đa được phân tích",
+	"Summarize file content":                              "Tóm tắt nội dung file",
+	"Path to custom instructions file":                    "Đường dẫn file hướng dẫn tùy chỉnh",
+	"Path to commit message file (used by git hook)":      "Đường dẫn file commit message (dùng bởi git hook)",
+	"Commit message source passed to prepare-commit-msg (used by git hook)":                                   "Nguồn commit message truyền cho prepare-commit-msg (dùng bởi git hook)",
+	"Read a unified diff from stdin instead of staged changes and print the message":                          "Đọc unified diff từ stdin thay cho thay đổi đã stage và in message",
+	"Don't call any AI; fill the message template with facts about the diff":                                  "Không gọi AI; điền template message bằng thông tin về diff",
+	"Generate with two or more comma-separated models side by side and pick one (model, or provider[:model])": "Tạo song song bằng hai model trở lên, cách nhau bởi dấu phẩy, rồi chọn một (model, hoặc provider[:model])",
+	"Send the message back with the diff for a critique-and-improve pass (two requests)":                      "Gửi lại message kèm diff để nhận xét và cải thiện (hai request)",
+	"Generate N candidate messages and show the one ranked best by token log probability and local checks":    "Tạo N message ứng viên và hiển thị message tốt nhất theo log probability của token và các kiểm tra cục bộ",
+	"Also ask the model to flag risky changes before I commit (one more request)":                             "Nhờ model chỉ ra các thay đổi rủi ro trước khi commit (thêm một request)",
+	"List the staged files first and let me leave some out of the message":                                    "Liệt kê các file đã stage trước để có thể bỏ bớt khỏi message",
+	"Skip the action list: Enter commits the message, shortcut keys do the rest":                              "Bỏ danh sách thao tác: Enter để commit, phím tắt cho các thao tác còn lại",
+	"Use plain prompts instead of the full-screen interface (for screen readers)":                             "Dùng câu hỏi dạng văn bản thường thay cho giao diện toàn màn hình (cho trình đọc màn hình)",
+	"Go template file for --no-ai (default: message_template setting, else built-in)":                         "File Go template cho --no-ai (mặc định: cấu hình message_template, nếu không có thì dùng template có sẵn)",
+	"Strip emoji and non-ASCII punctuation from the message":                                                  "Bỏ emoji và dấu câu không phải ASCII khỏi message",
+	"Add a Signed-off-by trailer for the committer":                                                           "Thêm trailer Signed-off-by cho người commit",
+	"Go through these comma-separated repositories in turn, each with staged changes":                         "Lần lượt xử lý các repository này (cách nhau bởi dấu phẩy), mỗi repository có thay đổi đã stage",
+	"Like --repos, with the repositories listed in this file, one path per line":                              "Như --repos, với các repository liệt kê trong file này, mỗi dòng một đường dẫn",
+	"Log this much work on the branch's Jira issue with a smart commit, e.g. 2h (implies jira_smart_commit)":  "Ghi nhận thời gian làm việc này vào issue Jira của nhánh bằng smart commit, ví dụ 2h (bật jira_smart_commit)",
+	"Output path (default: stdout)":                                                                           "Đường dẫn đầu ra (mặc định: stdout)",
+	"Alias for -out":                                                                                          "Tên khác của -out",
+	"Read a unified diff from stdin instead of staged changes":                                                "Đọc unified diff từ stdin thay cho thay đổi đã stage",
+	"Comma-separated providers to compare, each optionally with :model (e.g. openai:gpt-4o-mini)":             "Các nhà cung cấp cần so sánh, cách nhau bởi dấu phẩy, có thể kèm :model (ví dụ openai:gpt-4o-mini)",
+	"Review a unified diff from stdin instead of staged changes":                                              "Review unified diff từ stdin thay cho thay đổi đã stage",
+	"Branch to merge into (default: origin's default branch, else main)":                                      "Nhánh đích để merge vào (mặc định: nhánh mặc định của origin, nếu không có thì main)",
+	"Push the branch and open the pull request (with gh if installed, else GITHUB_TOKEN)":                     "Push nhánh và mở pull request (bằng gh nếu đã cài, nếu không thì dùng GITHUB_TOKEN)",
+	"With --open, open the pull request as a draft":                                                           "Khi dùng --open, mở pull request ở dạng nháp",
+	"Push the branch and open the merge request with the GitLab API":                                          "Push nhánh và mở merge request bằng GitLab API",
+	"With --open, open the merge request as a draft":                                                          "Khi dùng --open, mở merge request ở dạng nháp",
+	"How often to check the index for changes":                                                                "Tần suất kiểm tra index để tìm thay đổi",
+	"Address to listen on": "Địa chỉ lắng nghe",
+	"Require this bearer token on every request (default: env COMMITGEN_SERVE_TOKEN)":                                                         "Yêu cầu bearer token này ở mọi request (mặc định: biến môi trường COMMITGEN_SERVE_TOKEN)",
+	"What to generate: description (PR body) or squash (squash-merge message comment) (default: env COMMITGEN_ACTION_MODE, else description)": "Nội dung cần tạo: description (mô tả PR) hoặc squash (bình luận message cho squash-merge) (mặc định: biến môi trường COMMITGEN_ACTION_MODE, nếu không có thì description)",
+	"show: replace API keys with a placeholder":                                                                                               "show: thay API key bằng chuỗi giữ chỗ",
+	"Revision range to check, e.g. main..HEAD (default: last commit)":                                                                         "Khoảng revision cần kiểm tra, ví dụ main..HEAD (mặc định: commit cuối)",
+	"Check the message in this file ('-' for stdin)":                                                                                          "Kiểm tra message trong file này ('-' là stdin)",
+	"Output format: text | json | github | junit (default: github in GitHub Actions, else text)":                                              "Định dạng đầu ra: text | json | github | junit (mặc định: github khi chạy trong GitHub Actions, nếu không thì text)",
+	"If the --file message fails, offer an AI-corrected version and save it on accept":                                                        "Nếu message trong --file không đạt, đề xuất bản do AI sửa và lưu lại khi chấp nhận",
+	"Generate a replacement message for each failing commit (non-interactive)":                                                                "Tạo message thay thế cho mỗi commit không đạt (không tương tác)",
+	"Also write a JUnit XML report to this file":                                                                                              "Ghi thêm báo cáo JUnit XML vào file này",
+	"Release tag to count from (default: the highest semantic-version tag reachable from HEAD)":                                               "Tag phát hành làm mốc tính (mặc định: tag semver cao nhất đi tới được từ HEAD)",
+	"Only count usage from this long ago, e.g. 168h (default: all)":                                                                           "Chỉ tính mức sử dụng trong khoảng thời gian này, ví dụ 168h (mặc định: tất cả)",
+	"Hook to manage: prepare-commit-msg (generate), commit-msg (validate and fix) or post-commit (record how suggestions were committed)":     "Hook cần quản lý: prepare-commit-msg (tạo message), commit-msg (kiểm tra và sửa) hoặc post-commit (ghi nhận cách các gợi ý được commit)",
+
+	// Errors
+	"no changes":                             "không có thay đổi",
+	"AI provider error":                      "lỗi nhà cung cấp AI",
+	"canceled":                               "đã hủy",
+	"rate limited":                           "bị giới hạn tần suất",
+	"prompt too large for the model":         "prompt quá lớn so với model",
+	"empty response":                         "phản hồi rỗng",
+	"commit message lint failed":             "commit message không đạt kiểm tra",
+	"%w: no diff found in input":             "%w: không tìm thấy diff trong đầu vào",
+	"%w: no staged files match %s":           "%w: không có file đã stage nào khớp %s",
+	"%w: nothing is staged. Run: git add -A": "%w: chưa stage gì cả. Hãy chạy: git add -A",
+	"not inside a git repository. Use --repo /path/to/repo":                                            "không nằm trong repository git nào. Hãy dùng --repo /đường/dẫn/tới/repo",
+	"bare repository has no work tree to commit from; set GIT_WORK_TREE or use --repo with a checkout": "repository bare không có work tree để commit; hãy đặt GIT_WORK_TREE hoặc dùng --repo với một bản checkout",
+	"must be between 0.0 and 2.0":                                                                      "phải nằm trong khoảng 0.0 đến 2.0",
+
+	// Suggest screens
+	"Generating commit message":       "Đang tạo commit message",
+	"Generating commit message...":    "Đang tạo commit message...",
+	"Generating...":                   "Đang tạo...",
+	"Generating with %s...":           "Đang tạo bằng %s...",
+	"Esc or Ctrl-C to cancel":         "Esc hoặc Ctrl-C để hủy",
+	"Generation canceled.":            "Đã hủy việc tạo message.",
+	"Committing...":                   "Đang commit...",
+	"Done.":                           "Xong.",
+	"Commit message saved.":           "Đã lưu commit message.",
+	"Committed successfully!":         "Commit thành công!",
+	"Dry run: nothing was committed.": "Chạy thử: chưa commit gì cả.",
+	"%w\nThe message was saved to %s. Run commitgen resume to commit it again, or commitgen resume --print to see it.": "%w\nMessage đã được lưu vào %s. Chạy commitgen resume để commit lại, hoặc commitgen resume --print để xem nó.",
+	"no saved message: %s exists only after a commit fails":                                                            "không có message nào được lưu: %s chỉ có sau khi commit thất bại",
+	"Committed the saved message.\n": "Đã commit message được lưu.\n",
+	"Enter commit message...":        "Nhập commit message...",
+	"Generated Commit Message":       "Commit message đã tạo",
+	"Commit message:":                "Commit message:",
+	"(no message yet)":               "(chưa có message)",
+	"Check before committing":        "Kiểm tra trước khi commit",
+	"Check before committing: %s.":   "Kiểm tra trước khi commit: %s.",
+	"Action":                         "Thao tác",
+	"Commit (Apply)":                 "Commit (Áp dụng)",
+	"Regenerate":                     "Tạo lại",
+	"Edit":                           "Sửa",
+	"Edit in $EDITOR":                "Sửa bằng $EDITOR",
+	"Previous suggestions":           "Các gợi ý trước",
+	"Change type":                    "Đổi loại",
+	"Try another model":              "Thử model khác",
+	"Cancel":                         "Hủy",
+	"Enter to commit":                "Enter để commit",
+	"commit":                         "commit",
+	"regenerate":                     "tạo lại",
+	"edit":                           "sửa",
+	"editor":                         "trình soạn thảo",
+	"previous":                       "gợi ý trước",
+	"type":                           "loại",
+	"model":                          "model",
+	"Type: %s":                       "Loại: %s",
+	"Type %s, guessed from the changed files":       "Loại %s, đoán từ các file đã thay đổi",
+	"Type set to %s; regenerated messages keep it.": "Đã đặt loại %s; các message tạo lại sẽ giữ loại này.",
+	"Model: %s":         "Model: %s",
+	"Try Another Model": "Thử model khác",
+	"(in use)":          "(đang dùng)",
+	"Enter to generate with it • Esc to go back":                     "Enter để tạo bằng model này • Esc để quay lại",
+	"No other models to try; list them in the other_models setting.": "Không có model nào khác để thử; hãy liệt kê chúng trong thiết lập other_models.",
+	"cancel":                                                      "hủy",
+	" ↓ PgDn/Scroll  %d%% ":                                       " ↓ PgDn/Cuộn  %d%% ",
+	" ↑ PgUp/Scroll  %d%% ":                                       " ↑ PgUp/Cuộn  %d%% ",
+	"Edit Commit Message":                                         "Sửa commit message",
+	"(Press Esc to finish editing)":                               "(Nhấn Esc để sửa xong)",
+	"Previous Suggestions":                                        "Các gợi ý trước",
+	"Enter to use • Esc to go back":                               "Enter để dùng • Esc để quay lại",
+	"Editor failed: %v":                                           "Trình soạn thảo bị lỗi: %v",
+	"Select at least one file.":                                   "Hãy chọn ít nhất một file.",
+	"Could not leave out the files: %v":                           "Không thể bỏ các file ra: %v",
+	"Staged Files (%d)":                                           "File đã stage (%d)",
+	"Compare Models":                                              "So sánh model",
+	"%s has no message to pick.":                                  "%s không có message để chọn.",
+	"There is no message yet; regenerate or edit one first.":      "Chưa có message; hãy tạo lại hoặc tự sửa trước.",
+	"Policy: %s; edit or regenerate the message.":                 "Chính sách: %s; hãy sửa hoặc tạo lại message.",
+	"No previous suggestions for these changes.":                  "Chưa có gợi ý nào trước đây cho các thay đổi này.",
+	"Editor returned an empty message; keeping the previous one.": "Trình soạn thảo trả về message rỗng; giữ lại message cũ.",
+	"Space to toggle • a for all • d to view the diff • Enter to generate • Esc to quit":  "Space để chọn/bỏ • a để chọn tất cả • d để xem diff • Enter để tạo • Esc để thoát",
+	"Deselected files are left out of the message but stay staged.":                       "File bị bỏ chọn không đưa vào message nhưng vẫn được stage.",
+	"Space to toggle • n/p for next/previous file • Enter to generate • Esc for the list": "Space để chọn/bỏ • n/p để sang file sau/trước • Enter để tạo • Esc để về danh sách",
+	"The file's previous contents are sent too.":                                          "Nội dung cũ của file cũng được gửi đi.",
+	"←/→ to choose • Enter or 1-%d to pick • Esc to quit":                                 "←/→ để chọn • Enter hoặc 1-%d để lấy • Esc để thoát",
+
+	// Accessible mode
+	"Enter a number from 1 to %d.":       "Hãy nhập một số từ 1 đến %d.",
+	"Staged files (%d):":                 "File đã stage (%d):",
+	"%d. %s, %d added, %d deleted lines": "%d. %s, thêm %d dòng, xóa %d dòng",
+	"Numbers of files to leave out, separated by spaces (Enter keeps all, q cancels): ": "Số thứ tự các file cần bỏ ra, cách nhau bởi dấu cách (Enter để giữ tất cả, q để hủy): ",
+	"%q is not a file number.": "%q không phải số thứ tự file.",
+	"Message %d, from %s:":     "Message %d, từ %s:",
+	"Failed: %v":               "Thất bại: %v",
+	"Use which message? Enter 1 to %d, or q to cancel: ": "Dùng message nào? Nhập 1 đến %d, hoặc q để hủy: ",
+	"Action: Enter to commit, or a shortcut: ":           "Thao tác: Enter để commit, hoặc một phím tắt: ",
+	", shortcut %s":                   ", phím tắt %s",
+	" or ":                            " hoặc ",
+	"Choose an action, 1 to %d: ":     "Chọn thao tác, 1 đến %d: ",
+	"Choose an action by its number.": "Hãy chọn thao tác bằng số thứ tự.",
+	"%q is not an action.":            "%q không phải một thao tác.",
+	"Type the new message. End it with a line holding only a period; a period alone keeps the current message.": "Nhập message mới. Kết thúc bằng một dòng chỉ có dấu chấm; chỉ nhập dấu chấm sẽ giữ message hiện tại.",
+	"Previous suggestions:":                               "Các gợi ý trước:",
+	"Use which one? Enter 1 to %d, or Enter to go back: ": "Dùng gợi ý nào? Nhập 1 đến %d, hoặc Enter để quay lại: ",
+	"Models:": "Các model:",
+	"Generate with which one? Enter 1 to %d, or Enter to go back: ": "Tạo bằng model nào? Nhập 1 đến %d, hoặc Enter để quay lại: ",
+
+	// Configuration form
+	"CommitGen Configuration":    "Cấu hình CommitGen",
+	"Update your settings in %s": "Cập nhật cấu hình trong %s",
+	"AI Provider":                "Nhà cung cấp AI",
+	"Ollama (Local)":             "Ollama (Cục bộ)",
+	"Base URL":                   "Base URL",
+	"API endpoint (default varies by provider)": "Endpoint API (mặc định tùy nhà cung cấp)",
+	"OpenAI API Key":                      "API key của OpenAI",
+	"Key for OpenAI/Compatible providers": "Key cho OpenAI và các nhà cung cấp tương thích",
+	"Anthropic API Key":                   "API key của Anthropic",
+	"Key for Claude models":               "Key cho các model Claude",
+	"Gemini API Key":                      "API key của Gemini",
+	"Key for Google Gemini":               "Key cho Google Gemini",
+	"Model":                               "Model",
+	"Model name":                          "Tên model",
+	"System Prompt Template":              "Template system prompt",
+	"Custom system prompt (leave empty for default)": "System prompt tùy chỉnh (để trống để dùng mặc định)",
+	"Recent Commits":                              "Commit gần đây",
+	"Max Files":                                   "Số file tối đa",
+	"Max staged files to verify":                  "Số file đã stage tối đa được xem xét",
+	"Temperature":                                 "Temperature",
+	"LLM Temperature (0.0 - 2.0)":                 "Temperature của LLM (0.0 - 2.0)",
+	"Summarize Changes":                           "Tóm tắt thay đổi",
+	"Summarize file content for larger files?":    "Tóm tắt nội dung với các file lớn?",
+	"Conventional Commits":                        "Conventional Commits",
+	"Enforce Conventional Commits specification?": "Bắt buộc theo đặc tả Conventional Commits?",
+	"Ignored Files":                               "File bị bỏ qua",
+	"Glob patterns (comma separated)":             "Mẫu glob (cách nhau bởi dấu phẩy)",
+	"Operation cancelled.\n":                      "Đã hủy thao tác.\n",
+	"\nConfiguration saved to %s\n":               "\nĐã lưu cấu hình vào %s\n",
+	"Removed %s from %s\n":                        "Đã xóa %s khỏi %s\n",
+	"Set %s in %s\n":                              "Đã đặt %s trong %s\n",
+	"Set %s = %s in %s\n":                         "Đã đặt %s = %s trong %s\n",
+
+	// Hooks
+	"Existing hook moved to %s; it will run before commitgen.\n": "Hook có sẵn đã được chuyển sang %s; nó sẽ chạy trước commitgen.\n",
+	"Hook updated at %s\n":             "Đã cập nhật hook tại %s\n",
+	"Hook installed to %s\n":           "Đã cài hook vào %s\n",
+	"Hook is not installed.\n":         "Hook chưa được cài.\n",
+	"Restored previous hook from %s\n": "Đã khôi phục hook cũ từ %s\n",
+	"Hook uninstalled successfully.\n": "Đã gỡ hook thành công.\n",
+
+	// Init
+	"Created %s\n": "Đã tạo %s\n",
+	"%s already exists; kept it (use --force to overwrite)\n":                               "%s đã tồn tại; giữ nguyên (dùng --force để ghi đè)\n",
+	"Note: .commitgen.%s takes precedence over %s/config.yaml; merge them or remove one.\n": "Lưu ý: .commitgen.%s được ưu tiên hơn %s/config.yaml; hãy gộp chúng hoặc xóa một tệp.\n",
+	"Commit %s/ to share these settings with everyone working in the repository.\n":         "Hãy commit %s/ để chia sẻ các thiết lập này với mọi người làm việc trong kho.\n",
+	"Hooks directory: %s\n":                  "Thư mục hook: %s\n",
+	"not installed":                          "chưa cài",
+	"present, not managed by commitgen":      "có sẵn, không do commitgen quản lý",
+	"installed (commitgen, unknown version)": "đã cài (commitgen, không rõ phiên bản)",
+	"installed (commitgen %s)":               "đã cài (commitgen %s)",
+	", current is %s; reinstall to update":   ", phiên bản hiện tại là %s; hãy cài lại để cập nhật",
+	", chains to %s%s":                       ", gọi tiếp %s%s",
+
+	// Other commands
+	"Pull request #%d has no changes; nothing to do.\n":          "Pull request #%d không có thay đổi; không có gì để làm.\n",
+	"Updated the description of pull request #%d.\n":             "Đã cập nhật mô tả của pull request #%d.\n",
+	"Posted the suggested squash message on pull request #%d.\n": "Đã đăng squash message gợi ý lên pull request #%d.\n",
+	"Sending the same prompt (%d files) to %d providers...\n\n":  "Đang gửi cùng một prompt (%d file) tới %d nhà cung cấp...\n\n",
+	"failed":                             "thất bại",
+	"Opened %s\n":                        "Đã mở %s\n",
+	"\nPushing %s to %s...\n":            "\nĐang push %s lên %s...\n",
+	"commitgen listening on http://%s\n": "commitgen đang lắng nghe tại http://%s\n",
+	"Watching %s for staged changes (Ctrl+C to stop)\n": "Đang theo dõi thay đổi đã stage trong %s (Ctrl+C để dừng)\n",
+	"No history yet.":        "Chưa có lịch sử.",
+	"No usage recorded yet.": "Chưa ghi nhận lượt sử dụng nào.",
+	"Total: %d runs, %d prompt + %d completion tokens\n": "Tổng: %d lượt chạy, %d token prompt + %d token completion\n",
+	"    suggestion: %s\n":                               "    gợi ý: %s\n",
+	"%d of %d message(s) passed\n":                       "%d trên %d message đạt\n",
+	"No release: nothing since %s calls for one (feat, fix, perf, revert or a breaking change)\n":                "Không phát hành: từ %s chưa có gì cần phát hành (feat, fix, perf, revert hoặc thay đổi không tương thích)\n",
+	"No release: no release tag yet, and no commit calls for one (feat, fix, perf, revert or a breaking change)": "Không phát hành: chưa có tag phát hành, và không commit nào cần phát hành (feat, fix, perf, revert hoặc thay đổi không tương thích)",
+	"%s release: %s -> %s\n":                     "Phát hành %s: %s -> %s\n",
+	"First release: no semantic-version tag yet": "Phát hành đầu tiên: chưa có tag semver",
+	"  ... and %d more\n":                        "  ... và %d commit khác\n",
+	"  %d commits need no release (%s)\n":        "  %d commit không cần phát hành (%s)\n",
+	"  %d commit needs no release (%s)\n":        "  %d commit không cần phát hành (%s)\n",
+
+	// config explain
+	"explain: also list settings that are set nowhere and empty": "explain: liệt kê cả các cấu hình không được đặt ở đâu và để trống",
+	"default":                   "mặc định",
+	"(overrides %s)":            "(ghi đè %s)",
+	"flag --%s":                 "cờ --%s",
+	"env %s":                    "biến môi trường %s",
+	"env %s (from a .env file)": "biến môi trường %s (từ file .env)",
+	"global config %s":          "cấu hình chung %s",
+	"repo config %s":            "cấu hình repo %s",
+
+	// unrelated areas
+	"The staged changes span unrelated areas: %s":                                                          "Các thay đổi đã stage thuộc những phần không liên quan: %s",
+	"Write one message that describes each area":                                                           "Viết một message mô tả từng phần",
+	"Commit %s now and keep the rest staged for the next commit":                                           "Commit %s trước, giữ phần còn lại đã stage cho commit sau",
+	"The rest of the changes are still staged: run commitgen again to commit them.\n":                      "Phần thay đổi còn lại vẫn đã stage: chạy lại commitgen để commit chúng.\n",
+	"Set aside the other changes. Should commitgen stop before staging them again, run git read-tree %s\n": "Đã tạm bỏ stage các thay đổi khác. Nếu commitgen dừng trước khi stage lại chúng, hãy chạy git read-tree %s\n",
+	"%w\nRun git read-tree %s to stage the set-aside changes again.":                                       "%w\nChạy git read-tree %s để stage lại các thay đổi đã tạm bỏ.",
+}
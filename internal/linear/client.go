@@ -0,0 +1,98 @@
+package linear
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Issue when Linear has no issue with that identifier.
+var ErrNotFound = errors.New("linear: issue not found")
+
+type Config struct {
+	BaseURL string // default: https://api.linear.app/graphql
+	APIKey  string // personal API key
+}
+
+// Client is a minimal Linear GraphQL client covering what commit messages need.
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+func New(cfg Config) *Client {
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		cfg.BaseURL = "https://api.linear.app/graphql"
+	}
+	return &Client{
+		cfg: cfg,
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+type Issue struct {
+	Identifier  string `json:"identifier"` // e.g. ENG-123
+	Title       string `json:"title"`
+	Description string `json:"description"` // markdown
+	URL         string `json:"url"`
+}
+
+const issueQuery = `query Issue($id: String!) { issue(id: $id) { identifier title description url } }`
+
+// Issue returns the issue with identifier id, such as "ENG-123".
+func (c *Client) Issue(ctx context.Context, id string) (Issue, error) {
+	payload, _ := json.Marshal(map[string]any{
+		"query":     issueQuery,
+		"variables": map[string]string{"id": id},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL, bytes.NewReader(payload))
+	if err != nil {
+		return Issue{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.cfg.APIKey) // personal keys are sent without "Bearer"
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Issue{}, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	var out struct {
+		Data struct {
+			Issue *Issue `json:"issue"`
+		} `json:"data"`
+		Errors []struct {
+			Message    string `json:"message"`
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return Issue{}, fmt.Errorf("linear: decode response (status %d): %w", resp.StatusCode, err)
+	}
+	if len(out.Errors) > 0 {
+		e := out.Errors[0]
+		if e.Extensions.Code == "ENTITY_NOT_FOUND" || strings.Contains(strings.ToLower(e.Message), "not found") {
+			return Issue{}, ErrNotFound
+		}
+		return Issue{}, fmt.Errorf("linear: %s (%d)", e.Message, resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		return Issue{}, fmt.Errorf("linear: status %d", resp.StatusCode)
+	}
+	if out.Data.Issue == nil {
+		return Issue{}, ErrNotFound
+	}
+	return *out.Data.Issue, nil
+}
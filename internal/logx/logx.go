@@ -0,0 +1,81 @@
+// Package logx configures the process-wide slog logger used for diagnostics.
+package logx
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+var (
+	level = new(slog.LevelVar)
+	out   = &holdWriter{w: os.Stderr}
+	quiet bool
+)
+
+func init() {
+	level.Set(slog.LevelWarn)
+	slog.SetDefault(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// Timestamps are noise for a short-lived CLI.
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+}
+
+// Setup sets the log level: verbose shows debug diagnostics, quiet hides all logs.
+// By default only warnings and errors are logged.
+func Setup(verbose, q bool) {
+	quiet = q
+	switch {
+	case q:
+		level.Set(slog.LevelError + 4)
+	case verbose:
+		level.Set(slog.LevelDebug)
+	default:
+		level.Set(slog.LevelWarn)
+	}
+}
+
+// Quiet reports whether --quiet was given, so informational output can be skipped.
+func Quiet() bool {
+	return quiet
+}
+
+// Hold buffers log output until the returned function is called. It is used while a
+// full-screen TUI owns the terminal, so log lines don't corrupt the display.
+func Hold() (release func()) {
+	out.mu.Lock()
+	out.held = true
+	out.mu.Unlock()
+
+	return func() {
+		out.mu.Lock()
+		defer out.mu.Unlock()
+		out.held = false
+		out.w.Write(out.buf.Bytes())
+		out.buf.Reset()
+	}
+}
+
+type holdWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	buf  bytes.Buffer
+	held bool
+}
+
+func (h *holdWriter) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.held {
+		return h.buf.Write(p)
+	}
+	return h.w.Write(p)
+}
@@ -6,9 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/hoanghonghuy/commitgen/internal/ai"
 	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
 )
 
@@ -16,13 +21,22 @@ import (
 type Config struct {
 	BaseURL string // e.g. "http://localhost:11434"
 	Model   string // e.g. "llama3"
+	NumCtx  int    // context window to request, in tokens; 0 keeps the model's
 }
 
+// defaultNumCtx is the context window Ollama runs a model with when neither the
+// request, the Modelfile, nor OLLAMA_CONTEXT_LENGTH sets one.
+const defaultNumCtx = 4096
+
 // Client implements ai.Provider for Ollama
 type Client struct {
 	baseURL string
 	model   string
+	numCtx  int
 	client  *http.Client
+
+	windowOnce sync.Once
+	window     int
 }
 
 func New(cfg Config) *Client {
@@ -33,6 +47,7 @@ func New(cfg Config) *Client {
 	return &Client{
 		baseURL: baseURL,
 		model:   cfg.Model,
+		numCtx:  cfg.NumCtx,
 		client:  &http.Client{},
 	}
 }
@@ -51,11 +66,17 @@ type message struct {
 
 type options struct {
 	Temperature float64 `json:"temperature"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
 }
 
 type chatResponse struct {
 	Message message `json:"message"`
 	Done    bool    `json:"done"`
+
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+
+	Error string `json:"error"` // set when a streamed response fails midway
 }
 
 func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temperature float64) (string, error) {
@@ -81,12 +102,14 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 		})
 	}
 
+	stream := ai.StreamFunc(ctx)
 	reqBody := chatRequest{
 		Model:    c.model,
 		Messages: ollamaMsgs,
-		Stream:   false,
+		Stream:   stream != nil,
 		Options: options{
 			Temperature: temperature,
+			NumCtx:      c.numCtx,
 		},
 	}
 
@@ -110,7 +133,18 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+		var out struct {
+			Error string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &out) == nil && out.Error != "" {
+			msg = out.Error
+		}
+		return "", &ai.APIError{Provider: "ollama", StatusCode: resp.StatusCode, Message: msg}
+	}
+
+	if stream != nil {
+		return readStream(ctx, resp.Body, stream)
 	}
 
 	var chatResp chatResponse
@@ -118,5 +152,106 @@ func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.
 		return "", fmt.Errorf("decode response: %w", err)
 	}
 
+	ai.ReportUsage(ctx, chatResp.PromptEvalCount, chatResp.EvalCount)
 	return chatResp.Message.Content, nil
 }
+
+// ContextWindow returns the context window requests run with: Config.NumCtx if set,
+// else the model's num_ctx parameter, else the server default, capped at what the
+// model supports. It asks the server once.
+func (c *Client) ContextWindow(ctx context.Context) int {
+	c.windowOnce.Do(func() {
+		c.window = c.numCtx
+		info, err := c.show(ctx)
+		if err != nil {
+			slog.Debug("could not read the Ollama model's parameters", "model", c.model, "err", err)
+		}
+		if c.window == 0 {
+			c.window = info.numCtx()
+		}
+		if c.window == 0 {
+			c.window = defaultNumCtx
+			if n, err := strconv.Atoi(os.Getenv("OLLAMA_CONTEXT_LENGTH")); err == nil && n > 0 {
+				c.window = n // the server usually runs on this machine
+			}
+		}
+		if limit := info.contextLength(); limit > 0 && c.window > limit {
+			c.window = limit
+		}
+	})
+	return c.window
+}
+
+type showResponse struct {
+	Parameters string         `json:"parameters"` // Modelfile PARAMETER lines, e.g. "num_ctx 8192"
+	ModelInfo  map[string]any `json:"model_info"` // includes "<architecture>.context_length"
+}
+
+// show returns the model's details from /api/show.
+func (c *Client) show(ctx context.Context) (showResponse, error) {
+	b, _ := json.Marshal(map[string]string{"model": c.model})
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/show", bytes.NewReader(b))
+	if err != nil {
+		return showResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return showResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return showResponse{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var out showResponse
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	return out, err
+}
+
+// numCtx returns the num_ctx parameter set in the Modelfile, or 0.
+func (r showResponse) numCtx() int {
+	for _, ln := range strings.Split(r.Parameters, "\n") {
+		if f := strings.Fields(ln); len(f) == 2 && f[0] == "num_ctx" {
+			n, _ := strconv.Atoi(f[1])
+			return n
+		}
+	}
+	return 0
+}
+
+// contextLength returns the longest context the model supports, or 0.
+func (r showResponse) contextLength() int {
+	for k, v := range r.ModelInfo {
+		if n, ok := v.(float64); ok && strings.HasSuffix(k, ".context_length") {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// readStream reads a streamed chat response: one JSON object per line, each holding the
+// next piece of the message, the last one with done set and the token counts.
+func readStream(ctx context.Context, r io.Reader, stream func(string)) (string, error) {
+	var content strings.Builder
+	dec := json.NewDecoder(r)
+	for {
+		var chunk chatResponse
+		if err := dec.Decode(&chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("decode response: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", &ai.APIError{Provider: "ollama", Message: chunk.Error}
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			stream(chunk.Message.Content)
+		}
+		if chunk.Done {
+			ai.ReportUsage(ctx, chunk.PromptEvalCount, chunk.EvalCount)
+			break
+		}
+	}
+	return content.String(), nil
+}
@@ -1,7 +1,9 @@
 package openai
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,13 +12,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hoanghonghuy/commitgen/internal/ai"
 	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
 )
 
+// API values for Config.API.
+const (
+	APIChat      = "chat"      // /chat/completions, which most proxies and compatible servers offer
+	APIResponses = "responses" // /responses, needed by some newer models and features
+)
+
 type Config struct {
 	BaseURL string
 	APIKey  string
 	Model   string
+	API     string // APIChat (default) or APIResponses
+
+	Organization string // sent as OpenAI-Organization, if set
+	Project      string // sent as OpenAI-Project, if set
+
+	// Token, if set, returns the bearer token for each request instead of APIKey,
+	// e.g. a Microsoft Entra ID token for Azure OpenAI.
+	Token func(ctx context.Context) (string, error)
 }
 
 type Client struct {
@@ -40,6 +57,44 @@ type chatReq struct {
 	Model       string                       `json:"model"`
 	Messages    []vscodeprompt.OpenAIMessage `json:"messages"`
 	Temperature float64                      `json:"temperature,omitempty"`
+	Logprobs    bool                         `json:"logprobs,omitempty"`
+
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// logprobs are the log probabilities of a choice's tokens, when asked for.
+type logprobs struct {
+	Content []struct {
+		Logprob float64 `json:"logprob"`
+	} `json:"content"`
+}
+
+func (lp *logprobs) sum() float64 {
+	total := 0.0
+	for _, t := range lp.Content {
+		total += t.Logprob
+	}
+	return total
+}
+
+// chunkResp is one server-sent event of a streamed completion.
+type chunkResp struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		Logprobs *logprobs `json:"logprobs"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *apiError `json:"error,omitempty"`
 }
 
 type chatResp struct {
@@ -47,47 +102,149 @@ type chatResp struct {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
+		Logprobs *logprobs `json:"logprobs"`
 	} `json:"choices"`
-	Error *struct {
-		Message string `json:"message"`
-		Type    string `json:"type"`
-	} `json:"error,omitempty"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+// apiError is the error object of an error response.
+type apiError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    any    `json:"code"` // a string from OpenAI; some compatible servers send a number
+}
+
+func (e *apiError) err(status int) error {
+	code, _ := e.Code.(string)
+	return &ai.APIError{Provider: "openai", StatusCode: status, Code: cmp.Or(code, e.Type), Message: e.Message}
+}
+
+// decodeError describes a response body that is not the expected JSON: an error
+// page from a proxy, if the status says so.
+func decodeError(status int, body []byte, err error) error {
+	if status >= 400 {
+		return &ai.APIError{Provider: "openai", StatusCode: status, Message: strings.TrimSpace(string(body))}
+	}
+	return fmt.Errorf("decode error: %v\nraw: %s", err, string(body))
 }
 
 func (c *Client) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	if c.cfg.API == APIResponses {
+		return c.generateResponse(ctx, msgs, temp)
+	}
 	oaiMsgs := vscodeprompt.ToOpenAIMessages(msgs)
 
-	base := strings.TrimRight(c.cfg.BaseURL, "/")
-	url := base + "/chat/completions"
-
-	payload, _ := json.Marshal(chatReq{
+	req := chatReq{
 		Model:       c.cfg.Model,
 		Messages:    oaiMsgs,
 		Temperature: temp,
-	})
-
-	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
-	httpReq.Header.Set("Content-Type", "application/json")
-	if strings.TrimSpace(c.cfg.APIKey) != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+		Logprobs:    ai.LogprobsWanted(ctx),
 	}
-
-	resp, err := c.http.Do(httpReq)
+	stream := ai.StreamFunc(ctx)
+	if stream != nil {
+		req.Stream = true
+		req.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	resp, err := c.post(ctx, "/chat/completions", req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	// Errors come back as a plain JSON body even when streaming was asked for.
+	if stream != nil && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readStream(ctx, resp.Body, stream)
+	}
+
 	b, _ := io.ReadAll(resp.Body)
 	var out chatResp
 	if err := json.Unmarshal(b, &out); err != nil {
-		return "", fmt.Errorf("decode error: %v\nraw: %s", err, string(b))
+		return "", decodeError(resp.StatusCode, b, err)
 	}
 	if out.Error != nil {
-		return "", fmt.Errorf("llm error: %s (%s)", out.Error.Message, out.Error.Type)
+		return "", out.Error.err(resp.StatusCode)
 	}
 	if len(out.Choices) == 0 {
-		return "", fmt.Errorf("llm: empty choices")
+		return "", fmt.Errorf("openai: %w", ai.ErrEmptyResponse)
+	}
+	ai.ReportUsage(ctx, out.Usage.PromptTokens, out.Usage.CompletionTokens)
+	if lp := out.Choices[0].Logprobs; lp != nil {
+		ai.ReportLogprobs(ctx, lp.sum(), len(lp.Content))
 	}
 	return out.Choices[0].Message.Content, nil
 }
+
+// post sends body as JSON to path under the base URL.
+func (c *Client) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, _ := json.Marshal(body)
+	url := strings.TrimRight(c.cfg.BaseURL, "/") + path
+	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	httpReq.Header.Set("Content-Type", "application/json")
+	key := c.cfg.APIKey
+	if c.cfg.Token != nil {
+		var err error
+		if key, err = c.cfg.Token(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(key) != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+key)
+	}
+	if c.cfg.Organization != "" {
+		httpReq.Header.Set("OpenAI-Organization", c.cfg.Organization)
+	}
+	if c.cfg.Project != "" {
+		httpReq.Header.Set("OpenAI-Project", c.cfg.Project)
+	}
+	return c.http.Do(httpReq)
+}
+
+// readStream reads a streamed completion: server-sent events whose data is a chunk
+// holding the next piece of the message, ending with "[DONE]".
+func readStream(ctx context.Context, r io.Reader, stream func(string)) (string, error) {
+	var content strings.Builder
+	var lpSum float64
+	var lpTokens int
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk chunkResp
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("decode error: %v\nraw: %s", err, data)
+		}
+		if chunk.Error != nil {
+			return "", chunk.Error.err(0)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			stream(chunk.Choices[0].Delta.Content)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Logprobs != nil {
+			lpSum += chunk.Choices[0].Logprobs.sum()
+			lpTokens += len(chunk.Choices[0].Logprobs.Content)
+		}
+		if chunk.Usage != nil {
+			ai.ReportUsage(ctx, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("openai: %w", ai.ErrEmptyResponse)
+	}
+	ai.ReportLogprobs(ctx, lpSum, lpTokens)
+	return content.String(), nil
+}
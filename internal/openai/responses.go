@@ -0,0 +1,139 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// responsesReq is a request to the Responses API. Messages go in input as they
+// would to chat/completions; system messages are accepted there too.
+type responsesReq struct {
+	Model       string                       `json:"model"`
+	Input       []vscodeprompt.OpenAIMessage `json:"input"`
+	Temperature float64                      `json:"temperature,omitempty"`
+	Stream      bool                         `json:"stream,omitempty"`
+	Store       bool                         `json:"store"` // nothing to come back to later
+}
+
+type responsesResp struct {
+	Output []struct {
+		Type    string `json:"type"` // "message", "reasoning", ...
+		Content []struct {
+			Type string `json:"type"` // "output_text", "refusal"
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *apiError `json:"error"`
+}
+
+// text returns the output text of the response's messages.
+func (r responsesResp) text() string {
+	var b strings.Builder
+	for _, o := range r.Output {
+		if o.Type != "message" {
+			continue
+		}
+		for _, c := range o.Content {
+			if c.Type == "output_text" {
+				b.WriteString(c.Text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// responsesEvent is one server-sent event of a streamed response.
+type responsesEvent struct {
+	Type     string         `json:"type"` // e.g. "response.output_text.delta", "response.completed"
+	Delta    string         `json:"delta"`
+	Message  string         `json:"message"` // for "error"
+	Code     string         `json:"code"`    // for "error"
+	Response *responsesResp `json:"response"`
+}
+
+// generateResponse is GenerateCommitMessage for the Responses API.
+func (c *Client) generateResponse(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	stream := ai.StreamFunc(ctx)
+	resp, err := c.post(ctx, "/responses", responsesReq{
+		Model:       c.cfg.Model,
+		Input:       vscodeprompt.ToOpenAIMessages(msgs),
+		Temperature: temp,
+		Stream:      stream != nil,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Errors come back as a plain JSON body even when streaming was asked for.
+	if stream != nil && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readResponseStream(ctx, resp.Body, stream)
+	}
+
+	b, _ := io.ReadAll(resp.Body)
+	var out responsesResp
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", decodeError(resp.StatusCode, b, err)
+	}
+	if out.Error != nil {
+		return "", out.Error.err(resp.StatusCode)
+	}
+	text := out.text()
+	if text == "" {
+		return "", fmt.Errorf("openai: %w", ai.ErrEmptyResponse)
+	}
+	ai.ReportUsage(ctx, out.Usage.InputTokens, out.Usage.OutputTokens)
+	return text, nil
+}
+
+// readResponseStream reads a streamed response: server-sent events carrying text
+// deltas, ending with response.completed, which holds the usage.
+func readResponseStream(ctx context.Context, r io.Reader, stream func(string)) (string, error) {
+	var content strings.Builder
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var ev responsesEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+			return "", fmt.Errorf("decode error: %v\nraw: %s", err, data)
+		}
+		switch ev.Type {
+		case "response.output_text.delta":
+			content.WriteString(ev.Delta)
+			stream(ev.Delta)
+		case "response.completed":
+			if ev.Response != nil {
+				ai.ReportUsage(ctx, ev.Response.Usage.InputTokens, ev.Response.Usage.OutputTokens)
+			}
+		case "response.failed":
+			if ev.Response != nil && ev.Response.Error != nil {
+				return "", ev.Response.Error.err(0)
+			}
+			return "", &ai.APIError{Provider: "openai", Message: "response failed"}
+		case "error":
+			return "", &ai.APIError{Provider: "openai", Code: ev.Code, Message: ev.Message}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("openai: %w", ai.ErrEmptyResponse)
+	}
+	return content.String(), nil
+}
@@ -0,0 +1,193 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Outcome values. An event without an outcome records one generation request.
+const (
+	OutcomeAccepted    = "accepted"    // committed as generated
+	OutcomeEdited      = "edited"      // committed after the user changed it
+	OutcomeReplaced    = "replaced"    // committed with a message of the user's own instead
+	OutcomeRegenerated = "regenerated" // discarded in favour of a new suggestion
+	OutcomeRejected    = "rejected"    // discarded without committing
+)
+
+// Event is one line of the stats file: either a generation (tokens, latency)
+// or the outcome of the last suggestion shown for a run.
+type Event struct {
+	Time             time.Time `json:"time"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	LatencyMs        int64     `json:"latency_ms,omitempty"`
+	Outcome          string    `json:"outcome,omitempty"`
+}
+
+// DefaultPath returns ~/.commitgen_stats.jsonl, next to the history file.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".commitgen_stats.jsonl")
+}
+
+// Append writes e to the stats file at path, creating it if needed.
+func Append(path string, e Event) error {
+	if path == "" {
+		path = DefaultPath()
+	}
+	if path == "" {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Load reads all events from the stats file, oldest first.
+// A missing file is not an error; malformed lines are skipped.
+func Load(path string) ([]Event, error) {
+	if path == "" {
+		path = DefaultPath()
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Event
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// TokensSince returns the prompt and completion tokens of the generations since the
+// given time.
+func TokensSince(events []Event, since time.Time) int {
+	total := 0
+	for _, e := range events {
+		if !e.Time.Before(since) {
+			total += e.PromptTokens + e.CompletionTokens
+		}
+	}
+	return total
+}
+
+// Summary aggregates the events of one provider/model pair.
+type Summary struct {
+	Provider string
+	Model    string
+
+	Generations      int
+	PromptTokens     int
+	CompletionTokens int
+	TotalLatency     time.Duration
+
+	Accepted    int
+	Edited      int
+	Replaced    int
+	Regenerated int
+	Rejected    int
+}
+
+// Decisions is the number of suggestions the user acted on.
+func (s Summary) Decisions() int {
+	return s.Accepted + s.Edited + s.Replaced + s.Regenerated + s.Rejected
+}
+
+// AcceptanceRate is the share of decisions that ended in a commit of the suggestion,
+// edited or not. A commit whose message replaced the suggestion doesn't count.
+func (s Summary) AcceptanceRate() float64 {
+	if s.Decisions() == 0 {
+		return 0
+	}
+	return float64(s.Accepted+s.Edited) / float64(s.Decisions())
+}
+
+// AvgLatency is the mean time a generation took.
+func (s Summary) AvgLatency() time.Duration {
+	if s.Generations == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Generations)
+}
+
+// Summarize groups events since the given time (zero for all) by provider and model,
+// most used first.
+func Summarize(events []Event, since time.Time) []Summary {
+	byKey := map[[2]string]*Summary{}
+	var order [][2]string
+	for _, e := range events {
+		if e.Time.Before(since) {
+			continue
+		}
+		key := [2]string{e.Provider, e.Model}
+		s, ok := byKey[key]
+		if !ok {
+			s = &Summary{Provider: e.Provider, Model: e.Model}
+			byKey[key] = s
+			order = append(order, key)
+		}
+		switch e.Outcome {
+		case "":
+			s.Generations++
+			s.PromptTokens += e.PromptTokens
+			s.CompletionTokens += e.CompletionTokens
+			s.TotalLatency += time.Duration(e.LatencyMs) * time.Millisecond
+		case OutcomeAccepted:
+			s.Accepted++
+		case OutcomeEdited:
+			s.Edited++
+		case OutcomeReplaced:
+			s.Replaced++
+		case OutcomeRegenerated:
+			s.Regenerated++
+		case OutcomeRejected:
+			s.Rejected++
+		}
+	}
+
+	out := make([]Summary, 0, len(order))
+	for _, k := range order {
+		out = append(out, *byKey[k])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Generations > out[j].Generations })
+	return out
+}
@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendLoadSummarize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	old := time.Now().Add(-48 * time.Hour)
+	events := []Event{
+		{Time: old, Provider: "openai", Model: "gpt-4o", PromptTokens: 999},
+		{Provider: "openai", Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 10, LatencyMs: 1000},
+		{Provider: "openai", Model: "gpt-4o", Outcome: OutcomeRegenerated},
+		{Provider: "openai", Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 20, LatencyMs: 3000},
+		{Provider: "openai", Model: "gpt-4o", Outcome: OutcomeEdited},
+		{Provider: "ollama", Model: "llama3", LatencyMs: 500},
+		{Provider: "ollama", Model: "llama3", Outcome: OutcomeAccepted},
+		{Provider: "ollama", Model: "llama3", Outcome: OutcomeReplaced},
+	}
+	for _, e := range events {
+		if err := Append(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := Load(path)
+	if err != nil || len(loaded) != len(events) {
+		t.Fatalf("Load() = %d events, %v", len(loaded), err)
+	}
+
+	if n := TokensSince(loaded, time.Now().Add(-24*time.Hour)); n != 230 {
+		t.Errorf("TokensSince() = %d, want 230", n)
+	}
+
+	sums := Summarize(loaded, time.Now().Add(-24*time.Hour))
+	if len(sums) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(sums))
+	}
+	s := sums[0]
+	if s.Model != "gpt-4o" || s.Generations != 2 || s.PromptTokens != 200 || s.CompletionTokens != 30 {
+		t.Errorf("gpt-4o summary = %+v", s)
+	}
+	if s.AcceptanceRate() != 0.5 || s.AvgLatency() != 2*time.Second {
+		t.Errorf("rate = %v, latency = %v", s.AcceptanceRate(), s.AvgLatency())
+	}
+	if sums[1].Replaced != 1 || sums[1].AcceptanceRate() != 0.5 {
+		t.Errorf("llama3 replaced = %d, rate = %v", sums[1].Replaced, sums[1].AcceptanceRate())
+	}
+}
@@ -0,0 +1,223 @@
+package tracex
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	flushInterval = 5 * time.Second // for long-running commands such as serve and watch
+	maxBatch      = 256             // spans buffered before an early flush
+	maxQueued     = 4096            // spans kept while the collector is unreachable
+)
+
+// exporter sends ended spans to an OTLP/HTTP endpoint in batches.
+type exporter struct {
+	endpoint string
+	headers  map[string]string
+	resource []kv
+	http     *http.Client
+
+	mu    sync.Mutex
+	spans []*Span
+	kick  chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newExporter(endpoint string, headers map[string]string, service, version string) *exporter {
+	e := &exporter{
+		endpoint: endpoint,
+		headers:  headers,
+		resource: []kv{attrKV(String("service.name", service)), attrKV(String("service.version", version))},
+		http:     &http.Client{Timeout: 10 * time.Second},
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.loop()
+	return e
+}
+
+func (e *exporter) add(s *Span) {
+	e.mu.Lock()
+	if len(e.spans) < maxQueued {
+		e.spans = append(e.spans, s)
+	}
+	full := len(e.spans) >= maxBatch
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *exporter) loop() {
+	defer e.wg.Done()
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-t.C:
+		case <-e.kick:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), e.http.Timeout)
+		e.flush(ctx)
+		cancel()
+	}
+}
+
+// shutdown stops the background flushes and sends what is left, within ctx.
+func (e *exporter) shutdown(ctx context.Context) {
+	close(e.done)
+	e.wg.Wait()
+	e.flush(ctx)
+}
+
+// flush sends the buffered spans. Spans that could not be sent are dropped: tracing
+// must never fail or slow down a run more than the request timeout.
+func (e *exporter) flush(ctx context.Context) {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := e.send(ctx, spans); err != nil {
+		slog.Debug("could not export spans", "spans", len(spans), "err", err)
+	}
+}
+
+func (e *exporter) send(ctx context.Context, spans []*Span) error {
+	out := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		out[i] = toOTLP(s)
+	}
+	body := exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: e.resource},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: "commitgen"}, Spans: out}},
+	}}}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
+
+// The OTLP/JSON encoding of an export request; IDs are hex and 64-bit integers
+// are strings.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []kv `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []kv       `json:"attributes,omitempty"`
+		Status            spanStatus `json:"status"`
+	}
+	spanStatus struct {
+		Code    int    `json:"code,omitempty"` // 2: error
+		Message string `json:"message,omitempty"`
+	}
+	kv struct {
+		Key   string `json:"key"`
+		Value value  `json:"value"`
+	}
+	value struct {
+		String *string  `json:"stringValue,omitempty"`
+		Bool   *bool    `json:"boolValue,omitempty"`
+		Int    *string  `json:"intValue,omitempty"`
+		Double *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+func toOTLP(s *Span) otlpSpan {
+	o := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parent != [8]byte{} {
+		o.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	for _, a := range s.attrs {
+		o.Attributes = append(o.Attributes, attrKV(a))
+	}
+	if s.err != nil {
+		o.Status = spanStatus{Code: 2, Message: s.err.Error()}
+	}
+	return o
+}
+
+func attrKV(a Attr) kv {
+	var v value
+	switch x := a.Value.(type) {
+	case string:
+		v.String = &x
+	case bool:
+		v.Bool = &x
+	case int:
+		s := strconv.Itoa(x)
+		v.Int = &s
+	case float64:
+		v.Double = &x
+	default:
+		s := fmt.Sprint(x)
+		v.String = &s
+	}
+	return kv{Key: a.Key, Value: v}
+}
@@ -0,0 +1,183 @@
+// Package tracex records OpenTelemetry spans and exports them over OTLP/HTTP (JSON
+// encoding). It is configured from the standard OTEL_* environment variables and
+// does nothing unless an endpoint is set.
+package tracex
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds, as in OTLP.
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+// Attr is a span attribute; Value is a string, bool, int, or float64.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(k, v string) Attr           { return Attr{k, v} }
+func Int(k string, v int) Attr          { return Attr{k, v} }
+func Bool(k string, v bool) Attr        { return Attr{k, v} }
+func Float(k string, v float64) Attr    { return Attr{k, v} }
+func Strings(k string, v []string) Attr { return Attr{k, strings.Join(v, " ")} }
+
+// Span is an operation being timed. A nil *Span, as returned while tracing is off,
+// ignores every call.
+type Span struct {
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte // zero for a root span
+	name    string
+	kind    int
+	start   time.Time
+	end     time.Time
+	attrs   []Attr
+	err     error
+}
+
+type spanKey struct{}
+
+var (
+	mu       sync.Mutex
+	exp      *exporter
+	remote   *Span // parent from TRACEPARENT, if any
+	disabled = true
+)
+
+// Setup turns tracing on when OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or
+// OTEL_EXPORTER_OTLP_ENDPOINT is set (and OTEL_SDK_DISABLED isn't "true"). Spans
+// are sent with the headers in OTEL_EXPORTER_OTLP_HEADERS, under OTEL_SERVICE_NAME
+// (default "commitgen"). A W3C trace context in TRACEPARENT becomes the parent of
+// root spans, so a run joins the trace of whatever started it. The returned
+// function sends the spans still buffered; call it before exiting.
+func Setup(version string) (shutdown func(context.Context)) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" || strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return func(context.Context) {}
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "commitgen"
+	}
+	headers := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")
+	if headers == "" {
+		headers = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+
+	e := newExporter(endpoint, parseHeaders(headers), service, version)
+	mu.Lock()
+	exp, disabled = e, false
+	remote = parseTraceparent(os.Getenv("TRACEPARENT"))
+	mu.Unlock()
+	return func(ctx context.Context) {
+		mu.Lock()
+		exp, remote, disabled = nil, nil, true
+		mu.Unlock()
+		e.shutdown(ctx)
+	}
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return !disabled
+}
+
+// Start starts a span named name, a child of the span in ctx if any, and returns a
+// context holding it. It returns ctx and nil while tracing is off.
+func Start(ctx context.Context, name string, kind int, attrs ...Attr) (context.Context, *Span) {
+	mu.Lock()
+	off, root := disabled, remote
+	mu.Unlock()
+	if off {
+		return ctx, nil
+	}
+	s := &Span{name: name, kind: kind, start: time.Now(), attrs: attrs}
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil {
+		parent = root
+	}
+	if parent != nil {
+		s.traceID, s.parent = parent.traceID, parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SetAttrs adds attributes to s.
+func (s *Span) SetAttrs(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End ends s, failed with err if not nil, and queues it for export.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.end, s.err = time.Now(), err
+	mu.Lock()
+	e := exp
+	mu.Unlock()
+	if e != nil {
+		e.add(s)
+	}
+}
+
+// parseHeaders parses "k1=v1,k2=v2" with URL-encoded values, as the OTLP
+// exporter variables are written.
+func parseHeaders(s string) map[string]string {
+	h := map[string]string{}
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if dv, err := url.PathUnescape(strings.TrimSpace(v)); err == nil {
+			v = dv
+		}
+		h[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return h
+}
+
+// parseTraceparent parses a W3C traceparent ("00-<trace id>-<span id>-<flags>")
+// into a span that only serves as a parent, or returns nil.
+func parseTraceparent(s string) *Span {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return nil
+	}
+	var p Span
+	if _, err := hex.Decode(p.traceID[:], []byte(parts[1])); err != nil {
+		return nil
+	}
+	if _, err := hex.Decode(p.spanID[:], []byte(parts[2])); err != nil {
+		return nil
+	}
+	if p.traceID == [16]byte{} || p.spanID == [8]byte{} {
+		return nil
+	}
+	return &p
+}
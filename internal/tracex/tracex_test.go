@@ -0,0 +1,77 @@
+package tracex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	var got []exportRequest
+	var auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		got = append(got, req)
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL+"/")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20abc")
+	t.Setenv("TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	shutdown := Setup("1.2.3")
+
+	ctx, root := Start(context.Background(), "commitgen suggest", KindInternal)
+	_, child := Start(ctx, "git diff", KindInternal, Strings("git.args", []string{"diff", "--cached"}))
+	child.SetAttrs(Int("git.exit_code", 1))
+	child.End(errors.New("boom"))
+	root.End(nil)
+	shutdown(context.Background())
+
+	if Enabled() {
+		t.Error("still enabled after shutdown")
+	}
+	if len(got) != 1 || len(got[0].ResourceSpans) != 1 {
+		t.Fatalf("got %d requests: %+v", len(got), got)
+	}
+	if auth != "Bearer abc" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	rs := got[0].ResourceSpans[0]
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if r.TraceID != "0af7651916cd43dd8448eb211c80319c" || r.ParentSpanID != "b7ad6b7169203331" {
+		t.Errorf("root span not under TRACEPARENT: %+v", r)
+	}
+	if c.TraceID != r.TraceID || c.ParentSpanID != r.SpanID || c.Name != "git diff" {
+		t.Errorf("child span = %+v; want child of %s", c, r.SpanID)
+	}
+	if c.Status.Code != 2 || c.Status.Message != "boom" || r.Status.Code != 0 {
+		t.Errorf("statuses = %+v, %+v", c.Status, r.Status)
+	}
+	if len(c.Attributes) != 2 || *c.Attributes[0].Value.String != "diff --cached" || *c.Attributes[1].Value.Int != "1" {
+		t.Errorf("attributes = %+v", c.Attributes)
+	}
+	if *rs.Resource.Attributes[0].Value.String != "commitgen" {
+		t.Errorf("resource = %+v", rs.Resource)
+	}
+
+	// Off without an endpoint.
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	Setup("1.2.3")(context.Background())
+	if _, s := Start(context.Background(), "x", KindInternal); s != nil {
+		t.Error("span recorded while tracing is off")
+	}
+}
@@ -0,0 +1,182 @@
+// Package vault reads secrets from HashiCorp Vault's KV secrets engine, logging in
+// with a token or with AppRole.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Addr      string // e.g. https://vault.example.com:8200
+	Namespace string // Vault Enterprise namespace, if any
+	Token     string // a Vault token; else ~/.vault-token, as left by `vault login`
+	RoleID    string // AppRole login, used when there is no token
+	SecretID  string
+}
+
+// Client reads secrets, logging in once and keeping each secret it reads in memory
+// for the life of the process.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu      sync.Mutex
+	token   string
+	secrets map[string]map[string]any // path → fields
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("vault: no address. Set vault_addr, or env VAULT_ADDR")
+	}
+	token := cfg.Token
+	if token == "" && cfg.RoleID == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			b, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(b))
+		}
+	}
+	if token == "" && (cfg.RoleID == "" || cfg.SecretID == "") {
+		return nil, errors.New("vault: no credentials. Set vault_token (or env VAULT_TOKEN), vault_role_id and vault_secret_id, or run `vault login`")
+	}
+	return &Client{
+		cfg:     cfg,
+		http:    &http.Client{Timeout: 30 * time.Second},
+		token:   token,
+		secrets: map[string]map[string]any{},
+	}, nil
+}
+
+// Read returns the field of the secret a reference names: "PATH#FIELD", where
+// PATH is the API path after /v1/, e.g. "secret/data/commitgen#openai" for a KV
+// version 2 engine mounted at secret/. Without #FIELD, the secret must have
+// exactly one field.
+func (c *Client) Read(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("vault: invalid reference %q (want PATH#FIELD)", ref)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fields, ok := c.secrets[path]
+	if !ok {
+		var err error
+		if fields, err = c.read(ctx, path); err != nil {
+			return "", fmt.Errorf("vault: read %s: %w", path, err)
+		}
+		c.secrets[path] = fields
+	}
+
+	if field == "" {
+		if len(fields) != 1 {
+			keys := make([]string, 0, len(fields))
+			for k := range fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return "", fmt.Errorf("vault: %s has fields %s; name one as %s#FIELD", path, strings.Join(keys, ", "), path)
+		}
+		for field = range fields {
+		}
+	}
+	v, ok := fields[field].(string)
+	if !ok || v == "" {
+		return "", fmt.Errorf("vault: %s has no field %q", path, field)
+	}
+	return v, nil
+}
+
+// read returns the fields of the secret at path, from a KV version 1 or 2 engine.
+func (c *Client) read(ctx context.Context, path string) (map[string]any, error) {
+	if c.token == "" {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	// KV version 2 nests the fields under data, next to metadata.
+	if inner, ok := resp.Data["data"].(map[string]any); ok {
+		if _, v2 := resp.Data["metadata"]; v2 {
+			return inner, nil
+		}
+	}
+	if resp.Data == nil {
+		return nil, errors.New("no secret at this path")
+	}
+	return resp.Data, nil
+}
+
+// login gets a token with AppRole.
+func (c *Client) login(ctx context.Context) error {
+	body := map[string]string{"role_id": c.cfg.RoleID, "secret_id": c.cfg.SecretID}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := c.do(ctx, http.MethodPost, "auth/approle/login", body, &resp); err != nil {
+		return fmt.Errorf("approle login: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("approle login: no token in response")
+	}
+	c.token = resp.Auth.ClientToken
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, _ := json.Marshal(in)
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.cfg.Addr, "/")+"/v1/"+path, body)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.cfg.Namespace)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(b, &e) == nil && len(e.Errors) > 0 {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.Join(e.Errors, "; "))
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadAppRole(t *testing.T) {
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "role" || body["secret_id"] != "sid" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"s.tok"}}`))
+		case "/v1/secret/data/commitgen":
+			reads++
+			if r.Header.Get("X-Vault-Token") != "s.tok" || r.Header.Get("X-Vault-Namespace") != "team" {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"data":{"openai":"sk-1","anthropic":"sk-2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/gemini":
+			_, _ = w.Write([]byte(`{"data":{"key":"g-1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{Addr: srv.URL, Namespace: "team", RoleID: "role", SecretID: "sid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for ref, want := range map[string]string{
+		"secret/data/commitgen#openai":     "sk-1",
+		"/secret/data/commitgen#anthropic": "sk-2",
+		"kv/gemini":                        "g-1", // KV version 1, single field
+	} {
+		got, err := c.Read(ctx, ref)
+		if err != nil || got != want {
+			t.Errorf("Read(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("secret read %d times, want 1", reads)
+	}
+
+	if _, err := c.Read(ctx, "secret/data/commitgen"); err == nil || !strings.Contains(err.Error(), "anthropic, openai") {
+		t.Errorf("ambiguous field: err = %v", err)
+	}
+	if _, err := c.Read(ctx, "secret/data/commitgen#gitlab"); err == nil {
+		t.Error("missing field: expected an error")
+	}
+	if _, err := c.Read(ctx, "kv/missing#key"); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("missing secret: err = %v", err)
+	}
+}
+
+func TestReadTokenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{Addr: srv.URL, Token: "s.bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Read(context.Background(), "secret/data/commitgen#openai")
+	if err == nil || err.Error() != "vault: read secret/data/commitgen: status 403: permission denied" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNewNeedsAddress(t *testing.T) {
+	if _, err := New(Config{Token: "s.tok"}); err == nil {
+		t.Error("expected an error without an address")
+	}
+}
@@ -0,0 +1,41 @@
+package vscodeprompt
+
+import "strings"
+
+// BuildDescribeMessages builds a prompt asking for a prose explanation of the changes
+// in d: what they do and why. message is the commit message they came with, if any.
+func BuildDescribeMessages(d Data, message string) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant that explains code changes to developers.\n")
+	sys.WriteString("Explain what the CODE CHANGES do and why, for a developer who has not seen them: someone inheriting the code, or reviewing it.\n")
+	sys.WriteString("Start with a short paragraph summarizing the change and its purpose. Then describe the notable parts, grouped by area, and point out behavior changes, risks, and anything a reviewer should look at closely.\n")
+	sys.WriteString("Treat the COMMIT MESSAGE, if given, as the author's stated intent, and say so where the code does not match it.\n")
+	sys.WriteString("Use only what the changes show. Do not invent motivation, tests, issues or links; say when the reason for something is not clear.\n")
+	sys.WriteString("Answer in plain markdown prose, without a title and without wrapping the answer in a code block.\n")
+
+	var b strings.Builder
+	if d.RepositoryName != "" {
+		b.WriteString("<repository-context>\n")
+		b.WriteString("# REPOSITORY DETAILS:\n")
+		b.WriteString("Repository name: " + d.RepositoryName + "\n")
+		if d.RemoteProject != "" {
+			b.WriteString("Project: " + d.RemoteProject + "\n")
+		}
+		b.WriteString("</repository-context>\n")
+	}
+	if strings.TrimSpace(message) != "" {
+		b.WriteString("<commit-message>\n")
+		b.WriteString("# COMMIT MESSAGE:\n")
+		b.WriteString(strings.TrimSpace(message))
+		b.WriteString("\n</commit-message>\n")
+	}
+	writeChanges(&b, d)
+	b.WriteString("<reminder>\n")
+	b.WriteString("Now explain the CODE CHANGES: what they do and why.\n")
+	b.WriteString("</reminder>\n")
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
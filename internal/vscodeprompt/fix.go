@@ -0,0 +1,69 @@
+package vscodeprompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FixData describes a hand-written commit message that failed lint.
+type FixData struct {
+	Message          string
+	Issues           []string
+	Conventional     bool
+	Types            []string
+	MaxSubjectLength int
+	Diff             string // staged diff, for context; may be empty
+}
+
+// BuildFixMessages builds a prompt asking the model to rewrite d.Message so that it passes the rules.
+func BuildFixMessages(d FixData) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant that corrects git commit messages.\n")
+	sys.WriteString("Rewrite the given commit message so it satisfies every rule listed, keeping its meaning and any details the author wrote.\n")
+	sys.WriteString("Use the CODE CHANGES only to fill in what the message leaves vague. Do not invent changes.\n")
+	sys.WriteString("Only show the corrected message, wrapped with a single markdown ```text codeblock! Do not provide any explanations or details\n")
+
+	var b strings.Builder
+	b.WriteString("<rules>\n")
+	if d.Conventional {
+		b.WriteString("- The subject must follow Conventional Commits: 'type(scope): description' (scope optional).\n")
+		if len(d.Types) > 0 {
+			b.WriteString("- Allowed types: " + strings.Join(d.Types, ", ") + "\n")
+		}
+	}
+	if d.MaxSubjectLength > 0 {
+		b.WriteString(fmt.Sprintf("- The subject must be at most %d characters. Move detail that does not fit into the body rather than dropping it.\n", d.MaxSubjectLength))
+	}
+	b.WriteString("- The subject must not end with a period.\n")
+	b.WriteString("- Separate subject and body with a blank line.\n")
+	b.WriteString("</rules>\n")
+
+	b.WriteString("<violations>\n")
+	for _, is := range d.Issues {
+		b.WriteString("- " + is + "\n")
+	}
+	b.WriteString("</violations>\n")
+
+	b.WriteString("<original-message>\n")
+	b.WriteString(strings.TrimRight(d.Message, "\n"))
+	b.WriteString("\n</original-message>\n")
+
+	if strings.TrimSpace(d.Diff) != "" {
+		b.WriteString("<code-changes>\n")
+		b.WriteString("# CODE CHANGES:\n")
+		b.WriteString("```diff\n")
+		b.WriteString(strings.TrimRight(d.Diff, "\n"))
+		b.WriteString("\n```\n")
+		b.WriteString("</code-changes>\n")
+	}
+
+	b.WriteString("<reminder>\n")
+	b.WriteString("ONLY return a single markdown code block, NO OTHER PROSE!\n")
+	b.WriteString("```text\ncorrected commit message goes here\n```\n")
+	b.WriteString("</reminder>\n")
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
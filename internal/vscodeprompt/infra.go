@@ -0,0 +1,283 @@
+package vscodeprompt
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxDeltaAttrs caps the attributes listed for one changed block.
+const maxDeltaAttrs = 8
+
+var (
+	hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+	tfResource = regexp.MustCompile(`^(resource|data)\s+"([^"]+)"\s+"([^"]+)"`)
+	tfNamed    = regexp.MustCompile(`^(module|variable|output|provider)\s+"([^"]+)"`)
+	tfBare     = regexp.MustCompile(`^(locals|terraform)\s*\{`)
+	tfKey      = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)\s*(?:=|\{)`)
+
+	yamlKey = regexp.MustCompile(`^\s*(?:-\s+)?([\w./-]+)\s*:`)
+)
+
+// block is a Terraform block or Kubernetes object, and the lines it spans.
+type block struct {
+	name       string
+	start, end int // 0-based; end is exclusive
+}
+
+// infraFormat is how SummarizeInfraChange reads one kind of file.
+type infraFormat struct {
+	title  string
+	blocks func(lines []string) []block
+	key    *regexp.Regexp
+}
+
+var (
+	terraformFormat  = infraFormat{title: "Terraform blocks", blocks: terraformBlocks, key: tfKey}
+	kubernetesFormat = infraFormat{title: "Kubernetes objects", blocks: kubernetesBlocks, key: yamlKey}
+)
+
+// SummarizeInfraChange describes what diff does to the resources of a Terraform
+// file, or to the objects of a Kubernetes manifest: which ones it adds, removes or
+// changes, and which of their attributes. original is the file before the change.
+// It returns "" for other files, and when the diff doesn't apply to original.
+func SummarizeInfraChange(relPath, diff, original string) string {
+	before := splitLines(original)
+	after, removed, added, ok := applyDiff(before, diff)
+	if !ok {
+		return ""
+	}
+	return infraChange(relPath, before, after, removed, added)
+}
+
+// infraChange is SummarizeInfraChange of the file before and after the change, with
+// the lines removed from before and added to after.
+func infraChange(relPath string, before, after []string, removed, added map[int]bool) string {
+	var f infraFormat
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".tf", ".hcl":
+		f = terraformFormat
+	case ".yaml", ".yml":
+		f = kubernetesFormat
+	default:
+		return ""
+	}
+
+	oldBlocks, newBlocks := f.blocks(before), f.blocks(after)
+	oldByName := map[string]block{}
+	for _, b := range oldBlocks {
+		oldByName[b.name] = b
+	}
+	newNames := map[string]bool{}
+
+	var out []string
+	for _, nb := range newBlocks {
+		newNames[nb.name] = true
+		ob, existed := oldByName[nb.name]
+		if !existed {
+			out = append(out, fmt.Sprintf("- %s: added", nb.name))
+			continue
+		}
+		var attrs []string
+		changed := false
+		collect := func(lines []string, b block, lineNos map[int]bool) {
+			for i := b.start; i < b.end; i++ {
+				if !lineNos[i] {
+					continue
+				}
+				changed = true
+				if p := keyPath(lines, b, i, f.key); p != "" && !slices.Contains(attrs, p) {
+					attrs = append(attrs, p)
+				}
+			}
+		}
+		collect(before, ob, removed)
+		collect(after, nb, added)
+		if !changed {
+			continue
+		}
+		line := fmt.Sprintf("- %s: changed", nb.name)
+		if len(attrs) > maxDeltaAttrs {
+			attrs = append(attrs[:maxDeltaAttrs], fmt.Sprintf("and %d more", len(attrs)-maxDeltaAttrs))
+		}
+		if len(attrs) > 0 {
+			line += " " + strings.Join(attrs, ", ")
+		}
+		out = append(out, line)
+	}
+	for _, ob := range oldBlocks {
+		if !newNames[ob.name] {
+			out = append(out, fmt.Sprintf("- %s: removed", ob.name))
+		}
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return f.title + ":\n" + strings.Join(out, "\n") + "\n"
+}
+
+// splitLines splits a file into lines, without the empty one after a final newline.
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// applyDiff returns the file after a one-file unified diff, given the file before it,
+// and the 0-based numbers of the lines the diff removes from before and adds to after.
+// ok is false when the diff's context doesn't match before.
+func applyDiff(before []string, diff string) (after []string, removed, added map[int]bool, ok bool) {
+	removed, added = map[int]bool{}, map[int]bool{}
+	next := 0 // the next line of before to copy
+	inHunk := false
+	for _, ln := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		if m := hunkHeader.FindStringSubmatch(ln); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			at := start - 1
+			if m[2] == "0" {
+				at = start // nothing removed: the lines go after line start
+			}
+			if at < next || at > len(before) {
+				return nil, nil, nil, false
+			}
+			after = append(after, before[next:at]...)
+			next = at
+			inHunk = true
+			continue
+		}
+		if !inHunk || ln == "" {
+			continue
+		}
+		switch ln[0] {
+		case '+':
+			added[len(after)] = true
+			after = append(after, ln[1:])
+		case '-', ' ':
+			if next >= len(before) || before[next] != ln[1:] {
+				return nil, nil, nil, false
+			}
+			if ln[0] == '-' {
+				removed[next] = true
+			} else {
+				after = append(after, before[next])
+			}
+			next++
+		}
+	}
+	return append(after, before[next:]...), removed, added, true
+}
+
+// terraformBlocks finds the top-level blocks of a Terraform file, named as they are
+// referenced: aws_s3_bucket.logs, data.aws_iam_policy.ci, module.vpc, var.region.
+func terraformBlocks(lines []string) []block {
+	var out []block
+	for i, ln := range lines {
+		var name string
+		if m := tfResource.FindStringSubmatch(ln); m != nil {
+			name = m[2] + "." + m[3]
+			if m[1] == "data" {
+				name = "data." + name
+			}
+		} else if m := tfNamed.FindStringSubmatch(ln); m != nil {
+			prefix := m[1]
+			if prefix == "variable" {
+				prefix = "var"
+			}
+			name = prefix + "." + m[2]
+		} else if m := tfBare.FindStringSubmatch(ln); m != nil {
+			name = m[1]
+		} else {
+			continue
+		}
+		if len(out) > 0 {
+			out[len(out)-1].end = i
+		}
+		out = append(out, block{name: name, start: i, end: len(lines)})
+	}
+	return out
+}
+
+// kubernetesBlocks finds the objects of a Kubernetes manifest, one per YAML document
+// with an apiVersion and kind, named Kind/name.
+func kubernetesBlocks(lines []string) []block {
+	var out []block
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && strings.TrimSpace(lines[i]) != "---" {
+			continue
+		}
+		if name := kubernetesName(lines[start:i]); name != "" {
+			out = append(out, block{name: name, start: start, end: i})
+		}
+		start = i + 1
+	}
+	return out
+}
+
+// kubernetesName returns Kind/name for a YAML document, or "" if it has no kind
+// and apiVersion.
+func kubernetesName(doc []string) string {
+	var kind, name string
+	api, inMetadata := false, false
+	for _, ln := range doc {
+		switch {
+		case strings.HasPrefix(ln, "apiVersion:"):
+			api = true
+		case strings.HasPrefix(ln, "kind:"):
+			kind = strings.TrimSpace(strings.TrimPrefix(ln, "kind:"))
+		case strings.HasPrefix(ln, "metadata:"):
+			inMetadata = true
+			continue
+		}
+		if ln != "" && ln[0] != ' ' {
+			inMetadata = false
+		}
+		if v, ok := strings.CutPrefix(ln, "  name:"); ok && inMetadata && name == "" {
+			name = strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	if !api || kind == "" {
+		return ""
+	}
+	if name == "" {
+		return kind
+	}
+	return kind + "/" + name
+}
+
+// keyPath returns the dotted path of keys leading to line i of block b: the key on
+// the line, if any, and those of the less indented lines above it.
+func keyPath(lines []string, b block, i int, key *regexp.Regexp) string {
+	var path []string
+	indent := math.MaxInt
+	for j := i; j >= b.start; j-- {
+		ln := lines[j]
+		trimmed := strings.TrimLeft(ln, " \t")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		ind := len(ln) - len(trimmed)
+		if strings.HasPrefix(trimmed, "- ") {
+			ind += 2 // a list item's first key sits with the item's other keys
+		}
+		if ind >= indent {
+			continue
+		}
+		indent = ind
+		if m := key.FindStringSubmatch(ln); m != nil {
+			path = append(path, m[1])
+		}
+		if ind == 0 {
+			break
+		}
+	}
+	slices.Reverse(path)
+	return strings.Join(path, ".")
+}
@@ -0,0 +1,68 @@
+package vscodeprompt
+
+import "strings"
+
+// FileSummary is the one-line description of the changes to one file, used when the
+// whole changeset is too large for a single prompt.
+type FileSummary struct {
+	Path    string
+	Summary string
+}
+
+// BuildFileSummaryMessages builds a prompt asking for a one-line summary of the changes
+// to a single file.
+func BuildFileSummaryMessages(path, diff string) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant that summarizes code changes.\n")
+	sys.WriteString("Describe in one line of at most 20 words what the CODE CHANGES do to this file, and why if it is evident.\n")
+	sys.WriteString("Only show the summary line. Do not use markdown, a preamble or any explanation.\n")
+
+	var b strings.Builder
+	b.WriteString("<file>\n" + path + "\n</file>\n")
+	b.WriteString("<code-changes>\n")
+	b.WriteString("# CODE CHANGES:\n")
+	b.WriteString("```diff\n")
+	b.WriteString(strings.TrimRight(diff, "\n"))
+	b.WriteString("\n```\n")
+	b.WriteString("</code-changes>\n")
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
+
+// BuildSummarizedMessages builds the commit message prompt from per-file summaries
+// instead of the diffs. The rest of d (repository context, instructions, system
+// prompt) is used as in BuildVSCodeMessages.
+func BuildSummarizedMessages(d Data, summaries []FileSummary) []VSCodeMessage {
+	sections := defaultSections(d)
+
+	var b strings.Builder
+	b.WriteString("<changes>\n")
+	b.WriteString("# FILE SUMMARIES (the changeset is too large to show in full; one line per changed file):\n")
+	for _, s := range summaries {
+		b.WriteString("- " + s.Path + ": " + strings.Join(strings.Fields(s.Summary), " ") + "\n")
+	}
+	b.WriteString("\n</changes>\n")
+	writeTruncationReport(&b, d)
+	sections["changes"] = b.String()
+
+	b.Reset()
+	b.WriteString("<reminder>\n")
+	b.WriteString("Now generate a commit message that describes the whole changeset from the FILE SUMMARIES.\n")
+	b.WriteString("Lead with the overall purpose rather than listing every file.\n")
+	writeAreasReminder(&b, d)
+	writeTypeReminder(&b, d)
+	b.WriteString("DO NOT COPY commits from RECENT COMMITS, but use it as reference for the commit style.\n")
+	b.WriteString("ONLY return a single markdown code block, NO OTHER PROSE!\n")
+	b.WriteString("```text\ncommit message goes here\n```\n")
+	b.WriteString("</reminder>\n")
+	sections["reminder"] = b.String()
+
+	system, user := renderPrompt(d, sections)
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: system}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: user}}},
+	}
+}
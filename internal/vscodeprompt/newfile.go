@@ -0,0 +1,102 @@
+package vscodeprompt
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxDescribedSymbols caps the declarations DescribeNewFile lists.
+const maxDescribedSymbols = 40
+
+// language is what DescribeNewFile knows about a file type: its name, and a pattern
+// matching the start of a top-level declaration, if it has one.
+type language struct {
+	name string
+	decl *regexp.Regexp
+}
+
+var (
+	goDecl    = regexp.MustCompile(`^(?:func(?:\s*\([^)]*\))?|type|const|var)\s+\w+`)
+	pyDecl    = regexp.MustCompile(`^(?:async\s+)?(?:def|class)\s+\w+`)
+	jsDecl    = regexp.MustCompile(`^(?:export\s+(?:default\s+)?)?(?:declare\s+)?(?:async\s+)?(?:function\*?|class|interface|type|enum|const|let|var)\s+\w+`)
+	classDecl = regexp.MustCompile(`^(?:(?:public|private|protected|internal|abstract|final|static|sealed|partial|data|open)\s+)*(?:class|interface|enum|record|object|struct|fun|func|function|trait)\s+\w+`)
+	rustDecl  = regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:fn|struct|enum|trait|mod|type|const|static)\s+\w+`)
+	rubyDecl  = regexp.MustCompile(`^(?:class|module|def)\s+[\w:.]+`)
+	protoDecl = regexp.MustCompile(`^(?:message|service|enum)\s+\w+`)
+	sqlDecl   = regexp.MustCompile(`(?i)^create\s+(?:or\s+replace\s+)?(?:table|view|index|function|procedure|type)\s+[\w."]+`)
+	languages = map[string]language{
+		".go":    {"Go", goDecl},
+		".py":    {"Python", pyDecl},
+		".js":    {"JavaScript", jsDecl},
+		".mjs":   {"JavaScript", jsDecl},
+		".jsx":   {"JavaScript", jsDecl},
+		".ts":    {"TypeScript", jsDecl},
+		".tsx":   {"TypeScript", jsDecl},
+		".java":  {"Java", classDecl},
+		".kt":    {"Kotlin", classDecl},
+		".cs":    {"C#", classDecl},
+		".swift": {"Swift", classDecl},
+		".php":   {"PHP", classDecl},
+		".rs":    {"Rust", rustDecl},
+		".rb":    {"Ruby", rubyDecl},
+		".proto": {"Protocol Buffers", protoDecl},
+		".sql":   {"SQL", sqlDecl},
+		".c":     {name: "C"},
+		".h":     {name: "C"},
+		".cpp":   {name: "C++"},
+		".json":  {name: "JSON"},
+		".yaml":  {name: "YAML"},
+		".yml":   {name: "YAML"},
+		".md":    {name: "Markdown"},
+		".html":  {name: "HTML"},
+		".css":   {name: "CSS"},
+		".svg":   {name: "SVG"},
+		".csv":   {name: "CSV"},
+	}
+)
+
+// DescribeNewFile describes a new file too large to show, for the prompt in place of
+// its contents: its size, language, and top-level declarations. A huge new file is
+// usually generated or vendored, and that says more about it than its first lines.
+func DescribeNewFile(relPath, content string) string {
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n")
+	lang, known := languages[strings.ToLower(filepath.Ext(relPath))]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[New file, too large to show: %d lines, %s", len(lines), byteSize(len(content)))
+	if known {
+		fmt.Fprintf(&b, ", %s", lang.name)
+	}
+	b.WriteString("]\n")
+	if lang.decl == nil {
+		return b.String()
+	}
+
+	var symbols []string
+	for _, ln := range lines {
+		if s := lang.decl.FindString(ln); s != "" {
+			symbols = append(symbols, strings.Join(strings.Fields(s), " "))
+		}
+	}
+	if len(symbols) == 0 {
+		return b.String()
+	}
+	b.WriteString("Top-level declarations:\n")
+	for _, s := range symbols[:min(len(symbols), maxDescribedSymbols)] {
+		b.WriteString(s + "\n")
+	}
+	if more := len(symbols) - maxDescribedSymbols; more > 0 {
+		fmt.Fprintf(&b, "...and %d more\n", more)
+	}
+	return b.String()
+}
+
+// byteSize formats n bytes as KB or MB.
+func byteSize(n int) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%d KB", (n+1023)/1024)
+}
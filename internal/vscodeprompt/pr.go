@@ -0,0 +1,47 @@
+package vscodeprompt
+
+import "strings"
+
+// PRData describes a pull request whose description should be written.
+type PRData struct {
+	Title              string
+	Commits            []string // commit messages, oldest first
+	Diff               string
+	CustomInstructions string
+}
+
+// BuildPRMessages builds a prompt asking the model for a markdown pull request description.
+func BuildPRMessages(d PRData) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant that writes pull request descriptions.\n")
+	sys.WriteString("Describe what the pull request changes and why, for a reviewer who has not seen the code.\n")
+	sys.WriteString("Start with one or two plain sentences, then a short bulleted list of the notable changes. Mention anything reviewers should check carefully.\n")
+	sys.WriteString("Use only what the COMMITS and CODE CHANGES show. Do not invent tests, issues or links.\n")
+	sys.WriteString("Only show the description as GitHub markdown. Do not add a title, a preamble or any explanation.\n")
+
+	var b strings.Builder
+	if d.Title != "" {
+		b.WriteString("<title>\n" + d.Title + "\n</title>\n")
+	}
+	if len(d.Commits) > 0 {
+		b.WriteString("<commits>\n")
+		for _, c := range d.Commits {
+			b.WriteString("- " + strings.ReplaceAll(strings.TrimSpace(c), "\n", "\n  ") + "\n")
+		}
+		b.WriteString("</commits>\n")
+	}
+	b.WriteString("<code-changes>\n")
+	b.WriteString("# CODE CHANGES:\n")
+	b.WriteString("```diff\n")
+	b.WriteString(strings.TrimRight(d.Diff, "\n"))
+	b.WriteString("\n```\n")
+	b.WriteString("</code-changes>\n")
+	if strings.TrimSpace(d.CustomInstructions) != "" {
+		b.WriteString("<custom-instructions>\n" + strings.TrimSpace(d.CustomInstructions) + "\n</custom-instructions>\n")
+	}
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
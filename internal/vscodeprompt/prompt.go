@@ -1,8 +1,8 @@
 package vscodeprompt
 
 import (
-	"bytes"
-	"html/template"
+	"cmp"
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -33,33 +33,41 @@ type Change struct {
 	Path         string
 	Diff         string
 	OriginalCode string // already attachment-wrapped and numbered
+	Delta        string // what the change does to the file's resources or API schema, for files commitgen can read
+	Insertions   int    // lines added and deleted, counted on the whole diff before it may be cut
+	Deletions    int
+	Truncated    []Truncation // parts of the file cut short to fit, for the truncation report
+}
+
+// Truncation is a part of a file's change, its diff or original code, that was cut
+// short to fit in the prompt.
+type Truncation struct {
+	Part  string // "diff" or "original code"
+	Shown int    // bytes kept; 0 for a new file described instead of shown
+	Total int    // bytes before cutting
 }
 
 type Data struct {
 	RepositoryName       string
 	BranchName           string
+	RemoteProject        string   // origin's host and path, e.g. github.com/org/repo
+	DefaultBranch        string   // origin's default branch, e.g. main
+	Issue                string   // the linked issue, "ENG-123: title" and its description, if known
+	StyleExamples        []string // messages the user accepted before, to imitate their style
+	BranchCommits        []string // commits on the branch not yet on DefaultBranch, newest first
 	RecentUserCommits    []string
 	RecentRepoCommits    []string
 	Changes              []Change
+	OmittedFiles         []string // staged files left out for the max_files limit
+	Areas                []string // unrelated areas the changes span, e.g. "api/" and "web/", if more than one
+	Type                 string   // the conventional type to use, chosen or guessed from the changed files
 	CustomInstructions   string
 	SummarizeAttachments bool
-	SystemPromptTemplate string
+	SystemPromptTemplate string // prompt_template: the system prompt, or partials redefining parts of the prompt; see renderPrompt
 }
 
 func BuildVSCodeMessages(d Data) []VSCodeMessage {
-	tmpl := d.SystemPromptTemplate
-	if tmpl == "" {
-		tmpl = defaultSystemPromptTemplate()
-	}
-
-	// Simple template expansion if they use {{.RepositoryName}} etc
-	// For now, let's keep it simple. If they want to use text/template, we can support it.
-	// But mostly they just want to replace the text.
-	// Let's assume it is just a string for now, or text/template.
-	// We'll treat it as text/template.
-
-	systemText := renderTemplate(tmpl, d)
-	userText := buildUserText(d)
+	systemText, userText := renderPrompt(d, defaultSections(d))
 
 	return []VSCodeMessage{
 		{
@@ -96,33 +104,125 @@ func defaultSystemPromptTemplate() string {
 		"Keep your answers short and impersonal.\n"
 }
 
-func renderTemplate(tmplStr string, d Data) string {
-	// Quick pass: if no {{, just return string to save time
-	if !strings.Contains(tmplStr, "{{") {
-		return tmplStr
+func buildUserText(d Data) string {
+	_, user := renderPrompt(d, defaultSections(d))
+	return user
+}
+
+// defaultSections returns the text of each of the user message's userSections.
+func defaultSections(d Data) map[string]string {
+	return map[string]string{
+		"diffstat":     section(d, writeDiffStat),
+		"context":      section(d, writeRepositoryContext, writeStyleExamples),
+		"changes":      section(d, writeChanges, writeTruncationReport),
+		"reminder":     section(d, writeReminder),
+		"instructions": section(d, writeCustomInstructions),
 	}
-	// Use text/template
-	t, err := template.New("system").Parse(tmplStr)
-	if err != nil {
-		// Fallback to raw string if parsing fails, maybe warn?
-		return tmplStr
+}
+
+// section returns what the writers write for d, in order.
+func section(d Data, writers ...func(*strings.Builder, Data)) string {
+	var b strings.Builder
+	for _, w := range writers {
+		w(&b, d)
 	}
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, d); err != nil {
-		return tmplStr
+	return b.String()
+}
+
+// writeReminder writes the closing instructions.
+func writeReminder(b *strings.Builder, d Data) {
+	b.WriteString("<reminder>\n")
+	b.WriteString("Now generate a commit message that describes the CODE CHANGES.\n")
+	b.WriteString("DO NOT COPY commits from RECENT COMMITS, but use it as reference for the commit style.\n")
+	writeAreasReminder(b, d)
+	writeTypeReminder(b, d)
+	if hasBreakingChange(d) {
+		b.WriteString("Some STRUCTURED CHANGES are marked BREAKING: say that the commit breaks compatibility, the way the repository marks it (for Conventional Commits, a BREAKING CHANGE footer).\n")
 	}
-	return buf.String()
+	b.WriteString("ONLY return a single markdown code block, NO OTHER PROSE!\n")
+	b.WriteString("```text\ncommit message goes here\n```\n")
+	b.WriteString("</reminder>\n")
 }
 
-func buildUserText(d Data) string {
-	var b strings.Builder
+// writeDiffStat writes the number of files and lines changed, in all and per file, so
+// the model sees the shape of the change before details that may be cut short.
+func writeDiffStat(b *strings.Builder, d Data) {
+	if len(d.Changes) == 0 {
+		return
+	}
+	var ins, del int
+	for _, ch := range d.Changes {
+		ins += ch.Insertions
+		del += ch.Deletions
+	}
+	b.WriteString("<diffstat>\n")
+	b.WriteString("# DIFFSTAT:\n")
+	fmt.Fprintf(b, "%d files changed, %d insertions(+), %d deletions(-)\n", len(d.Changes), ins, del)
+	for _, ch := range d.Changes {
+		fmt.Fprintf(b, "%s | +%d -%d\n", ch.Path, ch.Insertions, ch.Deletions)
+	}
+	b.WriteString("</diffstat>\n")
+}
+
+// writeAreasReminder asks for a message that names each of the unrelated areas the
+// changes span, rather than one that makes up a link between them.
+func writeAreasReminder(b *strings.Builder, d Data) {
+	if len(d.Areas) < 2 {
+		return
+	}
+	b.WriteString("The CODE CHANGES span unrelated areas (" + strings.Join(d.Areas, "; ") + "). Do not invent a common purpose: name each area, e.g. a subject covering both and a body paragraph per area.\n")
+}
+
+// writeTypeReminder names the conventional type the subject must start with.
+func writeTypeReminder(b *strings.Builder, d Data) {
+	if d.Type == "" {
+		return
+	}
+	b.WriteString("Use the Conventional Commits type '" + d.Type + "': start the subject with '" + d.Type + ":' or '" + d.Type + "(scope):'.\n")
+}
+
+// hasBreakingChange reports whether a change's delta flags a backward-incompatible
+// schema change.
+func hasBreakingChange(d Data) bool {
+	for _, ch := range d.Changes {
+		if strings.Contains(ch.Delta, "- BREAKING: ") {
+			return true
+		}
+	}
+	return false
+}
 
+// writeRepositoryContext writes the repository details and recent commits.
+func writeRepositoryContext(b *strings.Builder, d Data) {
 	b.WriteString("<repository-context>\n")
 	b.WriteString("# REPOSITORY DETAILS:\n")
 	b.WriteString("Repository name: " + d.RepositoryName + "\n")
-	b.WriteString("Branch name: " + d.BranchName + "\n\n")
+	if d.RemoteProject != "" {
+		b.WriteString("Project: " + d.RemoteProject + "\n")
+	}
+	b.WriteString("Branch name: " + d.BranchName + "\n")
+	if d.DefaultBranch != "" {
+		b.WriteString("Default branch: " + d.DefaultBranch + "\n")
+	}
+	b.WriteString("\n")
 	b.WriteString("</repository-context>\n")
 
+	if strings.TrimSpace(d.Issue) != "" {
+		b.WriteString("<issue>\n")
+		b.WriteString("# LINKED ISSUE (what the changes are for; describe the CODE CHANGES, not the issue):\n")
+		b.WriteString(strings.TrimRight(d.Issue, "\n"))
+		b.WriteString("\n</issue>\n")
+	}
+
+	if len(d.BranchCommits) > 0 {
+		b.WriteString("<branch-commits>\n")
+		b.WriteString("# COMMITS ON THIS BRANCH NOT YET ON " + cmp.Or(d.DefaultBranch, "THE DEFAULT BRANCH") + " (the work these changes continue; do not repeat them):\n")
+		for _, c := range d.BranchCommits {
+			b.WriteString("- " + c + "\n")
+		}
+		b.WriteString("\n</branch-commits>\n")
+	}
+
 	if len(d.RecentUserCommits) > 0 {
 		b.WriteString("<user-commits>\n")
 		b.WriteString("# RECENT USER COMMITS (For reference only, do not copy!):\n")
@@ -140,13 +240,38 @@ func buildUserText(d Data) string {
 		}
 		b.WriteString("\n</recent-commits>\n")
 	}
+}
+
+// writeStyleExamples writes the messages the user accepted before, if any.
+func writeStyleExamples(b *strings.Builder, d Data) {
+	if len(d.StyleExamples) == 0 {
+		return
+	}
+	b.WriteString("<style-examples>\n")
+	b.WriteString("# MESSAGES THE USER WROTE OR APPROVED BEFORE (match their style and phrasing, not their content):\n")
+	for _, m := range d.StyleExamples {
+		b.WriteString("```text\n" + strings.TrimSpace(m) + "\n```\n")
+	}
+	b.WriteString("</style-examples>\n")
+}
 
+// writeChanges writes each change's original code, if attached, and diff.
+func writeChanges(b *strings.Builder, d Data) {
 	b.WriteString("<changes>\n")
 	for _, ch := range d.Changes {
-		b.WriteString("<original-code>\n")
-		b.WriteString("# ORIGINAL CODE:\n")
-		b.WriteString(ch.OriginalCode)
-		b.WriteString("\n</original-code>\n")
+		if ch.OriginalCode != "" {
+			b.WriteString("<original-code>\n")
+			b.WriteString("# ORIGINAL CODE:\n")
+			b.WriteString(ch.OriginalCode)
+			b.WriteString("\n</original-code>\n")
+		}
+
+		if ch.Delta != "" {
+			b.WriteString("<structured-changes>\n")
+			b.WriteString("# STRUCTURED CHANGES:\n")
+			b.WriteString(strings.TrimRight(ch.Delta, "\n"))
+			b.WriteString("\n</structured-changes>\n")
+		}
 
 		b.WriteString("<code-changes>\n")
 		b.WriteString("# CODE CHANGES:\n")
@@ -156,22 +281,50 @@ func buildUserText(d Data) string {
 		b.WriteString("</code-changes>\n")
 	}
 	b.WriteString("\n</changes>\n")
+}
 
-	b.WriteString("<reminder>\n")
-	b.WriteString("Now generate a commit message that describes the CODE CHANGES.\n")
-	b.WriteString("DO NOT COPY commits from RECENT COMMITS, but use it as reference for the commit style.\n")
-	b.WriteString("ONLY return a single markdown code block, NO OTHER PROSE!\n")
-	b.WriteString("```text\ncommit message goes here\n```\n")
-	b.WriteString("</reminder>\n")
+// maxOmittedListed bounds how many of the files left out are named in the
+// truncation report; a commit touching thousands of files would otherwise fill
+// the prompt with their paths.
+const maxOmittedListed = 20
+
+// writeTruncationReport lists what was left out of the changes: the parts of files
+// cut short, and the files not shown at all. The model would otherwise take a cut
+// diff for the whole change.
+func writeTruncationReport(b *strings.Builder, d Data) {
+	var lines []string
+	for _, ch := range d.Changes {
+		for _, t := range ch.Truncated {
+			if t.Shown == 0 {
+				lines = append(lines, fmt.Sprintf("- %s: %s of %d bytes described, not shown", ch.Path, t.Part, t.Total))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("- %s: %s cut to %d of %d bytes (%d%% left out)", ch.Path, t.Part, t.Shown, t.Total, 100-t.Shown*100/max(t.Total, 1)))
+		}
+	}
+	if n := len(d.OmittedFiles); n > 0 {
+		listed := strings.Join(d.OmittedFiles[:min(n, maxOmittedListed)], ", ")
+		if n > maxOmittedListed {
+			listed += fmt.Sprintf(" and %d more", n-maxOmittedListed)
+		}
+		lines = append(lines, fmt.Sprintf("- staged files not shown (%d): %s", n, listed))
+	}
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString("<truncation-report>\n")
+	b.WriteString("# PARTS OF THE CHANGES LEFT OUT OF THIS PROMPT (describe what is shown; do not guess at the rest):\n")
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n</truncation-report>\n")
+}
 
+func writeCustomInstructions(b *strings.Builder, d Data) {
 	b.WriteString("<custom-instructions>\n")
 	if strings.TrimSpace(d.CustomInstructions) != "" {
 		b.WriteString(strings.TrimRight(d.CustomInstructions, "\n"))
 		b.WriteString("\n")
 	}
 	b.WriteString("\n</custom-instructions>\n")
-
-	return b.String()
 }
 
 func ToOpenAIMessages(vs []VSCodeMessage) []OpenAIMessage {
@@ -209,4 +362,3 @@ func ExtractOneTextCodeBlock(s string) (string, bool) {
 	// Current caller behavior: if !ok, it prints warning and usage raw s.
 	return s, false
 }
-
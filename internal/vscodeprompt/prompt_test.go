@@ -1,6 +1,8 @@
 package vscodeprompt
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -26,6 +28,39 @@ func TestBuildVSCodeMessages_DefaultTemplate(t *testing.T) {
 	}
 }
 
+func TestBuildVSCodeMessages_RemoteContext(t *testing.T) {
+	data := Data{
+		RepositoryName: "checkout",
+		RemoteProject:  "github.com/acme/api",
+		BranchName:     "fix/retry",
+		DefaultBranch:  "main",
+		Changes:        []Change{{Path: "main.go", Diff: "package main"}},
+	}
+
+	user := BuildVSCodeMessages(data)[1].Content[0].Text
+	want := "Repository name: checkout\nProject: github.com/acme/api\nBranch name: fix/retry\nDefault branch: main\n"
+	if !strings.Contains(user, want) {
+		t.Errorf("repository context missing %q in:\n%s", want, user)
+	}
+}
+
+func TestBuildVSCodeMessages_StyleExamples(t *testing.T) {
+	data := Data{
+		StyleExamples: []string{"fix(auth): expire idle sessions\n\nSessions now end after 30 minutes."},
+		Changes:       []Change{{Path: "main.go", Diff: "package main"}},
+	}
+
+	user := BuildVSCodeMessages(data)[1].Content[0].Text
+	want := "<style-examples>\n# MESSAGES THE USER WROTE OR APPROVED BEFORE (match their style and phrasing, not their content):\n" +
+		"```text\nfix(auth): expire idle sessions\n\nSessions now end after 30 minutes.\n```\n</style-examples>\n<changes>"
+	if !strings.Contains(user, want) {
+		t.Errorf("style examples missing from:\n%s", user)
+	}
+	if strings.Contains(BuildVSCodeMessages(Data{})[1].Content[0].Text, "<style-examples>") {
+		t.Error("empty style examples block written")
+	}
+}
+
 func TestBuildVSCodeMessages_CustomTemplate(t *testing.T) {
 	customTmpl := "Hello {{.RepositoryName}} on branch {{.BranchName}}"
 	data := Data{
@@ -47,3 +82,347 @@ func TestBuildVSCodeMessages_CustomTemplate(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, sysContent)
 	}
 }
+
+func TestBuildReviewMessages(t *testing.T) {
+	data := Data{
+		RepositoryName:     "test-repo",
+		Changes:            []Change{{Path: "main.go", Diff: "+x := 1", OriginalCode: "package main"}},
+		CustomInstructions: "We use table-driven tests.",
+	}
+
+	msgs := BuildReviewMessages(data)
+
+	if !strings.Contains(msgs[0].Content[0].Text, "Missing tests") {
+		t.Error("review instructions not found")
+	}
+	user := msgs[1].Content[0].Text
+	for _, want := range []string{"Repository name: test-repo", "# ORIGINAL CODE:\npackage main", "+x := 1", "We use table-driven tests."} {
+		if !strings.Contains(user, want) {
+			t.Errorf("user text lacks %q", want)
+		}
+	}
+	if strings.Contains(user, "commit message") {
+		t.Error("review prompt asks for a commit message")
+	}
+}
+
+type summarizerFunc func(string, []string) ([]int, error)
+
+func (f summarizerFunc) Summarize(relPath string, lines []string) ([]int, error) {
+	return f(relPath, lines)
+}
+
+func TestBuildAttachment_RegisteredSummarizer(t *testing.T) {
+	RegisterSummarizer(".ex", summarizerFunc(func(_ string, lines []string) ([]int, error) {
+		return []int{2, 99}, nil
+	}))
+	RegisterSummarizer(".tf", summarizerFunc(func(string, []string) ([]int, error) {
+		return nil, errors.New("boom")
+	}))
+	defer func() {
+		summarizersMu.Lock()
+		delete(summarizers, ".ex")
+		delete(summarizers, ".tf")
+		summarizersMu.Unlock()
+	}()
+
+	a := BuildAttachment("/repo", "lib/app.EX", "defmodule App do\n  def run, do: :ok\nend", true)
+	if !strings.Contains(a, " 2:   def run, do: :ok") || strings.Contains(a, "defmodule") {
+		t.Errorf("registered summarizer not used:\n%s", a)
+	}
+	// A failing summarizer falls back to the built-in summary.
+	a = BuildAttachment("/repo", "main.tf", "resource \"x\" \"y\" {}", true)
+	if !strings.Contains(a, ` 1: resource "x" "y" {}`) {
+		t.Errorf("no fallback to the built-in summary:\n%s", a)
+	}
+}
+
+func TestDescribeNewFile(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pb\n\n")
+	for i := range 50 {
+		fmt.Fprintf(&b, "type Msg%d struct {\n\tName string\n}\n\nfunc (x *Msg%d) GetName() string {\n\treturn x.Name\n}\n", i, i)
+	}
+	got := DescribeNewFile("api/v1/api.pb.go", b.String())
+	for _, want := range []string{"[New file, too large to show: 353 lines, 5 KB, Go]", "Top-level declarations:\ntype Msg0\nfunc (x *Msg0) GetName\n", "...and 60 more"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("description lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Name string") {
+		t.Errorf("description shows the contents:\n%s", got)
+	}
+
+	if got := DescribeNewFile("data/dump.bin", "x\ny\n"); got != "[New file, too large to show: 2 lines, 1 KB]\n" {
+		t.Errorf("unknown type: %q", got)
+	}
+}
+
+func TestSummarizeInfraChange(t *testing.T) {
+	tf := `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  tags = {
+    team = "infra"
+  }
+}
+
+module "vpc" {
+  source = "./vpc"
+}
+`
+	tfDiff := `diff --git a/main.tf b/main.tf
+--- a/main.tf
++++ b/main.tf
+@@ -1,10 +1,16 @@
+ resource "aws_s3_bucket" "logs" {
+   bucket = "logs"
+   tags = {
+-    team = "infra"
++    team = "platform"
+   }
++  lifecycle_rule {
++    enabled = true
++  }
+ }
+ 
+-module "vpc" {
+-  source = "./vpc"
++resource "aws_iam_role" "ci" {
++  name = "ci"
+ }
+`
+	want := "Terraform blocks:\n- aws_s3_bucket.logs: changed tags.team, lifecycle_rule, lifecycle_rule.enabled\n- aws_iam_role.ci: added\n- module.vpc: removed\n"
+	if got := SummarizeInfraChange("main.tf", tfDiff, tf); got != want {
+		t.Errorf("terraform:\n%s\nwant:\n%s", got, want)
+	}
+
+	k8s := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\nspec:\n  replicas: 2\n  template:\n    spec:\n      containers:\n        - name: api\n          image: api:1.0\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: api\n"
+	k8sDiff := "--- a/deploy.yaml\n+++ b/deploy.yaml\n@@ -5,7 +5,7 @@ metadata:\n spec:\n-  replicas: 2\n+  replicas: 3\n   template:\n     spec:\n       containers:\n         - name: api\n-          image: api:1.0\n+          image: api:1.1\n"
+	want = "Kubernetes objects:\n- Deployment/api: changed spec.replicas, spec.template.spec.containers.image\n"
+	if got := SummarizeInfraChange("deploy.yaml", k8sDiff, k8s); got != want {
+		t.Errorf("kubernetes:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := SummarizeInfraChange("deploy.yaml", k8sDiff, "something else\n"); got != "" {
+		t.Errorf("diff that doesn't apply: %q", got)
+	}
+	if got := SummarizeInfraChange("ci.yaml", "--- /dev/null\n+++ b/ci.yaml\n@@ -0,0 +1 @@\n+on: push\n", ""); got != "" {
+		t.Errorf("plain YAML: %q", got)
+	}
+	if got := SummarizeStructuredChange("deploy.yaml", k8sDiff, k8s); got != want {
+		t.Errorf("structured kubernetes:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSummarizeSchemaChange(t *testing.T) {
+	proto := `syntax = "proto3";
+
+message User {
+  int32 id = 1;
+  string name = 2;
+  oneof contact {
+    string email = 3;
+  }
+}
+
+service Users {
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc DeleteUser(DeleteUserRequest) returns (Empty);
+}
+`
+	protoDiff := `--- a/users.proto
++++ b/users.proto
+@@ -1,15 +1,19 @@
+ syntax = "proto3";
+ 
+ message User {
+-  int32 id = 1;
++  string id = 1;
+   string name = 2;
+   oneof contact {
+     string email = 3;
++    string phone = 4;
+   }
+ }
+ 
++message Team {
++  repeated User members = 1;
++}
++
+ service Users {
+   rpc GetUser(GetUserRequest) returns (User);
+-  rpc DeleteUser(DeleteUserRequest) returns (Empty);
+ }
+`
+	want := "Protocol Buffers schema:\n" +
+		"- BREAKING: changed field User.id from int32 = 1 to string = 1\n" +
+		"- BREAKING: removed rpc Users.DeleteUser\n" +
+		"- added message Team\n" +
+		"- added field User.phone (string = 4)\n"
+	if got := SummarizeSchemaChange("users.proto", protoDiff, proto); got != want {
+		t.Errorf("proto:\n%s\nwant:\n%s", got, want)
+	}
+
+	openapi := `openapi: 3.0.0
+paths:
+  /users:
+    get:
+      summary: List users
+    delete:
+      summary: Delete users
+components:
+  schemas:
+    User:
+      properties:
+        id:
+          type: integer
+`
+	openapiDiff := `--- a/openapi.yaml
++++ b/openapi.yaml
+@@ -3,11 +3,16 @@ paths:
+   /users:
+     get:
+       summary: List users
+-    delete:
+-      summary: Delete users
++    post:
++      summary: Create a user
+ components:
+   schemas:
+     User:
++      required: [email]
+       properties:
+         id:
+           type: integer
++        email:
++          type: string
+`
+	want = "OpenAPI schema:\n" +
+		"- BREAKING: made User.email required\n" +
+		"- BREAKING: removed endpoint DELETE /users\n" +
+		"- added endpoint POST /users\n" +
+		"- added property User.email (string)\n"
+	if got := SummarizeSchemaChange("api/openapi.yaml", openapiDiff, openapi); got != want {
+		t.Errorf("openapi:\n%s\nwant:\n%s", got, want)
+	}
+	if got := SummarizeStructuredChange("api/openapi.yaml", openapiDiff, openapi); got != want {
+		t.Errorf("structured openapi:\n%s\nwant:\n%s", got, want)
+	}
+	minified := "--- /dev/null\n+++ b/api.json\n@@ -0,0 +1 @@\n+{\"info\":{},\"openapi\":\"3.0.0\",\"paths\":{\"/ping\":{\"get\":{}}}}\n"
+	if got := SummarizeSchemaChange("api.json", minified, ""); got != "OpenAPI schema:\n- added endpoint GET /ping\n" {
+		t.Errorf("minified OpenAPI JSON: %q", got)
+	}
+
+	if got := SummarizeSchemaChange("deploy.yaml", "--- /dev/null\n+++ b/deploy.yaml\n@@ -0,0 +1 @@\n+kind: Pod\n", ""); got != "" {
+		t.Errorf("YAML that isn't OpenAPI: %q", got)
+	}
+
+	d := Data{Changes: []Change{{Path: "users.proto", Diff: protoDiff, Delta: want}}}
+	if !strings.Contains(buildUserText(d), "BREAKING CHANGE footer") {
+		t.Error("breaking schema change not pointed out in the reminder")
+	}
+}
+
+func TestDiffStat(t *testing.T) {
+	d := Data{Changes: []Change{
+		{Path: "main.go", Diff: "...[Diff truncated due to size]...", Insertions: 120, Deletions: 4},
+		{Path: "README.md", Diff: "+docs\n", Insertions: 1},
+	}}
+	want := "<diffstat>\n# DIFFSTAT:\n2 files changed, 121 insertions(+), 4 deletions(-)\nmain.go | +120 -4\nREADME.md | +1 -0\n</diffstat>\n<repository-context>"
+	if got := buildUserText(d); !strings.HasPrefix(got, want) {
+		t.Errorf("user prompt starts:\n%s\nwant:\n%s", got[:min(len(got), len(want))], want)
+	}
+	if got := BuildSummarizedMessages(d, nil)[1].Content[0].Text; !strings.HasPrefix(got, "<diffstat>\n") {
+		t.Errorf("summarized prompt starts: %q", got[:min(len(got), 40)])
+	}
+}
+
+func TestAreasReminder(t *testing.T) {
+	d := Data{Changes: []Change{{Path: "api/users.go", Diff: "+x\n"}, {Path: "scripts/release.py", Diff: "+y\n"}}}
+	if strings.Contains(buildUserText(d), "unrelated areas") {
+		t.Error("reminder added without areas")
+	}
+	d.Areas = []string{"api/", "scripts/"}
+	if !strings.Contains(buildUserText(d), "The CODE CHANGES span unrelated areas (api/; scripts/)") {
+		t.Error("unrelated areas not pointed out in the reminder")
+	}
+}
+
+func TestBranchCommits(t *testing.T) {
+	d := Data{BranchName: "feature/export", DefaultBranch: "main", Changes: []Change{{Path: "export.go", Diff: "+x\n"}}}
+	if strings.Contains(buildUserText(d), "<branch-commits>") {
+		t.Error("branch commits section without commits")
+	}
+	d.BranchCommits = []string{"feat: add CSV export", "refactor: split writer"}
+	want := "<branch-commits>\n# COMMITS ON THIS BRANCH NOT YET ON main (the work these changes continue; do not repeat them):\n- feat: add CSV export\n- refactor: split writer\n"
+	if !strings.Contains(buildUserText(d), want) {
+		t.Errorf("branch commits not listed:\n%s", buildUserText(d))
+	}
+}
+
+func TestTruncationReport(t *testing.T) {
+	d := Data{Changes: []Change{{Path: "a.go", Diff: "+x\n"}}}
+	if strings.Contains(buildUserText(d), "<truncation-report>") {
+		t.Error("truncation report without truncations")
+	}
+	d.Changes = append(d.Changes, Change{Path: "big.go", Diff: "+y\n", Truncated: []Truncation{
+		{Part: "original code", Shown: 2000, Total: 8000},
+		{Part: "diff", Shown: 2000, Total: 200000},
+	}}, Change{Path: "data.json", Diff: "+z\n", Truncated: []Truncation{{Part: "new file", Total: 300000}}})
+	d.OmittedFiles = []string{"c.go", "d.go"}
+	want := "<truncation-report>\n# PARTS OF THE CHANGES LEFT OUT OF THIS PROMPT (describe what is shown; do not guess at the rest):\n" +
+		"- big.go: original code cut to 2000 of 8000 bytes (75% left out)\n" +
+		"- big.go: diff cut to 2000 of 200000 bytes (99% left out)\n" +
+		"- data.json: new file of 300000 bytes described, not shown\n" +
+		"- staged files not shown (2): c.go, d.go\n</truncation-report>\n"
+	if !strings.Contains(buildUserText(d), want) {
+		t.Errorf("truncation report missing:\n%s", buildUserText(d))
+	}
+
+	d.OmittedFiles = nil
+	for i := range maxOmittedListed + 5 {
+		d.OmittedFiles = append(d.OmittedFiles, fmt.Sprintf("f%d.go", i))
+	}
+	want = fmt.Sprintf("- staged files not shown (%d): f0.go, f1.go, ", maxOmittedListed+5)
+	want2 := fmt.Sprintf("f%d.go and 5 more\n", maxOmittedListed-1)
+	if text := buildUserText(d); !strings.Contains(text, want) || !strings.Contains(text, want2) || strings.Contains(text, fmt.Sprintf("f%d.go", maxOmittedListed)) {
+		t.Errorf("omitted files not capped:\n%s", text)
+	}
+}
+
+func TestTypeReminder(t *testing.T) {
+	d := Data{Changes: []Change{{Path: "parser_test.go", Diff: "+x\n"}}, Type: "test"}
+	if !strings.Contains(buildUserText(d), "start the subject with 'test:' or 'test(scope):'") {
+		t.Error("type not named in the reminder")
+	}
+}
+
+func TestPromptTemplatePartials(t *testing.T) {
+	d := Data{RepositoryName: "shop", Changes: []Change{{Path: "a.go", Diff: "+x\n"}}}
+	plain := BuildVSCodeMessages(d)
+
+	d.SystemPromptTemplate = `{{define "reminder"}}{{template "default_reminder" .}}Mention the ticket number.
+{{end}}`
+	msgs := BuildVSCodeMessages(d)
+	if got := msgs[0].Content[0].Text; got != defaultSystemPromptTemplate() {
+		t.Errorf("system prompt changed by a reminder partial:\n%s", got)
+	}
+	want := strings.Replace(plain[1].Content[0].Text, "</reminder>\n", "</reminder>\nMention the ticket number.\n", 1)
+	if got := msgs[1].Content[0].Text; got != want {
+		t.Errorf("user message:\n%s\nwant:\n%s", got, want)
+	}
+
+	d.SystemPromptTemplate = `{{define "system"}}{{template "default_system" .}}Write in British English.{{end}}`
+	if got := BuildVSCodeMessages(d)[0].Content[0].Text; got != defaultSystemPromptTemplate()+"Write in British English." {
+		t.Errorf("system partial:\n%s", got)
+	}
+
+	d.SystemPromptTemplate = "You write commits for {{.RepositoryName}} & friends."
+	msgs = BuildVSCodeMessages(d)
+	if got := msgs[0].Content[0].Text; got != "You write commits for shop & friends." {
+		t.Errorf("whole system prompt: %q", got)
+	}
+	if msgs[1].Content[0].Text != plain[1].Content[0].Text {
+		t.Error("user message changed by a system prompt template")
+	}
+}
@@ -0,0 +1,68 @@
+package vscodeprompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RefineData describes a generated commit message to be critiqued and improved.
+type RefineData struct {
+	Message            string
+	Diff               string
+	Conventional       bool
+	Types              []string
+	MaxSubjectLength   int
+	CustomInstructions string
+}
+
+// BuildRefineMessages builds a prompt asking the model to review d.Message against the
+// diff and return an improved version.
+func BuildRefineMessages(d RefineData) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant that reviews and improves git commit messages.\n")
+	sys.WriteString("Critique the CANDIDATE MESSAGE against the CODE CHANGES, silently:\n")
+	sys.WriteString("1. Accuracy: does it describe what the changes actually do, and why? Remove claims the changes do not support and add anything important it misses.\n")
+	sys.WriteString("2. Conventions: does it follow the rules listed?\n")
+	sys.WriteString("3. Brevity: is every word needed? Prefer a short imperative subject and a body only when it adds information.\n")
+	sys.WriteString("Then only show the improved message, wrapped with a single markdown ```text codeblock! Do not show the critique or any explanation. If the candidate needs no change, return it unchanged.\n")
+
+	var b strings.Builder
+	b.WriteString("<rules>\n")
+	if d.Conventional {
+		b.WriteString("- The subject must follow Conventional Commits: 'type(scope): description' (scope optional).\n")
+		if len(d.Types) > 0 {
+			b.WriteString("- Allowed types: " + strings.Join(d.Types, ", ") + "\n")
+		}
+	}
+	if d.MaxSubjectLength > 0 {
+		b.WriteString(fmt.Sprintf("- The subject must be at most %d characters.\n", d.MaxSubjectLength))
+	}
+	b.WriteString("- The subject must not end with a period.\n")
+	b.WriteString("- Separate subject and body with a blank line.\n")
+	b.WriteString("</rules>\n")
+
+	b.WriteString("<candidate-message>\n")
+	b.WriteString(strings.TrimRight(d.Message, "\n"))
+	b.WriteString("\n</candidate-message>\n")
+
+	b.WriteString("<code-changes>\n")
+	b.WriteString("# CODE CHANGES:\n")
+	b.WriteString("```diff\n")
+	b.WriteString(strings.TrimRight(d.Diff, "\n"))
+	b.WriteString("\n```\n")
+	b.WriteString("</code-changes>\n")
+
+	if strings.TrimSpace(d.CustomInstructions) != "" {
+		b.WriteString("<custom-instructions>\n" + strings.TrimSpace(d.CustomInstructions) + "\n</custom-instructions>\n")
+	}
+
+	b.WriteString("<reminder>\n")
+	b.WriteString("ONLY return a single markdown code block, NO OTHER PROSE!\n")
+	b.WriteString("```text\nimproved commit message goes here\n```\n")
+	b.WriteString("</reminder>\n")
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
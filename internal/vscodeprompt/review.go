@@ -0,0 +1,30 @@
+package vscodeprompt
+
+import "strings"
+
+// BuildReviewMessages builds a prompt asking for a code review of the changes in d:
+// the same context as BuildVSCodeMessages (repository, recent commits, original code,
+// diffs, custom instructions), with a reviewer's instructions instead.
+func BuildReviewMessages(d Data) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant reviewing code changes before they are committed.\n")
+	sys.WriteString("Review the CODE CHANGES, using the ORIGINAL CODE for context, and report what the author should look at before committing:\n")
+	sys.WriteString("1. Likely bugs: wrong logic, off-by-one errors, unhandled errors or nil values, races, resource leaks.\n")
+	sys.WriteString("2. Missing tests for new or changed behavior.\n")
+	sys.WriteString("3. Risky spots: security, data loss, breaking changes to APIs or formats, performance.\n")
+	sys.WriteString("Give each finding as a markdown bullet starting with the file path (and function, if known), most serious first, and say briefly why it matters.\n")
+	sys.WriteString("Only report real problems the changes show; do not comment on style or restate what the code does. If there is nothing worth reporting, say so in one sentence.\n")
+
+	var b strings.Builder
+	writeRepositoryContext(&b, d)
+	writeChanges(&b, d)
+	b.WriteString("<reminder>\n")
+	b.WriteString("Now review the CODE CHANGES. Only list findings, as markdown bullets.\n")
+	b.WriteString("</reminder>\n")
+	writeCustomInstructions(&b, d)
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
@@ -0,0 +1,24 @@
+package vscodeprompt
+
+import "strings"
+
+// BuildRiskMessages builds a prompt asking whether the changes in d are risky to
+// commit, answered as at most three short bullets, or NONE.
+func BuildRiskMessages(d Data) []VSCodeMessage {
+	var sys strings.Builder
+	sys.WriteString("You are an AI programming assistant that flags risky changes before they are committed.\n")
+	sys.WriteString("Look for changes that could cause an outage, data loss, a security hole, or break other code: migrations, authentication and permissions, deleted behavior, changed public APIs or formats, disabled checks.\n")
+	sys.WriteString("Reply with at most three risks, most serious first, each on its own line starting with '- ' and under 100 characters. Name the file where it helps.\n")
+	sys.WriteString("Only flag real risks the changes show. If there are none, reply with the single word NONE.\n")
+
+	var b strings.Builder
+	writeChanges(&b, d)
+	b.WriteString("<reminder>\n")
+	b.WriteString("Reply with '- ' bullets or NONE, nothing else.\n")
+	b.WriteString("</reminder>\n")
+
+	return []VSCodeMessage{
+		{Role: RoleSystem, Content: []VSCodeContentPart{{Type: 1, Text: sys.String()}}},
+		{Role: RoleUser, Content: []VSCodeContentPart{{Type: 1, Text: b.String()}}},
+	}
+}
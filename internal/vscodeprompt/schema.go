@@ -0,0 +1,267 @@
+package vscodeprompt
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxSchemaChanges caps the lines SummarizeSchemaChange lists.
+const maxSchemaChanges = 40
+
+var (
+	protoBlock = regexp.MustCompile(`^(message|enum|service)\s+(\w+)\s*\{`)
+	protoField = regexp.MustCompile(`^(?:(?:repeated|optional|required)\s+)?(map\s*<[^>]+>|[\w.]+)\s+(\w+)\s*=\s*(\d+)`)
+	protoValue = regexp.MustCompile(`^(\w+)\s*=\s*(-?\d+)`)
+	protoRPC   = regexp.MustCompile(`^rpc\s+(\w+)\s*\(\s*(stream\s+)?([\w.]+)\s*\)\s*returns\s*\(\s*(stream\s+)?([\w.]+)\s*\)`)
+
+	// openAPIVersion matches the key that makes a document OpenAPI or Swagger.
+	openAPIVersion = regexp.MustCompile(`(^|[\s{,])"?(openapi|swagger)"?\s*:`)
+
+	httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+)
+
+// schemaItem is one element of an API schema, such as a message field or an
+// endpoint, keyed by its full name. sig is the part callers depend on: a field's
+// type and number, an RPC's request and response. Required properties
+// and parameters are items of their own, named by label in the summary.
+type schemaItem struct {
+	kind  string
+	sig   string
+	label string
+}
+
+// SummarizeSchemaChange lists what diff does to an API schema, a .proto file or an
+// OpenAPI (or Swagger) document: the messages, fields, RPCs, endpoints and schema
+// properties it adds, removes or changes. Changes that break existing clients, such
+// as a removed field or a changed field number, are marked BREAKING. original is the
+// file before the change. It returns "" for other files, and when the diff doesn't
+// apply to original.
+func SummarizeSchemaChange(relPath, diff, original string) string {
+	before := splitLines(original)
+	after, _, _, ok := applyDiff(before, diff)
+	if !ok {
+		return ""
+	}
+	return schemaChange(relPath, before, after)
+}
+
+// SummarizeStructuredChange describes what diff does to the file at relPath, as
+// SummarizeSchemaChange does or else as SummarizeInfraChange does, applying the
+// diff once for both.
+func SummarizeStructuredChange(relPath, diff, original string) string {
+	before := splitLines(original)
+	after, removed, added, ok := applyDiff(before, diff)
+	if !ok {
+		return ""
+	}
+	if delta := schemaChange(relPath, before, after); delta != "" {
+		return delta
+	}
+	return infraChange(relPath, before, after, removed, added)
+}
+
+// schemaChange is SummarizeSchemaChange of the file before and after the change.
+func schemaChange(relPath string, before, after []string) string {
+	var title string
+	var parse func(lines []string) map[string]schemaItem
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".proto":
+		title, parse = "Protocol Buffers schema", protoSchema
+	case ".yaml", ".yml", ".json":
+		title, parse = "OpenAPI schema", openAPISchema
+	default:
+		return ""
+	}
+
+	newItems := parse(after)
+	if newItems == nil {
+		return ""
+	}
+	oldItems := parse(before)
+
+	var breaking, compatible []string
+	for _, name := range sortedKeys(newItems) {
+		it := newItems[name]
+		old, existed := oldItems[name]
+		switch {
+		case !existed && addedWithParent(name, newItems, oldItems):
+		case !existed && it.label != "":
+			breaking = append(breaking, fmt.Sprintf("BREAKING: made %s required", it.label))
+		case !existed:
+			compatible = append(compatible, fmt.Sprintf("added %s %s%s", it.kind, name, sigSuffix(it.sig)))
+		case old.sig != it.sig:
+			breaking = append(breaking, fmt.Sprintf("BREAKING: changed %s %s from %s to %s", it.kind, name, old.sig, it.sig))
+		}
+	}
+	for _, name := range sortedKeys(oldItems) {
+		it := oldItems[name]
+		switch _, kept := newItems[name]; {
+		case kept || addedWithParent(name, oldItems, newItems):
+		case it.label != "":
+			compatible = append(compatible, fmt.Sprintf("made %s optional", it.label))
+		default:
+			breaking = append(breaking, fmt.Sprintf("BREAKING: removed %s %s", it.kind, name))
+		}
+	}
+
+	out := append(breaking, compatible...)
+	if len(out) == 0 {
+		return ""
+	}
+	if len(out) > maxSchemaChanges {
+		out = append(out[:maxSchemaChanges], fmt.Sprintf("and %d more", len(out)-maxSchemaChanges))
+	}
+	return title + ":\n- " + strings.Join(out, "\n- ") + "\n"
+}
+
+// addedWithParent reports whether an item of items, such as a field, belongs to one
+// that other lacks, such as its message: the change to the whole says enough about
+// its parts.
+func addedWithParent(name string, items, other map[string]schemaItem) bool {
+	for parent := name; ; {
+		i := strings.LastIndexAny(parent, ". ")
+		if i < 0 {
+			return false
+		}
+		parent = parent[:i]
+		if _, ok := items[parent]; !ok {
+			continue
+		}
+		if _, ok := other[parent]; !ok {
+			return true
+		}
+	}
+}
+
+func sigSuffix(sig string) string {
+	if sig == "" {
+		return ""
+	}
+	return " (" + sig + ")"
+}
+
+func sortedKeys(m map[string]schemaItem) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// protoSchema reads the messages, enums and services of a .proto file, with their
+// fields, values and RPCs. Nested messages are named Outer.Inner.
+func protoSchema(lines []string) map[string]schemaItem {
+	items := map[string]schemaItem{}
+	type scope struct {
+		kind, name string
+	}
+	var stack []scope // the enclosing blocks, with "" for braces that aren't one
+	for _, ln := range lines {
+		if i := strings.Index(ln, "//"); i >= 0 {
+			ln = ln[:i]
+		}
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			continue
+		}
+		var cur scope
+		if len(stack) > 0 {
+			cur = stack[len(stack)-1]
+		}
+		// Fields of a oneof belong to its message.
+		owner := cur
+		for i := len(stack) - 1; i >= 0 && owner.kind == ""; i-- {
+			owner = stack[i]
+		}
+
+		if m := protoBlock.FindStringSubmatch(ln); m != nil {
+			name := m[2]
+			if cur.kind == "message" {
+				name = cur.name + "." + name
+			}
+			items[name] = schemaItem{kind: m[1]}
+			stack = append(stack, scope{m[1], name})
+			ln = strings.Replace(ln, "{", "", 1)
+		} else if m := protoRPC.FindStringSubmatch(ln); m != nil && owner.kind == "service" {
+			items[owner.name+"."+m[1]] = schemaItem{kind: "rpc", sig: m[2] + m[3] + " -> " + m[4] + m[5]}
+		} else if m := protoField.FindStringSubmatch(ln); m != nil && owner.kind == "message" && m[1] != "reserved" && m[1] != "option" {
+			items[owner.name+"."+m[2]] = schemaItem{kind: "field", sig: strings.Join(strings.Fields(m[1]), "") + " = " + m[3]}
+		} else if m := protoValue.FindStringSubmatch(ln); m != nil && owner.kind == "enum" {
+			items[owner.name+"."+m[1]] = schemaItem{kind: "value", sig: m[2]}
+		}
+		for range strings.Count(ln, "{") {
+			stack = append(stack, scope{})
+		}
+		stack = stack[:max(len(stack)-strings.Count(ln, "}"), 0)]
+	}
+	return items
+}
+
+// openAPISchema reads the endpoints and their parameters, and the schemas and their
+// properties, of an OpenAPI 3 or Swagger 2 document. It returns nil for other YAML
+// and JSON files, which it tells apart without parsing them.
+func openAPISchema(lines []string) map[string]schemaItem {
+	if !slices.ContainsFunc(lines, openAPIVersion.MatchString) {
+		return nil
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &doc); err != nil {
+		return nil
+	}
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil
+	}
+	items := map[string]schemaItem{}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for path, v := range paths {
+		ops, _ := v.(map[string]any)
+		for _, method := range httpMethods {
+			op, ok := ops[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			endpoint := strings.ToUpper(method) + " " + path
+			items[endpoint] = schemaItem{kind: "endpoint"}
+			params, _ := op["parameters"].([]any)
+			for _, p := range params {
+				param, _ := p.(map[string]any)
+				name, _ := param["name"].(string)
+				if required, _ := param["required"].(bool); name != "" && required {
+					items[endpoint+" "+name] = schemaItem{kind: "required parameter", label: "parameter " + name + " of " + endpoint}
+				}
+			}
+		}
+	}
+
+	schemas, _ := doc["definitions"].(map[string]any)
+	if components, ok := doc["components"].(map[string]any); ok {
+		schemas, _ = components["schemas"].(map[string]any)
+	}
+	for name, v := range schemas {
+		schema, _ := v.(map[string]any)
+		items[name] = schemaItem{kind: "schema"}
+		props, _ := schema["properties"].(map[string]any)
+		for prop, pv := range props {
+			p, _ := pv.(map[string]any)
+			typ, _ := p["type"].(string)
+			if ref, ok := p["$ref"].(string); ok {
+				typ = ref[strings.LastIndex(ref, "/")+1:]
+			}
+			items[name+"."+prop] = schemaItem{kind: "property", sig: typ}
+		}
+		required, _ := schema["required"].([]any)
+		for _, r := range required {
+			if prop, ok := r.(string); ok {
+				items[name+" required "+prop] = schemaItem{kind: "required property", label: name + "." + prop}
+			}
+		}
+	}
+	return items
+}
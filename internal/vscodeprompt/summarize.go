@@ -2,10 +2,32 @@ package vscodeprompt
 
 import (
 	"fmt"
+	"log/slog"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
+// A Summarizer picks the lines of a file that a summarized attachment keeps, for
+// file types the built-in summaries don't understand.
+type Summarizer interface {
+	// Summarize returns the 1-based numbers of the lines of relPath to keep.
+	Summarize(relPath string, lines []string) ([]int, error)
+}
+
+var (
+	summarizersMu sync.RWMutex
+	summarizers   = map[string]Summarizer{}
+)
+
+// RegisterSummarizer makes s summarize files with extension ext (e.g. ".ex") in
+// place of the built-in summary, replacing any summarizer registered for it before.
+func RegisterSummarizer(ext string, s Summarizer) {
+	summarizersMu.Lock()
+	defer summarizersMu.Unlock()
+	summarizers[strings.ToLower(ext)] = s
+}
+
 func BuildAttachment(repoRoot, relPath, content string, summarize bool) string {
 	base := filepath.Base(relPath)
 	abs := filepath.Join(repoRoot, relPath)
@@ -46,8 +68,28 @@ func BuildAttachment(repoRoot, relPath, content string, summarize bool) string {
 
 func summarizeByType(relPath string, lines []string) map[int]string {
 	ext := strings.ToLower(filepath.Ext(relPath))
+	summarizersMu.RLock()
+	s := summarizers[ext]
+	summarizersMu.RUnlock()
+	if s != nil {
+		kept, err := summarizeWith(s, relPath, lines)
+		if err == nil {
+			return kept
+		}
+		slog.Warn("summarizer failed; using the built-in summary", "path", relPath, "err", err)
+	}
+
 	switch ext {
-	case ".md", ".txt", ".json", ".yml", ".yaml":
+	case ".tf", ".hcl":
+		return summarizeShallow(lines, 2)
+
+	case ".yml", ".yaml":
+		if len(kubernetesBlocks(lines)) > 0 {
+			return summarizeShallow(lines, 2)
+		}
+		return summarizeHeadPlusLast(lines, 25)
+
+	case ".md", ".txt", ".json":
 		return summarizeHeadPlusLast(lines, 25)
 
 	case ".go":
@@ -58,6 +100,36 @@ func summarizeByType(relPath string, lines []string) map[int]string {
 	}
 }
 
+// summarizeWith keeps the lines s picks, ignoring numbers outside the file.
+func summarizeWith(s Summarizer, relPath string, lines []string) (map[int]string, error) {
+	keep, err := s.Summarize(relPath, lines)
+	if err != nil {
+		return nil, err
+	}
+	kept := map[int]string{}
+	for _, ln := range keep {
+		if ln >= 1 && ln <= len(lines) {
+			kept[ln] = strings.TrimRight(lines[ln-1], "\r")
+		}
+	}
+	return kept, nil
+}
+
+// summarizeShallow keeps the lines indented by at most maxIndent spaces, for files
+// whose outline is their shallow lines: the blocks of a Terraform file and their
+// arguments, or the objects of a Kubernetes manifest and their top-level fields.
+func summarizeShallow(lines []string, maxIndent int) map[int]string {
+	kept := map[int]string{}
+	for i, ln := range lines {
+		ln = strings.TrimRight(ln, "\r")
+		trimmed := strings.TrimLeft(ln, " ")
+		if trimmed != "" && len(ln)-len(trimmed) <= maxIndent {
+			kept[i+1] = ln
+		}
+	}
+	return kept
+}
+
 // Like VSCode dump for .md: keep head and last-line marker.
 func summarizeHeadPlusLast(lines []string, headN int) map[int]string {
 	kept := map[int]string{}
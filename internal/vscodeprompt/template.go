@@ -0,0 +1,81 @@
+package vscodeprompt
+
+import (
+	"log/slog"
+	"strings"
+	"text/template"
+)
+
+// userSections are the parts of the user message, in order.
+var userSections = []string{"diffstat", "context", "changes", "reminder", "instructions"}
+
+// renderPrompt returns the system and user messages for d, given the default text of
+// each of the userSections.
+//
+// d.SystemPromptTemplate, if set, is a text/template. Its text is the system prompt,
+// as before partials existed. It can also redefine "system" or any of the
+// userSections and leave its own text empty, and use the default of each as a
+// partial, so that changing one part of the prompt doesn't mean copying the rest:
+//
+//	{{define "reminder"}}{{template "default_reminder" .}}Mention the ticket number.
+//	{{end}}
+func renderPrompt(d Data, sections map[string]string) (system, user string) {
+	var b strings.Builder
+	for _, name := range userSections {
+		b.WriteString(sections[name])
+	}
+	custom := d.SystemPromptTemplate
+	if custom == "" {
+		return defaultSystemPromptTemplate(), b.String()
+	}
+	if !strings.Contains(custom, "{{") {
+		return custom, b.String()
+	}
+
+	t, err := promptTemplates(sections).New("custom").Parse(custom)
+	if err != nil {
+		slog.Warn("invalid prompt template; using it as plain text", "err", err)
+		return custom, b.String()
+	}
+	system, err = execute(t, "custom", d)
+	if err == nil && strings.TrimSpace(system) == "" {
+		system, err = execute(t, "system", d)
+	}
+	if err != nil {
+		slog.Warn("could not render the prompt template; using it as plain text", "err", err)
+		return custom, b.String()
+	}
+	if user, err = execute(t, "user", d); err != nil {
+		slog.Warn("could not render the prompt template's user message", "err", err)
+		return system, b.String()
+	}
+	return system, user
+}
+
+// promptTemplates defines "default_system" and "system", a "default_<name>" and a
+// "<name>" for each of the userSections with the text in sections, and "user", the
+// userSections in order. A template parsed after can redefine any of them.
+func promptTemplates(sections map[string]string) *template.Template {
+	var b strings.Builder
+	b.WriteString(`{{define "default_system"}}` + defaultSystemPromptTemplate() + `{{end}}`)
+	b.WriteString(`{{define "system"}}{{template "default_system" .}}{{end}}`)
+	b.WriteString(`{{define "user"}}`)
+	for _, name := range userSections {
+		b.WriteString(`{{template "` + name + `" .}}`)
+	}
+	b.WriteString(`{{end}}`)
+	for _, name := range userSections {
+		b.WriteString(`{{define "default_` + name + `"}}{{section "` + name + `"}}{{end}}`)
+		b.WriteString(`{{define "` + name + `"}}{{template "default_` + name + `" .}}{{end}}`)
+	}
+	section := func(name string) string { return sections[name] }
+	return template.Must(template.New("prompt").Funcs(template.FuncMap{"section": section}).Parse(b.String()))
+}
+
+func execute(t *template.Template, name string, d Data) (string, error) {
+	var b strings.Builder
+	if err := t.ExecuteTemplate(&b, name, d); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,8 @@
+;; The source of keep.wasm: a plugin that keeps lines 1 to 2 of every file.
+(module
+  (import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (memory (export "memory") 1)
+  ;; An iovec for fd_write at 0, pointing at the output at 8.
+  (data (i32.const 0) "\08\00\00\00\04\00\00\00" "1-2\n")
+  (func (export "_start")
+    (drop (call $fd_write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 16)))))
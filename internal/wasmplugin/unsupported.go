@@ -0,0 +1,13 @@
+//go:build !wasmplugins
+
+package wasmplugin
+
+import (
+	"context"
+	"errors"
+)
+
+// Load fails: this build has no WebAssembly runtime.
+func Load(ctx context.Context, path string) (*Summarizer, error) {
+	return nil, errors.New("this commitgen was built without WASM plugin support (build it with -tags wasmplugins)")
+}
@@ -0,0 +1,69 @@
+// Package wasmplugin runs summarizers compiled to WebAssembly, so teams can
+// summarize file types commitgen has no built-in summary for (Elixir, Terraform,
+// ...) without changing commitgen.
+//
+// A plugin is a WASI command module (wasm32-wasi, e.g. built with TinyGo or Rust).
+// For each file it is run with the file's repository-relative path as its only
+// argument and the file's contents on stdin, and prints the 1-based numbers of the
+// lines to keep, separated by whitespace; "a-b" keeps lines a to b. A plugin that
+// exits with a non-zero status fails, and the built-in summary is used instead.
+// Plugins get no filesystem, network, or environment access.
+//
+// The WebAssembly runtime (wazero) is only compiled in with the wasmplugins build
+// tag; without it, Load fails.
+package wasmplugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runTimeout bounds each run of a plugin.
+const runTimeout = 5 * time.Second
+
+// Summarizer is a loaded plugin. It implements vscodeprompt.Summarizer and is safe
+// for concurrent use.
+type Summarizer struct {
+	name string
+	run  func(ctx context.Context, relPath, content string) (string, error)
+}
+
+// Summarize runs the plugin on a file and returns the lines it keeps.
+func (s *Summarizer) Summarize(relPath string, lines []string) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+	out, err := s.run(ctx, relPath, strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	keep, err := parseKept(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	return keep, nil
+}
+
+// parseKept parses a plugin's output: line numbers and "a-b" ranges.
+func parseKept(out string) ([]int, error) {
+	var keep []int
+	for _, f := range strings.Fields(out) {
+		from, to, isRange := strings.Cut(f, "-")
+		a, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line number %q", f)
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(to); err != nil || b < a {
+				return nil, fmt.Errorf("invalid line range %q", f)
+			}
+		}
+		for ln := a; ln <= b; ln++ {
+			keep = append(keep, ln)
+		}
+	}
+	return keep, nil
+}
@@ -0,0 +1,43 @@
+package wasmplugin
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseKept(t *testing.T) {
+	got, err := parseKept("1 3-5\n9\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 3, 4, 5, 9}; !slices.Equal(got, want) {
+		t.Errorf("parseKept = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"x", "5-2", "1-y"} {
+		if _, err := parseKept(bad); err == nil {
+			t.Errorf("parseKept(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var gotPath, gotContent string
+	s := &Summarizer{name: "hcl.wasm", run: func(_ context.Context, relPath, content string) (string, error) {
+		gotPath, gotContent = relPath, content
+		return "1 3", nil
+	}}
+	keep, err := s.Summarize("main.tf", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keep, []int{1, 3}) || gotPath != "main.tf" || gotContent != "a\nb\nc" {
+		t.Errorf("Summarize = %v (path %q, content %q)", keep, gotPath, gotContent)
+	}
+
+	s.run = func(context.Context, string, string) (string, error) { return "", errors.New("exit status 1") }
+	if _, err := s.Summarize("main.tf", nil); err == nil || err.Error() != "hcl.wasm: exit status 1" {
+		t.Errorf("failing plugin: err = %v", err)
+	}
+}
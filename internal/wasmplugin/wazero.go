@@ -0,0 +1,65 @@
+//go:build wasmplugins
+
+package wasmplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+var (
+	runtimeOnce sync.Once
+	rt          wazero.Runtime
+)
+
+// Load compiles the plugin at path.
+func Load(ctx context.Context, path string) (*Summarizer, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	runtimeOnce.Do(func() {
+		rt = wazero.NewRuntimeWithConfig(context.Background(), wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+		wasi_snapshot_preview1.MustInstantiate(context.Background(), rt)
+	})
+	compiled, err := rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("compile %s: %w", path, err)
+	}
+	name := filepath.Base(path)
+	return &Summarizer{name: name, run: func(ctx context.Context, relPath, content string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		// An anonymous module, so files can be summarized concurrently.
+		cfg := wazero.NewModuleConfig().
+			WithName("").
+			WithArgs(name, relPath).
+			WithStdin(strings.NewReader(content)).
+			WithStdout(&stdout).
+			WithStderr(&stderr)
+		mod, err := rt.InstantiateModule(ctx, compiled, cfg)
+		if mod != nil {
+			_ = mod.Close(ctx)
+		}
+		var exit *sys.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 0 {
+			err = nil
+		}
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("%v: %s", err, msg)
+			}
+			return "", err
+		}
+		return stdout.String(), nil
+	}}, nil
+}
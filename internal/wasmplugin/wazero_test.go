@@ -0,0 +1,27 @@
+//go:build wasmplugins
+
+package wasmplugin
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load(context.Background(), "testdata/keep.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep, err := s.Summarize("lib/app.ex", []string{"defmodule App do", "  def run, do: :ok", "end"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keep, []int{1, 2}) {
+		t.Errorf("Summarize = %v, want [1 2]", keep)
+	}
+
+	if _, err := Load(context.Background(), "testdata/keep.wat"); err == nil {
+		t.Error("Load of a text file: expected an error")
+	}
+}
@@ -0,0 +1,308 @@
+// Package commitgen generates commit messages from staged changes or a diff, for
+// tools that embed commitgen instead of running the CLI. Its types are stable;
+// everything under internal/ may change between releases.
+//
+//	g, err := commitgen.New(commitgen.Config{Provider: "anthropic", Model: "claude-3-5-sonnet-latest", APIKey: key, Conventional: true})
+//	if err != nil { ... }
+//	msg, err := g.Generate(ctx, commitgen.Input{Repo: "."})
+//
+// Unlike the CLI, the package reads no config files or environment variables,
+// writes no history or statistics, and never commits.
+package commitgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+	"github.com/hoanghonghuy/commitgen/internal/commitmsg"
+	"github.com/hoanghonghuy/commitgen/internal/generate"
+	"github.com/hoanghonghuy/commitgen/internal/gitx"
+	"github.com/hoanghonghuy/commitgen/internal/vscodeprompt"
+)
+
+// Errors returned by Generate can be told apart with errors.Is.
+var (
+	// ErrNoChanges: nothing is staged, or the diff is empty.
+	ErrNoChanges = errors.New("commitgen: no changes")
+	// ErrProvider: the model's provider failed; the kinds below narrow it down.
+	ErrProvider = errors.New("commitgen: provider error")
+	// ErrRateLimited: the provider refused the request for too many requests or tokens.
+	ErrRateLimited = ai.ErrRateLimited
+	// ErrContextTooLarge: the prompt is over the model's context window.
+	ErrContextTooLarge = ai.ErrContextTooLarge
+	// ErrEmptyResponse: the model answered without a message.
+	ErrEmptyResponse = ai.ErrEmptyResponse
+)
+
+// The errors above under the names they were first specified with; errors.Is
+// matches either name.
+var (
+	ErrNoStagedChanges     = ErrNoChanges     // nothing is staged, or the diff is empty
+	ErrProviderRateLimited = ErrRateLimited   // the provider refused for too many requests or tokens
+	ErrUserCancelled       = context.Canceled // ctx was canceled, e.g. by the user
+)
+
+// APIError is an error answer from a provider's API, with its status code and
+// message; use errors.As to get it.
+type APIError struct {
+	Provider   string // e.g. "openai"
+	StatusCode int    // 0 for errors inside a stream
+	Code       string // the provider's error type or code, if it gives one
+	Message    string
+}
+
+func (e *APIError) Error() string { return (*ai.APIError)(e).Error() }
+
+// Is reports whether e is of one of the kinds above, such as ErrRateLimited.
+func (e *APIError) Is(target error) bool { return (*ai.APIError)(e).Is(target) }
+
+// Config configures a Generator. The zero value of each field turns its feature
+// off, except where a default is given.
+type Config struct {
+	Provider string // openai (default), anthropic, gemini, or ollama
+	Model    string // required unless Client is set
+	BaseURL  string // the provider's API endpoint, e.g. for a proxy or OpenAI-compatible server
+	APIKey   string // the key for Provider; not needed for ollama
+
+	// Client, if set, generates instead of the built-in provider.
+	Client Provider
+
+	Temperature  float64 // the CLI uses 0.7
+	Conventional bool    // ask for Conventional Commits (implied by Rules.Conventional)
+	Instructions string  // extra instructions for the model, like --instructions files
+
+	RecentCommits int           // recent subjects from the repository shown as examples of its style
+	MaxFiles      int           // files included in full (default 10)
+	IgnoredFiles  []string      // glob patterns of files left out of the prompt
+	ContextBudget int           // estimated prompt tokens above which files are summarized first (default 32000)
+	Timeout       time.Duration // per message, including repairs (default 60s)
+
+	// Checks on generated messages; a message that fails them is sent back to be
+	// repaired up to RepairAttempts times.
+	Rules          Rules
+	RepairAttempts int
+
+	Imperative bool // rewrite subjects into the imperative mood
+	BodyWrap   int  // hard-wrap bodies at this column
+}
+
+// Rules are the checks Lint applies to a message.
+type Rules struct {
+	Conventional      bool     // require Conventional Commits syntax
+	Types             []string // allowed conventional types (default: feat, fix, docs, ...)
+	MaxSubjectLength  int
+	MaxBodyLineLength int
+}
+
+// Issue is a rule a message breaks.
+type Issue struct {
+	Rule    string // e.g. "subject-max-length"
+	Line    int    // 1-based line in the message
+	Message string
+}
+
+func (i Issue) String() string {
+	return commitmsg.Issue{Rule: i.Rule, Line: i.Line, Message: i.Message}.String()
+}
+
+// Message is one message of a prompt.
+type Message struct {
+	Role    string // system, user, or assistant
+	Content string
+}
+
+// Provider is a model that answers a prompt, for Config.Client.
+type Provider interface {
+	Complete(ctx context.Context, msgs []Message, temperature float64) (string, error)
+}
+
+// Input is what a message is generated for.
+type Input struct {
+	Repo string // path to a repository; its staged changes are described unless Diff is set
+	Diff string // a unified diff to describe instead; Repo then only adds context
+}
+
+// Generator generates commit messages. It is safe for concurrent use.
+type Generator struct {
+	cfg      Config
+	provider ai.Provider
+	rules    commitmsg.Rules // what a message is repaired for
+	fixes    commitmsg.Fixes
+}
+
+// New returns a Generator for cfg.
+func New(cfg Config) (*Generator, error) {
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = 10
+	}
+	if cfg.ContextBudget <= 0 {
+		cfg.ContextBudget = 32000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	cfg.Conventional = cfg.Conventional || cfg.Rules.Conventional
+
+	var provider ai.Provider
+	if cfg.Client != nil {
+		provider = clientProvider{cfg.Client}
+	} else {
+		var err error
+		provider, err = generate.NewProvider(generate.ProviderConfig{
+			Provider: cfg.Provider,
+			Model:    cfg.Model,
+			BaseURL:  cfg.BaseURL,
+			APIKey:   cfg.APIKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	wrap := cfg.BodyWrap
+	if cfg.Rules.MaxBodyLineLength > 0 && wrap > cfg.Rules.MaxBodyLineLength {
+		wrap = cfg.Rules.MaxBodyLineLength
+	}
+	return &Generator{
+		cfg:      cfg,
+		provider: provider,
+		rules: commitmsg.Rules{
+			Conventional:     cfg.Conventional,
+			Types:            cfg.Rules.Types,
+			MaxSubjectLength: cfg.Rules.MaxSubjectLength,
+		},
+		fixes: commitmsg.Fixes{Imperative: cfg.Imperative, Wrap: wrap},
+	}, nil
+}
+
+// Generate returns a commit message for in.
+func (g *Generator) Generate(ctx context.Context, in Input) (string, error) {
+	data, msgs, err := g.prompt(ctx, in)
+	if err != nil {
+		return "", err
+	}
+
+	// A prompt over the budget is written from one-line summaries of each file, and
+	// one over a context window is split even for a single file.
+	budget, windowed := generate.ContextBudget(ctx, g.provider, g.cfg.ContextBudget)
+	p := g.provider
+	if (len(data.Changes) >= 2 || windowed) && generate.Tokens(msgs) > budget {
+		mr := &generate.MapReduce{Provider: g.provider, Data: data, Base: len(msgs)}
+		if windowed {
+			mr.MaxDiffChars = budget * generate.CharsPerToken / 2
+		}
+		p = mr
+	}
+	if g.cfg.RepairAttempts > 0 && (g.rules.MaxSubjectLength > 0 || g.rules.Conventional) {
+		p = generate.NewRepair(g.provider, p, g.cfg.RepairAttempts, g.rules, commitmsg.Policy{}, generate.Diff(data, budget))
+	}
+	if g.fixes.Enabled() {
+		p = generate.Fixing{Provider: p, Fixes: g.fixes}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+	defer cancel()
+	msg, err := generate.Message(ctx, p, msgs, g.cfg.Temperature, g.cfg.Conventional)
+	if err != nil {
+		var ae *ai.APIError
+		if errors.As(err, &ae) {
+			err = (*APIError)(ae)
+		}
+		return "", fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+	return strings.TrimSpace(msg), nil
+}
+
+// Prompt returns the prompt Generate would send for in.
+func (g *Generator) Prompt(ctx context.Context, in Input) ([]Message, error) {
+	_, msgs, err := g.prompt(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return toMessages(msgs), nil
+}
+
+// prompt builds the prompt for in.
+func (g *Generator) prompt(ctx context.Context, in Input) (vscodeprompt.Data, []vscodeprompt.VSCodeMessage, error) {
+	if strings.TrimSpace(in.Repo) == "" && strings.TrimSpace(in.Diff) == "" {
+		return vscodeprompt.Data{}, nil, errors.New("commitgen: either Repo or Diff is required")
+	}
+	var repoRoot string
+	if in.Repo != "" {
+		var err error
+		repoRoot, err = gitx.ResolveRepoRoot(ctx, in.Repo)
+		// A diff needs no checkout; the repository only adds context.
+		if err != nil && in.Diff == "" {
+			return vscodeprompt.Data{}, nil, err
+		}
+	}
+
+	var changes []gitx.StagedChange
+	if in.Diff != "" {
+		changes = gitx.ParseUnifiedDiff(in.Diff)
+	} else {
+		// Fetch more changes than are shown, as some may be left out.
+		var err error
+		if changes, err = gitx.StagedChanges(ctx, repoRoot, max(2*g.cfg.MaxFiles, 20)); err != nil {
+			return vscodeprompt.Data{}, nil, err
+		}
+	}
+	if len(changes) == 0 {
+		return vscodeprompt.Data{}, nil, ErrNoChanges
+	}
+
+	data, err := generate.BuildData(ctx, repoRoot, changes, generate.Options{
+		RecentN:      g.cfg.RecentCommits,
+		MaxFiles:     g.cfg.MaxFiles,
+		Summarize:    true,
+		Instructions: g.cfg.Instructions,
+		IgnoredFiles: g.cfg.IgnoredFiles,
+	})
+	if err != nil {
+		return vscodeprompt.Data{}, nil, err
+	}
+	return data, vscodeprompt.BuildVSCodeMessages(data), nil
+}
+
+// Extract returns the commit message in a model's answer: the contents of its code
+// block if it has one, without git comment lines and trailing whitespace.
+func Extract(answer string) string {
+	msg, ok := vscodeprompt.ExtractOneTextCodeBlock(answer)
+	if !ok {
+		msg = answer
+	}
+	return commitmsg.Clean(msg)
+}
+
+// Lint returns the rules msg breaks.
+func Lint(msg string, r Rules) []Issue {
+	rules := commitmsg.Rules{
+		Conventional:      r.Conventional,
+		Types:             r.Types,
+		MaxSubjectLength:  r.MaxSubjectLength,
+		MaxBodyLineLength: r.MaxBodyLineLength,
+	}
+	var out []Issue
+	for _, is := range commitmsg.Lint(msg, rules) {
+		out = append(out, Issue{Rule: is.Rule, Line: is.Line, Message: is.Message})
+	}
+	return out
+}
+
+// clientProvider adapts a Provider to the internal interface.
+type clientProvider struct{ Provider }
+
+func (p clientProvider) GenerateCommitMessage(ctx context.Context, msgs []vscodeprompt.VSCodeMessage, temp float64) (string, error) {
+	return p.Complete(ctx, toMessages(msgs), temp)
+}
+
+func toMessages(msgs []vscodeprompt.VSCodeMessage) []Message {
+	out := make([]Message, 0, len(msgs))
+	for _, m := range vscodeprompt.ToOpenAIMessages(msgs) {
+		out = append(out, Message{Role: m.Role, Content: m.Content})
+	}
+	return out
+}
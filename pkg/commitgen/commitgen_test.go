@@ -0,0 +1,118 @@
+package commitgen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hoanghonghuy/commitgen/internal/ai"
+)
+
+type fakeClient struct {
+	prompts [][]Message
+	answers []string
+}
+
+func (c *fakeClient) Complete(ctx context.Context, msgs []Message, temperature float64) (string, error) {
+	c.prompts = append(c.prompts, msgs)
+	answer := c.answers[0]
+	if len(c.answers) > 1 {
+		c.answers = c.answers[1:]
+	}
+	return answer, nil
+}
+
+const diff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-var x = 1
++var x = 2
+`
+
+func TestGenerate(t *testing.T) {
+	client := &fakeClient{answers: []string{
+		"```text\nfix: bump x to two because the old value overflowed the buffer downstream\n```",
+		"```text\nfix: bump x to two\n```",
+	}}
+	g, err := New(Config{
+		Client:         client,
+		Instructions:   "Mention the ticket.",
+		Rules:          Rules{Conventional: true, MaxSubjectLength: 50},
+		RepairAttempts: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := g.Generate(context.Background(), Input{Diff: diff})
+	if err != nil || msg != "fix: bump x to two" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if len(client.prompts) != 2 {
+		t.Fatalf("got %d requests; want the draft and one repair", len(client.prompts))
+	}
+	first := client.prompts[0]
+	if first[0].Role != "system" || first[1].Role != "user" {
+		t.Errorf("roles = %s, %s", first[0].Role, first[1].Role)
+	}
+	if !strings.Contains(first[1].Content, "+var x = 2") || !strings.Contains(first[1].Content, "Mention the ticket.") {
+		t.Errorf("prompt lacks the diff or instructions:\n%s", first[1].Content)
+	}
+
+	prompt, err := g.Prompt(context.Background(), Input{Diff: diff})
+	if err != nil || len(prompt) < 2 {
+		t.Fatalf("Prompt: %v, %v", prompt, err)
+	}
+	if _, err := g.Generate(context.Background(), Input{}); err == nil {
+		t.Error("empty input: no error")
+	}
+}
+
+func TestExtractAndLint(t *testing.T) {
+	msg := Extract("Here you go:\n```text\nAdded the parser.   \n# comment\n```")
+	if msg != "Added the parser." {
+		t.Errorf("Extract = %q", msg)
+	}
+	issues := Lint(msg, Rules{Conventional: true})
+	if len(issues) == 0 || issues[0].Line != 1 || issues[0].String() == "" {
+		t.Errorf("Lint = %+v", issues)
+	}
+	if issues := Lint("feat: add the parser", Rules{Conventional: true}); len(issues) != 0 {
+		t.Errorf("valid message: %+v", issues)
+	}
+}
+
+// failingClient fails with err, or else with ctx's error.
+type failingClient struct{ err error }
+
+func (c failingClient) Complete(ctx context.Context, msgs []Message, temperature float64) (string, error) {
+	if c.err == nil {
+		return "", ctx.Err()
+	}
+	return "", c.err
+}
+
+func TestGenerateErrors(t *testing.T) {
+	g, err := New(Config{Client: failingClient{&ai.APIError{Provider: "openai", StatusCode: http.StatusTooManyRequests, Message: "slow down"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Generate(context.Background(), Input{Diff: diff})
+	var ae *APIError
+	if !errors.Is(err, ErrProvider) || !errors.Is(err, ErrRateLimited) || !errors.As(err, &ae) || ae.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got %v; want a rate-limited *APIError", err)
+	}
+	if _, err := g.Generate(context.Background(), Input{Diff: "no diff here"}); !errors.Is(err, ErrNoChanges) || !errors.Is(err, ErrNoStagedChanges) {
+		t.Errorf("empty diff: %v; want ErrNoChanges", err)
+	}
+
+	g, _ = New(Config{Client: failingClient{}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.Generate(ctx, Input{Diff: diff}); !errors.Is(err, ErrUserCancelled) {
+		t.Errorf("canceled: %v; want ErrUserCancelled", err)
+	}
+}